@@ -43,6 +43,9 @@ type ManagedClusterModuleStatus struct {
 
 	// Number of ManifestWorks that could not be successfully applied.
 	NumberDegraded int32 `json:"numberDegraded"`
+	// Conditions contains the conditions of the ManagedClusterModule
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 //+kubebuilder:object:root=true
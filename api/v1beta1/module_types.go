@@ -21,10 +21,31 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// BuildArgSource represents a source for the value of a BuildArg.
+// +kubebuilder:validation:XValidation:rule="has(self.secretKeyRef) != has(self.configMapKeyRef)",message="exactly one of secretKeyRef and configMapKeyRef must be set"
+type BuildArgSource struct {
+	// +optional
+	// SecretKeyRef selects a key of a secret in the Module's namespace.
+	SecretKeyRef *v1.SecretKeySelector `json:"secretKeyRef,omitempty"`
+
+	// +optional
+	// ConfigMapKeyRef selects a key of a ConfigMap in the Module's namespace.
+	ConfigMapKeyRef *v1.ConfigMapKeySelector `json:"configMapKeyRef,omitempty"`
+}
+
 // BuildArg represents a build argument used when building a container image.
+// +kubebuilder:validation:XValidation:rule="!(has(self.value) && has(self.valueFrom))",message="value and valueFrom are mutually exclusive"
 type BuildArg struct {
-	Name  string `json:"name"`
-	Value string `json:"value"`
+	Name string `json:"name"`
+
+	// +optional
+	// Value is a literal value for the build argument.
+	Value string `json:"value,omitempty"`
+
+	// +optional
+	// ValueFrom is a source for the build argument's value; cannot be used together with Value.
+	// The value is injected into the build through an environment variable and never appears in the build arguments.
+	ValueFrom *BuildArgSource `json:"valueFrom,omitempty"`
 }
 
 type TLSOptions struct {
@@ -36,6 +57,12 @@ type TLSOptions struct {
 	// +optional
 	// If InsecureSkipTLSVerify, the operator will accept any certificate provided by the registry.
 	InsecureSkipTLSVerify bool `json:"insecureSkipTLSVerify,omitempty"`
+
+	// +optional
+	// CABundle is a reference to a ConfigMap in the Module's namespace holding PEM-encoded CA certificates under the
+	// ca-bundle.crt key.
+	// Those certificates are trusted in addition to the system ones when accessing the registry.
+	CABundle *v1.LocalObjectReference `json:"caBundle,omitempty"`
 }
 
 type KanikoParams struct {
@@ -62,9 +89,20 @@ type Build struct {
 	// For container registries auth use module.spec.imagePullSecret instead.
 	Secrets []v1.LocalObjectReference `json:"secrets"`
 
+	// +optional
+	// BaseImagePullSecrets is an optional list of secrets used to pull the base images in the build-process'
+	// Dockerfile.
+	BaseImagePullSecrets []v1.LocalObjectReference `json:"baseImagePullSecrets,omitempty"`
+
 	// +optional
 	// KanikoParams is used to customize the building process of the image.
 	KanikoParams *KanikoParams `json:"kanikoParams,omitempty"`
+
+	// +optional
+	// SkipRebuildOnInputChange, if true, does not build the image again when the Dockerfile, the build arguments or the
+	// Secrets and ConfigMaps they reference change; an existing image is then never rebuilt.
+	// By default, KMM looks up the labels of the image in the registry to find out if it was built from other inputs.
+	SkipRebuildOnInputChange bool `json:"skipRebuildOnInputChange,omitempty"`
 }
 
 type Sign struct {
@@ -85,6 +123,11 @@ type Sign struct {
 	// +optional
 	// paths inside the image for the kernel modules to sign (if ommited all kmods are signed)
 	FilesToSign []string `json:"filesToSign,omitempty"`
+
+	// +optional
+	// DeleteIntermediateImage, if true, deletes the unsigned image produced by an in-cluster build once the signed
+	// image has been pushed.
+	DeleteIntermediateImage bool `json:"deleteIntermediateImage,omitempty"`
 }
 
 // KernelMapping pairs kernel versions with a DriverContainer image.
@@ -189,6 +232,22 @@ type ModuleLoaderContainerSpec struct {
 	// +optional
 	// RegistryTLS set the TLS configs for accessing the registry of the module-loader's image.
 	RegistryTLS TLSOptions `json:"registryTLS"`
+
+	// +optional
+	// PushSecret is an optional secret used to push the images resulting from in-cluster builds and signing.
+	// It is also used to check if those images already exist.
+	PushSecret *v1.LocalObjectReference `json:"pushSecret,omitempty"`
+
+	// +optional
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern=`^([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9]$`
+	// Version of the module.
+	// If it is set, the ModuleLoader is only run on nodes labeled with
+	// kmm.node.kubernetes.io/version-module.<namespace>.<name>=<version>, so that each node moves to a new version
+	// only when its label is changed.
+	// The ModuleLoader pod of the new version only starts on a node once that of the previous version is gone.
+	// The DaemonSets of the previous and the new versions coexist until no node is labeled with the previous version.
+	Version string `json:"version,omitempty"`
 }
 
 type ModuleLoaderSpec struct {
@@ -199,6 +258,45 @@ type ModuleLoaderSpec struct {
 	// ServiceAccountName is the name of the ServiceAccount to use to run this pod.
 	// More info: https://kubernetes.io/docs/tasks/configure-pod-container/configure-service-account/
 	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+
+	// +optional
+	// ImagePullSecrets is an optional list of secrets used to pull the module loader image.
+	ImagePullSecrets []v1.LocalObjectReference `json:"imagePullSecrets,omitempty"`
+
+	// +optional
+	// UpgradeStrategy makes KMM replace outdated module loader pods node by node, cordoning and optionally draining
+	// each node before the module is reloaded.
+	// If it is not set, the DaemonSets replace outdated pods on all nodes at once.
+	UpgradeStrategy *UpgradeStrategy `json:"upgradeStrategy,omitempty"`
+
+	// +optional
+	// Rollback makes KMM go back to the last known good ModuleLoader image of a kernel when the new one fails to load.
+	Rollback *RollbackPolicy `json:"rollback,omitempty"`
+}
+
+// RollbackPolicy describes when the ModuleLoader image is rolled back.
+type RollbackPolicy struct {
+	// +kubebuilder:default=3
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	// FailureThreshold is the number of restarts of a ModuleLoader container that is not ready after which its image
+	// is considered broken, and replaced with the last known good image of the kernel.
+	FailureThreshold int32 `json:"failureThreshold,omitempty"`
+}
+
+// UpgradeStrategy describes how outdated module loader pods are replaced on running nodes.
+type UpgradeStrategy struct {
+	// +kubebuilder:default=1
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	// MaxParallelNodes is the maximum number of nodes that are upgraded at the same time.
+	MaxParallelNodes int32 `json:"maxParallelNodes,omitempty"`
+
+	// +optional
+	// DrainResources is a list of resources, typically exposed by the device plugin, that prevent the module from
+	// being reloaded.
+	// Pods requesting any of those resources are evicted from a node before its module loader pod is replaced.
+	DrainResources []v1.ResourceName `json:"drainResources,omitempty"`
 }
 
 type DevicePluginContainerSpec struct {
@@ -261,6 +359,10 @@ type DevicePluginSpec struct {
 	// More info: https://kubernetes.io/docs/tasks/configure-pod-container/configure-service-account/
 	ServiceAccountName string `json:"serviceAccountName,omitempty"`
 
+	// +optional
+	// ImagePullSecrets is an optional list of secrets used to pull the device plugin image.
+	ImagePullSecrets []v1.LocalObjectReference `json:"imagePullSecrets,omitempty"`
+
 	Volumes []v1.Volume `json:"volumes,omitempty"`
 }
 
@@ -277,11 +379,114 @@ type ModuleSpec struct {
 
 	// ImageRepoSecret is an optional secret that is used to pull both the module loader and the device plugin, and
 	// to push the resulting image from the module loader build, if enabled.
+	// It is used in addition to the purpose-specific secrets.
 	// +optional
 	ImageRepoSecret *v1.LocalObjectReference `json:"imageRepoSecret,omitempty"`
 
 	// Selector describes on which nodes the Module should be loaded and optionally built.
 	Selector map[string]string `json:"selector"`
+
+	// KernelTargets lists kernels for which the ModuleLoader image should be built and signed ahead of time, even
+	// if no node runs them yet.
+	// No ModuleLoader DaemonSet is created for those kernels until a node runs them.
+	// +optional
+	KernelTargets []KernelTarget `json:"kernelTargets,omitempty"`
+
+	// GarbageCollection overrides the operator's default policy for deleting the jobs and DaemonSets that are no
+	// longer needed.
+	// +optional
+	GarbageCollection *GarbageCollectionPolicy `json:"garbageCollection,omitempty"`
+
+	// Suspend freezes the Module: while true, KMM does not build or sign images, and does not create, update or
+	// garbage-collect any DaemonSet or job of the Module.
+	// The ModuleLoader and device plugin pods that are already running are left in place.
+	// The status of the Module is still updated.
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+
+	// UnloadPolicy tells KMM what to do with the kernel module when the Module is deleted.
+	// With Unload, the ModuleLoader pods unload the kernel module from the nodes.
+	// With Keep, the ModuleLoader pods are removed without unloading the kernel module, and the nodes are labeled
+	// with kmm.node.kubernetes.io/unmanaged-module.<namespace>.<name>.
+	// +kubebuilder:default=Unload
+	// +optional
+	UnloadPolicy UnloadPolicy `json:"unloadPolicy,omitempty"`
+
+	// StartupTaint is a taint managed by KMM, that keeps workloads away from nodes on which the kernel module is not
+	// loaded.
+	// KMM removes it from a node once the ModuleLoader pod is ready on that node, and adds it back when that pod is
+	// not ready anymore.
+	// The ModuleLoader pods tolerate it.
+	// Nodes should be registered with the taint, so that no workload is scheduled before KMM loads the kernel module.
+	// +optional
+	StartupTaint *StartupTaint `json:"startupTaint,omitempty"`
+}
+
+// StartupTaint describes the taint that KMM removes from nodes once the kernel module is loaded.
+type StartupTaint struct {
+	// Key is the key of the taint, for example kmm.sigs.x-k8s.io/<module>-not-ready.
+	Key string `json:"key"`
+
+	// Value is the value of the taint.
+	// +optional
+	Value string `json:"value,omitempty"`
+
+	// Effect is the effect of the taint.
+	// +kubebuilder:validation:Enum=NoSchedule;PreferNoSchedule;NoExecute
+	// +kubebuilder:default=NoSchedule
+	// +optional
+	Effect v1.TaintEffect `json:"effect,omitempty"`
+}
+
+// Taint returns the node taint described by st.
+func (st *StartupTaint) Taint() v1.Taint {
+	effect := st.Effect
+	if effect == "" {
+		effect = v1.TaintEffectNoSchedule
+	}
+
+	return v1.Taint{Key: st.Key, Value: st.Value, Effect: effect}
+}
+
+// UnloadPolicy is what KMM does with the kernel module when a Module is deleted.
+// +kubebuilder:validation:Enum=Unload;Keep
+type UnloadPolicy string
+
+const (
+	// UnloadPolicyUnload unloads the kernel module from the nodes.
+	UnloadPolicyUnload UnloadPolicy = "Unload"
+	// UnloadPolicyKeep leaves the kernel module loaded on the nodes.
+	UnloadPolicyKeep UnloadPolicy = "Keep"
+)
+
+// KernelTarget is a kernel for which the ModuleLoader image is prepared before nodes run it.
+type KernelTarget struct {
+	// KernelVersion is the kernel version, as reported by the nodes in their NodeInfo.
+	KernelVersion string `json:"kernelVersion"`
+
+	// Architecture of the nodes that will run the kernel, as reported in their NodeInfo (e.g. amd64), so that the image
+	// built ahead of time is the one used by the nodes once they run the kernel.
+	// +kubebuilder:validation:MinLength=1
+	Architecture string `json:"architecture"`
+}
+
+// GarbageCollectionPolicy describes how long KMM keeps the jobs and DaemonSets of a Module once they are not needed
+// anymore.
+// Unset fields default to the operator settings.
+type GarbageCollectionPolicy struct {
+	// SucceededJobsTTL is how long build and sign jobs are kept after they have succeeded.
+	// +optional
+	SucceededJobsTTL *metav1.Duration `json:"succeededJobsTTL,omitempty"`
+
+	// FailedJobsTTL is how long build and sign jobs are kept after they have failed.
+	// If it is not set here nor in the operator settings, failed jobs are never deleted.
+	// +optional
+	FailedJobsTTL *metav1.Duration `json:"failedJobsTTL,omitempty"`
+
+	// OrphanedDaemonSetGracePeriod is how long the ModuleLoader DaemonSet of a kernel is kept once no node runs that
+	// kernel anymore.
+	// +optional
+	OrphanedDaemonSetGracePeriod *metav1.Duration `json:"orphanedDaemonSetGracePeriod,omitempty"`
 }
 
 // DaemonSetStatus contains the status for a daemonset deployed during
@@ -302,6 +507,136 @@ type ModuleStatus struct {
 	DevicePlugin DaemonSetStatus `json:"devicePlugin,omitempty"`
 	// ModuleLoader contains the status of the ModuleLoader daemonset
 	ModuleLoader DaemonSetStatus `json:"moduleLoader"`
+	// KernelTargets contains the readiness of the ModuleLoader image for each kernel in spec.kernelTargets
+	// +optional
+	KernelTargets []KernelTargetStatus `json:"kernelTargets,omitempty"`
+	// Upgrade contains the progress of the upgrade of the ModuleLoader pods, if spec.moduleLoader.upgradeStrategy
+	// is set
+	// +optional
+	Upgrade *UpgradeStatus `json:"upgrade,omitempty"`
+	// ModuleLoaderImages contains the pinned and last known good ModuleLoader images for each kernel
+	// +optional
+	ModuleLoaderImages []ModuleLoaderImageStatus `json:"moduleLoaderImages,omitempty"`
+	// DevicePluginImage is the digest that the device plugin image in the spec was resolved to
+	// +optional
+	DevicePluginImage *PinnedImage `json:"devicePluginImage,omitempty"`
+	// Conditions contains the conditions of the Module
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+const (
+	// ModuleConditionRolledBack is True when the ModuleLoader image of at least one kernel was rolled back to its last
+	// known good image.
+	ModuleConditionRolledBack = "RolledBack"
+	// ModuleConditionSuspended is True when spec.suspend is set and KMM is not reconciling the Module.
+	ModuleConditionSuspended = "Suspended"
+	// ModuleConditionTearingDown is True while KMM removes the device plugin and the ModuleLoader of a deleted Module.
+	// Its reason is the current teardown step.
+	ModuleConditionTearingDown = "TearingDown"
+	// ModuleConditionRebootRequired is True when the kernel module could not be unloaded from at least one targeted
+	// node, which needs to be drained or rebooted.
+	ModuleConditionRebootRequired = "RebootRequired"
+	// ModuleConditionConflict is True when other Modules load the same kernel module on some of the targeted nodes,
+	// on which KMM does not run the ModuleLoader of this Module.
+	ModuleConditionConflict = "Conflict"
+	// ModuleConditionPinningFailed is True when the digest of the ModuleLoader image of at least one kernel, or of the
+	// device plugin image, could not be resolved; those images are run by their tag.
+	ModuleConditionPinningFailed = "PinningFailed"
+)
+
+// ModuleLoaderImageStatus contains the ModuleLoader image history of a kernel.
+type ModuleLoaderImageStatus struct {
+	KernelVersion string `json:"kernelVersion"`
+	// +optional
+	Architecture string `json:"architecture,omitempty"`
+	// Version is the spec.moduleLoader.container.version that the images were run for, if the Module is versioned.
+	// The history starts over when the version changes.
+	// +optional
+	Version string `json:"version,omitempty"`
+	// LastKnownGoodImage is the last image, pinned by digest, that was available on all nodes running the kernel
+	// +optional
+	LastKnownGoodImage string `json:"lastKnownGoodImage,omitempty"`
+	// RolledBackFrom is the image that failed to load and was replaced with LastKnownGoodImage.
+	// KMM tries the image in the spec again once it is changed.
+	// +optional
+	RolledBackFrom string `json:"rolledBackFrom,omitempty"`
+	// Pinned is the digest that the ModuleLoader image in the spec was resolved to
+	// +optional
+	Pinned *PinnedImage `json:"pinned,omitempty"`
+	// PinError is why the ModuleLoader image in the spec could not be resolved to a digest.
+	// The image is run by its tag until it can be.
+	// +optional
+	PinError string `json:"pinError,omitempty"`
+}
+
+// PinnedImage is an image from the spec, resolved to the digest that its tag pointed to.
+// KMM keeps running that digest until the image in the spec changes, until KMM builds or signs the image again, or until
+// the kmm.node.kubernetes.io/refresh-image-digests annotation of the Module is set to a new value.
+type PinnedImage struct {
+	// Image is the image from the spec
+	Image string `json:"image"`
+	// Digest is the digest that Image was resolved to
+	Digest string `json:"digest"`
+	// Refresh is the value of the kmm.node.kubernetes.io/refresh-image-digests annotation of the Module when Image
+	// was resolved
+	// +optional
+	Refresh string `json:"refresh,omitempty"`
+	// ResolvedAt is when Image was resolved
+	// +optional
+	ResolvedAt *metav1.Time `json:"resolvedAt,omitempty"`
+}
+
+// NodeUpgradeState is the step a node is at in the upgrade of its ModuleLoader pod.
+type NodeUpgradeState string
+
+const (
+	// NodeUpgradeDraining means that pods using the resources in spec.moduleLoader.upgradeStrategy.drainResources
+	// are being evicted from the cordoned node.
+	NodeUpgradeDraining NodeUpgradeState = "Draining"
+	// NodeUpgradeReplacing means that the outdated ModuleLoader pod is being replaced.
+	NodeUpgradeReplacing NodeUpgradeState = "Replacing"
+	// NodeUpgradeWaitingForReady means that the new ModuleLoader pod runs, but the module is not ready yet.
+	NodeUpgradeWaitingForReady NodeUpgradeState = "WaitingForReady"
+)
+
+// NodeUpgradeStatus is the upgrade progress of a single node.
+type NodeUpgradeStatus struct {
+	Node  string           `json:"node"`
+	State NodeUpgradeState `json:"state"`
+}
+
+// UpgradeStatus contains the progress of the upgrade of the ModuleLoader pods.
+type UpgradeStatus struct {
+	// OutdatedNumber is the number of nodes running an outdated ModuleLoader pod
+	OutdatedNumber int32 `json:"outdatedNumber"`
+	// InProgress lists the nodes being upgraded
+	// +optional
+	InProgress []NodeUpgradeStatus `json:"inProgress,omitempty"`
+}
+
+// KernelTargetState is the state of the ModuleLoader image for a kernel.
+type KernelTargetState string
+
+const (
+	// KernelTargetBuilding means that the image is being built.
+	KernelTargetBuilding KernelTargetState = "Building"
+	// KernelTargetSigning means that the image is being signed.
+	KernelTargetSigning KernelTargetState = "Signing"
+	// KernelTargetReady means that the image is available and nodes can load the module as soon as they run the kernel.
+	KernelTargetReady KernelTargetState = "Ready"
+	// KernelTargetError means that the image cannot be prepared; see the message for details.
+	KernelTargetError KernelTargetState = "Error"
+)
+
+// KernelTargetStatus contains the readiness of the ModuleLoader image for a kernel.
+type KernelTargetStatus struct {
+	KernelVersion string `json:"kernelVersion"`
+	// +optional
+	Architecture string            `json:"architecture,omitempty"`
+	State        KernelTargetState `json:"state"`
+	// +optional
+	Message string `json:"message,omitempty"`
 }
 
 //+kubebuilder:object:root=true
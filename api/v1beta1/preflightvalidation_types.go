@@ -23,6 +23,7 @@ import (
 const (
 	VerificationTrue          string = "True"
 	VerificationFalse         string = "False"
+	VerificationSkipped       string = "Skipped"
 	VerificationStageImage    string = "Image"
 	VerificationStageBuild    string = "Build"
 	VerificationStageSign     string = "Sign"
@@ -39,6 +40,12 @@ type PreflightValidationSpec struct {
 	// +kubebuilder:validation:Required
 	KernelVersion string `json:"kernelVersion"`
 
+	// Architecture of the nodes that will run the kernel, as reported in their NodeInfo (e.g. amd64).
+	// It selects the image to check in multi-architecture image indexes and the value of NODE_ARCH; if it is not
+	// set, the architecture of the operator is used.
+	// +optional
+	Architecture string `json:"architecture,omitempty"`
+
 	// Boolean flag that determines whether images build during preflight must also
 	// be pushed to a defined repository
 	// +optional
@@ -47,10 +54,10 @@ type PreflightValidationSpec struct {
 
 type CRStatus struct {
 	// Status of Module CR verification: true (verified), false (verification failed),
-	// error (error during verification process), unknown (verification has not started yet)
+	// skipped (the Module is suspended and is not verified)
 	// +required
 	// +kubebuilder:validation:Required
-	// +kubebuilder:validation:Enum=True;False
+	// +kubebuilder:validation:Enum=True;False;Skipped
 	VerificationStatus string `json:"verificationStatus"`
 
 	// StatusReason contains a string describing the status source.
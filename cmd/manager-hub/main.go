@@ -18,12 +18,15 @@ package main
 
 import (
 	"flag"
+	"os"
+	"time"
 
 	"github.com/kubernetes-sigs/kernel-module-management/api-hub/v1beta1"
 	"github.com/kubernetes-sigs/kernel-module-management/controllers/hub"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/cmd"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/constants"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/klog/v2"
@@ -37,6 +40,7 @@ import (
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
+	"github.com/kubernetes-sigs/kernel-module-management/internal/auth"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/build"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/build/job"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/cluster"
@@ -99,40 +103,68 @@ func main() {
 
 	client := mgr.GetClient()
 
+	operatorNamespace := cmd.GetEnvOrFatalError(constants.OperatorNamespaceEnvVar, setupLogger)
+
 	filterAPI := filter.New(client, mgr.GetLogger())
 
 	metricsAPI := metrics.New()
 	metricsAPI.Register()
 
-	registryAPI := registry.NewRegistry()
+	var mirrorConfig *registry.MirrorConfig
+
+	if path := os.Getenv(constants.RegistryMirrorsConfigEnvVar); path != "" {
+		if mirrorConfig, err = registry.LoadMirrorConfig(path); err != nil {
+			cmd.FatalError(setupLogger, err, "unable to load the registry mirrors config")
+		}
+	}
+
+	registryCacheTTL, err := cmd.GetDurationEnv(constants.RegistryCacheTTLEnvVar, time.Minute)
+	if err != nil {
+		cmd.FatalError(setupLogger, err, "unable to determine the registry cache TTL")
+	}
+
+	var globalPullSecret *types.NamespacedName
+
+	if secretName := os.Getenv(constants.GlobalPullSecretEnvVar); secretName != "" {
+		globalPullSecret = &types.NamespacedName{Name: secretName, Namespace: operatorNamespace}
+	}
+
+	operatorAuth := auth.NewOperatorAuthGetter(client, globalPullSecret, os.Getenv(constants.RegistryDockerConfigEnvVar))
+
+	gcDefaults, err := cmd.GetGarbageCollectionPolicy()
+	if err != nil {
+		cmd.FatalError(setupLogger, err, "unable to determine the default garbage collection policy")
+	}
+
+	registryAPI := registry.NewRegistry(mirrorConfig, registryCacheTTL, operatorAuth)
 	jobHelperAPI := utils.NewJobHelper(client)
 	buildHelper := build.NewHelper()
 
 	buildAPI := job.NewBuildManager(
 		client,
-		job.NewMaker(client, buildHelper, jobHelperAPI, scheme),
+		job.NewMaker(client, buildHelper, jobHelperAPI, scheme, mirrorConfig),
 		jobHelperAPI,
 		registryAPI,
 	)
 
 	signAPI := signjob.NewSignJobManager(
 		client,
-		signjob.NewSigner(client, scheme, jobHelperAPI),
+		signjob.NewSigner(client, scheme, jobHelperAPI, mirrorConfig),
 		jobHelperAPI,
 		registryAPI,
+		mgr.GetEventRecorderFor("kmm-hub"),
 	)
 
 	ctrlLogger := setupLogger.WithValues("name", hub.ManagedClusterModuleReconcilerName)
 	ctrlLogger.Info("Adding controller")
 
-	operatorNamespace := cmd.GetEnvOrFatalError(constants.OperatorNamespaceEnvVar, setupLogger)
-
 	mcmr := hub.NewManagedClusterModuleReconciler(
 		client,
 		manifestwork.NewCreator(client, scheme),
-		cluster.NewClusterAPI(client, module.NewKernelMapper(buildHelper, sign.NewSignerHelper()), buildAPI, signAPI, operatorNamespace),
+		cluster.NewClusterAPI(client, module.NewKernelMapper(client, buildHelper, sign.NewSignerHelper()), buildAPI, signAPI, operatorNamespace),
 		statusupdater.NewManagedClusterModuleStatusUpdater(client),
 		filterAPI,
+		gcDefaults,
 	)
 
 	if err = mcmr.SetupWithManager(mgr); err != nil {
@@ -17,14 +17,17 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
 	"strconv"
+	"time"
 
 	v1beta12 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/cmd"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/klog/v2"
@@ -38,18 +41,25 @@ import (
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
 	"github.com/kubernetes-sigs/kernel-module-management/controllers"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/auth"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/build"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/build/job"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/conflict"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/constants"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/daemonset"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/filter"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/metrics"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/module"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/nodecondition"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/pin"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/preflight"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/registry"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/rollback"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/sign"
 	signjob "github.com/kubernetes-sigs/kernel-module-management/internal/sign/job"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/statusupdater"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/teardown"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/upgrade"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/utils"
 	//+kubebuilder:scaffold:imports
 )
@@ -112,26 +122,60 @@ func main() {
 	metricsAPI := metrics.New()
 	metricsAPI.Register()
 
-	registryAPI := registry.NewRegistry()
+	var mirrorConfig *registry.MirrorConfig
+
+	if path := os.Getenv(constants.RegistryMirrorsConfigEnvVar); path != "" {
+		if mirrorConfig, err = registry.LoadMirrorConfig(path); err != nil {
+			cmd.FatalError(setupLogger, err, "unable to load the registry mirrors config")
+		}
+	}
+
+	registryCacheTTL, err := cmd.GetDurationEnv(constants.RegistryCacheTTLEnvVar, time.Minute)
+	if err != nil {
+		cmd.FatalError(setupLogger, err, "unable to determine the registry cache TTL")
+	}
+
+	var globalPullSecret *types.NamespacedName
+
+	if secretName := os.Getenv(constants.GlobalPullSecretEnvVar); secretName != "" {
+		globalPullSecret = &types.NamespacedName{Name: secretName, Namespace: operatorNamespace}
+	}
+
+	operatorAuth := auth.NewOperatorAuthGetter(client, globalPullSecret, os.Getenv(constants.RegistryDockerConfigEnvVar))
+
+	gcDefaults, err := cmd.GetGarbageCollectionPolicy()
+	if err != nil {
+		cmd.FatalError(setupLogger, err, "unable to determine the default garbage collection policy")
+	}
+
+	registryAPI := registry.NewRegistry(mirrorConfig, registryCacheTTL, operatorAuth)
 	jobHelperAPI := utils.NewJobHelper(client)
 	buildHelperAPI := build.NewHelper()
 
 	buildAPI := job.NewBuildManager(
 		client,
-		job.NewMaker(client, buildHelperAPI, jobHelperAPI, scheme),
+		job.NewMaker(client, buildHelperAPI, jobHelperAPI, scheme, mirrorConfig),
 		jobHelperAPI,
 		registryAPI,
 	)
 
 	signAPI := signjob.NewSignJobManager(
 		client,
-		signjob.NewSigner(client, scheme, jobHelperAPI),
+		signjob.NewSigner(client, scheme, jobHelperAPI, mirrorConfig),
 		jobHelperAPI,
 		registryAPI,
+		mgr.GetEventRecorderFor("kmm"),
 	)
 
-	daemonAPI := daemonset.NewCreator(client, constants.KernelLabel, scheme)
-	kernelAPI := module.NewKernelMapper(buildHelperAPI, sign.NewSignerHelper())
+	daemonAPI := daemonset.NewCreator(client, constants.KernelLabel, scheme, mirrorConfig)
+	kernelAPI := module.NewKernelMapper(client, buildHelperAPI, sign.NewSignerHelper())
+
+	pendingKernelAnnotation := os.Getenv(constants.PendingKernelAnnotationEnvVar)
+	if pendingKernelAnnotation == "" {
+		pendingKernelAnnotation = constants.PendingKernelAnnotation
+	}
+
+	nodeConditionAPI := nodecondition.NewUpdater(client)
 
 	mc := controllers.NewModuleReconciler(
 		client,
@@ -142,9 +186,22 @@ func main() {
 		metricsAPI,
 		filterAPI,
 		statusupdater.NewModuleStatusUpdater(client, metricsAPI),
+		upgrade.NewOrchestrator(client, daemonAPI),
+		rollback.NewImageTracker(client),
+		pin.NewPinner(client, registryAPI, jobHelperAPI),
+		teardown.NewManager(client, nodeConditionAPI),
+		nodeConditionAPI,
+		conflict.NewDetector(client),
+		jobHelperAPI,
 		operatorNamespace,
+		gcDefaults,
+		pendingKernelAnnotation,
 	)
 
+	if err = upgrade.IndexPodsByNodeName(context.Background(), mgr.GetFieldIndexer()); err != nil {
+		cmd.FatalError(setupLogger, err, "unable to index the pods by node name")
+	}
+
 	if err = mc.SetupWithManager(mgr, constants.KernelLabel); err != nil {
 		cmd.FatalError(setupLogger, err, "unable to create controller", "name", controllers.ModuleReconcilerName)
 	}
@@ -155,7 +212,7 @@ func main() {
 		cmd.FatalError(setupLogger, err, "unable to create controller", "name", controllers.NodeKernelReconcilerName)
 	}
 
-	if err = controllers.NewPodNodeModuleReconciler(client, daemonAPI).SetupWithManager(mgr); err != nil {
+	if err = controllers.NewPodNodeModuleReconciler(client, daemonAPI, nodeConditionAPI).SetupWithManager(mgr); err != nil {
 		cmd.FatalError(setupLogger, err, "unable to create controller", "name", controllers.PodNodeModuleReconcilerName)
 	}
 
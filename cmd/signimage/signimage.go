@@ -8,6 +8,8 @@ import (
 	"fmt"
 	"github.com/go-logr/logr"
 	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/registry"
 	"io"
 	"io/fs"
@@ -42,6 +44,13 @@ func NewRepoAuth(configDir string, pullRepo string, pushRepo string) *repoAuth {
 		configDir: filepath.Clean(configDir),
 	}
 	r.PopulateAuthFromFileList()
+	// then try the docker config of the container ($DOCKER_CONFIG), which may configure credential helpers
+	if r.PullAuth == nil {
+		r.PullAuth = authFromDefaultKeychain(r.pullRepo)
+	}
+	if r.PushAuth == nil {
+		r.PushAuth = authFromDefaultKeychain(r.pushRepo)
+	}
 	// if we haven't found appropriate secrets try anonymous
 	// its not clear if this is what the user wants so we need to log it and let them decide
 	if r.PullAuth == nil {
@@ -55,6 +64,27 @@ func NewRepoAuth(configDir string, pullRepo string, pushRepo string) *repoAuth {
 	return r
 }
 
+// authFromDefaultKeychain returns the credentials for repo from the default keychain, or nil if there are none.
+func authFromDefaultKeychain(repo string) authn.Authenticator {
+	reg, err := name.NewRegistry(repo)
+	if err != nil {
+		return nil
+	}
+
+	a, err := authn.DefaultKeychain.Resolve(reg)
+	if err != nil {
+		logger.Info("could not resolve credentials from the default keychain", "registry", repo, "error", err)
+		return nil
+	}
+
+	if a == authn.Anonymous {
+		return nil
+	}
+
+	logger.Info("Found credentials in the default keychain", "registry", repo)
+	return a
+}
+
 func (r *repoAuth) PopulateAuthFromFileList() {
 	// not giving any secrets should short cuircuit the whole process
 	if r.configDir == "" {
@@ -173,6 +203,15 @@ func signFile(filename string, publickey string, privatekey string) error {
 	}
 	return nil
 }
+
+// readCABundle returns the content of the CA bundle file, or nil if no file was specified.
+func readCABundle(path string) ([]byte, error) {
+	if path == "" {
+		return nil, nil
+	}
+	return os.ReadFile(path)
+}
+
 func die(exitval int, message string, err error) {
 	fmt.Fprintf(os.Stderr, "\n%s\n", message)
 	logger.Info("ERROR "+message, "err", err)
@@ -260,6 +299,7 @@ func main() {
 	var unsignedImageName string
 	var signedImageName string
 	var secretDir string
+	var pushSecretDir string
 	var extractionDir string
 	var filesList string
 	var privKeyFile string
@@ -269,21 +309,28 @@ func main() {
 	var skipTlsVerifyPull bool
 	var insecurePush bool
 	var skipTlsVerifyPush bool
+	var caBundlePullFile string
+	var caBundlePushFile string
+	var unsignedImageMirrors string
 
 	logger = klogr.New()
 
 	flag.StringVar(&unsignedImageName, "unsignedimage", "", "name of the image to sign")
 	flag.StringVar(&signedImageName, "signedimage", "", "name of the signed image to produce")
+	flag.StringVar(&unsignedImageMirrors, "unsignedimage-mirrors", "", "comma separated list of mirrors of the image to sign, tried in order before unsignedimage")
 	flag.StringVar(&filesList, "filestosign", "", "colon seperated list of kmods to sign")
 	flag.StringVar(&privKeyFile, "key", "", "path to file containing private key for signing")
 	flag.StringVar(&pubKeyFile, "cert", "", "path to file containing public key for signing")
-	flag.StringVar(&secretDir, "secretdir", "", "path to directory containing credentials for pushing images")
+	flag.StringVar(&secretDir, "secretdir", "", "path to directory containing credentials for pulling and pushing images")
+	flag.StringVar(&pushSecretDir, "pushsecretdir", "", "path to directory containing credentials for pushing images; if set, credentials in secretdir are only used for pulling")
 	flag.BoolVar(&nopush, "no-push", false, "do not push the resulting image")
 
 	flag.BoolVar(&insecurePull, "insecure-pull", false, "images can be pulled from an insecure (plain HTTP) registry")
 	flag.BoolVar(&skipTlsVerifyPull, "skip-tls-verify-pull", false, "do not check TLS certs on pull")
 	flag.BoolVar(&insecurePush, "insecure", false, "built images can be pushed to an insecure (plain HTTP) registry")
 	flag.BoolVar(&skipTlsVerifyPush, "skip-tls-verify", false, "do not check TLS certs on push")
+	flag.StringVar(&caBundlePullFile, "ca-bundle-pull", "", "path to a file containing additional PEM-encoded CA certificates to trust on pull")
+	flag.StringVar(&caBundlePushFile, "ca-bundle", "", "path to a file containing additional PEM-encoded CA certificates to trust on push")
 
 	flag.Parse()
 
@@ -318,14 +365,48 @@ func main() {
 
 	a := NewRepoAuth(secretDir, strings.Split(unsignedImageName, "/")[0], strings.Split(signedImageName, "/")[0])
 
-	r := registry.NewRegistry()
+	if pushSecretDir != "" {
+		pushRepo := strings.Split(signedImageName, "/")[0]
+		a.PushAuth = NewRepoAuth(pushSecretDir, pushRepo, pushRepo).PushAuth
+	}
+
+	caBundlePull, err := readCABundle(caBundlePullFile)
+	if err != nil {
+		die(2, "could not read the pull CA bundle", err)
+	}
 
-	img, err := r.GetImageByName(unsignedImageName, a.PullAuth, insecurePull, skipTlsVerifyPull)
+	caBundlePush, err := readCABundle(caBundlePushFile)
 	if err != nil {
-		die(3, "could not Image()", err)
+		die(2, "could not read the push CA bundle", err)
+	}
+
+	r := registry.NewRegistry(nil, 0, nil)
+
+	var img v1.Image
+
+	if unsignedImageMirrors != "" {
+		for _, mirror := range strings.Split(unsignedImageMirrors, ",") {
+			mirrorAuth := NewRepoAuth(secretDir, strings.Split(mirror, "/")[0], "")
+
+			img, err = r.GetImageByName(mirror, mirrorAuth.PullAuth, insecurePull, skipTlsVerifyPull, caBundlePull)
+			if err == nil {
+				logger.Info("Successfully pulled image from mirror", "image", mirror)
+				break
+			}
+
+			logger.Info("could not pull image from mirror", "image", mirror, "error", err)
+		}
+	}
+
+	if img == nil {
+		img, err = r.GetImageByName(unsignedImageName, a.PullAuth, insecurePull, skipTlsVerifyPull, caBundlePull)
+		if err != nil {
+			die(3, "could not Image()", err)
+		}
+
+		logger.Info("Successfully pulled image", "image", unsignedImageName)
 	}
 
-	logger.Info("Successfully pulled image", "image", unsignedImageName)
 	logger.Info("Looking for files", "filelist", strings.Replace(filesList, ":", " ", -1))
 
 	/*
@@ -372,7 +453,7 @@ func main() {
 
 	if !nopush {
 		// write the image back to the name:tag set via the args
-		err := r.WriteImageByName(signedImageName, signedImage, a.PushAuth, insecurePush, skipTlsVerifyPush)
+		err := r.WriteImageByName(signedImageName, signedImage, a.PushAuth, insecurePush, skipTlsVerifyPush, caBundlePush)
 		if err != nil {
 			die(8, "failed to write signed image", err)
 		}
@@ -36,6 +36,7 @@ import (
 	hubv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api-hub/v1beta1"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/cluster"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/filter"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/gc"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/manifestwork"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/statusupdater"
 )
@@ -51,6 +52,8 @@ type ManagedClusterModuleReconciler struct {
 	statusupdaterAPI statusupdater.ManagedClusterModuleStatusUpdater
 
 	filter *filter.Filter
+
+	gcDefaults gc.Policy
 }
 
 //+kubebuilder:rbac:groups=hub.kmm.sigs.x-k8s.io,resources=managedclustermodules,verbs=get;list;watch;update;patch
@@ -59,21 +62,24 @@ type ManagedClusterModuleReconciler struct {
 //+kubebuilder:rbac:groups=work.open-cluster-management.io,resources=manifestworks,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=cluster.open-cluster-management.io,resources=managedclusters,verbs=get;list;watch
 //+kubebuilder:rbac:groups=batch,resources=jobs,verbs=create;list;watch;delete
-//+kubebuilder:rbac:groups="core",resources=secrets,verbs=get;list;watch
+//+kubebuilder:rbac:groups="core",resources=secrets,verbs=create;get;list;patch;watch
 //+kubebuilder:rbac:groups="core",resources=configmaps,verbs=get;list;watch
+//+kubebuilder:rbac:groups="core",resources=serviceaccounts,verbs=get;list;watch
 
 func NewManagedClusterModuleReconciler(
 	client client.Client,
 	manifestAPI manifestwork.ManifestWorkCreator,
 	clusterAPI cluster.ClusterAPI,
 	statusupdaterAPI statusupdater.ManagedClusterModuleStatusUpdater,
-	filter *filter.Filter) *ManagedClusterModuleReconciler {
+	filter *filter.Filter,
+	gcDefaults gc.Policy) *ManagedClusterModuleReconciler {
 	return &ManagedClusterModuleReconciler{
 		client:           client,
 		manifestAPI:      manifestAPI,
 		clusterAPI:       clusterAPI,
 		statusupdaterAPI: statusupdaterAPI,
 		filter:           filter,
+		gcDefaults:       gcDefaults,
 	}
 }
 
@@ -94,6 +100,11 @@ func (r *ManagedClusterModuleReconciler) Reconcile(ctx context.Context, req ctrl
 
 	logger.Info("Requested KMMO ManagedClusterModule")
 
+	if mcm.Spec.ModuleSpec.Suspend {
+		logger.Info("ManagedClusterModule is suspended; only updating its status")
+		return res, r.updateStatus(ctx, mcm)
+	}
+
 	clusters, err := r.clusterAPI.SelectedManagedClusters(ctx, mcm)
 	if err != nil {
 		return res, fmt.Errorf("failed to get selected clusters: %v", err)
@@ -136,7 +147,9 @@ func (r *ManagedClusterModuleReconciler) Reconcile(ctx context.Context, req ctrl
 		return res, fmt.Errorf("failed to garbage collect ManifestWorks with no matching cluster selector: %v", err)
 	}
 
-	deleted, err := r.clusterAPI.GarbageCollectBuilds(ctx, *mcm)
+	gcPolicy := r.gcDefaults.WithOverrides(mcm.Spec.ModuleSpec.GarbageCollection)
+
+	deleted, requeueAfter, err := r.clusterAPI.GarbageCollectBuilds(ctx, *mcm, gcPolicy)
 	if err != nil {
 		return res, fmt.Errorf("failed to garbage collect build objects: %v", err)
 	}
@@ -144,15 +157,22 @@ func (r *ManagedClusterModuleReconciler) Reconcile(ctx context.Context, req ctrl
 		logger.Info("Garbage-collected Build objects", "names", deleted)
 	}
 
+	// jobs kept by the garbage collection need another reconciliation once they expire
+	res.RequeueAfter = requeueAfter
+
+	return res, r.updateStatus(ctx, mcm)
+}
+
+func (r *ManagedClusterModuleReconciler) updateStatus(ctx context.Context, mcm *hubv1beta1.ManagedClusterModule) error {
 	ownedManifestWorkList, err := r.manifestAPI.GetOwnedManifestWorks(ctx, *mcm)
 	if err != nil {
-		return res, fmt.Errorf("failed to fetch owned ManifestWorks of the ManagedClusterModule: %v", err)
+		return fmt.Errorf("failed to fetch owned ManifestWorks of the ManagedClusterModule: %v", err)
 	}
 	if err := r.statusupdaterAPI.ManagedClusterModuleUpdateStatus(ctx, mcm, ownedManifestWorkList.Items); err != nil {
-		return res, fmt.Errorf("failed to update status of the ManagedClusterModule: %v", err)
+		return fmt.Errorf("failed to update status of the ManagedClusterModule: %v", err)
 	}
 
-	return res, nil
+	return nil
 }
 
 // SetupWithManager sets up the controller with the Manager.
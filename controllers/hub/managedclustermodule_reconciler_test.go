@@ -19,6 +19,7 @@ package hub
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/kubernetes-sigs/kernel-module-management/api-hub/v1beta1"
 	kmmv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
@@ -36,6 +37,7 @@ import (
 
 	"github.com/kubernetes-sigs/kernel-module-management/internal/client"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/cluster"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/gc"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/manifestwork"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/statusupdater"
 )
@@ -76,7 +78,7 @@ var _ = Describe("ManagedClusterModuleReconciler_Reconcile", func() {
 				apierrors.NewNotFound(schema.GroupResource{}, mcmName),
 			)
 
-		mcmr := NewManagedClusterModuleReconciler(clnt, nil, mockClusterAPI, nil, nil)
+		mcmr := NewManagedClusterModuleReconciler(clnt, nil, mockClusterAPI, nil, nil, gc.Policy{})
 		Expect(
 			mcmr.Reconcile(ctx, req),
 		).To(
@@ -88,7 +90,7 @@ var _ = Describe("ManagedClusterModuleReconciler_Reconcile", func() {
 		mockClusterAPI.EXPECT().RequestedManagedClusterModule(ctx, req.NamespacedName).
 			Return(nil, errors.New("test"))
 
-		mr := NewManagedClusterModuleReconciler(clnt, nil, mockClusterAPI, nil, nil)
+		mr := NewManagedClusterModuleReconciler(clnt, nil, mockClusterAPI, nil, nil, gc.Policy{})
 
 		res, err := mr.Reconcile(ctx, req)
 		Expect(err).To(HaveOccurred())
@@ -115,7 +117,7 @@ var _ = Describe("ManagedClusterModuleReconciler_Reconcile", func() {
 				),
 		)
 
-		mr := NewManagedClusterModuleReconciler(clnt, nil, mockClusterAPI, nil, nil)
+		mr := NewManagedClusterModuleReconciler(clnt, nil, mockClusterAPI, nil, nil, gc.Policy{})
 
 		res, err := mr.Reconcile(context.Background(), req)
 		Expect(err).To(HaveOccurred())
@@ -140,12 +142,38 @@ var _ = Describe("ManagedClusterModuleReconciler_Reconcile", func() {
 			mockClusterAPI.EXPECT().RequestedManagedClusterModule(ctx, req.NamespacedName).Return(mcm, nil),
 			mockClusterAPI.EXPECT().SelectedManagedClusters(ctx, gomock.Any()).Return(&clusterList, nil),
 			mockMW.EXPECT().GarbageCollect(ctx, clusterList, *mcm),
-			mockClusterAPI.EXPECT().GarbageCollectBuilds(ctx, *mcm),
+			mockClusterAPI.EXPECT().GarbageCollectBuilds(ctx, *mcm, gc.Policy{}),
 			mockMW.EXPECT().GetOwnedManifestWorks(ctx, *mcm).Return(&manifestWorkList, nil),
 			mockSU.EXPECT().ManagedClusterModuleUpdateStatus(ctx, mcm, manifestWorkList.Items).Return(nil),
 		)
 
-		mr := NewManagedClusterModuleReconciler(clnt, mockMW, mockClusterAPI, mockSU, nil)
+		mr := NewManagedClusterModuleReconciler(clnt, mockMW, mockClusterAPI, mockSU, nil, gc.Policy{})
+
+		res, err := mr.Reconcile(context.Background(), req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).To(Equal(reconcile.Result{}))
+	})
+
+	It("should only update the status when the ManagedClusterModule is suspended", func() {
+		mcm := &v1beta1.ManagedClusterModule{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: mcmName,
+			},
+			Spec: v1beta1.ManagedClusterModuleSpec{
+				ModuleSpec: kmmv1beta1.ModuleSpec{Suspend: true},
+				Selector:   map[string]string{"key": "value"},
+			},
+		}
+
+		manifestWorkList := workv1.ManifestWorkList{}
+
+		gomock.InOrder(
+			mockClusterAPI.EXPECT().RequestedManagedClusterModule(ctx, req.NamespacedName).Return(mcm, nil),
+			mockMW.EXPECT().GetOwnedManifestWorks(ctx, *mcm).Return(&manifestWorkList, nil),
+			mockSU.EXPECT().ManagedClusterModuleUpdateStatus(ctx, mcm, manifestWorkList.Items).Return(nil),
+		)
+
+		mr := NewManagedClusterModuleReconciler(clnt, mockMW, mockClusterAPI, mockSU, nil, gc.Policy{})
 
 		res, err := mr.Reconcile(context.Background(), req)
 		Expect(err).NotTo(HaveOccurred())
@@ -171,7 +199,7 @@ var _ = Describe("ManagedClusterModuleReconciler_Reconcile", func() {
 			mockMW.EXPECT().GarbageCollect(ctx, clusterList, *mcm).Return(errors.New("test")),
 		)
 
-		mr := NewManagedClusterModuleReconciler(clnt, mockMW, mockClusterAPI, nil, nil)
+		mr := NewManagedClusterModuleReconciler(clnt, mockMW, mockClusterAPI, nil, nil, gc.Policy{})
 
 		res, err := mr.Reconcile(context.Background(), req)
 		Expect(err).To(HaveOccurred())
@@ -195,10 +223,10 @@ var _ = Describe("ManagedClusterModuleReconciler_Reconcile", func() {
 			mockClusterAPI.EXPECT().RequestedManagedClusterModule(ctx, req.NamespacedName).Return(mcm, nil),
 			mockClusterAPI.EXPECT().SelectedManagedClusters(ctx, gomock.Any()).Return(&clusterList, nil),
 			mockMW.EXPECT().GarbageCollect(ctx, clusterList, *mcm),
-			mockClusterAPI.EXPECT().GarbageCollectBuilds(ctx, *mcm).Return(nil, errors.New("test")),
+			mockClusterAPI.EXPECT().GarbageCollectBuilds(ctx, *mcm, gc.Policy{}).Return(nil, time.Duration(0), errors.New("test")),
 		)
 
-		mr := NewManagedClusterModuleReconciler(clnt, mockMW, mockClusterAPI, nil, nil)
+		mr := NewManagedClusterModuleReconciler(clnt, mockMW, mockClusterAPI, nil, nil, gc.Policy{})
 
 		res, err := mr.Reconcile(context.Background(), req)
 		Expect(err).To(HaveOccurred())
@@ -222,11 +250,11 @@ var _ = Describe("ManagedClusterModuleReconciler_Reconcile", func() {
 			mockClusterAPI.EXPECT().RequestedManagedClusterModule(ctx, req.NamespacedName).Return(mcm, nil),
 			mockClusterAPI.EXPECT().SelectedManagedClusters(ctx, gomock.Any()).Return(&clusterList, nil),
 			mockMW.EXPECT().GarbageCollect(ctx, clusterList, *mcm),
-			mockClusterAPI.EXPECT().GarbageCollectBuilds(ctx, *mcm),
+			mockClusterAPI.EXPECT().GarbageCollectBuilds(ctx, *mcm, gc.Policy{}),
 			mockMW.EXPECT().GetOwnedManifestWorks(ctx, *mcm).Return(nil, errors.New("generic-error")),
 		)
 
-		mr := NewManagedClusterModuleReconciler(clnt, mockMW, mockClusterAPI, nil, nil)
+		mr := NewManagedClusterModuleReconciler(clnt, mockMW, mockClusterAPI, nil, nil, gc.Policy{})
 
 		res, err := mr.Reconcile(context.Background(), req)
 		Expect(err).To(HaveOccurred())
@@ -251,12 +279,12 @@ var _ = Describe("ManagedClusterModuleReconciler_Reconcile", func() {
 			mockClusterAPI.EXPECT().RequestedManagedClusterModule(ctx, req.NamespacedName).Return(mcm, nil),
 			mockClusterAPI.EXPECT().SelectedManagedClusters(ctx, gomock.Any()).Return(&clusterList, nil),
 			mockMW.EXPECT().GarbageCollect(ctx, clusterList, *mcm),
-			mockClusterAPI.EXPECT().GarbageCollectBuilds(ctx, *mcm),
+			mockClusterAPI.EXPECT().GarbageCollectBuilds(ctx, *mcm, gc.Policy{}),
 			mockMW.EXPECT().GetOwnedManifestWorks(ctx, *mcm).Return(&manifestWorkList, nil),
 			mockSU.EXPECT().ManagedClusterModuleUpdateStatus(ctx, mcm, manifestWorkList.Items).Return(errors.New("generic-error")),
 		)
 
-		mr := NewManagedClusterModuleReconciler(clnt, mockMW, mockClusterAPI, mockSU, nil)
+		mr := NewManagedClusterModuleReconciler(clnt, mockMW, mockClusterAPI, mockSU, nil, gc.Policy{})
 
 		res, err := mr.Reconcile(context.Background(), req)
 		Expect(err).To(HaveOccurred())
@@ -298,18 +326,48 @@ var _ = Describe("ManagedClusterModuleReconciler_Reconcile", func() {
 			mockClusterAPI.EXPECT().SelectedManagedClusters(ctx, gomock.Any()).Return(&clusterList, nil),
 			mockClusterAPI.EXPECT().BuildAndSign(gomock.Any(), mcm, clusterList.Items[0]).Return(false, nil),
 			mockMW.EXPECT().GarbageCollect(ctx, clusterList, mcm),
-			mockClusterAPI.EXPECT().GarbageCollectBuilds(ctx, mcm),
+			mockClusterAPI.EXPECT().GarbageCollectBuilds(ctx, mcm, gc.Policy{}),
 			mockMW.EXPECT().GetOwnedManifestWorks(ctx, mcm).Return(&manifestWorkList, nil),
 			mockSU.EXPECT().ManagedClusterModuleUpdateStatus(ctx, &mcm, manifestWorkList.Items).Return(nil),
 		)
 
-		mr := NewManagedClusterModuleReconciler(clnt, mockMW, mockClusterAPI, mockSU, nil)
+		mr := NewManagedClusterModuleReconciler(clnt, mockMW, mockClusterAPI, mockSU, nil, gc.Policy{})
 
 		res, err := mr.Reconcile(context.Background(), req)
 		Expect(err).ToNot(HaveOccurred())
 		Expect(res).To(Equal(reconcile.Result{Requeue: false}))
 	})
 
+	It("should requeue the request when the earliest kept build job expires", func() {
+		mcm := &v1beta1.ManagedClusterModule{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: mcmName,
+			},
+			Spec: v1beta1.ManagedClusterModuleSpec{
+				ModuleSpec: kmmv1beta1.ModuleSpec{},
+				Selector:   map[string]string{"key": "value"},
+			},
+		}
+
+		clusterList := clusterv1.ManagedClusterList{}
+		manifestWorkList := workv1.ManifestWorkList{}
+
+		gomock.InOrder(
+			mockClusterAPI.EXPECT().RequestedManagedClusterModule(ctx, req.NamespacedName).Return(mcm, nil),
+			mockClusterAPI.EXPECT().SelectedManagedClusters(ctx, gomock.Any()).Return(&clusterList, nil),
+			mockMW.EXPECT().GarbageCollect(ctx, clusterList, *mcm),
+			mockClusterAPI.EXPECT().GarbageCollectBuilds(ctx, *mcm, gc.Policy{}).Return(nil, 5*time.Minute, nil),
+			mockMW.EXPECT().GetOwnedManifestWorks(ctx, *mcm).Return(&manifestWorkList, nil),
+			mockSU.EXPECT().ManagedClusterModuleUpdateStatus(ctx, mcm, manifestWorkList.Items),
+		)
+
+		mr := NewManagedClusterModuleReconciler(clnt, mockMW, mockClusterAPI, mockSU, nil, gc.Policy{})
+
+		res, err := mr.Reconcile(context.Background(), req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).To(Equal(reconcile.Result{RequeueAfter: 5 * time.Minute}))
+	})
+
 	It("should create a ManifestWork if a managed cluster matches the selector and build/sign is completed", func() {
 		mcm := v1beta1.ManagedClusterModule{
 			ObjectMeta: metav1.ObjectMeta{
@@ -348,12 +406,12 @@ var _ = Describe("ManagedClusterModuleReconciler_Reconcile", func() {
 			mockMW.EXPECT().SetManifestWorkAsDesired(context.Background(), &mw, gomock.AssignableToTypeOf(mcm)),
 			clnt.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil),
 			mockMW.EXPECT().GarbageCollect(ctx, clusterList, mcm),
-			mockClusterAPI.EXPECT().GarbageCollectBuilds(ctx, mcm),
+			mockClusterAPI.EXPECT().GarbageCollectBuilds(ctx, mcm, gc.Policy{}),
 			mockMW.EXPECT().GetOwnedManifestWorks(ctx, mcm).Return(&manifestWorkList, nil),
 			mockSU.EXPECT().ManagedClusterModuleUpdateStatus(ctx, &mcm, manifestWorkList.Items).Return(nil),
 		)
 
-		mr := NewManagedClusterModuleReconciler(clnt, mockMW, mockClusterAPI, mockSU, nil)
+		mr := NewManagedClusterModuleReconciler(clnt, mockMW, mockClusterAPI, mockSU, nil, gc.Policy{})
 
 		res, err := mr.Reconcile(context.Background(), req)
 		Expect(err).NotTo(HaveOccurred())
@@ -402,12 +460,12 @@ var _ = Describe("ManagedClusterModuleReconciler_Reconcile", func() {
 				}),
 			clnt.EXPECT().Patch(gomock.Any(), gomock.Any(), gomock.Any()),
 			mockMW.EXPECT().GarbageCollect(ctx, clusterList, mcm),
-			mockClusterAPI.EXPECT().GarbageCollectBuilds(ctx, mcm),
+			mockClusterAPI.EXPECT().GarbageCollectBuilds(ctx, mcm, gc.Policy{}),
 			mockMW.EXPECT().GetOwnedManifestWorks(ctx, mcm).Return(&manifestWorkList, nil),
 			mockSU.EXPECT().ManagedClusterModuleUpdateStatus(ctx, &mcm, manifestWorkList.Items).Return(nil),
 		)
 
-		mr := NewManagedClusterModuleReconciler(clnt, mockMW, mockClusterAPI, mockSU, nil)
+		mr := NewManagedClusterModuleReconciler(clnt, mockMW, mockClusterAPI, mockSU, nil, gc.Policy{})
 
 		res, err := mr.Reconcile(context.Background(), req)
 		Expect(err).NotTo(HaveOccurred())
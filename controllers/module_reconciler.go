@@ -18,24 +18,37 @@ package controllers
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"reflect"
+	"sort"
 	"strings"
+	"time"
 
 	kmmv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/api"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/build"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/conflict"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/constants"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/daemonset"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/filter"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/gc"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/metrics"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/module"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/nodecondition"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/pin"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/rollback"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/sign"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/statusupdater"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/teardown"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/upgrade"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/utils"
 	appsv1 "k8s.io/api/apps/v1"
 	batchv1 "k8s.io/api/batch/v1"
 	v1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/sets"
@@ -50,6 +63,21 @@ import (
 
 const ModuleReconcilerName = "Module"
 
+// upgradeRequeueAfter is how often the Module is reconciled while nodes are being upgraded, as some steps such as
+// pod evictions do not trigger a reconciliation.
+const upgradeRequeueAfter = 10 * time.Second
+
+// teardownRequeueAfter is how often a Module is reconciled while it is being torn down.
+const teardownRequeueAfter = 10 * time.Second
+
+// versionSwitchRequeueAfter is how often a Module is reconciled while nodes wait for the ModuleLoader pod of their
+// previous version to be gone.
+const versionSwitchRequeueAfter = 10 * time.Second
+
+// conflictExclusionRequeueAfter is how often a Module is reconciled while nodes from which it is excluded wait for its
+// ModuleLoader pod to acknowledge that it will not unload the kernel module.
+const conflictExclusionRequeueAfter = 10 * time.Second
+
 // ModuleReconciler reconciles a Module object
 type ModuleReconciler struct {
 	client.Client
@@ -62,6 +90,17 @@ type ModuleReconciler struct {
 	operatorNamespace string
 	filter            *filter.Filter
 	statusUpdaterAPI  statusupdater.ModuleStatusUpdater
+	upgradeAPI        upgrade.Orchestrator
+	rollbackAPI       rollback.ImageTracker
+	pinAPI            pin.Pinner
+	teardownAPI       teardown.Manager
+	nodeConditionAPI  nodecondition.Updater
+	conflictAPI       conflict.Detector
+	jobHelperAPI      utils.JobHelper
+	gcDefaults        gc.Policy
+
+	// pendingKernelAnnotation is the node annotation holding the kernel version that the node will run next
+	pendingKernelAnnotation string
 }
 
 func NewModuleReconciler(
@@ -73,7 +112,16 @@ func NewModuleReconciler(
 	metricsAPI metrics.Metrics,
 	filter *filter.Filter,
 	statusUpdaterAPI statusupdater.ModuleStatusUpdater,
+	upgradeAPI upgrade.Orchestrator,
+	rollbackAPI rollback.ImageTracker,
+	pinAPI pin.Pinner,
+	teardownAPI teardown.Manager,
+	nodeConditionAPI nodecondition.Updater,
+	conflictAPI conflict.Detector,
+	jobHelperAPI utils.JobHelper,
 	operatorNamespace string,
+	gcDefaults gc.Policy,
+	pendingKernelAnnotation string,
 ) *ModuleReconciler {
 	return &ModuleReconciler{
 		Client:            client,
@@ -84,16 +132,31 @@ func NewModuleReconciler(
 		metricsAPI:        metricsAPI,
 		filter:            filter,
 		statusUpdaterAPI:  statusUpdaterAPI,
+		upgradeAPI:        upgradeAPI,
+		rollbackAPI:       rollbackAPI,
+		pinAPI:            pinAPI,
+		teardownAPI:       teardownAPI,
+		nodeConditionAPI:  nodeConditionAPI,
+		conflictAPI:       conflictAPI,
+		jobHelperAPI:      jobHelperAPI,
 		operatorNamespace: operatorNamespace,
+		gcDefaults:        gcDefaults,
+
+		pendingKernelAnnotation: pendingKernelAnnotation,
 	}
 }
 
 //+kubebuilder:rbac:groups=kmm.sigs.x-k8s.io,resources=modules,verbs=get;list;watch;update;patch
 //+kubebuilder:rbac:groups=kmm.sigs.x-k8s.io,resources=modules/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=apps,resources=daemonsets,verbs=create;delete;get;list;patch;watch
-//+kubebuilder:rbac:groups="core",resources=nodes,verbs=get;list;watch
-//+kubebuilder:rbac:groups="core",resources=secrets,verbs=get;list;watch
+//+kubebuilder:rbac:groups="core",resources=nodes,verbs=get;list;patch;watch
+//+kubebuilder:rbac:groups="core",resources=nodes/status,verbs=patch
+//+kubebuilder:rbac:groups="core",resources=pods,verbs=delete;list;patch;watch
+//+kubebuilder:rbac:groups="core",resources=pods/eviction,verbs=create
+//+kubebuilder:rbac:groups="core",resources=secrets,verbs=create;get;list;patch;watch
 //+kubebuilder:rbac:groups="core",resources=configmaps,verbs=get;list;watch
+//+kubebuilder:rbac:groups="core",resources=serviceaccounts,verbs=get;list;watch
+//+kubebuilder:rbac:groups="core",resources=events,verbs=create;patch
 //+kubebuilder:rbac:groups="batch",resources=jobs,verbs=create;list;watch;delete
 
 // Reconcile lists all nodes and looks for kernels that match its mappings.
@@ -108,20 +171,52 @@ func (r *ModuleReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 	if err != nil {
 		if k8serrors.IsNotFound(err) {
 			logger.Info("Module deleted")
+			r.metricsAPI.DeleteRebootRequiredNodes(req.Name, req.Namespace)
 			return ctrl.Result{}, nil
 		}
 
 		return res, fmt.Errorf("failed to get the requested %s KMMO CR: %w", req.NamespacedName, err)
 	}
 
+	if mod.GetDeletionTimestamp() != nil {
+		return r.handleDeletion(ctx, mod)
+	}
+
+	if mod.Spec.Suspend {
+		logger.Info("Module is suspended; only setting its Suspended condition")
+
+		if err = r.statusUpdaterAPI.ModuleSuspendedUpdateStatus(ctx, mod); err != nil {
+			return res, fmt.Errorf("failed to update status of the module: %w", err)
+		}
+
+		return res, nil
+	}
+
+	if err = r.addFinalizer(ctx, mod); err != nil {
+		return res, fmt.Errorf("could not add the finalizer to module %s: %v", mod.Name, err)
+	}
+
 	r.setKMMOMetrics(ctx)
 
-	targetedNodes, err := r.getNodesListBySelector(ctx, mod)
+	startupTaints, err := r.getStartupTaints(ctx)
+	if err != nil {
+		return res, fmt.Errorf("could not get the startup taints of the modules: %v", err)
+	}
+
+	targetedNodes, err := r.getNodesListBySelector(ctx, mod, startupTaints)
 	if err != nil {
 		return res, fmt.Errorf("could get targeted nodes for module %s: %w", mod.Name, err)
 	}
 
-	mldMappings, nodesWithMapping, err := r.getRelevantKernelMappingsAndNodes(ctx, mod, targetedNodes)
+	conflicts, err := r.conflictAPI.FindConflicts(ctx, mod, targetedNodes)
+	if err != nil {
+		return res, fmt.Errorf("could not find the conflicts of module %s: %v", mod.Name, err)
+	}
+
+	// nodes on which another Module loads the same kernel module do not run the ModuleLoader of this Module
+	deployableNodes, excludedNodes := excludeConflictingNodes(targetedNodes, conflicts)
+
+	mldMappings, nodesWithMapping, inconsistentMLDs, err := r.getRelevantKernelMappingsAndNodes(ctx, mod, deployableNodes)
 	if err != nil {
 		return res, fmt.Errorf("could get kernel mappings and nodes for modules %s: %w", mod.Name, err)
 	}
@@ -131,8 +226,43 @@ func (r *ModuleReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 		return res, fmt.Errorf("could get DaemonSets for module %s: %v", mod.Name, err)
 	}
 
+	adoptDaemonSetsWithoutArchitecture(mldMappings, dsByKernelVersion)
+
+	excludingNodes, err := r.labelConflictingNodes(ctx, mod, targetedNodes, excludedNodes)
+	if err != nil {
+		return res, fmt.Errorf("could not label the conflicting nodes of module %s: %v", mod.Name, err)
+	}
+
+	switchingVersions, err := r.switchNodeVersions(ctx, mod, targetedNodes)
+	if err != nil {
+		return res, fmt.Errorf("could not switch the version of the nodes of module %s: %v", mod.Name, err)
+	}
+
+	kernelStates := make(map[string]kmmv1beta1.KernelTargetStatus, len(mldMappings))
+	imageStatuses := make(map[string]kmmv1beta1.ModuleLoaderImageStatus, len(mldMappings))
+
+	// keep the image history of the kernels whose image is being built or signed again
+	for _, s := range mod.Status.ModuleLoaderImages {
+		if key := api.KernelArchKey(s.KernelVersion, s.Architecture); mldMappings[key] != nil || inconsistentMLDs[key] != nil {
+			imageStatuses[key] = s
+		}
+	}
+
+	for key := range inconsistentMLDs {
+		kernelStates[key] = kmmv1beta1.KernelTargetStatus{
+			State:   kmmv1beta1.KernelTargetError,
+			Message: "the nodes running this kernel need different images; check the node-specific template variables of the kernel mapping",
+		}
+	}
+
 	for kernelVersion, mld := range mldMappings {
+		var jobErr *jobFailedError
+
 		completedSuccessfully, err := r.handleBuild(ctx, mld)
+		if errors.As(err, &jobErr) {
+			kernelStates[kernelVersion] = kmmv1beta1.KernelTargetStatus{State: kmmv1beta1.KernelTargetError, Message: err.Error()}
+			continue
+		}
 		if err != nil {
 			return res, fmt.Errorf("failed to handle build for kernel version %s: %v", kernelVersion, err)
 		}
@@ -142,37 +272,117 @@ func (r *ModuleReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 		)
 		if !completedSuccessfully {
 			mldLogger.Info("Build has not finished successfully yet:skipping handling signing and driver container for now")
+			kernelStates[kernelVersion] = kmmv1beta1.KernelTargetStatus{State: kmmv1beta1.KernelTargetBuilding}
 			continue
 		}
 
 		completedSuccessfully, err = r.handleSigning(ctx, mld)
+		if errors.As(err, &jobErr) {
+			kernelStates[kernelVersion] = kmmv1beta1.KernelTargetStatus{State: kmmv1beta1.KernelTargetError, Message: err.Error()}
+			continue
+		}
 		if err != nil {
 			return res, fmt.Errorf("failed to handle signing for kernel version %s: %v", kernelVersion, err)
 		}
 		if !completedSuccessfully {
 			mldLogger.Info("Signing has not finished successfully yet; skipping handling driver container for now")
+			kernelStates[kernelVersion] = kmmv1beta1.KernelTargetStatus{State: kmmv1beta1.KernelTargetSigning}
 			continue
 		}
 
-		err = r.handleDriverContainer(ctx, mld, dsByKernelVersion)
+		kernelStates[kernelVersion] = kmmv1beta1.KernelTargetStatus{State: kmmv1beta1.KernelTargetReady}
+
+		dsKey := api.DaemonSetKey(mld.KernelVersion, mld.Architecture, mld.ModuleVersion)
+
+		imageStatus, err := r.rollbackAPI.SyncImage(ctx, mod, mld, dsByKernelVersion[dsKey])
+		if err != nil {
+			return res, fmt.Errorf("failed to sync the image history for kernel version %s: %v", kernelVersion, err)
+		}
+
+		dsMLD := new(api.ModuleLoaderData)
+		*dsMLD = *mld
+		dsMLD.StartupTaints = startupTaints
+
+		if imageStatus.RolledBackFrom != "" {
+			mldLogger.Info("Running the last known good image", "image", imageStatus.LastKnownGoodImage)
+			dsMLD.ContainerImage = imageStatus.LastKnownGoodImage
+		} else {
+			imageStatus.PinError = ""
+
+			imageStatus.Pinned, err = r.pinAPI.PinModuleLoaderImage(ctx, mod, mld)
+			if err != nil {
+				// the operator may not reach the registry, or may lack credentials only known to the kubelet: the
+				// image is then run by its tag
+				mldLogger.Info(utils.WarnString("could not pin the image; running it by its tag"), "error", err)
+				imageStatus.PinError = err.Error()
+			} else {
+				dsMLD.ContainerImage = pin.Reference(imageStatus.Pinned)
+			}
+		}
+
+		imageStatuses[kernelVersion] = imageStatus
+
+		err = r.handleDriverContainer(ctx, dsMLD, dsByKernelVersion)
 		if err != nil {
 			return res, fmt.Errorf("failed to handle driver container for kernel version %s: %v", kernelVersion, err)
 		}
 	}
 
+	logger.Info("Update node conditions")
+	if err = r.setNodeConditions(ctx, mod, deployableNodes, kernelStates); err != nil {
+		return res, fmt.Errorf("failed to set the node conditions: %v", err)
+	}
+
+	logger.Info("Handle pending kernels")
+	pendingMLDs := r.handlePendingKernels(ctx, mod, deployableNodes, kernelStates)
+
+	logger.Info("Handle kernel targets")
+	kernelTargets, targetMLDs := r.handleKernelTargets(ctx, mod, kernelStates)
+
+	for k, mld := range pendingMLDs {
+		targetMLDs[k] = mld
+	}
+
 	logger.Info("Handle device plugin")
-	err = r.handleDevicePlugin(ctx, mod)
+	devicePluginImage, err := r.handleDevicePlugin(ctx, mod)
 	if err != nil {
 		return res, fmt.Errorf("could handle device plugin: %w", err)
 	}
 
 	logger.Info("Run garbage collection")
-	err = r.garbageCollect(ctx, mod, mldMappings, dsByKernelVersion)
+	gcPolicy := r.gcDefaults.WithOverrides(mod.Spec.GarbageCollection)
+	// objects kept by the garbage collection need another reconciliation once they expire
+	res.RequeueAfter, err = r.garbageCollect(ctx, mod, mldMappings, targetMLDs, inconsistentMLDs, dsByKernelVersion, gcPolicy)
 	if err != nil {
 		return res, fmt.Errorf("failed to run garbage collection: %v", err)
 	}
 
-	err = r.statusUpdaterAPI.ModuleUpdateStatus(ctx, mod, nodesWithMapping, targetedNodes, dsByKernelVersion)
+	logger.Info("Handle upgrade")
+	upgradeStatus, err := r.upgradeAPI.Upgrade(ctx, mod, dsByKernelVersion)
+	if err != nil {
+		return res, fmt.Errorf("failed to upgrade the ModuleLoader pods: %v", err)
+	}
+
+	if upgradeStatus != nil && len(upgradeStatus.InProgress) > 0 &&
+		(res.RequeueAfter == 0 || res.RequeueAfter > upgradeRequeueAfter) {
+		res.RequeueAfter = upgradeRequeueAfter
+	}
+
+	if switchingVersions && (res.RequeueAfter == 0 || res.RequeueAfter > versionSwitchRequeueAfter) {
+		res.RequeueAfter = versionSwitchRequeueAfter
+	}
+
+	if excludingNodes && (res.RequeueAfter == 0 || res.RequeueAfter > conflictExclusionRequeueAfter) {
+		res.RequeueAfter = conflictExclusionRequeueAfter
+	}
+
+	images := make([]kmmv1beta1.ModuleLoaderImageStatus, 0, len(imageStatuses))
+
+	for _, key := range sets.List(sets.KeySet(imageStatuses)) {
+		images = append(images, imageStatuses[key])
+	}
+
+	err = r.statusUpdaterAPI.ModuleUpdateStatus(ctx, mod, nodesWithMapping, targetedNodes, dsByKernelVersion, kernelTargets, upgradeStatus, images, devicePluginImage, conflicts)
 	if err != nil {
 		return res, fmt.Errorf("failed to update status of the module: %w", err)
 	}
@@ -182,14 +392,84 @@ func (r *ModuleReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 	return res, nil
 }
 
+// addFinalizer adds the finalizer that lets KMM tear mod down according to its unload policy.
+func (r *ModuleReconciler) addFinalizer(ctx context.Context, mod *kmmv1beta1.Module) error {
+	if controllerutil.ContainsFinalizer(mod, constants.ModuleFinalizer) {
+		return nil
+	}
+
+	patchFrom := client.MergeFrom(mod.DeepCopy())
+
+	controllerutil.AddFinalizer(mod, constants.ModuleFinalizer)
+
+	return r.Patch(ctx, mod, patchFrom)
+}
+
+// handleDeletion tears mod down and removes its finalizer once done.
+func (r *ModuleReconciler) handleDeletion(ctx context.Context, mod *kmmv1beta1.Module) (ctrl.Result, error) {
+	if !controllerutil.ContainsFinalizer(mod, constants.ModuleFinalizer) {
+		return ctrl.Result{}, nil
+	}
+
+	logger := log.FromContext(ctx)
+
+	stage, err := r.teardownAPI.TearDown(ctx, mod)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to tear down module %s: %v", mod.Name, err)
+	}
+
+	if stage != teardown.StageDone {
+		logger.Info("Module is being torn down", "stage", stage)
+
+		if err = r.statusUpdaterAPI.ModuleTeardownUpdateStatus(ctx, mod, string(stage), stage.Message()); err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to update the teardown status of module %s: %v", mod.Name, err)
+		}
+
+		return ctrl.Result{RequeueAfter: teardownRequeueAfter}, nil
+	}
+
+	logger.Info("Module torn down; removing the finalizer")
+
+	patchFrom := client.MergeFrom(mod.DeepCopy())
+
+	controllerutil.RemoveFinalizer(mod, constants.ModuleFinalizer)
+
+	if err = r.Patch(ctx, mod, patchFrom); client.IgnoreNotFound(err) != nil {
+		return ctrl.Result{}, fmt.Errorf("could not remove the finalizer from module %s: %v", mod.Name, err)
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// sameModuleLoaderData returns true if a and b are the same once their templated fields are rendered.
+// Their TemplateVars are not compared: they always hold node-specific variables, such as OS_IMAGE_VERSION, which
+// differ during a rolling OS update even if no template uses them.
+func sameModuleLoaderData(a, b *api.ModuleLoaderData) bool {
+	aCopy := *a
+	bCopy := *b
+
+	aCopy.TemplateVars = nil
+	bCopy.TemplateVars = nil
+
+	return reflect.DeepEqual(&aCopy, &bCopy)
+}
+
+// getRelevantKernelMappingsAndNodes returns the ModuleLoaderData of each kernel and architecture run by targetedNodes,
+// keyed by api.KernelArchKey, and the nodes that can run it.
+// The ModuleLoaderData may depend on node-specific template variables such as OS_IMAGE_VERSION: the nodes running the
+// same kernel on the same architecture, which share a DaemonSet, must then agree on it.
+// The kernels for which they do not are returned separately, with the ModuleLoaderData of their first node, and their
+// nodes are not returned.
 func (r *ModuleReconciler) getRelevantKernelMappingsAndNodes(ctx context.Context,
 	mod *kmmv1beta1.Module,
-	targetedNodes []v1.Node) (map[string]*api.ModuleLoaderData, []v1.Node, error) {
+	targetedNodes []v1.Node) (map[string]*api.ModuleLoaderData, []v1.Node, map[string]*api.ModuleLoaderData, error) {
 
 	mldMappings := make(map[string]*api.ModuleLoaderData)
+	inconsistentMLDs := make(map[string]*api.ModuleLoaderData)
 	logger := log.FromContext(ctx)
 
 	nodes := make([]v1.Node, 0, len(targetedNodes))
+	nodeKeys := make([]string, 0, len(targetedNodes))
 
 	for _, node := range targetedNodes {
 		kernelVersion := strings.TrimSuffix(node.Status.NodeInfo.KernelVersion, "+")
@@ -197,32 +477,358 @@ func (r *ModuleReconciler) getRelevantKernelMappingsAndNodes(ctx context.Context
 		nodeLogger := logger.WithValues(
 			"node", node.Name,
 			"kernel version", kernelVersion,
+			"architecture", node.Status.NodeInfo.Architecture,
 		)
 
-		if mld, ok := mldMappings[kernelVersion]; ok {
-			nodes = append(nodes, node)
-			nodeLogger.V(1).Info("Using cached mld mapping", "mld", mld)
-			continue
-		}
+		key := api.KernelArchKey(kernelVersion, node.Status.NodeInfo.Architecture)
 
-		mld, err := r.kernelAPI.GetModuleLoaderDataForKernel(mod, kernelVersion)
+		mld, err := r.kernelAPI.GetModuleLoaderDataForKernel(ctx, mod, kernelVersion, &node.Status.NodeInfo)
 		if err != nil {
 			nodeLogger.Error(err, "failed to get and process kernel mapping")
 			continue
 		}
 
+		nodes = append(nodes, node)
+		nodeKeys = append(nodeKeys, key)
+
+		if _, ok := inconsistentMLDs[key]; ok {
+			continue
+		}
+
+		if cached, ok := mldMappings[key]; ok {
+			if !sameModuleLoaderData(mld, cached) {
+				nodeLogger.Error(
+					errors.New("nodes running the same kernel need different ModuleLoader images"),
+					"skipping the kernel; check the node-specific template variables of the kernel mapping",
+					"mld", mld,
+					"other mld", cached,
+				)
+				inconsistentMLDs[key] = cached
+				delete(mldMappings, key)
+			} else if strings.Join(mld.TemplateVars, "\n") < strings.Join(cached.TemplateVars, "\n") {
+				// keep the same ModuleLoaderData whatever the order of the nodes, as its variables are build arguments
+				mldMappings[key] = mld
+			}
+			continue
+		}
+
 		nodeLogger.V(1).Info("Found a valid mapping",
 			"image", mld.ContainerImage,
 			"build", mld.Build != nil,
 		)
 
-		mldMappings[kernelVersion] = mld
-		nodes = append(nodes, node)
+		mldMappings[key] = mld
+	}
+
+	relevantNodes := make([]v1.Node, 0, len(nodes))
+
+	for i, node := range nodes {
+		if _, ok := inconsistentMLDs[nodeKeys[i]]; !ok {
+			relevantNodes = append(relevantNodes, node)
+		}
+	}
+
+	return mldMappings, relevantNodes, inconsistentMLDs, nil
+}
+
+// excludeConflictingNodes splits nodes into the nodes that can run the ModuleLoader of a Module and the names of those
+// claimed by another Module in conflicts.
+func excludeConflictingNodes(nodes []v1.Node, conflicts []conflict.Conflict) ([]v1.Node, sets.Set[string]) {
+	claimedNodes := sets.New[string]()
+
+	for _, c := range conflicts {
+		claimedNodes.Insert(c.Nodes...)
+	}
+
+	deployableNodes := make([]v1.Node, 0, len(nodes))
+	excludedNodes := sets.New[string]()
+
+	for _, n := range nodes {
+		if claimedNodes.Has(n.Name) {
+			excludedNodes.Insert(n.Name)
+			continue
+		}
+
+		deployableNodes = append(deployableNodes, n)
+	}
+
+	return deployableNodes, excludedNodes
+}
+
+// labelConflictingNodes sets the conflict label of mod on the nodes in excludedNodes, which the ModuleLoader
+// DaemonSets of mod do not target, and removes it from the other nodes.
+// Once a node is labeled, the ModuleLoader pod of mod on that node is deleted and would unload the kernel module that
+// another Module loads: the node is only labeled once the pod acknowledged that it will not.
+// The pods of nodes that are not excluded anymore are not asked to skip unloading anymore.
+// It returns true if some nodes wait for that acknowledgement.
+func (r *ModuleReconciler) labelConflictingNodes(ctx context.Context, mod *kmmv1beta1.Module, nodes []v1.Node,
+	excludedNodes sets.Set[string]) (bool, error) {
+
+	labelName := utils.GetModuleConflictLabelName(mod.Namespace, mod.Name)
+
+	var podsByNode map[string][]v1.Pod
+
+	// pods are only asked to skip unloading while mod has conflicts; they are listed once more after the conflicts are
+	// resolved, as the condition is only updated at the end of the reconciliation
+	if excludedNodes.Len() > 0 || meta.IsStatusConditionTrue(mod.Status.Conditions, kmmv1beta1.ModuleConditionConflict) {
+		pods := v1.PodList{}
+
+		opts := []client.ListOption{
+			client.InNamespace(mod.Namespace),
+			client.MatchingLabels{constants.ModuleNameLabel: mod.Name, constants.DaemonSetRole: "module-loader"},
+		}
+
+		if err := r.Client.List(ctx, &pods, opts...); err != nil {
+			return false, fmt.Errorf("could not list the ModuleLoader pods: %v", err)
+		}
+
+		podsByNode = make(map[string][]v1.Pod)
+
+		for _, p := range pods.Items {
+			if p.DeletionTimestamp == nil {
+				podsByNode[p.Spec.NodeName] = append(podsByNode[p.Spec.NodeName], p)
+			}
+		}
+	}
+
+	waiting := false
+
+	for i := range nodes {
+		node := &nodes[i]
+
+		_, labeled := node.Labels[labelName]
+		excluded := excludedNodes.Has(node.Name)
+
+		if !excluded {
+			if err := r.cancelSkipUnload(ctx, podsByNode[node.Name]); err != nil {
+				return false, err
+			}
+		}
+
+		if labeled == excluded {
+			continue
+		}
+
+		if excluded {
+			canExclude, err := r.skipUnload(ctx, podsByNode[node.Name])
+			if err != nil {
+				return false, err
+			}
+
+			if !canExclude {
+				waiting = true
+				continue
+			}
+		}
+
+		patchFrom := client.MergeFrom(node.DeepCopy())
+
+		if excluded {
+			if node.Labels == nil {
+				node.Labels = make(map[string]string, 1)
+			}
+
+			node.Labels[labelName] = ""
+		} else {
+			delete(node.Labels, labelName)
+		}
+
+		if err := r.Patch(ctx, node, patchFrom); err != nil {
+			return false, fmt.Errorf("could not patch node %s: %v", node.Name, err)
+		}
+	}
+
+	return waiting, nil
+}
+
+// skipUnload asks pods not to unload the kernel module when they are deleted.
+// It returns true once all of them can be deleted without unloading the kernel module.
+func (r *ModuleReconciler) skipUnload(ctx context.Context, pods []v1.Pod) (bool, error) {
+	done := true
+
+	for i := range pods {
+		canDelete, err := daemonset.SkipUnload(ctx, r.Client, &pods[i])
+		if err != nil {
+			return false, err
+		}
+
+		if !canDelete {
+			log.FromContext(ctx).Info(
+				"Waiting for the ModuleLoader pod to acknowledge that it will not unload the kernel module",
+				"name", pods[i].Name,
+			)
+
+			done = false
+		}
+	}
+
+	return done, nil
+}
+
+// cancelSkipUnload removes the skip-unload annotation from pods, so that they unload the kernel module again when
+// deleted and become ready again.
+func (r *ModuleReconciler) cancelSkipUnload(ctx context.Context, pods []v1.Pod) error {
+	for i := range pods {
+		pod := &pods[i]
+
+		if _, ok := pod.Annotations[constants.SkipUnloadAnnotation]; !ok {
+			continue
+		}
+
+		patchFrom := client.MergeFrom(pod.DeepCopy())
+
+		delete(pod.Annotations, constants.SkipUnloadAnnotation)
+
+		if err := r.Patch(ctx, pod, patchFrom); err != nil {
+			return fmt.Errorf("could not patch pod %s: %v", pod.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// switchNodeVersions sets the scheduled version label of mod, which its ModuleLoader DaemonSets select, to the version
+// label of each of nodes.
+// A node only moves to another version once the ModuleLoader pod of its previous version is gone: the new pod would
+// otherwise load the kernel module while the previous one unloads it.
+// It returns true if some nodes wait for the ModuleLoader pod of their previous version to be gone.
+func (r *ModuleReconciler) switchNodeVersions(ctx context.Context, mod *kmmv1beta1.Module, nodes []v1.Node) (bool, error) {
+	versionLabelName := utils.GetModuleVersionLabelName(mod.Namespace, mod.Name)
+	scheduledLabelName := utils.GetModuleScheduledVersionLabelName(mod.Namespace, mod.Name)
+	versioned := mod.Spec.ModuleLoader.Container.Version != ""
+
+	nodesWithPods := sets.New[string]()
+
+	if versioned {
+		pods := v1.PodList{}
+
+		opts := []client.ListOption{
+			client.InNamespace(mod.Namespace),
+			client.MatchingLabels{constants.ModuleNameLabel: mod.Name, constants.DaemonSetRole: "module-loader"},
+		}
+
+		if err := r.Client.List(ctx, &pods, opts...); err != nil {
+			return false, fmt.Errorf("could not list the ModuleLoader pods: %v", err)
+		}
+
+		for _, p := range pods.Items {
+			nodesWithPods.Insert(p.Spec.NodeName)
+		}
+	}
+
+	waiting := false
+
+	for i := range nodes {
+		node := &nodes[i]
+
+		// the scheduled version label of a Module that is not versioned anymore is removed
+		version := ""
+		if versioned {
+			version = node.Labels[versionLabelName]
+		}
+
+		scheduledVersion := node.Labels[scheduledLabelName]
+
+		if scheduledVersion == version {
+			continue
+		}
+
+		if scheduledVersion == "" && nodesWithPods.Has(node.Name) {
+			waiting = true
+			continue
+		}
+
+		patchFrom := client.MergeFrom(node.DeepCopy())
+
+		if scheduledVersion != "" {
+			// the ModuleLoader pod of the previous version is deleted first
+			delete(node.Labels, scheduledLabelName)
+			waiting = waiting || version != ""
+		} else {
+			node.Labels[scheduledLabelName] = version
+		}
+
+		if err := r.Patch(ctx, node, patchFrom); err != nil {
+			return false, fmt.Errorf("could not patch node %s: %v", node.Name, err)
+		}
+	}
+
+	return waiting, nil
+}
+
+// setNodeConditions sets the condition of mod on the targeted nodes that cannot run the ModuleLoader yet, either
+// because no kernel mapping matches their kernel or because the image for their kernel is being built or signed, or
+// could not be.
+// The condition of the other nodes is maintained by the PodNodeModuleReconciler.
+func (r *ModuleReconciler) setNodeConditions(ctx context.Context, mod *kmmv1beta1.Module, targetedNodes []v1.Node,
+	kernelStates map[string]kmmv1beta1.KernelTargetStatus) error {
+
+	for i := range targetedNodes {
+		node := &targetedNodes[i]
+
+		kernelVersion := strings.TrimSuffix(node.Status.NodeInfo.KernelVersion, "+")
+		status, ok := kernelStates[api.KernelArchKey(kernelVersion, node.Status.NodeInfo.Architecture)]
+
+		var reason, message string
+
+		switch {
+		case !ok:
+			reason, message = nodecondition.ReasonNoMapping, "No kernel mapping matches kernel "+kernelVersion
+		case status.State == kmmv1beta1.KernelTargetBuilding || status.State == kmmv1beta1.KernelTargetSigning:
+			reason, message = nodecondition.ReasonBuildPending, "The image for kernel "+kernelVersion+" is being built or signed"
+		case status.State == kmmv1beta1.KernelTargetError:
+			reason, message = nodecondition.ReasonLoadFailed, "The image for kernel "+kernelVersion+" could not be prepared: "+status.Message
+		default:
+			continue
+		}
+
+		if err := r.nodeConditionAPI.SetModuleCondition(ctx, node, mod.Namespace, mod.Name, v1.ConditionFalse, reason, message); err != nil {
+			return fmt.Errorf("could not set the condition of node %s: %v", node.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// getStartupTaints returns the startup taints of all Modules, sorted.
+// Nodes may be registered with the startup taints of several Modules: the ModuleLoader of each Module must then
+// tolerate and ignore the startup taints of the others, or none of the kernel modules would ever be loaded.
+func (r *ModuleReconciler) getStartupTaints(ctx context.Context) ([]v1.Taint, error) {
+	mods := kmmv1beta1.ModuleList{}
+
+	if err := r.Client.List(ctx, &mods); err != nil {
+		return nil, fmt.Errorf("could not list modules: %v", err)
+	}
+
+	var taints []v1.Taint
+
+	for _, m := range mods.Items {
+		if st := m.Spec.StartupTaint; st != nil {
+			taints = append(taints, st.Taint())
+		}
+	}
+
+	// the ModuleLoader pods tolerate the taints in that order, which must not change between reconciliations
+	sort.Slice(taints, func(i, j int) bool {
+		if taints[i].Key != taints[j].Key {
+			return taints[i].Key < taints[j].Key
+		}
+
+		return taints[i].Effect < taints[j].Effect
+	})
+
+	unique := taints[:0]
+
+	for i, t := range taints {
+		if i == 0 || !t.MatchTaint(&taints[i-1]) {
+			unique = append(unique, t)
+		}
 	}
-	return mldMappings, nodes, nil
+
+	return unique, nil
 }
 
-func (r *ModuleReconciler) getNodesListBySelector(ctx context.Context, mod *kmmv1beta1.Module) ([]v1.Node, error) {
+func (r *ModuleReconciler) getNodesListBySelector(ctx context.Context, mod *kmmv1beta1.Module, startupTaints []v1.Taint) ([]v1.Node, error) {
 	logger := log.FromContext(ctx)
 	logger.V(1).Info("Listing nodes", "selector", mod.Spec.Selector)
 
@@ -235,7 +841,9 @@ func (r *ModuleReconciler) getNodesListBySelector(ctx context.Context, mod *kmmv
 	nodes := make([]v1.Node, 0, len(selectedNodes.Items))
 
 	for _, node := range selectedNodes.Items {
-		if isNodeSchedulable(&node) {
+		// nodes cordoned to upgrade any Module must keep the DaemonSets of all Modules, which would otherwise be
+		// garbage-collected if no other node runs the same kernel
+		if isNodeSchedulable(&node, startupTaints) || upgrade.IsAnyInProgress(&node) {
 			nodes = append(nodes, node)
 		}
 	}
@@ -270,6 +878,7 @@ func (r *ModuleReconciler) handleBuild(ctx context.Context, mld *api.ModuleLoade
 		r.metricsAPI.SetCompletedStage(mld.Name, mld.Namespace, mld.KernelVersion, metrics.BuildStage, true)
 	case utils.StatusFailed:
 		logger.Info(utils.WarnString("Build job has failed. If the fix is not in Module CR, then delete job after the fix in order to restart the job"))
+		return false, r.jobFailedError(ctx, mld, utils.JobTypeBuild)
 	}
 
 	return completedSuccessfully, nil
@@ -308,11 +917,178 @@ func (r *ModuleReconciler) handleSigning(ctx context.Context, mld *api.ModuleLoa
 		r.metricsAPI.SetCompletedStage(mld.Name, mld.Namespace, mld.KernelVersion, metrics.SignStage, true)
 	case utils.StatusFailed:
 		logger.Info(utils.WarnString("Sign job has failed. If the fix is not in Module CR, then delete job after the fix in order to restart the job"))
+		return false, r.jobFailedError(ctx, mld, utils.JobTypeSign)
 	}
 
 	return completedSuccessfully, nil
 }
 
+// handlePendingKernels builds and signs the images for the kernels that nodes will run next, as announced in their
+// pending kernel annotation, so that they are available when the nodes reboot.
+// kernelStates contains the state of the kernels that were already handled, keyed by api.KernelArchKey; it is updated
+// with the state of the pending kernels.
+// It returns the ModuleLoaderData computed for the pending kernels.
+func (r *ModuleReconciler) handlePendingKernels(
+	ctx context.Context,
+	mod *kmmv1beta1.Module,
+	nodes []v1.Node,
+	kernelStates map[string]kmmv1beta1.KernelTargetStatus) map[string]*api.ModuleLoaderData {
+	logger := log.FromContext(ctx)
+
+	pendingMLDs := make(map[string]*api.ModuleLoaderData)
+
+	for _, node := range nodes {
+		pendingKernel := strings.TrimSuffix(node.Annotations[r.pendingKernelAnnotation], "+")
+		if pendingKernel == "" {
+			continue
+		}
+
+		key := api.KernelArchKey(pendingKernel, node.Status.NodeInfo.Architecture)
+		if _, ok := kernelStates[key]; ok {
+			continue
+		}
+
+		nodeLogger := logger.WithValues("node", node.Name, "pending kernel", key)
+
+		mld, err := r.kernelAPI.GetModuleLoaderDataForKernel(ctx, mod, pendingKernel, &node.Status.NodeInfo)
+		if err != nil {
+			nodeLogger.Info(utils.WarnString("could not get the mapping for the pending kernel"), "error", err)
+			kernelStates[key] = kmmv1beta1.KernelTargetStatus{State: kmmv1beta1.KernelTargetError, Message: err.Error()}
+			continue
+		}
+
+		pendingMLDs[key] = mld
+
+		status := kmmv1beta1.KernelTargetStatus{}
+
+		status.State, err = r.prepareKernelTarget(ctx, mld)
+		if err != nil {
+			nodeLogger.Info(utils.WarnString("could not prepare the image for the pending kernel"), "error", err)
+			status = kmmv1beta1.KernelTargetStatus{State: kmmv1beta1.KernelTargetError, Message: err.Error()}
+		}
+
+		nodeLogger.Info("Handled pending kernel", "state", status.State)
+		kernelStates[key] = status
+	}
+
+	return pendingMLDs
+}
+
+// handleKernelTargets builds and signs the images for the kernels in spec.kernelTargets that no node runs yet.
+// kernelStates contains the state of the kernels that nodes already run, keyed by api.KernelArchKey.
+// It returns the status of each target and the ModuleLoaderData computed for the kernels that no node runs.
+// Errors are only reported in the status, so that preparing future kernels does not block the current ones.
+func (r *ModuleReconciler) handleKernelTargets(
+	ctx context.Context,
+	mod *kmmv1beta1.Module,
+	kernelStates map[string]kmmv1beta1.KernelTargetStatus) ([]kmmv1beta1.KernelTargetStatus, map[string]*api.ModuleLoaderData) {
+	logger := log.FromContext(ctx)
+
+	statuses := make([]kmmv1beta1.KernelTargetStatus, 0, len(mod.Spec.KernelTargets))
+	targetMLDs := make(map[string]*api.ModuleLoaderData)
+
+	for _, kt := range mod.Spec.KernelTargets {
+		status := kmmv1beta1.KernelTargetStatus{KernelVersion: kt.KernelVersion, Architecture: kt.Architecture}
+		key := api.KernelArchKey(kt.KernelVersion, kt.Architecture)
+
+		if s, ok := kernelStates[key]; ok {
+			status.State = s.State
+			status.Message = s.Message
+			statuses = append(statuses, status)
+			continue
+		}
+
+		nodeInfo := &v1.NodeSystemInfo{Architecture: kt.Architecture}
+
+		mld, err := r.kernelAPI.GetModuleLoaderDataForKernel(ctx, mod, kt.KernelVersion, nodeInfo)
+		if err != nil {
+			status.State = kmmv1beta1.KernelTargetError
+			status.Message = err.Error()
+			statuses = append(statuses, status)
+			continue
+		}
+
+		targetMLDs[key] = mld
+
+		status.State, err = r.prepareKernelTarget(ctx, mld)
+		if err != nil {
+			logger.Info(utils.WarnString("could not prepare the image for kernel target"), "kernel", key, "error", err)
+			status.State = kmmv1beta1.KernelTargetError
+			status.Message = err.Error()
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	return statuses, targetMLDs
+}
+
+// jobFailedError is returned by handleBuild and handleSigning when the job building or signing the image has failed.
+type jobFailedError struct {
+	jobType string
+	jobName string
+}
+
+func (e *jobFailedError) Error() string {
+	return fmt.Sprintf("%s job %s has failed", e.jobType, e.jobName)
+}
+
+// jobFailedError returns a *jobFailedError naming the failed job of type jobType for mld.
+func (r *ModuleReconciler) jobFailedError(ctx context.Context, mld *api.ModuleLoaderData, jobType string) error {
+	job, err := r.jobHelperAPI.GetModuleJobByKernel(ctx, mld.Name, mld.Namespace, mld.KernelVersion, mld.Architecture, jobType, mld.Owner)
+	if err != nil {
+		return fmt.Errorf("could not get the failed %s job: %v", jobType, err)
+	}
+
+	return &jobFailedError{jobType: jobType, jobName: job.Name}
+}
+
+func (r *ModuleReconciler) prepareKernelTarget(ctx context.Context, mld *api.ModuleLoaderData) (kmmv1beta1.KernelTargetState, error) {
+	completedSuccessfully, err := r.handleBuild(ctx, mld)
+	if err != nil {
+		return "", fmt.Errorf("failed to handle build: %v", err)
+	}
+	if !completedSuccessfully {
+		return kmmv1beta1.KernelTargetBuilding, nil
+	}
+
+	completedSuccessfully, err = r.handleSigning(ctx, mld)
+	if err != nil {
+		return "", fmt.Errorf("failed to handle signing: %v", err)
+	}
+	if !completedSuccessfully {
+		return kmmv1beta1.KernelTargetSigning, nil
+	}
+
+	return kmmv1beta1.KernelTargetReady, nil
+}
+
+// adoptDaemonSetsWithoutArchitecture re-keys in dsByKernelVersion the ModuleLoader DaemonSets created before they were
+// labeled with an architecture, so that they are patched rather than replaced.
+// Replacing them would unload the kernel module while the new DaemonSet loads it again.
+func adoptDaemonSetsWithoutArchitecture(mldMappings map[string]*api.ModuleLoaderData, dsByKernelVersion map[string]*appsv1.DaemonSet) {
+	for _, mld := range mldMappings {
+		if mld.Architecture == "" {
+			continue
+		}
+
+		key := api.DaemonSetKey(mld.KernelVersion, mld.Architecture, mld.ModuleVersion)
+		if dsByKernelVersion[key] != nil {
+			continue
+		}
+
+		legacyKey := api.DaemonSetKey(mld.KernelVersion, "", mld.ModuleVersion)
+
+		ds := dsByKernelVersion[legacyKey]
+		if ds == nil || ds.Labels[constants.DaemonSetRole] != "module-loader" {
+			continue
+		}
+
+		dsByKernelVersion[key] = ds
+		delete(dsByKernelVersion, legacyKey)
+	}
+}
+
 func (r *ModuleReconciler) handleDriverContainer(ctx context.Context,
 	mld *api.ModuleLoaderData,
 	dsByKernelVersion map[string]*appsv1.DaemonSet) error {
@@ -321,7 +1097,7 @@ func (r *ModuleReconciler) handleDriverContainer(ctx context.Context,
 	}
 
 	logger := log.FromContext(ctx)
-	if existingDS := dsByKernelVersion[mld.KernelVersion]; existingDS != nil {
+	if existingDS := dsByKernelVersion[api.DaemonSetKey(mld.KernelVersion, mld.Architecture, mld.ModuleVersion)]; existingDS != nil {
 		logger.Info("updating existing driver container DS", "kernel version", mld.KernelVersion, "image", mld.ContainerImage, "name", ds.Name)
 		ds = existingDS
 	} else {
@@ -343,24 +1119,36 @@ func (r *ModuleReconciler) handleDriverContainer(ctx context.Context,
 	return err
 }
 
-func (r *ModuleReconciler) handleDevicePlugin(ctx context.Context, mod *kmmv1beta1.Module) error {
+// handleDevicePlugin creates or patches the device plugin DaemonSet of mod to run the pinned device plugin image.
+// It returns the pinned image, or nil if the image could not be pinned and is run by its tag.
+func (r *ModuleReconciler) handleDevicePlugin(ctx context.Context, mod *kmmv1beta1.Module) (*kmmv1beta1.PinnedImage, error) {
 	if mod.Spec.DevicePlugin == nil {
-		return nil
+		return nil, nil
 	}
 
 	logger := log.FromContext(ctx)
+
+	dsMod := mod.DeepCopy()
+
+	pinned, err := r.pinAPI.PinDevicePluginImage(ctx, mod)
+	if err != nil {
+		logger.Info(utils.WarnString("could not pin the device plugin image; running it by its tag"), "error", err)
+	} else {
+		dsMod.Spec.DevicePlugin.Container.Image = pin.Reference(pinned)
+	}
+
 	ds := &appsv1.DaemonSet{
 		ObjectMeta: metav1.ObjectMeta{Namespace: mod.Namespace},
 	}
 	name := mod.Name + "-device-plugin"
 	ds.Name = name
-	err := r.Client.Get(ctx, types.NamespacedName{Name: name, Namespace: mod.Namespace}, ds)
+	err = r.Client.Get(ctx, types.NamespacedName{Name: name, Namespace: mod.Namespace}, ds)
 	if err != nil && !apierrors.IsNotFound(err) {
-		return fmt.Errorf("failed to get the device plugin daemonset %s/%s: %w", name, mod.Namespace, err)
+		return nil, fmt.Errorf("failed to get the device plugin daemonset %s/%s: %w", name, mod.Namespace, err)
 	}
 
 	opRes, err := controllerutil.CreateOrPatch(ctx, r.Client, ds, func() error {
-		return r.daemonAPI.SetDevicePluginAsDesired(ctx, ds, mod)
+		return r.daemonAPI.SetDevicePluginAsDesired(ctx, ds, dsMod)
 	})
 
 	if err == nil {
@@ -370,41 +1158,76 @@ func (r *ModuleReconciler) handleDevicePlugin(ctx context.Context, mod *kmmv1bet
 		logger.Info("Reconciled Device Plugin", "name", ds.Name, "result", opRes)
 	}
 
-	return err
+	return pinned, err
 }
 
+// garbageCollect deletes the expired DaemonSets and jobs of mod.
+// It returns how long until the earliest of the objects it kept expires, or 0 if none will.
 func (r *ModuleReconciler) garbageCollect(ctx context.Context,
 	mod *kmmv1beta1.Module,
 	mldMappings map[string]*api.ModuleLoaderData,
-	existingDS map[string]*appsv1.DaemonSet) error {
+	targetMLDs map[string]*api.ModuleLoaderData,
+	inconsistentMLDs map[string]*api.ModuleLoaderData,
+	existingDS map[string]*appsv1.DaemonSet,
+	policy gc.Policy) (time.Duration, error) {
 	logger := log.FromContext(ctx)
 	// Garbage collect old DaemonSets for which there are no nodes.
-	validKernels := sets.KeySet[string](mldMappings)
+	validKernels := sets.New[string]()
+
+	for _, mld := range mldMappings {
+		validKernels.Insert(api.DaemonSetKey(mld.KernelVersion, mld.Architecture, mld.ModuleVersion))
+	}
 
-	deleted, err := r.daemonAPI.GarbageCollect(ctx, existingDS, validKernels)
+	// nodes still run the DaemonSets of the kernels whose nodes need different images: deleting them would unload
+	// the kernel module
+	for _, mld := range inconsistentMLDs {
+		validKernels.Insert(api.DaemonSetKey(mld.KernelVersion, mld.Architecture, mld.ModuleVersion))
+	}
+
+	if mod.Spec.ModuleLoader.Container.Version != "" {
+		// the nodes that are not labeled with a version yet keep running the DaemonSets created before the Module was
+		// versioned
+		versionLabel := utils.GetModuleVersionLabelName(mod.Namespace, mod.Name)
+
+		if err := r.daemonAPI.RetireUnversioned(ctx, existingDS, versionLabel); err != nil {
+			return 0, fmt.Errorf("could not retire the unversioned DaemonSets: %v", err)
+		}
+	}
+
+	deleted, dsRequeueAfter, err := r.daemonAPI.GarbageCollect(ctx, existingDS, validKernels, policy)
 	if err != nil {
-		return fmt.Errorf("could not garbage collect DaemonSets: %v", err)
+		return 0, fmt.Errorf("could not garbage collect DaemonSets: %v", err)
 	}
 
 	logger.Info("Garbage-collected DaemonSets", "names", deleted)
 
-	// Garbage collect for successfully finished build jobs
-	deleted, err = r.buildAPI.GarbageCollect(ctx, mod.Name, mod.Namespace, mod)
+	// Garbage collect for finished build jobs
+	deleted, buildRequeueAfter, err := r.buildAPI.GarbageCollect(ctx, mod.Name, mod.Namespace, policy, mod)
 	if err != nil {
-		return fmt.Errorf("could not garbage collect build objects: %v", err)
+		return 0, fmt.Errorf("could not garbage collect build objects: %v", err)
 	}
 
 	logger.Info("Garbage-collected Build objects", "names", deleted)
 
-	// Garbage collect for successfully finished sign jobs
-	deleted, err = r.signAPI.GarbageCollect(ctx, mod.Name, mod.Namespace, mod)
+	// Garbage collect for finished sign jobs, including those of the kernel targets
+	signedMLDs := make(map[string]*api.ModuleLoaderData, len(mldMappings)+len(targetMLDs))
+
+	for k, mld := range targetMLDs {
+		signedMLDs[k] = mld
+	}
+
+	for k, mld := range mldMappings {
+		signedMLDs[k] = mld
+	}
+
+	deleted, signRequeueAfter, err := r.signAPI.GarbageCollect(ctx, mod.Name, mod.Namespace, signedMLDs, policy, mod)
 	if err != nil {
-		return fmt.Errorf("could not garbage collect sign objects: %v", err)
+		return 0, fmt.Errorf("could not garbage collect sign objects: %v", err)
 	}
 
 	logger.Info("Garbage-collected Sign objects", "names", deleted)
 
-	return nil
+	return gc.Earliest(dsRequeueAfter, buildRequeueAfter, signRequeueAfter), nil
 }
 
 func (r *ModuleReconciler) setKMMOMetrics(ctx context.Context) {
@@ -438,18 +1261,44 @@ func (r *ModuleReconciler) SetupWithManager(mgr ctrl.Manager, kernelLabel string
 			&source.Kind{Type: &v1.Node{}},
 			handler.EnqueueRequestsFromMapFunc(r.filter.FindModulesForNode),
 			builder.WithPredicates(
-				r.filter.ModuleReconcilerNodePredicate(kernelLabel),
+				r.filter.ModuleReconcilerNodePredicate(kernelLabel, r.pendingKernelAnnotation),
 			),
 		).
+		Watches(
+			&source.Kind{Type: &kmmv1beta1.Module{}},
+			handler.EnqueueRequestsFromMapFunc(r.filter.FindModulesWithSameKernelModule),
+		).
+		Watches(
+			&source.Kind{Type: &kmmv1beta1.Module{}},
+			handler.EnqueueRequestsFromMapFunc(r.filter.FindModulesForStartupTaint),
+		).
 		Named(ModuleReconcilerName).
 		Complete(r)
 }
 
-func isNodeSchedulable(node *v1.Node) bool {
+// isNodeSchedulable returns true if node has no NoSchedule taint other than the startupTaints, which the ModuleLoader
+// tolerates and which are only removed once the ModuleLoaders are ready on the node.
+func isNodeSchedulable(node *v1.Node, startupTaints []v1.Taint) bool {
 	for _, taint := range node.Spec.Taints {
-		if taint.Effect == v1.TaintEffectNoSchedule {
-			return false
+		if taint.Effect != v1.TaintEffectNoSchedule {
+			continue
 		}
+
+		if isStartupTaint(&taint, startupTaints) {
+			continue
+		}
+
+		return false
 	}
 	return true
 }
+
+func isStartupTaint(taint *v1.Taint, startupTaints []v1.Taint) bool {
+	for i := range startupTaints {
+		if taint.MatchTaint(&startupTaints[i]) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -2,21 +2,31 @@ package controllers
 
 import (
 	"context"
+	"errors"
 
 	"github.com/golang/mock/gomock"
 	kmmv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/api"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/build"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/client"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/conflict"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/constants"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/daemonset"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/gc"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/metrics"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/module"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/nodecondition"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/pin"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/rollback"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/sign"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/statusupdater"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/teardown"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/upgrade"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/utils"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	v1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -28,7 +38,8 @@ import (
 )
 
 const (
-	namespace = "namespace"
+	namespace               = "namespace"
+	pendingKernelAnnotation = "pending-kernel"
 )
 
 var _ = Describe("ModuleReconciler_Reconcile", func() {
@@ -41,6 +52,13 @@ var _ = Describe("ModuleReconciler_Reconcile", func() {
 		mockKM      *module.MockKernelMapper
 		mockMetrics *metrics.MockMetrics
 		mockSU      *statusupdater.MockModuleStatusUpdater
+		mockUO      *upgrade.MockOrchestrator
+		mockRB      *rollback.MockImageTracker
+		mockPin     *pin.MockPinner
+		mockTD      *teardown.MockManager
+		mockNC      *nodecondition.MockUpdater
+		mockCD      *conflict.MockDetector
+		mockJH      *utils.MockJobHelper
 	)
 
 	BeforeEach(func() {
@@ -52,6 +70,13 @@ var _ = Describe("ModuleReconciler_Reconcile", func() {
 		mockKM = module.NewMockKernelMapper(ctrl)
 		mockMetrics = metrics.NewMockMetrics(ctrl)
 		mockSU = statusupdater.NewMockModuleStatusUpdater(ctrl)
+		mockUO = upgrade.NewMockOrchestrator(ctrl)
+		mockRB = rollback.NewMockImageTracker(ctrl)
+		mockPin = pin.NewMockPinner(ctrl)
+		mockTD = teardown.NewMockManager(ctrl)
+		mockNC = nodecondition.NewMockUpdater(ctrl)
+		mockCD = conflict.NewMockDetector(ctrl)
+		mockJH = utils.NewMockJobHelper(ctrl)
 	})
 
 	const moduleName = "test-module"
@@ -65,15 +90,116 @@ var _ = Describe("ModuleReconciler_Reconcile", func() {
 
 	ctx := context.Background()
 
-	It("should do nothing if the Module is not available anymore", func() {
-		clnt.
-			EXPECT().
-			Get(ctx, nsn, &kmmv1beta1.Module{}).
-			Return(
-				apierrors.NewNotFound(schema.GroupResource{}, moduleName),
-			)
+	It("should only delete its metrics if the Module is not available anymore", func() {
+		gomock.InOrder(
+			clnt.
+				EXPECT().
+				Get(ctx, nsn, &kmmv1beta1.Module{}).
+				Return(
+					apierrors.NewNotFound(schema.GroupResource{}, moduleName),
+				),
+			mockMetrics.EXPECT().DeleteRebootRequiredNodes(moduleName, namespace),
+		)
+
+		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockDC, mockKM, mockMetrics, nil, mockSU, mockUO, mockRB, mockPin, mockTD, mockNC, mockCD, mockJH, namespace, gc.Policy{}, pendingKernelAnnotation)
+		Expect(
+			mr.Reconcile(ctx, req),
+		).To(
+			Equal(reconcile.Result{}),
+		)
+	})
+
+	It("should add the finalizer if it is missing", func() {
+		mod := kmmv1beta1.Module{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      moduleName,
+				Namespace: namespace,
+			},
+		}
+
+		gomock.InOrder(
+			clnt.EXPECT().Get(ctx, req.NamespacedName, gomock.Any()).DoAndReturn(
+				func(_ interface{}, _ interface{}, m *kmmv1beta1.Module, _ ...ctrlclient.GetOption) error {
+					m.ObjectMeta = mod.ObjectMeta
+					return nil
+				},
+			),
+			clnt.EXPECT().Patch(ctx, gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ interface{}, m *kmmv1beta1.Module, _ ctrlclient.Patch, _ ...ctrlclient.PatchOption) error {
+					Expect(m.Finalizers).To(Equal([]string{constants.ModuleFinalizer}))
+					return errors.New("some error")
+				},
+			),
+		)
+
+		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockDC, mockKM, mockMetrics, nil, mockSU, mockUO, mockRB, mockPin, mockTD, mockNC, mockCD, mockJH, namespace, gc.Policy{}, pendingKernelAnnotation)
+
+		_, err := mr.Reconcile(ctx, req)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should requeue a deleted Module while it is being torn down", func() {
+		now := metav1.Now()
+
+		mod := kmmv1beta1.Module{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              moduleName,
+				Namespace:         namespace,
+				DeletionTimestamp: &now,
+				Finalizers:        []string{constants.ModuleFinalizer},
+			},
+		}
+
+		gomock.InOrder(
+			clnt.EXPECT().Get(ctx, req.NamespacedName, gomock.Any()).DoAndReturn(
+				func(_ interface{}, _ interface{}, m *kmmv1beta1.Module, _ ...ctrlclient.GetOption) error {
+					m.ObjectMeta = mod.ObjectMeta
+					return nil
+				},
+			),
+			mockTD.EXPECT().TearDown(ctx, &mod).Return(teardown.StageDevicePlugin, nil),
+			mockSU.EXPECT().ModuleTeardownUpdateStatus(ctx, &mod, "DevicePluginTermination", teardown.StageDevicePlugin.Message()),
+		)
+
+		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockDC, mockKM, mockMetrics, nil, mockSU, mockUO, mockRB, mockPin, mockTD, mockNC, mockCD, mockJH, namespace, gc.Policy{}, pendingKernelAnnotation)
+
+		Expect(
+			mr.Reconcile(ctx, req),
+		).To(
+			Equal(reconcile.Result{RequeueAfter: teardownRequeueAfter}),
+		)
+	})
+
+	It("should remove the finalizer once a deleted Module is torn down", func() {
+		now := metav1.Now()
+
+		mod := kmmv1beta1.Module{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              moduleName,
+				Namespace:         namespace,
+				DeletionTimestamp: &now,
+				Finalizers:        []string{constants.ModuleFinalizer},
+			},
+		}
+
+		gomock.InOrder(
+			clnt.EXPECT().Get(ctx, req.NamespacedName, gomock.Any()).DoAndReturn(
+				func(_ interface{}, _ interface{}, m *kmmv1beta1.Module, _ ...ctrlclient.GetOption) error {
+					m.ObjectMeta = mod.ObjectMeta
+					return nil
+				},
+			),
+			mockTD.EXPECT().TearDown(ctx, &mod).Return(teardown.StageDone, nil),
+			clnt.EXPECT().Patch(ctx, gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ interface{}, m *kmmv1beta1.Module, _ ctrlclient.Patch, _ ...ctrlclient.PatchOption) error {
+					Expect(m.Finalizers).To(BeEmpty())
+					return nil
+				},
+			),
+		)
+
+		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockDC, mockKM, mockMetrics, nil, mockSU, mockUO, mockRB, mockPin, mockTD, mockNC, mockCD, mockJH, namespace, gc.Policy{}, pendingKernelAnnotation)
 
-		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockDC, mockKM, mockMetrics, nil, mockSU, namespace)
 		Expect(
 			mr.Reconcile(ctx, req),
 		).To(
@@ -81,26 +207,908 @@ var _ = Describe("ModuleReconciler_Reconcile", func() {
 		)
 	})
 
-	It("should add the module loader and device plugin ServiceAccounts if they are not set", func() {
+	It("should return an error if the Module cannot be torn down", func() {
+		now := metav1.Now()
+
+		mod := kmmv1beta1.Module{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              moduleName,
+				Namespace:         namespace,
+				DeletionTimestamp: &now,
+				Finalizers:        []string{constants.ModuleFinalizer},
+			},
+		}
+
+		gomock.InOrder(
+			clnt.EXPECT().Get(ctx, req.NamespacedName, gomock.Any()).DoAndReturn(
+				func(_ interface{}, _ interface{}, m *kmmv1beta1.Module, _ ...ctrlclient.GetOption) error {
+					m.ObjectMeta = mod.ObjectMeta
+					return nil
+				},
+			),
+			mockTD.EXPECT().TearDown(ctx, &mod).Return(teardown.StageDevicePlugin, errors.New("some error")),
+		)
+
+		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockDC, mockKM, mockMetrics, nil, mockSU, mockUO, mockRB, mockPin, mockTD, mockNC, mockCD, mockJH, namespace, gc.Policy{}, pendingKernelAnnotation)
+
+		_, err := mr.Reconcile(ctx, req)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should add the module loader and device plugin ServiceAccounts if they are not set", func() {
+		mod := kmmv1beta1.Module{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       moduleName,
+				Namespace:  namespace,
+				Finalizers: []string{constants.ModuleFinalizer},
+			},
+			Spec: kmmv1beta1.ModuleSpec{
+				Selector: map[string]string{"key": "value"},
+				ModuleLoader: kmmv1beta1.ModuleLoaderSpec{
+					ServiceAccountName: "",
+				},
+				DevicePlugin: &kmmv1beta1.DevicePluginSpec{
+					ServiceAccountName: "",
+				},
+			},
+		}
+		ds := appsv1.DaemonSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      moduleName + "-device-plugin",
+				Namespace: namespace,
+			},
+		}
+
+		pinned := &kmmv1beta1.PinnedImage{Image: "example.org/repo/device-plugin:tag", Digest: "sha256:1234"}
+
+		gomock.InOrder(
+			clnt.EXPECT().Get(ctx, req.NamespacedName, gomock.Any()).DoAndReturn(
+				func(_ interface{}, _ interface{}, m *kmmv1beta1.Module, _ ...ctrlclient.GetOption) error {
+					m.ObjectMeta = mod.ObjectMeta
+					m.Spec = mod.Spec
+					return nil
+				},
+			),
+			clnt.EXPECT().List(ctx, gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ interface{}, list *kmmv1beta1.ModuleList, _ ...interface{}) error {
+					list.Items = []kmmv1beta1.Module{mod}
+					return nil
+				},
+			),
+			mockMetrics.EXPECT().SetExistingKMMOModules(1),
+			clnt.EXPECT().List(ctx, &kmmv1beta1.ModuleList{}),
+			clnt.EXPECT().List(ctx, gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ interface{}, list *v1.NodeList, _ ...interface{}) error {
+					list.Items = []v1.Node{}
+					return nil
+				},
+			),
+			mockPin.EXPECT().PinDevicePluginImage(ctx, &mod).Return(pinned, nil),
+			clnt.EXPECT().Get(ctx, gomock.Any(), gomock.Any()).Return(apierrors.NewNotFound(schema.GroupResource{}, "whatever")),
+			clnt.EXPECT().Get(ctx, gomock.Any(), gomock.Any()).Return(apierrors.NewNotFound(schema.GroupResource{}, "whatever")),
+			mockDC.EXPECT().SetDevicePluginAsDesired(context.Background(), &ds, gomock.AssignableToTypeOf(&mod)).Do(
+				func(_ context.Context, _ *appsv1.DaemonSet, m *kmmv1beta1.Module) {
+					Expect(m.Spec.DevicePlugin.Container.Image).To(Equal("example.org/repo/device-plugin:tag@sha256:1234"))
+				},
+			),
+			clnt.EXPECT().Create(ctx, gomock.Any()).Return(nil),
+			mockMetrics.EXPECT().SetCompletedStage(moduleName, namespace, "", metrics.DevicePluginStage, false),
+		)
+
+		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockDC, mockKM, mockMetrics, nil, mockSU, mockUO, mockRB, mockPin, mockTD, mockNC, mockCD, mockJH, namespace, gc.Policy{}, pendingKernelAnnotation)
+
+		mockCD.EXPECT().FindConflicts(ctx, &mod, []v1.Node{})
+
+		dsByKernelVersion := make(map[string]*appsv1.DaemonSet)
+
+		gomock.InOrder(
+			mockDC.EXPECT().ModuleDaemonSetsByKernelVersion(ctx, moduleName, namespace).Return(dsByKernelVersion, nil),
+			mockDC.EXPECT().GarbageCollect(ctx, dsByKernelVersion, sets.New[string](), gc.Policy{}),
+			mockBM.EXPECT().GarbageCollect(ctx, mod.Name, mod.Namespace, gc.Policy{}, &mod),
+			mockSM.EXPECT().GarbageCollect(ctx, mod.Name, mod.Namespace, gomock.Any(), gc.Policy{}, &mod),
+			mockUO.EXPECT().Upgrade(ctx, &mod, dsByKernelVersion),
+			mockSU.EXPECT().ModuleUpdateStatus(ctx, &mod, []v1.Node{}, []v1.Node{}, dsByKernelVersion, []kmmv1beta1.KernelTargetStatus{}, nil, []kmmv1beta1.ModuleLoaderImageStatus{}, pinned, nil).Return(nil),
+		)
+
+		res, err := mr.Reconcile(context.Background(), req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).To(Equal(reconcile.Result{}))
+	})
+
+	It("should do nothing when no nodes match the selector", func() {
+		const serviceAccountName = "module-loader-service-account"
+
+		mod := kmmv1beta1.Module{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       moduleName,
+				Namespace:  namespace,
+				Finalizers: []string{constants.ModuleFinalizer},
+			},
+			Spec: kmmv1beta1.ModuleSpec{
+				Selector: map[string]string{"key": "value"},
+				ModuleLoader: kmmv1beta1.ModuleLoaderSpec{
+					ServiceAccountName: serviceAccountName,
+				},
+			},
+		}
+
+		gomock.InOrder(
+			clnt.EXPECT().Get(ctx, req.NamespacedName, gomock.Any()).DoAndReturn(
+				func(_ interface{}, _ interface{}, m *kmmv1beta1.Module, _ ...ctrlclient.GetOption) error {
+					m.ObjectMeta = mod.ObjectMeta
+					m.Spec = mod.Spec
+					return nil
+				},
+			),
+			clnt.EXPECT().List(ctx, gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ interface{}, list *kmmv1beta1.ModuleList, _ ...interface{}) error {
+					list.Items = []kmmv1beta1.Module{mod}
+					return nil
+				},
+			),
+			mockMetrics.EXPECT().SetExistingKMMOModules(1),
+			clnt.EXPECT().List(ctx, &kmmv1beta1.ModuleList{}),
+			clnt.EXPECT().List(ctx, gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ interface{}, list *v1.NodeList, _ ...interface{}) error {
+					list.Items = []v1.Node{}
+					return nil
+				},
+			),
+		)
+
+		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockDC, mockKM, mockMetrics, nil, mockSU, mockUO, mockRB, mockPin, mockTD, mockNC, mockCD, mockJH, namespace, gc.Policy{}, pendingKernelAnnotation)
+
+		mockCD.EXPECT().FindConflicts(ctx, &mod, []v1.Node{})
+
+		dsByKernelVersion := make(map[string]*appsv1.DaemonSet)
+
+		gomock.InOrder(
+			mockDC.EXPECT().ModuleDaemonSetsByKernelVersion(ctx, moduleName, namespace).Return(dsByKernelVersion, nil),
+			mockDC.EXPECT().GarbageCollect(ctx, dsByKernelVersion, sets.New[string](), gc.Policy{}),
+			mockBM.EXPECT().GarbageCollect(ctx, mod.Name, mod.Namespace, gc.Policy{}, &mod),
+			mockSM.EXPECT().GarbageCollect(ctx, mod.Name, mod.Namespace, gomock.Any(), gc.Policy{}, &mod),
+			mockUO.EXPECT().Upgrade(ctx, &mod, dsByKernelVersion),
+			mockSU.EXPECT().ModuleUpdateStatus(ctx, &mod, []v1.Node{}, []v1.Node{}, dsByKernelVersion, []kmmv1beta1.KernelTargetStatus{}, nil, []kmmv1beta1.ModuleLoaderImageStatus{}, nil, nil).Return(nil),
+		)
+
+		res, err := mr.Reconcile(context.Background(), req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).To(Equal(reconcile.Result{}))
+	})
+
+	It("should only set the Suspended condition when the Module is suspended", func() {
+		mod := kmmv1beta1.Module{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       moduleName,
+				Namespace:  namespace,
+				Finalizers: []string{constants.ModuleFinalizer},
+			},
+			Spec: kmmv1beta1.ModuleSpec{
+				Selector: map[string]string{"key": "value"},
+				Suspend:  true,
+			},
+			Status: kmmv1beta1.ModuleStatus{
+				KernelTargets: []kmmv1beta1.KernelTargetStatus{
+					{KernelVersion: "1.2.3", State: kmmv1beta1.KernelTargetReady},
+				},
+				ModuleLoaderImages: []kmmv1beta1.ModuleLoaderImageStatus{
+					{KernelVersion: "1.2.3", LastKnownGoodImage: "example.org/repo/image@sha256:1234"},
+				},
+			},
+		}
+
+		gomock.InOrder(
+			clnt.EXPECT().Get(ctx, req.NamespacedName, gomock.Any()).DoAndReturn(
+				func(_ interface{}, _ interface{}, m *kmmv1beta1.Module, _ ...ctrlclient.GetOption) error {
+					m.ObjectMeta = mod.ObjectMeta
+					m.Spec = mod.Spec
+					m.Status = mod.Status
+					return nil
+				},
+			),
+			mockSU.EXPECT().ModuleSuspendedUpdateStatus(ctx, &mod).Return(nil),
+		)
+
+		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockDC, mockKM, mockMetrics, nil, mockSU, mockUO, mockRB, mockPin, mockTD, mockNC, mockCD, mockJH, namespace, gc.Policy{}, pendingKernelAnnotation)
+
+		res, err := mr.Reconcile(context.Background(), req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).To(Equal(reconcile.Result{}))
+	})
+
+	It("should build the images of kernel targets that no node runs", func() {
+		const (
+			kernelVersion = "1.2.3"
+			arch          = "amd64"
+		)
+
+		mod := kmmv1beta1.Module{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       moduleName,
+				Namespace:  namespace,
+				Finalizers: []string{constants.ModuleFinalizer},
+			},
+			Spec: kmmv1beta1.ModuleSpec{
+				Selector:      map[string]string{"key": "value"},
+				KernelTargets: []kmmv1beta1.KernelTarget{{KernelVersion: kernelVersion, Architecture: arch}},
+			},
+		}
+
+		mld := api.ModuleLoaderData{
+			Name:          moduleName,
+			Namespace:     namespace,
+			KernelVersion: kernelVersion,
+			Architecture:  arch,
+		}
+
+		gomock.InOrder(
+			clnt.EXPECT().Get(ctx, req.NamespacedName, gomock.Any()).DoAndReturn(
+				func(_ interface{}, _ interface{}, m *kmmv1beta1.Module, _ ...ctrlclient.GetOption) error {
+					m.ObjectMeta = mod.ObjectMeta
+					m.Spec = mod.Spec
+					return nil
+				},
+			),
+			clnt.EXPECT().List(ctx, gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ interface{}, list *kmmv1beta1.ModuleList, _ ...interface{}) error {
+					list.Items = []kmmv1beta1.Module{mod}
+					return nil
+				},
+			),
+			mockMetrics.EXPECT().SetExistingKMMOModules(1),
+			clnt.EXPECT().List(ctx, &kmmv1beta1.ModuleList{}),
+			clnt.EXPECT().List(ctx, gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ interface{}, list *v1.NodeList, _ ...interface{}) error {
+					list.Items = []v1.Node{}
+					return nil
+				},
+			),
+		)
+
+		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockDC, mockKM, mockMetrics, nil, mockSU, mockUO, mockRB, mockPin, mockTD, mockNC, mockCD, mockJH, namespace, gc.Policy{}, pendingKernelAnnotation)
+
+		mockCD.EXPECT().FindConflicts(ctx, &mod, []v1.Node{})
+
+		dsByKernelVersion := make(map[string]*appsv1.DaemonSet)
+
+		expectedStatuses := []kmmv1beta1.KernelTargetStatus{
+			{KernelVersion: kernelVersion, Architecture: arch, State: kmmv1beta1.KernelTargetBuilding},
+		}
+
+		gomock.InOrder(
+			mockDC.EXPECT().ModuleDaemonSetsByKernelVersion(ctx, moduleName, namespace).Return(dsByKernelVersion, nil),
+			mockKM.EXPECT().GetModuleLoaderDataForKernel(ctx, &mod, kernelVersion, &v1.NodeSystemInfo{Architecture: arch}).Return(&mld, nil),
+			mockBM.EXPECT().ShouldSync(gomock.Any(), &mld).Return(true, nil),
+			mockBM.EXPECT().Sync(gomock.Any(), &mld, true, mld.Owner).Return(utils.Status(utils.StatusCreated), nil),
+			mockMetrics.EXPECT().SetCompletedStage(moduleName, namespace, kernelVersion, metrics.BuildStage, false),
+			mockDC.EXPECT().GarbageCollect(ctx, dsByKernelVersion, sets.New[string](), gc.Policy{}),
+			mockBM.EXPECT().GarbageCollect(ctx, mod.Name, mod.Namespace, gc.Policy{}, &mod),
+			mockSM.EXPECT().GarbageCollect(ctx, mod.Name, mod.Namespace, map[string]*api.ModuleLoaderData{kernelVersion + "/" + arch: &mld}, gc.Policy{}, &mod),
+			mockUO.EXPECT().Upgrade(ctx, &mod, dsByKernelVersion),
+			mockSU.EXPECT().ModuleUpdateStatus(ctx, &mod, []v1.Node{}, []v1.Node{}, dsByKernelVersion, expectedStatuses, nil, []kmmv1beta1.ModuleLoaderImageStatus{}, nil, nil).Return(nil),
+		)
+
+		res, err := mr.Reconcile(context.Background(), req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).To(Equal(reconcile.Result{}))
+	})
+
+	It("should build the image of the kernel pending on a node", func() {
+		const (
+			kernelVersion        = "1.2.3"
+			pendingKernelVersion = "4.5.6"
+		)
+
+		mod := kmmv1beta1.Module{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       moduleName,
+				Namespace:  namespace,
+				Finalizers: []string{constants.ModuleFinalizer},
+			},
+			Spec: kmmv1beta1.ModuleSpec{
+				Selector: map[string]string{"key": "value"},
+			},
+		}
+
+		pendingMld := api.ModuleLoaderData{
+			Name:          moduleName,
+			Namespace:     namespace,
+			KernelVersion: pendingKernelVersion,
+		}
+
+		nodeList := v1.NodeList{
+			Items: []v1.Node{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:        "node1",
+						Labels:      map[string]string{"key": "value"},
+						Annotations: map[string]string{pendingKernelAnnotation: pendingKernelVersion},
+					},
+					Status: v1.NodeStatus{
+						NodeInfo: v1.NodeSystemInfo{KernelVersion: kernelVersion},
+					},
+				},
+			},
+		}
+
+		gomock.InOrder(
+			clnt.EXPECT().Get(ctx, req.NamespacedName, gomock.Any()).DoAndReturn(
+				func(_ interface{}, _ interface{}, m *kmmv1beta1.Module, _ ...ctrlclient.GetOption) error {
+					m.ObjectMeta = mod.ObjectMeta
+					m.Spec = mod.Spec
+					return nil
+				},
+			),
+			clnt.EXPECT().List(ctx, gomock.Any(), gomock.Any()),
+			mockMetrics.EXPECT().SetExistingKMMOModules(0),
+			clnt.EXPECT().List(ctx, &kmmv1beta1.ModuleList{}),
+			clnt.EXPECT().List(ctx, gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ interface{}, list *v1.NodeList, _ ...interface{}) error {
+					list.Items = nodeList.Items
+					return nil
+				},
+			),
+		)
+
+		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockDC, mockKM, mockMetrics, nil, mockSU, mockUO, mockRB, mockPin, mockTD, mockNC, mockCD, mockJH, namespace, gc.Policy{}, pendingKernelAnnotation)
+
+		mockCD.EXPECT().FindConflicts(ctx, &mod, nodeList.Items)
+
+		dsByKernelVersion := make(map[string]*appsv1.DaemonSet)
+
+		gomock.InOrder(
+			mockKM.EXPECT().GetModuleLoaderDataForKernel(ctx, &mod, kernelVersion, &nodeList.Items[0].Status.NodeInfo).Return(nil, errors.New("no mapping")),
+			mockDC.EXPECT().ModuleDaemonSetsByKernelVersion(ctx, moduleName, namespace).Return(dsByKernelVersion, nil),
+			mockNC.EXPECT().SetModuleCondition(
+				ctx,
+				&nodeList.Items[0],
+				namespace,
+				moduleName,
+				v1.ConditionFalse,
+				nodecondition.ReasonNoMapping,
+				"No kernel mapping matches kernel "+kernelVersion,
+			),
+			mockKM.EXPECT().GetModuleLoaderDataForKernel(ctx, &mod, pendingKernelVersion, &nodeList.Items[0].Status.NodeInfo).Return(&pendingMld, nil),
+			mockBM.EXPECT().ShouldSync(gomock.Any(), &pendingMld).Return(false, nil),
+			mockSM.EXPECT().ShouldSync(gomock.Any(), &pendingMld).Return(false, nil),
+			mockDC.EXPECT().GarbageCollect(ctx, dsByKernelVersion, sets.New[string](), gc.Policy{}),
+			mockBM.EXPECT().GarbageCollect(ctx, mod.Name, mod.Namespace, gc.Policy{}, &mod),
+			mockSM.EXPECT().GarbageCollect(ctx, mod.Name, mod.Namespace, map[string]*api.ModuleLoaderData{pendingKernelVersion: &pendingMld}, gc.Policy{}, &mod),
+			mockUO.EXPECT().Upgrade(ctx, &mod, dsByKernelVersion),
+			mockSU.EXPECT().ModuleUpdateStatus(ctx, &mod, []v1.Node{}, nodeList.Items, dsByKernelVersion, []kmmv1beta1.KernelTargetStatus{}, nil, []kmmv1beta1.ModuleLoaderImageStatus{}, nil, nil).Return(nil),
+		)
+
+		res, err := mr.Reconcile(context.Background(), req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).To(Equal(reconcile.Result{}))
+	})
+
+	It("should report the failed build job on the nodes running the kernel", func() {
+		const kernelVersion = "1.2.3"
+
+		mod := kmmv1beta1.Module{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       moduleName,
+				Namespace:  namespace,
+				Finalizers: []string{constants.ModuleFinalizer},
+			},
+			Spec: kmmv1beta1.ModuleSpec{
+				Selector: map[string]string{"key": "value"},
+			},
+		}
+
+		mld := api.ModuleLoaderData{
+			Name:          moduleName,
+			Namespace:     namespace,
+			KernelVersion: kernelVersion,
+			Build:         &kmmv1beta1.Build{},
+			Owner:         &mod,
+		}
+
+		nodeList := v1.NodeList{
+			Items: []v1.Node{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:   "node1",
+						Labels: map[string]string{"key": "value"},
+					},
+					Status: v1.NodeStatus{
+						NodeInfo: v1.NodeSystemInfo{KernelVersion: kernelVersion},
+					},
+				},
+			},
+		}
+
+		gomock.InOrder(
+			clnt.EXPECT().Get(ctx, req.NamespacedName, gomock.Any()).DoAndReturn(
+				func(_ interface{}, _ interface{}, m *kmmv1beta1.Module, _ ...ctrlclient.GetOption) error {
+					m.ObjectMeta = mod.ObjectMeta
+					m.Spec = mod.Spec
+					return nil
+				},
+			),
+			clnt.EXPECT().List(ctx, gomock.Any(), gomock.Any()),
+			mockMetrics.EXPECT().SetExistingKMMOModules(0),
+			clnt.EXPECT().List(ctx, &kmmv1beta1.ModuleList{}),
+			clnt.EXPECT().List(ctx, gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ interface{}, list *v1.NodeList, _ ...interface{}) error {
+					list.Items = nodeList.Items
+					return nil
+				},
+			),
+		)
+
+		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockDC, mockKM, mockMetrics, nil, mockSU, mockUO, mockRB, mockPin, mockTD, mockNC, mockCD, mockJH, namespace, gc.Policy{}, pendingKernelAnnotation)
+
+		mockCD.EXPECT().FindConflicts(ctx, &mod, nodeList.Items)
+
+		dsByKernelVersion := make(map[string]*appsv1.DaemonSet)
+		job := batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "build-job"}}
+
+		gomock.InOrder(
+			mockKM.EXPECT().GetModuleLoaderDataForKernel(ctx, &mod, kernelVersion, &nodeList.Items[0].Status.NodeInfo).Return(&mld, nil),
+			mockDC.EXPECT().ModuleDaemonSetsByKernelVersion(ctx, moduleName, namespace).Return(dsByKernelVersion, nil),
+			mockBM.EXPECT().ShouldSync(gomock.Any(), &mld).Return(true, nil),
+			mockBM.EXPECT().Sync(gomock.Any(), &mld, true, mld.Owner).Return(utils.Status(utils.StatusFailed), nil),
+			mockJH.EXPECT().GetModuleJobByKernel(gomock.Any(), moduleName, namespace, kernelVersion, "", utils.JobTypeBuild, mld.Owner).Return(&job, nil),
+			mockNC.EXPECT().SetModuleCondition(
+				ctx,
+				&nodeList.Items[0],
+				namespace,
+				moduleName,
+				v1.ConditionFalse,
+				nodecondition.ReasonLoadFailed,
+				"The image for kernel "+kernelVersion+" could not be prepared: build job build-job has failed",
+			),
+			mockDC.EXPECT().GarbageCollect(ctx, dsByKernelVersion, sets.New[string](kernelVersion), gc.Policy{}),
+			mockBM.EXPECT().GarbageCollect(ctx, mod.Name, mod.Namespace, gc.Policy{}, &mod),
+			mockSM.EXPECT().GarbageCollect(ctx, mod.Name, mod.Namespace, map[string]*api.ModuleLoaderData{kernelVersion: &mld}, gc.Policy{}, &mod),
+			mockUO.EXPECT().Upgrade(ctx, &mod, dsByKernelVersion),
+			mockSU.EXPECT().ModuleUpdateStatus(ctx, &mod, nodeList.Items, nodeList.Items, dsByKernelVersion, []kmmv1beta1.KernelTargetStatus{}, nil, []kmmv1beta1.ModuleLoaderImageStatus{}, nil, nil).Return(nil),
+		)
+
+		res, err := mr.Reconcile(context.Background(), req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).To(Equal(reconcile.Result{}))
+	})
+
+	It("should skip the kernels whose nodes need different images, but keep their DaemonSets", func() {
+		const kernelVersion = "1.2.3"
+
+		mod := kmmv1beta1.Module{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       moduleName,
+				Namespace:  namespace,
+				Finalizers: []string{constants.ModuleFinalizer},
+			},
+			Spec: kmmv1beta1.ModuleSpec{
+				Selector: map[string]string{"key": "value"},
+			},
+		}
+
+		mld1 := api.ModuleLoaderData{
+			Name:           moduleName,
+			Namespace:      namespace,
+			KernelVersion:  kernelVersion,
+			ContainerImage: "some-image:9.0",
+		}
+
+		mld2 := mld1
+		mld2.ContainerImage = "some-image:9.2"
+
+		nodeList := v1.NodeList{
+			Items: []v1.Node{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:   "node1",
+						Labels: map[string]string{"key": "value"},
+					},
+					Status: v1.NodeStatus{
+						NodeInfo: v1.NodeSystemInfo{KernelVersion: kernelVersion, OSImage: "OS 9.0"},
+					},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:   "node2",
+						Labels: map[string]string{"key": "value"},
+					},
+					Status: v1.NodeStatus{
+						NodeInfo: v1.NodeSystemInfo{KernelVersion: kernelVersion, OSImage: "OS 9.2"},
+					},
+				},
+			},
+		}
+
+		gomock.InOrder(
+			clnt.EXPECT().Get(ctx, req.NamespacedName, gomock.Any()).DoAndReturn(
+				func(_ interface{}, _ interface{}, m *kmmv1beta1.Module, _ ...ctrlclient.GetOption) error {
+					m.ObjectMeta = mod.ObjectMeta
+					m.Spec = mod.Spec
+					return nil
+				},
+			),
+			clnt.EXPECT().List(ctx, gomock.Any(), gomock.Any()),
+			mockMetrics.EXPECT().SetExistingKMMOModules(0),
+			clnt.EXPECT().List(ctx, &kmmv1beta1.ModuleList{}),
+			clnt.EXPECT().List(ctx, gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ interface{}, list *v1.NodeList, _ ...interface{}) error {
+					list.Items = nodeList.Items
+					return nil
+				},
+			),
+		)
+
+		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockDC, mockKM, mockMetrics, nil, mockSU, mockUO, mockRB, mockPin, mockTD, mockNC, mockCD, mockJH, namespace, gc.Policy{}, pendingKernelAnnotation)
+
+		mockCD.EXPECT().FindConflicts(ctx, &mod, nodeList.Items)
+
+		dsByKernelVersion := make(map[string]*appsv1.DaemonSet)
+		message := "The image for kernel " + kernelVersion + " could not be prepared: the nodes running this kernel need " +
+			"different images; check the node-specific template variables of the kernel mapping"
+
+		gomock.InOrder(
+			mockKM.EXPECT().GetModuleLoaderDataForKernel(ctx, &mod, kernelVersion, &nodeList.Items[0].Status.NodeInfo).Return(&mld1, nil),
+			mockKM.EXPECT().GetModuleLoaderDataForKernel(ctx, &mod, kernelVersion, &nodeList.Items[1].Status.NodeInfo).Return(&mld2, nil),
+			mockDC.EXPECT().ModuleDaemonSetsByKernelVersion(ctx, moduleName, namespace).Return(dsByKernelVersion, nil),
+			mockNC.EXPECT().SetModuleCondition(ctx, &nodeList.Items[0], namespace, moduleName, v1.ConditionFalse, nodecondition.ReasonLoadFailed, message),
+			mockNC.EXPECT().SetModuleCondition(ctx, &nodeList.Items[1], namespace, moduleName, v1.ConditionFalse, nodecondition.ReasonLoadFailed, message),
+			mockDC.EXPECT().GarbageCollect(ctx, dsByKernelVersion, sets.New[string](kernelVersion), gc.Policy{}),
+			mockBM.EXPECT().GarbageCollect(ctx, mod.Name, mod.Namespace, gc.Policy{}, &mod),
+			mockSM.EXPECT().GarbageCollect(ctx, mod.Name, mod.Namespace, map[string]*api.ModuleLoaderData{}, gc.Policy{}, &mod),
+			mockUO.EXPECT().Upgrade(ctx, &mod, dsByKernelVersion),
+			mockSU.EXPECT().ModuleUpdateStatus(ctx, &mod, []v1.Node{}, nodeList.Items, dsByKernelVersion, []kmmv1beta1.KernelTargetStatus{}, nil, []kmmv1beta1.ModuleLoaderImageStatus{}, nil, nil).Return(nil),
+		)
+
+		res, err := mr.Reconcile(context.Background(), req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).To(Equal(reconcile.Result{}))
+	})
+
+	It("should remove obsolete DaemonSets when no nodes match the selector", func() {
+		const (
+			kernelVersion      = "1.2.3"
+			serviceAccountName = "module-loader-service-account"
+		)
+
+		mod := kmmv1beta1.Module{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       moduleName,
+				Namespace:  namespace,
+				Finalizers: []string{constants.ModuleFinalizer},
+			},
+			Spec: kmmv1beta1.ModuleSpec{
+				Selector: map[string]string{"key": "value"},
+				ModuleLoader: kmmv1beta1.ModuleLoaderSpec{
+					ServiceAccountName: serviceAccountName,
+				},
+			},
+		}
+
+		ds := appsv1.DaemonSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "some-daemonset",
+				Namespace: namespace,
+			},
+		}
+
+		gomock.InOrder(
+			clnt.EXPECT().Get(ctx, req.NamespacedName, gomock.Any()).DoAndReturn(
+				func(_ interface{}, _ interface{}, m *kmmv1beta1.Module, _ ...ctrlclient.GetOption) error {
+					m.ObjectMeta = mod.ObjectMeta
+					m.Spec = mod.Spec
+					return nil
+				},
+			),
+			clnt.EXPECT().List(ctx, gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ interface{}, list *kmmv1beta1.ModuleList, _ ...interface{}) error {
+					list.Items = []kmmv1beta1.Module{mod}
+					return nil
+				},
+			),
+			mockMetrics.EXPECT().SetExistingKMMOModules(1),
+			clnt.EXPECT().List(ctx, &kmmv1beta1.ModuleList{}),
+			clnt.EXPECT().List(ctx, gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ interface{}, list *v1.NodeList, _ ...interface{}) error {
+					list.Items = []v1.Node{}
+					return nil
+				},
+			),
+		)
+
+		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockDC, mockKM, mockMetrics, nil, mockSU, mockUO, mockRB, mockPin, mockTD, mockNC, mockCD, mockJH, namespace, gc.Policy{}, pendingKernelAnnotation)
+
+		mockCD.EXPECT().FindConflicts(ctx, &mod, []v1.Node{})
+
+		dsByKernelVersion := map[string]*appsv1.DaemonSet{kernelVersion: &ds}
+
+		gomock.InOrder(
+			mockDC.EXPECT().ModuleDaemonSetsByKernelVersion(ctx, moduleName, namespace).Return(dsByKernelVersion, nil),
+			mockDC.EXPECT().GarbageCollect(ctx, dsByKernelVersion, sets.New[string](), gc.Policy{}),
+			mockBM.EXPECT().GarbageCollect(ctx, mod.Name, mod.Namespace, gc.Policy{}, &mod),
+			mockSM.EXPECT().GarbageCollect(ctx, mod.Name, mod.Namespace, gomock.Any(), gc.Policy{}, &mod),
+			mockUO.EXPECT().Upgrade(ctx, &mod, dsByKernelVersion),
+			mockSU.EXPECT().ModuleUpdateStatus(ctx, &mod, []v1.Node{}, []v1.Node{}, dsByKernelVersion, []kmmv1beta1.KernelTargetStatus{}, nil, []kmmv1beta1.ModuleLoaderImageStatus{}, nil, nil).Return(nil),
+		)
+
+		res, err := mr.Reconcile(context.Background(), req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).To(Equal(reconcile.Result{}))
+	})
+
+	It("should not target the nodes claimed by another Module", func() {
+		mod := kmmv1beta1.Module{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       moduleName,
+				Namespace:  namespace,
+				Finalizers: []string{constants.ModuleFinalizer},
+			},
+			Spec: kmmv1beta1.ModuleSpec{
+				Selector: map[string]string{"key": "value"},
+			},
+		}
+
+		node := v1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "node1",
+				Labels: map[string]string{"key": "value"},
+			},
+		}
+
+		conflicts := []conflict.Conflict{
+			{
+				Module: types.NamespacedName{Namespace: "other-namespace", Name: moduleName},
+				Nodes:  []string{node.Name},
+			},
+		}
+
+		gomock.InOrder(
+			clnt.EXPECT().Get(ctx, req.NamespacedName, gomock.Any()).DoAndReturn(
+				func(_ interface{}, _ interface{}, m *kmmv1beta1.Module, _ ...ctrlclient.GetOption) error {
+					m.ObjectMeta = mod.ObjectMeta
+					m.Spec = mod.Spec
+					return nil
+				},
+			),
+			clnt.EXPECT().List(ctx, gomock.Any(), gomock.Any()),
+			mockMetrics.EXPECT().SetExistingKMMOModules(0),
+			clnt.EXPECT().List(ctx, &kmmv1beta1.ModuleList{}),
+			clnt.EXPECT().List(ctx, gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ interface{}, list *v1.NodeList, _ ...interface{}) error {
+					list.Items = []v1.Node{*node.DeepCopy()}
+					return nil
+				},
+			),
+			clnt.EXPECT().List(ctx, &v1.PodList{}, gomock.Any()),
+			clnt.EXPECT().Patch(ctx, gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ interface{}, n *v1.Node, _ ctrlclient.Patch, _ ...ctrlclient.PatchOption) error {
+					Expect(n.Name).To(Equal(node.Name))
+					Expect(n.Labels).To(HaveKey(utils.GetModuleConflictLabelName(namespace, moduleName)))
+					return nil
+				},
+			),
+		)
+
+		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockDC, mockKM, mockMetrics, nil, mockSU, mockUO, mockRB, mockPin, mockTD, mockNC, mockCD, mockJH, namespace, gc.Policy{}, pendingKernelAnnotation)
+
+		dsByKernelVersion := make(map[string]*appsv1.DaemonSet)
+
+		gomock.InOrder(
+			mockCD.EXPECT().FindConflicts(ctx, &mod, []v1.Node{node}).Return(conflicts, nil),
+			mockDC.EXPECT().ModuleDaemonSetsByKernelVersion(ctx, moduleName, namespace).Return(dsByKernelVersion, nil),
+			mockDC.EXPECT().GarbageCollect(ctx, dsByKernelVersion, sets.New[string](), gc.Policy{}),
+			mockBM.EXPECT().GarbageCollect(ctx, mod.Name, mod.Namespace, gc.Policy{}, &mod),
+			mockSM.EXPECT().GarbageCollect(ctx, mod.Name, mod.Namespace, gomock.Any(), gc.Policy{}, &mod),
+			mockUO.EXPECT().Upgrade(ctx, &mod, dsByKernelVersion),
+			mockSU.EXPECT().ModuleUpdateStatus(ctx, &mod, []v1.Node{}, gomock.Any(), dsByKernelVersion, []kmmv1beta1.KernelTargetStatus{}, nil, []kmmv1beta1.ModuleLoaderImageStatus{}, nil, conflicts).Return(nil),
+		)
+
+		res, err := mr.Reconcile(context.Background(), req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).To(Equal(reconcile.Result{}))
+	})
+
+	It("should create a DaemonSet when a node matches the selector", func() {
+		const (
+			imageName          = "test-image"
+			kernelVersion      = "1.2.3"
+			serviceAccountName = "module-loader-service-account"
+		)
+
+		mappings := []kmmv1beta1.KernelMapping{
+			{
+				ContainerImage: imageName,
+				Literal:        kernelVersion,
+			},
+		}
+
+		mod := kmmv1beta1.Module{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       moduleName,
+				Namespace:  namespace,
+				Finalizers: []string{constants.ModuleFinalizer},
+			},
+			Spec: kmmv1beta1.ModuleSpec{
+				ModuleLoader: kmmv1beta1.ModuleLoaderSpec{
+					ServiceAccountName: serviceAccountName,
+					Container: kmmv1beta1.ModuleLoaderContainerSpec{
+						KernelMappings: mappings,
+					},
+				},
+				Selector: map[string]string{"key": "value"},
+			},
+		}
+
+		returnedMld := api.ModuleLoaderData{
+			ContainerImage:     imageName,
+			Name:               mod.Name,
+			Namespace:          mod.Namespace,
+			ServiceAccountName: serviceAccountName,
+			Selector:           mod.Spec.Selector,
+			KernelVersion:      kernelVersion,
+		}
+
+		nodeList := v1.NodeList{
+			Items: []v1.Node{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:   "node1",
+						Labels: map[string]string{"key": "value"},
+					},
+					Status: v1.NodeStatus{
+						NodeInfo: v1.NodeSystemInfo{KernelVersion: kernelVersion},
+					},
+				},
+			},
+		}
+
+		dsByKernelVersion := make(map[string]*appsv1.DaemonSet)
+
+		imageStatus := kmmv1beta1.ModuleLoaderImageStatus{KernelVersion: kernelVersion}
+
+		pinned := &kmmv1beta1.PinnedImage{Image: imageName, Digest: "sha256:1234"}
+
+		pinnedStatus := imageStatus
+		pinnedStatus.Pinned = pinned
+
+		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockDC, mockKM, mockMetrics, nil, mockSU, mockUO, mockRB, mockPin, mockTD, mockNC, mockCD, mockJH, namespace, gc.Policy{}, pendingKernelAnnotation)
+
+		mockCD.EXPECT().FindConflicts(ctx, &mod, nodeList.Items)
+
+		ds := appsv1.DaemonSet{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: moduleName + "-",
+				Namespace:    namespace,
+			},
+		}
+
+		gomock.InOrder(
+			clnt.EXPECT().Get(ctx, req.NamespacedName, gomock.Any()).DoAndReturn(
+				func(_ interface{}, _ interface{}, m *kmmv1beta1.Module, _ ...ctrlclient.GetOption) error {
+					m.ObjectMeta = mod.ObjectMeta
+					m.Spec = mod.Spec
+					return nil
+				},
+			),
+			clnt.EXPECT().List(ctx, gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ interface{}, list *kmmv1beta1.ModuleList, _ ...interface{}) error {
+					return nil
+				},
+			),
+			mockMetrics.EXPECT().SetExistingKMMOModules(0),
+			clnt.EXPECT().List(ctx, &kmmv1beta1.ModuleList{}),
+			clnt.EXPECT().List(ctx, gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ interface{}, list *v1.NodeList, _ ...interface{}) error {
+					list.Items = nodeList.Items
+					return nil
+				},
+			),
+			mockKM.EXPECT().GetModuleLoaderDataForKernel(ctx, &mod, kernelVersion, &nodeList.Items[0].Status.NodeInfo).Return(&returnedMld, nil),
+			mockDC.EXPECT().ModuleDaemonSetsByKernelVersion(ctx, moduleName, namespace).Return(dsByKernelVersion, nil),
+			mockBM.EXPECT().ShouldSync(gomock.Any(), &returnedMld).Return(true, nil),
+			mockBM.EXPECT().Sync(gomock.Any(), &returnedMld, true, returnedMld.Owner).Return(utils.Status(utils.StatusCompleted), nil),
+			mockMetrics.EXPECT().SetCompletedStage(moduleName, namespace, kernelVersion, metrics.BuildStage, true),
+			mockSM.EXPECT().ShouldSync(gomock.Any(), &returnedMld).Return(true, nil),
+			mockSM.EXPECT().Sync(gomock.Any(), &returnedMld, "", true, returnedMld.Owner).Return(utils.Status(utils.StatusCompleted), nil),
+			mockMetrics.EXPECT().SetCompletedStage(moduleName, namespace, kernelVersion, metrics.SignStage, true),
+			mockRB.EXPECT().SyncImage(ctx, &mod, &returnedMld, nil).Return(imageStatus, nil),
+			mockPin.EXPECT().PinModuleLoaderImage(ctx, &mod, &returnedMld).Return(pinned, nil),
+			clnt.EXPECT().Get(ctx, gomock.Any(), gomock.Any()).Return(apierrors.NewNotFound(schema.GroupResource{}, "whatever")),
+			mockDC.EXPECT().SetDriverContainerAsDesired(context.Background(), &ds, gomock.AssignableToTypeOf(&returnedMld)).Do(
+				func(_ context.Context, _ *appsv1.DaemonSet, mld *api.ModuleLoaderData) {
+					Expect(mld.ContainerImage).To(Equal(imageName + "@sha256:1234"))
+				}),
+			clnt.EXPECT().Create(ctx, gomock.Any()).Return(nil),
+			mockMetrics.EXPECT().SetCompletedStage(moduleName, namespace, kernelVersion, metrics.ModuleLoaderStage, false),
+			mockDC.EXPECT().GarbageCollect(ctx, dsByKernelVersion, sets.New[string](kernelVersion), gc.Policy{}),
+			mockBM.EXPECT().GarbageCollect(ctx, mod.Name, mod.Namespace, gc.Policy{}, &mod),
+			mockSM.EXPECT().GarbageCollect(ctx, mod.Name, mod.Namespace, gomock.Any(), gc.Policy{}, &mod),
+			mockUO.EXPECT().Upgrade(ctx, &mod, dsByKernelVersion),
+			mockSU.EXPECT().ModuleUpdateStatus(ctx, &mod, nodeList.Items, nodeList.Items, dsByKernelVersion, []kmmv1beta1.KernelTargetStatus{}, nil, []kmmv1beta1.ModuleLoaderImageStatus{pinnedStatus}, nil, nil).Return(nil),
+		)
+
+		res, err := mr.Reconcile(context.Background(), req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).To(Equal(reconcile.Result{}))
+	})
+
+	It("should create a DaemonSet for a node that only has the startup taints of Modules", func() {
+		const (
+			imageName          = "test-image"
+			kernelVersion      = "1.2.3"
+			serviceAccountName = "module-loader-service-account"
+		)
+
+		mappings := []kmmv1beta1.KernelMapping{
+			{
+				ContainerImage: imageName,
+				Literal:        kernelVersion,
+			},
+		}
+
 		mod := kmmv1beta1.Module{
 			ObjectMeta: metav1.ObjectMeta{
-				Name:      moduleName,
-				Namespace: namespace,
+				Name:       moduleName,
+				Namespace:  namespace,
+				Finalizers: []string{constants.ModuleFinalizer},
 			},
 			Spec: kmmv1beta1.ModuleSpec{
-				Selector: map[string]string{"key": "value"},
 				ModuleLoader: kmmv1beta1.ModuleLoaderSpec{
-					ServiceAccountName: "",
+					ServiceAccountName: serviceAccountName,
+					Container: kmmv1beta1.ModuleLoaderContainerSpec{
+						KernelMappings: mappings,
+					},
 				},
-				DevicePlugin: &kmmv1beta1.DevicePluginSpec{
-					ServiceAccountName: "",
+				Selector:     map[string]string{"key": "value"},
+				StartupTaint: &kmmv1beta1.StartupTaint{Key: "not-ready"},
+			},
+		}
+
+		returnedMld := api.ModuleLoaderData{
+			ContainerImage:     imageName,
+			Name:               mod.Name,
+			Namespace:          mod.Namespace,
+			ServiceAccountName: serviceAccountName,
+			Selector:           mod.Spec.Selector,
+			KernelVersion:      kernelVersion,
+		}
+
+		nodeList := v1.NodeList{
+			Items: []v1.Node{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:   "node1",
+						Labels: map[string]string{"key": "value"},
+					},
+					Spec: v1.NodeSpec{
+						Taints: []v1.Taint{
+							{Key: "not-ready", Effect: v1.TaintEffectNoSchedule},
+							{Key: "other-not-ready", Effect: v1.TaintEffectNoSchedule},
+						},
+					},
+					Status: v1.NodeStatus{
+						NodeInfo: v1.NodeSystemInfo{KernelVersion: kernelVersion},
+					},
 				},
 			},
 		}
+
+		dsByKernelVersion := make(map[string]*appsv1.DaemonSet)
+
+		imageStatus := kmmv1beta1.ModuleLoaderImageStatus{KernelVersion: kernelVersion}
+
+		pinned := &kmmv1beta1.PinnedImage{Image: imageName, Digest: "sha256:1234"}
+
+		pinnedStatus := imageStatus
+		pinnedStatus.Pinned = pinned
+
+		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockDC, mockKM, mockMetrics, nil, mockSU, mockUO, mockRB, mockPin, mockTD, mockNC, mockCD, mockJH, namespace, gc.Policy{}, pendingKernelAnnotation)
+
+		mockCD.EXPECT().FindConflicts(ctx, &mod, nodeList.Items)
+
 		ds := appsv1.DaemonSet{
 			ObjectMeta: metav1.ObjectMeta{
-				Name:      moduleName + "-device-plugin",
-				Namespace: namespace,
+				GenerateName: moduleName + "-",
+				Namespace:    namespace,
 			},
 		}
 
@@ -114,34 +1122,50 @@ var _ = Describe("ModuleReconciler_Reconcile", func() {
 			),
 			clnt.EXPECT().List(ctx, gomock.Any(), gomock.Any()).DoAndReturn(
 				func(_ interface{}, list *kmmv1beta1.ModuleList, _ ...interface{}) error {
-					list.Items = []kmmv1beta1.Module{mod}
 					return nil
 				},
 			),
-			mockMetrics.EXPECT().SetExistingKMMOModules(1),
+			mockMetrics.EXPECT().SetExistingKMMOModules(0),
+			clnt.EXPECT().List(ctx, &kmmv1beta1.ModuleList{}).DoAndReturn(
+				func(_ interface{}, list *kmmv1beta1.ModuleList, _ ...interface{}) error {
+					other := kmmv1beta1.Module{
+						Spec: kmmv1beta1.ModuleSpec{
+							StartupTaint: &kmmv1beta1.StartupTaint{Key: "other-not-ready"},
+						},
+					}
+
+					list.Items = []kmmv1beta1.Module{other, mod}
+					return nil
+				},
+			),
 			clnt.EXPECT().List(ctx, gomock.Any(), gomock.Any()).DoAndReturn(
 				func(_ interface{}, list *v1.NodeList, _ ...interface{}) error {
-					list.Items = []v1.Node{}
+					list.Items = nodeList.Items
 					return nil
 				},
 			),
+			mockKM.EXPECT().GetModuleLoaderDataForKernel(ctx, &mod, kernelVersion, &nodeList.Items[0].Status.NodeInfo).Return(&returnedMld, nil),
+			mockDC.EXPECT().ModuleDaemonSetsByKernelVersion(ctx, moduleName, namespace).Return(dsByKernelVersion, nil),
+			mockBM.EXPECT().ShouldSync(gomock.Any(), &returnedMld).Return(false, nil),
+			mockSM.EXPECT().ShouldSync(gomock.Any(), &returnedMld).Return(false, nil),
+			mockRB.EXPECT().SyncImage(ctx, &mod, &returnedMld, nil).Return(imageStatus, nil),
+			mockPin.EXPECT().PinModuleLoaderImage(ctx, &mod, &returnedMld).Return(pinned, nil),
 			clnt.EXPECT().Get(ctx, gomock.Any(), gomock.Any()).Return(apierrors.NewNotFound(schema.GroupResource{}, "whatever")),
-			clnt.EXPECT().Get(ctx, gomock.Any(), gomock.Any()).Return(apierrors.NewNotFound(schema.GroupResource{}, "whatever")),
-			mockDC.EXPECT().SetDevicePluginAsDesired(context.Background(), &ds, gomock.AssignableToTypeOf(&mod)),
+			mockDC.EXPECT().SetDriverContainerAsDesired(context.Background(), &ds, gomock.AssignableToTypeOf(&returnedMld)).Do(
+				func(_ context.Context, _ *appsv1.DaemonSet, mld *api.ModuleLoaderData) {
+					Expect(mld.ContainerImage).To(Equal(imageName + "@sha256:1234"))
+					Expect(mld.StartupTaints).To(Equal([]v1.Taint{
+						{Key: "not-ready", Effect: v1.TaintEffectNoSchedule},
+						{Key: "other-not-ready", Effect: v1.TaintEffectNoSchedule},
+					}))
+				}),
 			clnt.EXPECT().Create(ctx, gomock.Any()).Return(nil),
-			mockMetrics.EXPECT().SetCompletedStage(moduleName, namespace, "", metrics.DevicePluginStage, false),
-		)
-
-		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockDC, mockKM, mockMetrics, nil, mockSU, namespace)
-
-		dsByKernelVersion := make(map[string]*appsv1.DaemonSet)
-
-		gomock.InOrder(
-			mockDC.EXPECT().ModuleDaemonSetsByKernelVersion(ctx, moduleName, namespace).Return(dsByKernelVersion, nil),
-			mockDC.EXPECT().GarbageCollect(ctx, dsByKernelVersion, sets.New[string]()),
-			mockBM.EXPECT().GarbageCollect(ctx, mod.Name, mod.Namespace, &mod),
-			mockSM.EXPECT().GarbageCollect(ctx, mod.Name, mod.Namespace, &mod),
-			mockSU.EXPECT().ModuleUpdateStatus(ctx, &mod, []v1.Node{}, []v1.Node{}, dsByKernelVersion).Return(nil),
+			mockMetrics.EXPECT().SetCompletedStage(moduleName, namespace, kernelVersion, metrics.ModuleLoaderStage, false),
+			mockDC.EXPECT().GarbageCollect(ctx, dsByKernelVersion, sets.New[string](kernelVersion), gc.Policy{}),
+			mockBM.EXPECT().GarbageCollect(ctx, mod.Name, mod.Namespace, gc.Policy{}, &mod),
+			mockSM.EXPECT().GarbageCollect(ctx, mod.Name, mod.Namespace, gomock.Any(), gc.Policy{}, &mod),
+			mockUO.EXPECT().Upgrade(ctx, &mod, dsByKernelVersion),
+			mockSU.EXPECT().ModuleUpdateStatus(ctx, &mod, nodeList.Items, nodeList.Items, dsByKernelVersion, []kmmv1beta1.KernelTargetStatus{}, nil, []kmmv1beta1.ModuleLoaderImageStatus{pinnedStatus}, nil, nil).Return(nil),
 		)
 
 		res, err := mr.Reconcile(context.Background(), req)
@@ -149,22 +1173,74 @@ var _ = Describe("ModuleReconciler_Reconcile", func() {
 		Expect(res).To(Equal(reconcile.Result{}))
 	})
 
-	It("should do nothing when no nodes match the selector", func() {
-		const serviceAccountName = "module-loader-service-account"
+	It("should patch the DaemonSet when it already exists", func() {
+		const (
+			imageName          = "test-image"
+			kernelVersion      = "1.2.3"
+			serviceAccountName = "module-loader-service-account"
+		)
+
+		mappings := []kmmv1beta1.KernelMapping{
+			{
+				ContainerImage: imageName,
+				Literal:        kernelVersion,
+			},
+		}
+
+		nodeLabels := map[string]string{"key": "value"}
 
 		mod := kmmv1beta1.Module{
 			ObjectMeta: metav1.ObjectMeta{
-				Name:      moduleName,
-				Namespace: namespace,
+				Name:       moduleName,
+				Namespace:  namespace,
+				Finalizers: []string{constants.ModuleFinalizer},
 			},
 			Spec: kmmv1beta1.ModuleSpec{
-				Selector: map[string]string{"key": "value"},
 				ModuleLoader: kmmv1beta1.ModuleLoaderSpec{
 					ServiceAccountName: serviceAccountName,
+					Container: kmmv1beta1.ModuleLoaderContainerSpec{
+						KernelMappings: mappings,
+					},
+				},
+				Selector: nodeLabels,
+			},
+		}
+
+		returnedMld := api.ModuleLoaderData{
+			ContainerImage:     imageName,
+			Name:               mod.Name,
+			Namespace:          mod.Namespace,
+			ServiceAccountName: serviceAccountName,
+			Selector:           mod.Spec.Selector,
+			KernelVersion:      kernelVersion,
+		}
+
+		nodeList := v1.NodeList{
+			Items: []v1.Node{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:   "node1",
+						Labels: nodeLabels,
+					},
+					Status: v1.NodeStatus{
+						NodeInfo: v1.NodeSystemInfo{KernelVersion: kernelVersion},
+					},
 				},
 			},
 		}
 
+		const (
+			dsName      = "some-daemonset"
+			dsNamespace = "test-namespace"
+		)
+
+		ds := appsv1.DaemonSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      dsName,
+				Namespace: dsNamespace,
+			},
+		}
+
 		gomock.InOrder(
 			clnt.EXPECT().Get(ctx, req.NamespacedName, gomock.Any()).DoAndReturn(
 				func(_ interface{}, _ interface{}, m *kmmv1beta1.Module, _ ...ctrlclient.GetOption) error {
@@ -180,24 +1256,50 @@ var _ = Describe("ModuleReconciler_Reconcile", func() {
 				},
 			),
 			mockMetrics.EXPECT().SetExistingKMMOModules(1),
+			clnt.EXPECT().List(ctx, &kmmv1beta1.ModuleList{}),
 			clnt.EXPECT().List(ctx, gomock.Any(), gomock.Any()).DoAndReturn(
 				func(_ interface{}, list *v1.NodeList, _ ...interface{}) error {
-					list.Items = []v1.Node{}
+					list.Items = nodeList.Items
 					return nil
 				},
 			),
+			clnt.EXPECT().Get(ctx, gomock.Any(), gomock.Any()),
+			clnt.EXPECT().Patch(ctx, gomock.Any(), gomock.Any()),
 		)
 
-		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockDC, mockKM, mockMetrics, nil, mockSU, namespace)
+		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockDC, mockKM, mockMetrics, nil, mockSU, mockUO, mockRB, mockPin, mockTD, mockNC, mockCD, mockJH, namespace, gc.Policy{}, pendingKernelAnnotation)
 
-		dsByKernelVersion := make(map[string]*appsv1.DaemonSet)
+		mockCD.EXPECT().FindConflicts(ctx, &mod, nodeList.Items)
+
+		dsByKernelVersion := map[string]*appsv1.DaemonSet{kernelVersion: &ds}
+
+		imageStatus := kmmv1beta1.ModuleLoaderImageStatus{KernelVersion: kernelVersion}
+
+		pinned := &kmmv1beta1.PinnedImage{Image: imageName, Digest: "sha256:1234"}
+
+		pinnedStatus := imageStatus
+		pinnedStatus.Pinned = pinned
 
 		gomock.InOrder(
+			mockKM.EXPECT().GetModuleLoaderDataForKernel(ctx, &mod, kernelVersion, &nodeList.Items[0].Status.NodeInfo).Return(&returnedMld, nil),
 			mockDC.EXPECT().ModuleDaemonSetsByKernelVersion(ctx, moduleName, namespace).Return(dsByKernelVersion, nil),
-			mockDC.EXPECT().GarbageCollect(ctx, dsByKernelVersion, sets.New[string]()),
-			mockBM.EXPECT().GarbageCollect(ctx, mod.Name, mod.Namespace, &mod),
-			mockSM.EXPECT().GarbageCollect(ctx, mod.Name, mod.Namespace, &mod),
-			mockSU.EXPECT().ModuleUpdateStatus(ctx, &mod, []v1.Node{}, []v1.Node{}, dsByKernelVersion).Return(nil),
+			mockBM.EXPECT().ShouldSync(gomock.Any(), &returnedMld).Return(true, nil),
+			mockBM.EXPECT().Sync(gomock.Any(), &returnedMld, true, returnedMld.Owner).Return(utils.Status(utils.StatusCompleted), nil),
+			mockMetrics.EXPECT().SetCompletedStage(moduleName, namespace, kernelVersion, metrics.BuildStage, true),
+			mockSM.EXPECT().ShouldSync(gomock.Any(), &returnedMld).Return(true, nil),
+			mockSM.EXPECT().Sync(gomock.Any(), &returnedMld, "", true, returnedMld.Owner).Return(utils.Status(utils.StatusCompleted), nil),
+			mockMetrics.EXPECT().SetCompletedStage(moduleName, namespace, kernelVersion, metrics.SignStage, true),
+			mockRB.EXPECT().SyncImage(ctx, &mod, &returnedMld, &ds).Return(imageStatus, nil),
+			mockPin.EXPECT().PinModuleLoaderImage(ctx, &mod, &returnedMld).Return(pinned, nil),
+			mockDC.EXPECT().SetDriverContainerAsDesired(context.Background(), &ds, gomock.AssignableToTypeOf(&returnedMld)).Do(
+				func(ctx context.Context, d *appsv1.DaemonSet, _ *api.ModuleLoaderData) {
+					d.SetLabels(map[string]string{"test": "test"})
+				}),
+			mockDC.EXPECT().GarbageCollect(ctx, dsByKernelVersion, sets.New[string](kernelVersion), gc.Policy{}),
+			mockBM.EXPECT().GarbageCollect(ctx, mod.Name, mod.Namespace, gc.Policy{}, &mod),
+			mockSM.EXPECT().GarbageCollect(ctx, mod.Name, mod.Namespace, gomock.Any(), gc.Policy{}, &mod),
+			mockUO.EXPECT().Upgrade(ctx, &mod, dsByKernelVersion),
+			mockSU.EXPECT().ModuleUpdateStatus(ctx, &mod, nodeList.Items, nodeList.Items, dsByKernelVersion, []kmmv1beta1.KernelTargetStatus{}, nil, []kmmv1beta1.ModuleLoaderImageStatus{pinnedStatus}, nil, nil).Return(nil),
 		)
 
 		res, err := mr.Reconcile(context.Background(), req)
@@ -205,21 +1307,58 @@ var _ = Describe("ModuleReconciler_Reconcile", func() {
 		Expect(res).To(Equal(reconcile.Result{}))
 	})
 
-	It("should remove obsolete DaemonSets when no nodes match the selector", func() {
+	It("should run the image by its tag when it cannot be pinned", func() {
 		const (
+			imageName          = "test-image"
 			kernelVersion      = "1.2.3"
 			serviceAccountName = "module-loader-service-account"
 		)
 
+		mappings := []kmmv1beta1.KernelMapping{
+			{
+				ContainerImage: imageName,
+				Literal:        kernelVersion,
+			},
+		}
+
+		nodeLabels := map[string]string{"key": "value"}
+
 		mod := kmmv1beta1.Module{
 			ObjectMeta: metav1.ObjectMeta{
-				Name:      moduleName,
-				Namespace: namespace,
+				Name:       moduleName,
+				Namespace:  namespace,
+				Finalizers: []string{constants.ModuleFinalizer},
 			},
 			Spec: kmmv1beta1.ModuleSpec{
-				Selector: map[string]string{"key": "value"},
 				ModuleLoader: kmmv1beta1.ModuleLoaderSpec{
 					ServiceAccountName: serviceAccountName,
+					Container: kmmv1beta1.ModuleLoaderContainerSpec{
+						KernelMappings: mappings,
+					},
+				},
+				Selector: nodeLabels,
+			},
+		}
+
+		returnedMld := api.ModuleLoaderData{
+			ContainerImage:     imageName,
+			Name:               mod.Name,
+			Namespace:          mod.Namespace,
+			ServiceAccountName: serviceAccountName,
+			Selector:           mod.Spec.Selector,
+			KernelVersion:      kernelVersion,
+		}
+
+		nodeList := v1.NodeList{
+			Items: []v1.Node{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:   "node1",
+						Labels: nodeLabels,
+					},
+					Status: v1.NodeStatus{
+						NodeInfo: v1.NodeSystemInfo{KernelVersion: kernelVersion},
+					},
 				},
 			},
 		}
@@ -246,24 +1385,43 @@ var _ = Describe("ModuleReconciler_Reconcile", func() {
 				},
 			),
 			mockMetrics.EXPECT().SetExistingKMMOModules(1),
+			clnt.EXPECT().List(ctx, &kmmv1beta1.ModuleList{}),
 			clnt.EXPECT().List(ctx, gomock.Any(), gomock.Any()).DoAndReturn(
 				func(_ interface{}, list *v1.NodeList, _ ...interface{}) error {
-					list.Items = []v1.Node{}
+					list.Items = nodeList.Items
 					return nil
 				},
 			),
+			clnt.EXPECT().Get(ctx, gomock.Any(), gomock.Any()),
 		)
 
-		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockDC, mockKM, mockMetrics, nil, mockSU, namespace)
+		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockDC, mockKM, mockMetrics, nil, mockSU, mockUO, mockRB, mockPin, mockTD, mockNC, mockCD, mockJH, namespace, gc.Policy{}, pendingKernelAnnotation)
+
+		mockCD.EXPECT().FindConflicts(ctx, &mod, nodeList.Items)
 
 		dsByKernelVersion := map[string]*appsv1.DaemonSet{kernelVersion: &ds}
 
+		imageStatus := kmmv1beta1.ModuleLoaderImageStatus{KernelVersion: kernelVersion}
+
+		failedStatus := imageStatus
+		failedStatus.PinError = "registry unreachable"
+
 		gomock.InOrder(
+			mockKM.EXPECT().GetModuleLoaderDataForKernel(ctx, &mod, kernelVersion, &nodeList.Items[0].Status.NodeInfo).Return(&returnedMld, nil),
 			mockDC.EXPECT().ModuleDaemonSetsByKernelVersion(ctx, moduleName, namespace).Return(dsByKernelVersion, nil),
-			mockDC.EXPECT().GarbageCollect(ctx, dsByKernelVersion, sets.New[string]()),
-			mockBM.EXPECT().GarbageCollect(ctx, mod.Name, mod.Namespace, &mod),
-			mockSM.EXPECT().GarbageCollect(ctx, mod.Name, mod.Namespace, &mod),
-			mockSU.EXPECT().ModuleUpdateStatus(ctx, &mod, []v1.Node{}, []v1.Node{}, dsByKernelVersion).Return(nil),
+			mockBM.EXPECT().ShouldSync(gomock.Any(), &returnedMld).Return(false, nil),
+			mockSM.EXPECT().ShouldSync(gomock.Any(), &returnedMld).Return(false, nil),
+			mockRB.EXPECT().SyncImage(ctx, &mod, &returnedMld, &ds).Return(imageStatus, nil),
+			mockPin.EXPECT().PinModuleLoaderImage(ctx, &mod, &returnedMld).Return(nil, errors.New("registry unreachable")),
+			mockDC.EXPECT().SetDriverContainerAsDesired(context.Background(), &ds, gomock.AssignableToTypeOf(&returnedMld)).Do(
+				func(_ context.Context, _ *appsv1.DaemonSet, mld *api.ModuleLoaderData) {
+					Expect(mld.ContainerImage).To(Equal(imageName))
+				}),
+			mockDC.EXPECT().GarbageCollect(ctx, dsByKernelVersion, sets.New[string](kernelVersion), gc.Policy{}),
+			mockBM.EXPECT().GarbageCollect(ctx, mod.Name, mod.Namespace, gc.Policy{}, &mod),
+			mockSM.EXPECT().GarbageCollect(ctx, mod.Name, mod.Namespace, gomock.Any(), gc.Policy{}, &mod),
+			mockUO.EXPECT().Upgrade(ctx, &mod, dsByKernelVersion),
+			mockSU.EXPECT().ModuleUpdateStatus(ctx, &mod, nodeList.Items, nodeList.Items, dsByKernelVersion, []kmmv1beta1.KernelTargetStatus{}, nil, []kmmv1beta1.ModuleLoaderImageStatus{failedStatus}, nil, nil).Return(nil),
 		)
 
 		res, err := mr.Reconcile(context.Background(), req)
@@ -271,10 +1429,11 @@ var _ = Describe("ModuleReconciler_Reconcile", func() {
 		Expect(res).To(Equal(reconcile.Result{}))
 	})
 
-	It("should create a DaemonSet when a node matches the selector", func() {
+	It("should adopt the DaemonSet created before DaemonSets were labeled with an architecture", func() {
 		const (
 			imageName          = "test-image"
 			kernelVersion      = "1.2.3"
+			arch               = "amd64"
 			serviceAccountName = "module-loader-service-account"
 		)
 
@@ -285,10 +1444,13 @@ var _ = Describe("ModuleReconciler_Reconcile", func() {
 			},
 		}
 
+		nodeLabels := map[string]string{"key": "value"}
+
 		mod := kmmv1beta1.Module{
 			ObjectMeta: metav1.ObjectMeta{
-				Name:      moduleName,
-				Namespace: namespace,
+				Name:       moduleName,
+				Namespace:  namespace,
+				Finalizers: []string{constants.ModuleFinalizer},
 			},
 			Spec: kmmv1beta1.ModuleSpec{
 				ModuleLoader: kmmv1beta1.ModuleLoaderSpec{
@@ -297,7 +1459,7 @@ var _ = Describe("ModuleReconciler_Reconcile", func() {
 						KernelMappings: mappings,
 					},
 				},
-				Selector: map[string]string{"key": "value"},
+				Selector: nodeLabels,
 			},
 		}
 
@@ -308,6 +1470,7 @@ var _ = Describe("ModuleReconciler_Reconcile", func() {
 			ServiceAccountName: serviceAccountName,
 			Selector:           mod.Spec.Selector,
 			KernelVersion:      kernelVersion,
+			Architecture:       arch,
 		}
 
 		nodeList := v1.NodeList{
@@ -315,23 +1478,25 @@ var _ = Describe("ModuleReconciler_Reconcile", func() {
 				{
 					ObjectMeta: metav1.ObjectMeta{
 						Name:   "node1",
-						Labels: map[string]string{"key": "value"},
+						Labels: nodeLabels,
 					},
 					Status: v1.NodeStatus{
-						NodeInfo: v1.NodeSystemInfo{KernelVersion: kernelVersion},
+						NodeInfo: v1.NodeSystemInfo{KernelVersion: kernelVersion, Architecture: arch},
 					},
 				},
 			},
 		}
 
-		dsByKernelVersion := make(map[string]*appsv1.DaemonSet)
-
-		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockDC, mockKM, mockMetrics, nil, mockSU, namespace)
+		const (
+			dsName      = "some-daemonset"
+			dsNamespace = "test-namespace"
+		)
 
 		ds := appsv1.DaemonSet{
 			ObjectMeta: metav1.ObjectMeta{
-				GenerateName: moduleName + "-",
-				Namespace:    namespace,
+				Name:      dsName,
+				Namespace: dsNamespace,
+				Labels:    map[string]string{constants.DaemonSetRole: "module-loader"},
 			},
 		}
 
@@ -345,17 +1510,41 @@ var _ = Describe("ModuleReconciler_Reconcile", func() {
 			),
 			clnt.EXPECT().List(ctx, gomock.Any(), gomock.Any()).DoAndReturn(
 				func(_ interface{}, list *kmmv1beta1.ModuleList, _ ...interface{}) error {
+					list.Items = []kmmv1beta1.Module{mod}
 					return nil
 				},
 			),
-			mockMetrics.EXPECT().SetExistingKMMOModules(0),
+			mockMetrics.EXPECT().SetExistingKMMOModules(1),
+			clnt.EXPECT().List(ctx, &kmmv1beta1.ModuleList{}),
 			clnt.EXPECT().List(ctx, gomock.Any(), gomock.Any()).DoAndReturn(
 				func(_ interface{}, list *v1.NodeList, _ ...interface{}) error {
 					list.Items = nodeList.Items
 					return nil
 				},
 			),
-			mockKM.EXPECT().GetModuleLoaderDataForKernel(&mod, kernelVersion).Return(&returnedMld, nil),
+			clnt.EXPECT().Get(ctx, gomock.Any(), gomock.Any()),
+			clnt.EXPECT().Patch(ctx, gomock.Any(), gomock.Any()),
+		)
+
+		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockDC, mockKM, mockMetrics, nil, mockSU, mockUO, mockRB, mockPin, mockTD, mockNC, mockCD, mockJH, namespace, gc.Policy{}, pendingKernelAnnotation)
+
+		mockCD.EXPECT().FindConflicts(ctx, &mod, nodeList.Items)
+
+		// operator upgrade: the existing DaemonSet has no architecture label
+		dsByKernelVersion := map[string]*appsv1.DaemonSet{kernelVersion: &ds}
+
+		key := kernelVersion + "/" + arch
+		adoptedDSByKernelVersion := map[string]*appsv1.DaemonSet{key: &ds}
+
+		imageStatus := kmmv1beta1.ModuleLoaderImageStatus{KernelVersion: kernelVersion, Architecture: arch}
+
+		pinned := &kmmv1beta1.PinnedImage{Image: imageName, Digest: "sha256:1234"}
+
+		pinnedStatus := imageStatus
+		pinnedStatus.Pinned = pinned
+
+		gomock.InOrder(
+			mockKM.EXPECT().GetModuleLoaderDataForKernel(ctx, &mod, kernelVersion, &nodeList.Items[0].Status.NodeInfo).Return(&returnedMld, nil),
 			mockDC.EXPECT().ModuleDaemonSetsByKernelVersion(ctx, moduleName, namespace).Return(dsByKernelVersion, nil),
 			mockBM.EXPECT().ShouldSync(gomock.Any(), &returnedMld).Return(true, nil),
 			mockBM.EXPECT().Sync(gomock.Any(), &returnedMld, true, returnedMld.Owner).Return(utils.Status(utils.StatusCompleted), nil),
@@ -363,14 +1552,17 @@ var _ = Describe("ModuleReconciler_Reconcile", func() {
 			mockSM.EXPECT().ShouldSync(gomock.Any(), &returnedMld).Return(true, nil),
 			mockSM.EXPECT().Sync(gomock.Any(), &returnedMld, "", true, returnedMld.Owner).Return(utils.Status(utils.StatusCompleted), nil),
 			mockMetrics.EXPECT().SetCompletedStage(moduleName, namespace, kernelVersion, metrics.SignStage, true),
-			clnt.EXPECT().Get(ctx, gomock.Any(), gomock.Any()).Return(apierrors.NewNotFound(schema.GroupResource{}, "whatever")),
-			mockDC.EXPECT().SetDriverContainerAsDesired(context.Background(), &ds, gomock.AssignableToTypeOf(&returnedMld)),
-			clnt.EXPECT().Create(ctx, gomock.Any()).Return(nil),
-			mockMetrics.EXPECT().SetCompletedStage(moduleName, namespace, kernelVersion, metrics.ModuleLoaderStage, false),
-			mockDC.EXPECT().GarbageCollect(ctx, dsByKernelVersion, sets.New[string](kernelVersion)),
-			mockBM.EXPECT().GarbageCollect(ctx, mod.Name, mod.Namespace, &mod),
-			mockSM.EXPECT().GarbageCollect(ctx, mod.Name, mod.Namespace, &mod),
-			mockSU.EXPECT().ModuleUpdateStatus(ctx, &mod, nodeList.Items, nodeList.Items, dsByKernelVersion).Return(nil),
+			mockRB.EXPECT().SyncImage(ctx, &mod, &returnedMld, &ds).Return(imageStatus, nil),
+			mockPin.EXPECT().PinModuleLoaderImage(ctx, &mod, &returnedMld).Return(pinned, nil),
+			mockDC.EXPECT().SetDriverContainerAsDesired(context.Background(), &ds, gomock.AssignableToTypeOf(&returnedMld)).Do(
+				func(ctx context.Context, d *appsv1.DaemonSet, _ *api.ModuleLoaderData) {
+					d.SetLabels(map[string]string{"test": "test"})
+				}),
+			mockDC.EXPECT().GarbageCollect(ctx, adoptedDSByKernelVersion, sets.New[string](key), gc.Policy{}),
+			mockBM.EXPECT().GarbageCollect(ctx, mod.Name, mod.Namespace, gc.Policy{}, &mod),
+			mockSM.EXPECT().GarbageCollect(ctx, mod.Name, mod.Namespace, gomock.Any(), gc.Policy{}, &mod),
+			mockUO.EXPECT().Upgrade(ctx, &mod, adoptedDSByKernelVersion),
+			mockSU.EXPECT().ModuleUpdateStatus(ctx, &mod, nodeList.Items, nodeList.Items, adoptedDSByKernelVersion, []kmmv1beta1.KernelTargetStatus{}, nil, []kmmv1beta1.ModuleLoaderImageStatus{pinnedStatus}, nil, nil).Return(nil),
 		)
 
 		res, err := mr.Reconcile(context.Background(), req)
@@ -378,7 +1570,7 @@ var _ = Describe("ModuleReconciler_Reconcile", func() {
 		Expect(res).To(Equal(reconcile.Result{}))
 	})
 
-	It("should patch the DaemonSet when it already exists", func() {
+	It("should run the last known good image if the image was rolled back", func() {
 		const (
 			imageName          = "test-image"
 			kernelVersion      = "1.2.3"
@@ -396,8 +1588,9 @@ var _ = Describe("ModuleReconciler_Reconcile", func() {
 
 		mod := kmmv1beta1.Module{
 			ObjectMeta: metav1.ObjectMeta{
-				Name:      moduleName,
-				Namespace: namespace,
+				Name:       moduleName,
+				Namespace:  namespace,
+				Finalizers: []string{constants.ModuleFinalizer},
 			},
 			Spec: kmmv1beta1.ModuleSpec{
 				ModuleLoader: kmmv1beta1.ModuleLoaderSpec{
@@ -460,6 +1653,7 @@ var _ = Describe("ModuleReconciler_Reconcile", func() {
 				},
 			),
 			mockMetrics.EXPECT().SetExistingKMMOModules(1),
+			clnt.EXPECT().List(ctx, &kmmv1beta1.ModuleList{}),
 			clnt.EXPECT().List(ctx, gomock.Any(), gomock.Any()).DoAndReturn(
 				func(_ interface{}, list *v1.NodeList, _ ...interface{}) error {
 					list.Items = nodeList.Items
@@ -470,12 +1664,22 @@ var _ = Describe("ModuleReconciler_Reconcile", func() {
 			clnt.EXPECT().Patch(ctx, gomock.Any(), gomock.Any()),
 		)
 
-		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockDC, mockKM, mockMetrics, nil, mockSU, namespace)
+		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockDC, mockKM, mockMetrics, nil, mockSU, mockUO, mockRB, mockPin, mockTD, mockNC, mockCD, mockJH, namespace, gc.Policy{}, pendingKernelAnnotation)
+
+		mockCD.EXPECT().FindConflicts(ctx, &mod, nodeList.Items)
 
 		dsByKernelVersion := map[string]*appsv1.DaemonSet{kernelVersion: &ds}
 
+		const lastKnownGoodImage = "example.org/repo/image@sha256:1234"
+
+		imageStatus := kmmv1beta1.ModuleLoaderImageStatus{
+			KernelVersion:      kernelVersion,
+			LastKnownGoodImage: lastKnownGoodImage,
+			RolledBackFrom:     imageName,
+		}
+
 		gomock.InOrder(
-			mockKM.EXPECT().GetModuleLoaderDataForKernel(&mod, kernelVersion).Return(&returnedMld, nil),
+			mockKM.EXPECT().GetModuleLoaderDataForKernel(ctx, &mod, kernelVersion, &nodeList.Items[0].Status.NodeInfo).Return(&returnedMld, nil),
 			mockDC.EXPECT().ModuleDaemonSetsByKernelVersion(ctx, moduleName, namespace).Return(dsByKernelVersion, nil),
 			mockBM.EXPECT().ShouldSync(gomock.Any(), &returnedMld).Return(true, nil),
 			mockBM.EXPECT().Sync(gomock.Any(), &returnedMld, true, returnedMld.Owner).Return(utils.Status(utils.StatusCompleted), nil),
@@ -483,19 +1687,23 @@ var _ = Describe("ModuleReconciler_Reconcile", func() {
 			mockSM.EXPECT().ShouldSync(gomock.Any(), &returnedMld).Return(true, nil),
 			mockSM.EXPECT().Sync(gomock.Any(), &returnedMld, "", true, returnedMld.Owner).Return(utils.Status(utils.StatusCompleted), nil),
 			mockMetrics.EXPECT().SetCompletedStage(moduleName, namespace, kernelVersion, metrics.SignStage, true),
+			mockRB.EXPECT().SyncImage(ctx, &mod, &returnedMld, &ds).Return(imageStatus, nil),
 			mockDC.EXPECT().SetDriverContainerAsDesired(context.Background(), &ds, gomock.AssignableToTypeOf(&returnedMld)).Do(
-				func(ctx context.Context, d *appsv1.DaemonSet, _ *api.ModuleLoaderData) {
+				func(ctx context.Context, d *appsv1.DaemonSet, mld *api.ModuleLoaderData) {
+					Expect(mld.ContainerImage).To(Equal(lastKnownGoodImage))
 					d.SetLabels(map[string]string{"test": "test"})
 				}),
-			mockDC.EXPECT().GarbageCollect(ctx, dsByKernelVersion, sets.New[string](kernelVersion)),
-			mockBM.EXPECT().GarbageCollect(ctx, mod.Name, mod.Namespace, &mod),
-			mockSM.EXPECT().GarbageCollect(ctx, mod.Name, mod.Namespace, &mod),
-			mockSU.EXPECT().ModuleUpdateStatus(ctx, &mod, nodeList.Items, nodeList.Items, dsByKernelVersion).Return(nil),
+			mockDC.EXPECT().GarbageCollect(ctx, dsByKernelVersion, sets.New[string](kernelVersion), gc.Policy{}),
+			mockBM.EXPECT().GarbageCollect(ctx, mod.Name, mod.Namespace, gc.Policy{}, &mod),
+			mockSM.EXPECT().GarbageCollect(ctx, mod.Name, mod.Namespace, gomock.Any(), gc.Policy{}, &mod),
+			mockUO.EXPECT().Upgrade(ctx, &mod, dsByKernelVersion),
+			mockSU.EXPECT().ModuleUpdateStatus(ctx, &mod, nodeList.Items, nodeList.Items, dsByKernelVersion, []kmmv1beta1.KernelTargetStatus{}, nil, []kmmv1beta1.ModuleLoaderImageStatus{imageStatus}, nil, nil).Return(nil),
 		)
 
 		res, err := mr.Reconcile(context.Background(), req)
 		Expect(err).NotTo(HaveOccurred())
 		Expect(res).To(Equal(reconcile.Result{}))
+		Expect(returnedMld.ContainerImage).To(Equal(imageName))
 	})
 
 	It("should create a Device plugin if defined in the module", func() {
@@ -516,8 +1724,9 @@ var _ = Describe("ModuleReconciler_Reconcile", func() {
 
 		mod := kmmv1beta1.Module{
 			ObjectMeta: metav1.ObjectMeta{
-				Name:      moduleName,
-				Namespace: namespace,
+				Name:       moduleName,
+				Namespace:  namespace,
+				Finalizers: []string{constants.ModuleFinalizer},
 			},
 			Spec: kmmv1beta1.ModuleSpec{
 				DevicePlugin: &kmmv1beta1.DevicePluginSpec{
@@ -533,7 +1742,9 @@ var _ = Describe("ModuleReconciler_Reconcile", func() {
 			},
 		}
 
-		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockDC, mockKM, mockMetrics, nil, mockSU, namespace)
+		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockDC, mockKM, mockMetrics, nil, mockSU, mockUO, mockRB, mockPin, mockTD, mockNC, mockCD, mockJH, namespace, gc.Policy{}, pendingKernelAnnotation)
+
+		mockCD.EXPECT().FindConflicts(ctx, &mod, []v1.Node{})
 
 		ds := appsv1.DaemonSet{
 			ObjectMeta: metav1.ObjectMeta{
@@ -542,6 +1753,8 @@ var _ = Describe("ModuleReconciler_Reconcile", func() {
 			},
 		}
 
+		pinned := &kmmv1beta1.PinnedImage{Image: "example.org/repo/device-plugin:tag", Digest: "sha256:1234"}
+
 		gomock.InOrder(
 			clnt.EXPECT().Get(ctx, req.NamespacedName, gomock.Any()).DoAndReturn(
 				func(_ interface{}, _ interface{}, m *kmmv1beta1.Module, _ ...ctrlclient.GetOption) error {
@@ -556,6 +1769,7 @@ var _ = Describe("ModuleReconciler_Reconcile", func() {
 				},
 			),
 			mockMetrics.EXPECT().SetExistingKMMOModules(0),
+			clnt.EXPECT().List(ctx, &kmmv1beta1.ModuleList{}),
 			clnt.EXPECT().List(ctx, gomock.Any(), gomock.Any()).DoAndReturn(
 				func(_ interface{}, list *v1.NodeList, _ ...interface{}) error {
 					list.Items = []v1.Node{}
@@ -563,15 +1777,21 @@ var _ = Describe("ModuleReconciler_Reconcile", func() {
 				},
 			),
 			mockDC.EXPECT().ModuleDaemonSetsByKernelVersion(ctx, moduleName, namespace).Return(nil, nil),
+			mockPin.EXPECT().PinDevicePluginImage(ctx, &mod).Return(pinned, nil),
 			clnt.EXPECT().Get(ctx, gomock.Any(), gomock.Any()).Return(apierrors.NewNotFound(schema.GroupResource{}, "whatever")),
 			clnt.EXPECT().Get(ctx, gomock.Any(), gomock.Any()).Return(apierrors.NewNotFound(schema.GroupResource{}, "whatever")),
-			mockDC.EXPECT().SetDevicePluginAsDesired(context.Background(), &ds, gomock.AssignableToTypeOf(&mod)),
+			mockDC.EXPECT().SetDevicePluginAsDesired(context.Background(), &ds, gomock.AssignableToTypeOf(&mod)).Do(
+				func(_ context.Context, _ *appsv1.DaemonSet, m *kmmv1beta1.Module) {
+					Expect(m.Spec.DevicePlugin.Container.Image).To(Equal("example.org/repo/device-plugin:tag@sha256:1234"))
+				},
+			),
 			clnt.EXPECT().Create(ctx, gomock.Any()).Return(nil),
 			mockMetrics.EXPECT().SetCompletedStage(moduleName, namespace, "", metrics.DevicePluginStage, false),
-			mockDC.EXPECT().GarbageCollect(ctx, nil, sets.New[string]()),
-			mockBM.EXPECT().GarbageCollect(ctx, mod.Name, mod.Namespace, &mod),
-			mockSM.EXPECT().GarbageCollect(ctx, mod.Name, mod.Namespace, &mod),
-			mockSU.EXPECT().ModuleUpdateStatus(ctx, &mod, []v1.Node{}, []v1.Node{}, nil).Return(nil),
+			mockDC.EXPECT().GarbageCollect(ctx, nil, sets.New[string](), gc.Policy{}),
+			mockBM.EXPECT().GarbageCollect(ctx, mod.Name, mod.Namespace, gc.Policy{}, &mod),
+			mockSM.EXPECT().GarbageCollect(ctx, mod.Name, mod.Namespace, gomock.Any(), gc.Policy{}, &mod),
+			mockUO.EXPECT().Upgrade(ctx, &mod, nil),
+			mockSU.EXPECT().ModuleUpdateStatus(ctx, &mod, []v1.Node{}, []v1.Node{}, nil, []kmmv1beta1.KernelTargetStatus{}, nil, []kmmv1beta1.ModuleLoaderImageStatus{}, pinned, nil).Return(nil),
 		)
 
 		res, err := mr.Reconcile(context.Background(), req)
@@ -590,6 +1810,13 @@ var _ = Describe("ModuleReconciler_handleBuild", func() {
 		mockKM      *module.MockKernelMapper
 		mockMetrics *metrics.MockMetrics
 		mockSU      *statusupdater.MockModuleStatusUpdater
+		mockUO      *upgrade.MockOrchestrator
+		mockRB      *rollback.MockImageTracker
+		mockPin     *pin.MockPinner
+		mockTD      *teardown.MockManager
+		mockNC      *nodecondition.MockUpdater
+		mockCD      *conflict.MockDetector
+		mockJH      *utils.MockJobHelper
 	)
 
 	BeforeEach(func() {
@@ -601,6 +1828,13 @@ var _ = Describe("ModuleReconciler_handleBuild", func() {
 		mockKM = module.NewMockKernelMapper(ctrl)
 		mockMetrics = metrics.NewMockMetrics(ctrl)
 		mockSU = statusupdater.NewMockModuleStatusUpdater(ctrl)
+		mockUO = upgrade.NewMockOrchestrator(ctrl)
+		mockRB = rollback.NewMockImageTracker(ctrl)
+		mockPin = pin.NewMockPinner(ctrl)
+		mockTD = teardown.NewMockManager(ctrl)
+		mockNC = nodecondition.NewMockUpdater(ctrl)
+		mockCD = conflict.NewMockDetector(ctrl)
+		mockJH = utils.NewMockJobHelper(ctrl)
 	})
 
 	const (
@@ -616,7 +1850,7 @@ var _ = Describe("ModuleReconciler_handleBuild", func() {
 			mockBM.EXPECT().ShouldSync(gomock.Any(), mld).Return(false, nil),
 		)
 
-		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockDC, mockKM, mockMetrics, nil, mockSU, namespace)
+		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockDC, mockKM, mockMetrics, nil, mockSU, mockUO, mockRB, mockPin, mockTD, mockNC, mockCD, mockJH, namespace, gc.Policy{}, pendingKernelAnnotation)
 
 		completed, err := mr.handleBuild(context.Background(), mld)
 		Expect(err).NotTo(HaveOccurred())
@@ -638,7 +1872,7 @@ var _ = Describe("ModuleReconciler_handleBuild", func() {
 			mockMetrics.EXPECT().SetCompletedStage(mld.Name, mld.Namespace, kernelVersion, metrics.BuildStage, false),
 		)
 
-		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockDC, mockKM, mockMetrics, nil, mockSU, namespace)
+		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockDC, mockKM, mockMetrics, nil, mockSU, mockUO, mockRB, mockPin, mockTD, mockNC, mockCD, mockJH, namespace, gc.Policy{}, pendingKernelAnnotation)
 		completed, err := mr.handleBuild(context.Background(), &mld)
 		Expect(err).NotTo(HaveOccurred())
 		Expect(completed).To(BeFalse())
@@ -659,7 +1893,7 @@ var _ = Describe("ModuleReconciler_handleBuild", func() {
 			mockMetrics.EXPECT().SetCompletedStage(mld.Name, mld.Namespace, kernelVersion, metrics.BuildStage, true),
 		)
 
-		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockDC, mockKM, mockMetrics, nil, mockSU, namespace)
+		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockDC, mockKM, mockMetrics, nil, mockSU, mockUO, mockRB, mockPin, mockTD, mockNC, mockCD, mockJH, namespace, gc.Policy{}, pendingKernelAnnotation)
 		completed, err := mr.handleBuild(context.Background(), mld)
 		Expect(err).NotTo(HaveOccurred())
 		Expect(completed).To(BeTrue())
@@ -676,6 +1910,13 @@ var _ = Describe("ModuleReconciler_handleSigning", func() {
 		mockKM      *module.MockKernelMapper
 		mockMetrics *metrics.MockMetrics
 		mockSU      *statusupdater.MockModuleStatusUpdater
+		mockUO      *upgrade.MockOrchestrator
+		mockRB      *rollback.MockImageTracker
+		mockPin     *pin.MockPinner
+		mockTD      *teardown.MockManager
+		mockNC      *nodecondition.MockUpdater
+		mockCD      *conflict.MockDetector
+		mockJH      *utils.MockJobHelper
 	)
 
 	BeforeEach(func() {
@@ -687,6 +1928,13 @@ var _ = Describe("ModuleReconciler_handleSigning", func() {
 		mockKM = module.NewMockKernelMapper(ctrl)
 		mockMetrics = metrics.NewMockMetrics(ctrl)
 		mockSU = statusupdater.NewMockModuleStatusUpdater(ctrl)
+		mockUO = upgrade.NewMockOrchestrator(ctrl)
+		mockRB = rollback.NewMockImageTracker(ctrl)
+		mockPin = pin.NewMockPinner(ctrl)
+		mockTD = teardown.NewMockManager(ctrl)
+		mockNC = nodecondition.NewMockUpdater(ctrl)
+		mockCD = conflict.NewMockDetector(ctrl)
+		mockJH = utils.NewMockJobHelper(ctrl)
 	})
 
 	const (
@@ -705,7 +1953,7 @@ var _ = Describe("ModuleReconciler_handleSigning", func() {
 			mockSM.EXPECT().ShouldSync(gomock.Any(), mld).Return(false, nil),
 		)
 
-		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockDC, mockKM, mockMetrics, nil, mockSU, namespace)
+		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockDC, mockKM, mockMetrics, nil, mockSU, mockUO, mockRB, mockPin, mockTD, mockNC, mockCD, mockJH, namespace, gc.Policy{}, pendingKernelAnnotation)
 
 		completed, err := mr.handleSigning(context.Background(), mld)
 		Expect(err).NotTo(HaveOccurred())
@@ -727,7 +1975,7 @@ var _ = Describe("ModuleReconciler_handleSigning", func() {
 			mockMetrics.EXPECT().SetCompletedStage(mld.Name, mld.Namespace, kernelVersion, metrics.SignStage, false),
 		)
 
-		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockDC, mockKM, mockMetrics, nil, mockSU, namespace)
+		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockDC, mockKM, mockMetrics, nil, mockSU, mockUO, mockRB, mockPin, mockTD, mockNC, mockCD, mockJH, namespace, gc.Policy{}, pendingKernelAnnotation)
 
 		completed, err := mr.handleSigning(context.Background(), &mld)
 
@@ -750,7 +1998,7 @@ var _ = Describe("ModuleReconciler_handleSigning", func() {
 			mockMetrics.EXPECT().SetCompletedStage(mld.Name, mld.Namespace, kernelVersion, metrics.SignStage, true),
 		)
 
-		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockDC, mockKM, mockMetrics, nil, mockSU, namespace)
+		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockDC, mockKM, mockMetrics, nil, mockSU, mockUO, mockRB, mockPin, mockTD, mockNC, mockCD, mockJH, namespace, gc.Policy{}, pendingKernelAnnotation)
 
 		completed, err := mr.handleSigning(context.Background(), &mld)
 
@@ -758,6 +2006,31 @@ var _ = Describe("ModuleReconciler_handleSigning", func() {
 		Expect(completed).To(BeTrue())
 	})
 
+	It("should return the name of the sign job when it has failed", func() {
+		mld := api.ModuleLoaderData{
+			Name:           moduleName,
+			Namespace:      namespace,
+			ContainerImage: imageName,
+			Sign:           &kmmv1beta1.Sign{},
+			KernelVersion:  kernelVersion,
+		}
+
+		job := batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "sign-job"}}
+
+		gomock.InOrder(
+			mockSM.EXPECT().ShouldSync(gomock.Any(), &mld).Return(true, nil),
+			mockSM.EXPECT().Sync(gomock.Any(), &mld, "", true, mld.Owner).Return(utils.Status(utils.StatusFailed), nil),
+			mockJH.EXPECT().GetModuleJobByKernel(gomock.Any(), moduleName, namespace, kernelVersion, "", utils.JobTypeSign, mld.Owner).Return(&job, nil),
+		)
+
+		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockDC, mockKM, mockMetrics, nil, mockSU, mockUO, mockRB, mockPin, mockTD, mockNC, mockCD, mockJH, namespace, gc.Policy{}, pendingKernelAnnotation)
+
+		completed, err := mr.handleSigning(context.Background(), &mld)
+
+		Expect(err).To(Equal(&jobFailedError{jobType: utils.JobTypeSign, jobName: "sign-job"}))
+		Expect(completed).To(BeFalse())
+	})
+
 	It("should run sign sync with the previous image as well when module build and sign are specified", func() {
 		mld := &api.ModuleLoaderData{
 			Name:           moduleName,
@@ -776,7 +2049,7 @@ var _ = Describe("ModuleReconciler_handleSigning", func() {
 			mockMetrics.EXPECT().SetCompletedStage(mld.Name, mld.Namespace, kernelVersion, metrics.SignStage, true),
 		)
 
-		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockDC, mockKM, mockMetrics, nil, mockSU, namespace)
+		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockDC, mockKM, mockMetrics, nil, mockSU, mockUO, mockRB, mockPin, mockTD, mockNC, mockCD, mockJH, namespace, gc.Policy{}, pendingKernelAnnotation)
 
 		completed, err := mr.handleSigning(context.Background(), mld)
 
@@ -785,6 +2058,95 @@ var _ = Describe("ModuleReconciler_handleSigning", func() {
 	})
 })
 
+var _ = Describe("ModuleReconciler_getRelevantKernelMappingsAndNodes", func() {
+	const (
+		kernelVersion = "1.2.3"
+		moduleName    = "test-module"
+	)
+
+	var (
+		ctrl   *gomock.Controller
+		mockKM *module.MockKernelMapper
+		mr     *ModuleReconciler
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		mockKM = module.NewMockKernelMapper(ctrl)
+		mr = &ModuleReconciler{kernelAPI: mockKM}
+	})
+
+	ctx := context.Background()
+	mod := kmmv1beta1.Module{
+		ObjectMeta: metav1.ObjectMeta{Name: moduleName, Namespace: namespace},
+	}
+
+	nodes := []v1.Node{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "node1"},
+			Status: v1.NodeStatus{
+				NodeInfo: v1.NodeSystemInfo{KernelVersion: kernelVersion, OSImage: "OS 9.2"},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "node2"},
+			Status: v1.NodeStatus{
+				NodeInfo: v1.NodeSystemInfo{KernelVersion: kernelVersion, OSImage: "OS 9.0"},
+			},
+		},
+	}
+
+	It("should not skip a kernel whose nodes only differ by template variables that are not used", func() {
+		mld1 := api.ModuleLoaderData{
+			Name:           moduleName,
+			Namespace:      namespace,
+			KernelVersion:  kernelVersion,
+			ContainerImage: "some-image:" + kernelVersion,
+			TemplateVars:   []string{"OS_IMAGE_ID=OS", "OS_IMAGE_VERSION=9.2"},
+		}
+
+		mld2 := mld1
+		mld2.TemplateVars = []string{"OS_IMAGE_ID=OS", "OS_IMAGE_VERSION=9.0"}
+
+		gomock.InOrder(
+			mockKM.EXPECT().GetModuleLoaderDataForKernel(ctx, &mod, kernelVersion, &nodes[0].Status.NodeInfo).Return(&mld1, nil),
+			mockKM.EXPECT().GetModuleLoaderDataForKernel(ctx, &mod, kernelVersion, &nodes[1].Status.NodeInfo).Return(&mld2, nil),
+		)
+
+		mappings, relevantNodes, inconsistent, err := mr.getRelevantKernelMappingsAndNodes(ctx, &mod, nodes)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(mappings).To(HaveLen(1))
+		Expect(mappings).To(HaveKeyWithValue(api.KernelArchKey(kernelVersion, ""), &mld2))
+		Expect(relevantNodes).To(Equal(nodes))
+		Expect(inconsistent).To(BeEmpty())
+	})
+
+	It("should skip a kernel whose nodes need different images", func() {
+		mld1 := api.ModuleLoaderData{
+			Name:           moduleName,
+			Namespace:      namespace,
+			KernelVersion:  kernelVersion,
+			ContainerImage: "some-image:9.2",
+			TemplateVars:   []string{"OS_IMAGE_ID=OS", "OS_IMAGE_VERSION=9.2"},
+		}
+
+		mld2 := mld1
+		mld2.ContainerImage = "some-image:9.0"
+		mld2.TemplateVars = []string{"OS_IMAGE_ID=OS", "OS_IMAGE_VERSION=9.0"}
+
+		gomock.InOrder(
+			mockKM.EXPECT().GetModuleLoaderDataForKernel(ctx, &mod, kernelVersion, &nodes[0].Status.NodeInfo).Return(&mld1, nil),
+			mockKM.EXPECT().GetModuleLoaderDataForKernel(ctx, &mod, kernelVersion, &nodes[1].Status.NodeInfo).Return(&mld2, nil),
+		)
+
+		mappings, relevantNodes, inconsistent, err := mr.getRelevantKernelMappingsAndNodes(ctx, &mod, nodes)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(mappings).To(BeEmpty())
+		Expect(relevantNodes).To(BeEmpty())
+		Expect(inconsistent).To(HaveKeyWithValue(api.KernelArchKey(kernelVersion, ""), &mld1))
+	})
+})
+
 var _ = Describe("ModuleReconciler_getNodesListBySelector", func() {
 	var (
 		ctrl *gomock.Controller
@@ -810,8 +2172,8 @@ var _ = Describe("ModuleReconciler_getNodesListBySelector", func() {
 				return nil
 			},
 		)
-		mr := NewModuleReconciler(clnt, nil, nil, nil, nil, nil, nil, nil, namespace)
-		nodeList, err := mr.getNodesListBySelector(context.Background(), &mod)
+		mr := NewModuleReconciler(clnt, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, namespace, gc.Policy{}, pendingKernelAnnotation)
+		nodeList, err := mr.getNodesListBySelector(context.Background(), &mod, nil)
 		Expect(err).NotTo(HaveOccurred())
 		Expect(len(nodeList)).To(Equal(0))
 	})
@@ -823,8 +2185,8 @@ var _ = Describe("ModuleReconciler_getNodesListBySelector", func() {
 				return nil
 			},
 		)
-		mr := NewModuleReconciler(clnt, nil, nil, nil, nil, nil, nil, nil, namespace)
-		nodeList, err := mr.getNodesListBySelector(context.Background(), &mod)
+		mr := NewModuleReconciler(clnt, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, namespace, gc.Policy{}, pendingKernelAnnotation)
+		nodeList, err := mr.getNodesListBySelector(context.Background(), &mod, nil)
 		Expect(err).NotTo(HaveOccurred())
 		Expect(len(nodeList)).To(Equal(2))
 	})
@@ -845,9 +2207,331 @@ var _ = Describe("ModuleReconciler_getNodesListBySelector", func() {
 				return nil
 			},
 		)
-		mr := NewModuleReconciler(clnt, nil, nil, nil, nil, nil, nil, nil, namespace)
-		nodeList, err := mr.getNodesListBySelector(context.Background(), &mod)
+		mr := NewModuleReconciler(clnt, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, namespace, gc.Policy{}, pendingKernelAnnotation)
+		nodeList, err := mr.getNodesListBySelector(context.Background(), &mod, nil)
 		Expect(err).NotTo(HaveOccurred())
 		Expect(len(nodeList)).To(Equal(1))
 	})
+
+	It("2 nodes with matching labels, 1 only tainted with startup taints", func() {
+		startupTaints := []v1.Taint{
+			{Key: "not-ready", Effect: v1.TaintEffectNoSchedule},
+			{Key: "other-not-ready", Effect: v1.TaintEffectNoSchedule},
+		}
+
+		startupTaintedNode := v1.Node{
+			Spec: v1.NodeSpec{
+				Taints: []v1.Taint{
+					{
+						Key:    "not-ready",
+						Effect: v1.TaintEffectNoSchedule,
+					},
+					{
+						Key:    "other-not-ready",
+						Effect: v1.TaintEffectNoSchedule,
+					},
+				},
+			},
+		}
+		clnt.EXPECT().List(ctx, gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ interface{}, list *v1.NodeList, _ ...interface{}) error {
+				list.Items = []v1.Node{startupTaintedNode, v1.Node{}}
+				return nil
+			},
+		)
+		mr := NewModuleReconciler(clnt, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, namespace, gc.Policy{}, pendingKernelAnnotation)
+		nodeList, err := mr.getNodesListBySelector(context.Background(), &mod, startupTaints)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(len(nodeList)).To(Equal(2))
+	})
+
+	It("2 nodes with matching labels, 1 not schedulable because another Module is being upgraded on it", func() {
+		upgradingNode := v1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{upgrade.InProgressAnnotation("other-module"): ""},
+			},
+			Spec: v1.NodeSpec{
+				Taints: []v1.Taint{
+					{
+						Effect: v1.TaintEffectNoSchedule,
+					},
+				},
+			},
+		}
+		clnt.EXPECT().List(ctx, gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ interface{}, list *v1.NodeList, _ ...interface{}) error {
+				list.Items = []v1.Node{upgradingNode, v1.Node{}}
+				return nil
+			},
+		)
+		mr := NewModuleReconciler(clnt, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, namespace, gc.Policy{}, pendingKernelAnnotation)
+		nodeList, err := mr.getNodesListBySelector(context.Background(), &mod, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(len(nodeList)).To(Equal(2))
+	})
+})
+
+var _ = Describe("excludeConflictingNodes", func() {
+	It("should exclude the claimed nodes", func() {
+		claimed := v1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "claimed"},
+		}
+
+		free := v1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "free"},
+		}
+
+		conflicts := []conflict.Conflict{
+			{
+				Module: types.NamespacedName{Namespace: "other-namespace", Name: "other-name"},
+				Nodes:  []string{claimed.Name},
+			},
+		}
+
+		deployableNodes, excludedNodes := excludeConflictingNodes([]v1.Node{claimed, free}, conflicts)
+		Expect(deployableNodes).To(Equal([]v1.Node{free}))
+		Expect(excludedNodes).To(Equal(sets.New[string](claimed.Name)))
+	})
+})
+
+var _ = Describe("ModuleReconciler_labelConflictingNodes", func() {
+	var (
+		ctrl *gomock.Controller
+		clnt *client.MockClient
+		mr   *ModuleReconciler
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		clnt = client.NewMockClient(ctrl)
+		mr = NewModuleReconciler(clnt, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, namespace, gc.Policy{}, pendingKernelAnnotation)
+	})
+
+	const (
+		moduleName = "test-module"
+		nodeName   = "node"
+	)
+
+	ctx := context.Background()
+
+	conflictLabel := utils.GetModuleConflictLabelName(namespace, moduleName)
+
+	mod := kmmv1beta1.Module{
+		ObjectMeta: metav1.ObjectMeta{Name: moduleName, Namespace: namespace},
+	}
+
+	// both Modules load the kernel module on the node, which the other one keeps as it was created first
+	node := v1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: nodeName,
+			Labels: map[string]string{
+				utils.GetModuleLoadedKernelLabelName(namespace, moduleName):       "1.2.3",
+				utils.GetModuleLoadedKernelLabelName("other-namespace", "older"): "1.2.3",
+			},
+		},
+	}
+
+	makePod := func(ready bool, annotations map[string]string) v1.Pod {
+		return v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: namespace, Annotations: annotations},
+			Spec:       v1.PodSpec{NodeName: nodeName},
+			Status: v1.PodStatus{
+				ContainerStatuses: []v1.ContainerStatus{
+					{
+						Name:  constants.ModuleLoaderContainerName,
+						Ready: ready,
+						State: v1.ContainerState{Running: &v1.ContainerStateRunning{}},
+					},
+				},
+			},
+		}
+	}
+
+	expectPods := func(pods ...v1.Pod) *gomock.Call {
+		return clnt.EXPECT().List(ctx, &v1.PodList{}, gomock.Any()).DoAndReturn(
+			func(_ interface{}, list *v1.PodList, _ ...ctrlclient.ListOption) error {
+				list.Items = pods
+				return nil
+			},
+		)
+	}
+
+	It("should not list the pods if the Module has no conflicts", func() {
+		Expect(
+			mr.labelConflictingNodes(ctx, &mod, []v1.Node{*node.DeepCopy()}, sets.New[string]()),
+		).To(
+			BeFalse(),
+		)
+	})
+
+	It("should ask the ModuleLoader pod not to unload the kernel module before excluding the node", func() {
+		gomock.InOrder(
+			expectPods(makePod(true, nil)),
+			clnt.EXPECT().Patch(ctx, gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ interface{}, p *v1.Pod, _ ctrlclient.Patch, _ ...ctrlclient.PatchOption) error {
+					Expect(p.Annotations).To(HaveKey(constants.SkipUnloadAnnotation))
+					return nil
+				},
+			),
+		)
+
+		Expect(
+			mr.labelConflictingNodes(ctx, &mod, []v1.Node{*node.DeepCopy()}, sets.New[string](nodeName)),
+		).To(
+			BeTrue(),
+		)
+	})
+
+	It("should wait for the ModuleLoader pod to acknowledge the annotation before excluding the node", func() {
+		expectPods(makePod(true, map[string]string{constants.SkipUnloadAnnotation: ""}))
+
+		Expect(
+			mr.labelConflictingNodes(ctx, &mod, []v1.Node{*node.DeepCopy()}, sets.New[string](nodeName)),
+		).To(
+			BeTrue(),
+		)
+	})
+
+	It("should exclude the node once the ModuleLoader pod acknowledged the annotation", func() {
+		gomock.InOrder(
+			expectPods(makePod(false, map[string]string{constants.SkipUnloadAnnotation: ""})),
+			clnt.EXPECT().Patch(ctx, gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ interface{}, n *v1.Node, _ ctrlclient.Patch, _ ...ctrlclient.PatchOption) error {
+					Expect(n.Labels).To(HaveKey(conflictLabel))
+					return nil
+				},
+			),
+		)
+
+		Expect(
+			mr.labelConflictingNodes(ctx, &mod, []v1.Node{*node.DeepCopy()}, sets.New[string](nodeName)),
+		).To(
+			BeFalse(),
+		)
+	})
+
+	It("should not ask the ModuleLoader pod to skip unloading anymore once the conflict is resolved", func() {
+		conflicting := *mod.DeepCopy()
+		conflicting.Status.Conditions = []metav1.Condition{
+			{Type: kmmv1beta1.ModuleConditionConflict, Status: metav1.ConditionTrue},
+		}
+
+		gomock.InOrder(
+			expectPods(makePod(false, map[string]string{constants.SkipUnloadAnnotation: ""})),
+			clnt.EXPECT().Patch(ctx, gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ interface{}, p *v1.Pod, _ ctrlclient.Patch, _ ...ctrlclient.PatchOption) error {
+					Expect(p.Annotations).NotTo(HaveKey(constants.SkipUnloadAnnotation))
+					return nil
+				},
+			),
+		)
+
+		Expect(
+			mr.labelConflictingNodes(ctx, &conflicting, []v1.Node{*node.DeepCopy()}, sets.New[string]()),
+		).To(
+			BeFalse(),
+		)
+	})
+})
+
+var _ = Describe("ModuleReconciler_switchNodeVersions", func() {
+	var (
+		ctrl *gomock.Controller
+		clnt *client.MockClient
+		mr   *ModuleReconciler
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		clnt = client.NewMockClient(ctrl)
+		mr = NewModuleReconciler(clnt, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, namespace, gc.Policy{}, pendingKernelAnnotation)
+	})
+
+	const moduleName = "test-module"
+
+	ctx := context.Background()
+
+	versionLabel := utils.GetModuleVersionLabelName(namespace, moduleName)
+	scheduledLabel := utils.GetModuleScheduledVersionLabelName(namespace, moduleName)
+
+	makeNode := func(name string, labels map[string]string) v1.Node {
+		return v1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels},
+		}
+	}
+
+	It("should only schedule the new version once the ModuleLoader pod of the previous version is gone", func() {
+		mod := kmmv1beta1.Module{
+			ObjectMeta: metav1.ObjectMeta{Name: moduleName, Namespace: namespace},
+			Spec: kmmv1beta1.ModuleSpec{
+				ModuleLoader: kmmv1beta1.ModuleLoaderSpec{
+					Container: kmmv1beta1.ModuleLoaderContainerSpec{Version: "v2"},
+				},
+			},
+		}
+
+		nodes := []v1.Node{
+			makeNode("switching", map[string]string{versionLabel: "v2", scheduledLabel: "v1"}),
+			makeNode("waiting", map[string]string{versionLabel: "v2"}),
+			makeNode("switched", map[string]string{versionLabel: "v2"}),
+			makeNode("up-to-date", map[string]string{versionLabel: "v2", scheduledLabel: "v2"}),
+		}
+
+		gomock.InOrder(
+			clnt.
+				EXPECT().
+				List(ctx, &v1.PodList{}, ctrlclient.InNamespace(namespace), ctrlclient.MatchingLabels{
+					constants.ModuleNameLabel: moduleName,
+					constants.DaemonSetRole:   "module-loader",
+				}).
+				DoAndReturn(func(_ context.Context, list *v1.PodList, _ ...ctrlclient.ListOption) error {
+					list.Items = []v1.Pod{
+						{Spec: v1.PodSpec{NodeName: "switching"}},
+						{Spec: v1.PodSpec{NodeName: "waiting"}},
+					}
+					return nil
+				}),
+			clnt.
+				EXPECT().
+				Patch(ctx, gomock.Any(), gomock.Any()).
+				Do(func(_ context.Context, n *v1.Node, _ ctrlclient.Patch, _ ...ctrlclient.PatchOption) {
+					Expect(n.Name).To(Equal("switching"))
+					Expect(n.Labels).To(Equal(map[string]string{versionLabel: "v2"}))
+				}),
+			clnt.
+				EXPECT().
+				Patch(ctx, gomock.Any(), gomock.Any()).
+				Do(func(_ context.Context, n *v1.Node, _ ctrlclient.Patch, _ ...ctrlclient.PatchOption) {
+					Expect(n.Name).To(Equal("switched"))
+					Expect(n.Labels).To(Equal(map[string]string{versionLabel: "v2", scheduledLabel: "v2"}))
+				}),
+		)
+
+		waiting, err := mr.switchNodeVersions(ctx, &mod, nodes)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(waiting).To(BeTrue())
+	})
+
+	It("should remove the scheduled version of a Module that is not versioned", func() {
+		mod := kmmv1beta1.Module{
+			ObjectMeta: metav1.ObjectMeta{Name: moduleName, Namespace: namespace},
+		}
+
+		nodes := []v1.Node{
+			makeNode("scheduled", map[string]string{versionLabel: "v2", scheduledLabel: "v2"}),
+			makeNode("not-scheduled", map[string]string{versionLabel: "v2"}),
+		}
+
+		clnt.
+			EXPECT().
+			Patch(ctx, gomock.Any(), gomock.Any()).
+			Do(func(_ context.Context, n *v1.Node, _ ctrlclient.Patch, _ ...ctrlclient.PatchOption) {
+				Expect(n.Name).To(Equal("scheduled"))
+				Expect(n.Labels).To(Equal(map[string]string{versionLabel: "v2"}))
+			})
+
+		waiting, err := mr.switchNodeVersions(ctx, &mod, nodes)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(waiting).To(BeFalse())
+	})
 })
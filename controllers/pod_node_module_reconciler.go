@@ -3,10 +3,17 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
 
+	kmmv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/constants"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/daemonset"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/filter"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/nodecondition"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/utils"
 	v1 "k8s.io/api/core/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
@@ -18,17 +25,31 @@ import (
 )
 
 //+kubebuilder:rbac:groups="core",resources=pods,verbs=get;patch;list;watch
-//+kubebuilder:rbac:groups="core",resources=nodes,verbs=get;watch
+//+kubebuilder:rbac:groups="core",resources=nodes,verbs=get;patch;watch
+//+kubebuilder:rbac:groups="core",resources=nodes/status,verbs=patch
+//+kubebuilder:rbac:groups=kmm.sigs.x-k8s.io,resources=modules,verbs=get
 
 const PodNodeModuleReconcilerName = "PodNodeModule"
 
+// moduleLoaderTerminationTimeout is how long after its deletion timestamp KMM waits for a deleted ModuleLoader pod to
+// report the termination of its container, for example if the node is not reachable anymore.
+const moduleLoaderTerminationTimeout = time.Minute
+
+// moduleLoaderTerminationRequeueAfter is how often a deleted ModuleLoader pod is reconciled while its container runs.
+const moduleLoaderTerminationRequeueAfter = 10 * time.Second
+
 type PodNodeModuleReconciler struct {
-	client    client.Client
-	daemonAPI daemonset.DaemonSetCreator
+	client           client.Client
+	daemonAPI        daemonset.DaemonSetCreator
+	nodeConditionAPI nodecondition.Updater
 }
 
-func NewPodNodeModuleReconciler(client client.Client, daemonAPI daemonset.DaemonSetCreator) *PodNodeModuleReconciler {
-	return &PodNodeModuleReconciler{client: client, daemonAPI: daemonAPI}
+func NewPodNodeModuleReconciler(
+	client client.Client,
+	daemonAPI daemonset.DaemonSetCreator,
+	nodeConditionAPI nodecondition.Updater,
+) *PodNodeModuleReconciler {
+	return &PodNodeModuleReconciler{client: client, daemonAPI: daemonAPI, nodeConditionAPI: nodeConditionAPI}
 }
 
 func (pnmr *PodNodeModuleReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
@@ -54,6 +75,32 @@ func (pnmr *PodNodeModuleReconciler) Reconcile(ctx context.Context, req ctrl.Req
 	}
 
 	labelName := pnmr.daemonAPI.GetNodeLabelFromPod(&pod, moduleName)
+	unmanagedLabelName := utils.GetModuleUnmanagedLabelName(pod.Namespace, moduleName)
+	rebootRequiredLabelName := utils.GetModuleRebootRequiredLabelName(pod.Namespace, moduleName)
+	loadedKernelLabelName := utils.GetModuleLoadedKernelLabelName(pod.Namespace, moduleName)
+	loadedVersionLabelName := utils.GetModuleLoadedVersionLabelName(pod.Namespace, moduleName)
+	loadedVersionNumberLabelName := utils.GetModuleLoadedVersionNumberLabelName(pod.Namespace, moduleName)
+	loadedDigestAnnotationName := utils.GetModuleLoadedDigestAnnotationName(pod.Namespace, moduleName)
+	isModuleLoader := pod.Labels[constants.DaemonSetRole] == "module-loader"
+
+	// KMM manages the startup taint and the node condition of a Module through its ModuleLoader pods, as long as the
+	// Module is not being deleted.
+	var mod *kmmv1beta1.Module
+
+	if isModuleLoader {
+		var err error
+
+		if mod, err = pnmr.getManagingModule(ctx, pod.Namespace, moduleName); err != nil {
+			return ctrl.Result{}, fmt.Errorf("could not get module %s: %v", moduleName, err)
+		}
+	}
+
+	var startupTaint *v1.Taint
+
+	if mod != nil && mod.Spec.StartupTaint != nil {
+		taint := mod.Spec.StartupTaint.Taint()
+		startupTaint = &taint
+	}
 
 	logger = logger.WithValues(
 		"node name", nodeName,
@@ -70,10 +117,84 @@ func (pnmr *PodNodeModuleReconciler) Reconcile(ctx context.Context, req ctrl.Req
 	if !podutils.IsPodReady(&pod) || !pod.DeletionTimestamp.IsZero() {
 		logger.Info("Unlabeling node")
 
-		if err := pnmr.deleteLabel(ctx, nodeName, labelName); err != nil {
+		labelsToAdd := make(map[string]string, 1)
+		labelsToRemove := []string{labelName}
+		annotationsToRemove := make([]string, 0, 1)
+
+		if isModuleLoader {
+			labelsToRemove = append(labelsToRemove, loadedKernelLabelName, loadedVersionLabelName, loadedVersionNumberLabelName)
+			annotationsToRemove = append(annotationsToRemove, loadedDigestAnnotationName)
+		}
+
+		// the pod is, or is about to be, deleted without unloading the kernel module, which is now unmanaged on that node
+		if _, ok := pod.Annotations[constants.SkipUnloadAnnotation]; ok && (!pod.DeletionTimestamp.IsZero() || daemonset.IsSkipUnloadAcknowledged(&pod)) {
+			logger.Info("Kernel module left loaded; marking it as unmanaged", "unmanaged label name", unmanagedLabelName)
+			labelsToAdd[unmanagedLabelName] = ""
+		}
+
+		// the PreStop hook reports in the termination message of the container that the kernel module could not be
+		// unloaded; wait for the container to terminate before letting the pod go.
+		var rebootRequired, waitForTermination bool
+
+		if isModuleLoader && !pod.DeletionTimestamp.IsZero() {
+			state := moduleLoaderState(&pod)
+
+			switch {
+			case state.Terminated != nil && strings.Contains(state.Terminated.Message, constants.ModuleInUseTerminationMessage):
+				logger.Info("Kernel module could not be unloaded; the node requires a reboot", "reboot label name", rebootRequiredLabelName)
+				rebootRequired = true
+			case state.Running != nil && time.Now().Before(pod.DeletionTimestamp.Add(moduleLoaderTerminationTimeout)):
+				waitForTermination = true
+			}
+		}
+
+		// the labels, the taint and the condition of the node belong to the ModuleLoader pod of another version, which
+		// already loaded the kernel module on the node
+		var superseded bool
+
+		node, err := pnmr.patchNode(ctx, nodeName, func(node *v1.Node) {
+			superseded = isModuleLoader && loadedByOtherVersion(node, loadedVersionLabelName, pod.Labels[constants.ModuleVersionLabel])
+
+			if !superseded {
+				updateLabels(node, labelsToAdd, labelsToRemove)
+				updateAnnotations(node, nil, annotationsToRemove)
+			}
+
+			// record the current boot, so that the label is only removed once the node has been rebooted
+			if rebootRequired {
+				node.Labels[rebootRequiredLabelName] = node.Status.NodeInfo.BootID
+			}
+
+			// workloads should not be scheduled while the kernel module is not loaded
+			if startupTaint != nil && !superseded {
+				addTaint(node, *startupTaint)
+			}
+		})
+		if err != nil {
 			return ctrl.Result{}, fmt.Errorf("could not unlabel node %s: %v", nodeName, err)
 		}
 
+		if superseded {
+			logger.Info("Kernel module loaded by a ModuleLoader pod of another version; keeping the node labels")
+		}
+
+		if mod != nil && !superseded {
+			reason, message := nodecondition.ReasonLoadFailed, "The ModuleLoader pod is not ready"
+
+			if !pod.DeletionTimestamp.IsZero() {
+				reason, message = nodecondition.ReasonUnloading, "The ModuleLoader pod is being deleted"
+			}
+
+			if err = pnmr.nodeConditionAPI.SetModuleCondition(ctx, node, pod.Namespace, moduleName, v1.ConditionFalse, reason, message); err != nil {
+				return ctrl.Result{}, fmt.Errorf("could not set the condition of node %s: %v", nodeName, err)
+			}
+		}
+
+		if waitForTermination {
+			logger.Info("Waiting for the ModuleLoader container to terminate")
+			return ctrl.Result{RequeueAfter: moduleLoaderTerminationRequeueAfter}, nil
+		}
+
 		if !pod.DeletionTimestamp.IsZero() {
 			logger.Info("Pod deletion requested; removing finalizer")
 
@@ -91,10 +212,62 @@ func (pnmr *PodNodeModuleReconciler) Reconcile(ctx context.Context, req ctrl.Req
 
 	logger.Info("Labeling node")
 
-	if err := pnmr.addLabel(ctx, nodeName, labelName); err != nil {
+	labelsToAdd := map[string]string{labelName: ""}
+	labelsToRemove := make([]string, 0, 3)
+	annotationsToAdd := make(map[string]string, 1)
+	annotationsToRemove := make([]string, 0, 1)
+
+	if isModuleLoader {
+		// a ModuleLoader pod manages the kernel module again, and could load it
+		labelsToRemove = append(labelsToRemove, unmanagedLabelName)
+
+		// record what was loaded, so that workloads can select nodes running a specific version of the kernel module
+		labelsToAdd[loadedKernelLabelName] = pod.Labels[constants.KernelLabel]
+
+		if version := pod.Labels[constants.ModuleVersionLabel]; version != "" {
+			labelsToAdd[loadedVersionLabelName] = version
+		} else {
+			labelsToRemove = append(labelsToRemove, loadedVersionLabelName)
+		}
+
+		if number := versionNumber(pod.Labels[constants.ModuleVersionLabel]); number != "" {
+			labelsToAdd[loadedVersionNumberLabelName] = number
+		} else {
+			labelsToRemove = append(labelsToRemove, loadedVersionNumberLabelName)
+		}
+
+		if digest := moduleLoaderImageDigest(&pod); digest != "" {
+			annotationsToAdd[loadedDigestAnnotationName] = digest
+		} else {
+			annotationsToRemove = append(annotationsToRemove, loadedDigestAnnotationName)
+		}
+	}
+
+	node, err := pnmr.patchNode(ctx, nodeName, func(node *v1.Node) {
+		updateLabels(node, labelsToAdd, labelsToRemove)
+		updateAnnotations(node, annotationsToAdd, annotationsToRemove)
+
+		// a kernel module that could not be unloaded is still loaded until the node reboots, even if a new ModuleLoader
+		// pod is ready
+		if isModuleLoader && rebootedSince(node, rebootRequiredLabelName) {
+			delete(node.Labels, rebootRequiredLabelName)
+		}
+
+		if startupTaint != nil {
+			removeTaint(node, *startupTaint)
+		}
+	})
+	if err != nil {
 		return ctrl.Result{}, fmt.Errorf("could not label node %s with %q: %v", nodeName, labelName, err)
 	}
 
+	if mod != nil {
+		err = pnmr.nodeConditionAPI.SetModuleCondition(ctx, node, pod.Namespace, moduleName, v1.ConditionTrue, nodecondition.ReasonLoaded, "The kernel module is loaded")
+		if err != nil {
+			return ctrl.Result{}, fmt.Errorf("could not set the condition of node %s: %v", nodeName, err)
+		}
+	}
+
 	return ctrl.Result{}, nil
 }
 
@@ -119,42 +292,183 @@ func (pnmr *PodNodeModuleReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		Complete(pnmr)
 }
 
-func (pnmr *PodNodeModuleReconciler) addLabel(ctx context.Context, nodeName, labelName string) error {
+func (pnmr *PodNodeModuleReconciler) deleteFinalizer(ctx context.Context, pod *v1.Pod) error {
+	podCopy := pod.DeepCopy()
+
+	controllerutil.RemoveFinalizer(pod, constants.NodeLabelerFinalizer)
+
+	return pnmr.client.Patch(ctx, pod, client.MergeFrom(podCopy))
+}
+
+// getManagingModule returns the Module namespace/name, or nil if it does not exist or is being deleted.
+func (pnmr *PodNodeModuleReconciler) getManagingModule(ctx context.Context, namespace, name string) (*kmmv1beta1.Module, error) {
+	mod := kmmv1beta1.Module{}
+
+	if err := pnmr.client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, &mod); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	if !mod.DeletionTimestamp.IsZero() {
+		return nil, nil
+	}
+
+	return &mod, nil
+}
+
+// patchNode applies mutate to node nodeName in a single patch, and returns the patched node.
+func (pnmr *PodNodeModuleReconciler) patchNode(ctx context.Context, nodeName string, mutate func(node *v1.Node)) (*v1.Node, error) {
 	node := v1.Node{}
 
 	if err := pnmr.client.Get(ctx, types.NamespacedName{Name: nodeName}, &node); err != nil {
-		return fmt.Errorf("could not get node %s: %v", nodeName, err)
+		return nil, fmt.Errorf("could not get node %s: %v", nodeName, err)
 	}
 
 	nodeCopy := node.DeepCopy()
 
+	mutate(&node)
+
+	if err := pnmr.client.Patch(ctx, &node, client.MergeFrom(nodeCopy)); err != nil {
+		return nil, err
+	}
+
+	return &node, nil
+}
+
+// loadedByOtherVersion returns true if node has the label labelName, and its value is not version.
+func loadedByOtherVersion(node *v1.Node, labelName, version string) bool {
+	loadedVersion, ok := node.Labels[labelName]
+	if !ok {
+		return false
+	}
+
+	return loadedVersion != version
+}
+
+// rebootedSince returns true if node has the label labelName, and has been rebooted since the boot recorded as its value.
+func rebootedSince(node *v1.Node, labelName string) bool {
+	bootID, ok := node.Labels[labelName]
+	if !ok {
+		return false
+	}
+
+	return bootID != node.Status.NodeInfo.BootID
+}
+
+// updateLabels adds labelsToAdd to and removes labelsToRemove from node.
+func updateLabels(node *v1.Node, labelsToAdd map[string]string, labelsToRemove []string) {
 	if node.Labels == nil {
-		node.Labels = make(map[string]string, 1)
+		node.Labels = make(map[string]string, len(labelsToAdd))
 	}
 
-	node.Labels[labelName] = ""
+	for _, l := range labelsToRemove {
+		delete(node.Labels, l)
+	}
 
-	return pnmr.client.Patch(ctx, &node, client.MergeFrom(nodeCopy))
+	for k, v := range labelsToAdd {
+		node.Labels[k] = v
+	}
 }
 
-func (pnmr *PodNodeModuleReconciler) deleteFinalizer(ctx context.Context, pod *v1.Pod) error {
-	podCopy := pod.DeepCopy()
+// updateAnnotations adds annotationsToAdd to and removes annotationsToRemove from node.
+func updateAnnotations(node *v1.Node, annotationsToAdd map[string]string, annotationsToRemove []string) {
+	for _, a := range annotationsToRemove {
+		delete(node.Annotations, a)
+	}
 
-	controllerutil.RemoveFinalizer(pod, constants.NodeLabelerFinalizer)
+	if len(annotationsToAdd) == 0 {
+		return
+	}
 
-	return pnmr.client.Patch(ctx, pod, client.MergeFrom(podCopy))
+	if node.Annotations == nil {
+		node.Annotations = make(map[string]string, len(annotationsToAdd))
+	}
+
+	for k, v := range annotationsToAdd {
+		node.Annotations[k] = v
+	}
 }
 
-func (pnmr *PodNodeModuleReconciler) deleteLabel(ctx context.Context, nodeName, labelName string) error {
-	node := v1.Node{}
+// addTaint adds taint to node, unless node already has a taint with the same key and effect.
+func addTaint(node *v1.Node, taint v1.Taint) {
+	for _, t := range node.Spec.Taints {
+		if t.MatchTaint(&taint) {
+			return
+		}
+	}
 
-	if err := pnmr.client.Get(ctx, types.NamespacedName{Name: nodeName}, &node); err != nil {
-		return fmt.Errorf("could not get node %s: %v", nodeName, err)
+	node.Spec.Taints = append(node.Spec.Taints, taint)
+}
+
+// removeTaint removes the taints of node with the same key and effect as taint.
+func removeTaint(node *v1.Node, taint v1.Taint) {
+	taints := make([]v1.Taint, 0, len(node.Spec.Taints))
+
+	for _, t := range node.Spec.Taints {
+		if !t.MatchTaint(&taint) {
+			taints = append(taints, t)
+		}
 	}
 
-	nodeCopy := node.DeepCopy()
+	node.Spec.Taints = taints
+}
+
+// moduleLoaderState returns the state of the ModuleLoader container of pod.
+// The state is empty if the container has no status yet.
+func moduleLoaderState(pod *v1.Pod) v1.ContainerState {
+	for _, cs := range pod.Status.ContainerStatuses {
+		if cs.Name == constants.ModuleLoaderContainerName {
+			return cs.State
+		}
+	}
 
-	delete(node.Labels, labelName)
+	return v1.ContainerState{}
+}
+
+// moduleLoaderImageDigest returns the digest of the image that runs the ModuleLoader container of pod, as reported by
+// the container runtime, or an empty string if it is unknown.
+func moduleLoaderImageDigest(pod *v1.Pod) string {
+	for _, cs := range pod.Status.ContainerStatuses {
+		if cs.Name != constants.ModuleLoaderContainerName {
+			continue
+		}
+
+		// the image ID is either a digest or a reference to it, such as quay.io/org/image@sha256:...
+		if _, digest, ok := strings.Cut(cs.ImageID, "@"); ok {
+			return digest
+		}
+
+		if strings.HasPrefix(cs.ImageID, "sha256:") {
+			return cs.ImageID
+		}
+	}
+
+	return ""
+}
+
+// versionNumberRegexp matches the versions that versionNumber can convert: up to three numeric components of at most
+// three digits, optionally prefixed with a v.
+var versionNumberRegexp = regexp.MustCompile(`^v?(\d{1,3})(?:\.(\d{1,3}))?(?:\.(\d{1,3}))?$`)
+
+// versionNumber returns version as an integer that follows the order of versions, major*1000000 + minor*1000 + patch,
+// such as 1002003 for v1.2.3, or an empty string if version is not of that form; pre-releases are not supported, as
+// they come before the release they are named after.
+func versionNumber(version string) string {
+	m := versionNumberRegexp.FindStringSubmatch(version)
+	if m == nil {
+		return ""
+	}
+
+	number := 0
+
+	for _, component := range m[1:] {
+		// missing components are 0
+		n, _ := strconv.Atoi(component)
+		number = number*1000 + n
+	}
 
-	return pnmr.client.Patch(ctx, &node, client.MergeFrom(nodeCopy))
+	return strconv.Itoa(number)
 }
@@ -4,9 +4,12 @@ import (
 	"context"
 
 	"github.com/golang/mock/gomock"
+	kmmv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
 	mock_client "github.com/kubernetes-sigs/kernel-module-management/internal/client"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/constants"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/daemonset"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/nodecondition"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/utils"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	v1 "k8s.io/api/core/v1"
@@ -30,13 +33,15 @@ var _ = Describe("PodNodeModuleReconciler", func() {
 			kubeClient *mock_client.MockClient
 			r          *PodNodeModuleReconciler
 			mockDC     *daemonset.MockDaemonSetCreator
+			mockNC     *nodecondition.MockUpdater
 		)
 
 		BeforeEach(func() {
 			ctrl := gomock.NewController(GinkgoT())
 			kubeClient = mock_client.NewMockClient(ctrl)
 			mockDC = daemonset.NewMockDaemonSetCreator(ctrl)
-			r = NewPodNodeModuleReconciler(kubeClient, mockDC)
+			mockNC = nodecondition.NewMockUpdater(ctrl)
+			r = NewPodNodeModuleReconciler(kubeClient, mockDC, mockNC)
 		})
 
 		ctx := context.Background()
@@ -45,6 +50,7 @@ var _ = Describe("PodNodeModuleReconciler", func() {
 			Name:      podName,
 		}
 		req := ctrl.Request{NamespacedName: nn}
+		modNN := types.NamespacedName{Namespace: podNamespace, Name: moduleName}
 
 		It("should return an error if the pod is not labeled", func() {
 			gomock.InOrder(
@@ -220,5 +226,622 @@ var _ = Describe("PodNodeModuleReconciler", func() {
 			_, err := r.Reconcile(ctx, req)
 			Expect(err).NotTo(HaveOccurred())
 		})
+
+		It("should wait for the ModuleLoader container to terminate before removing the pod finalizer", func() {
+			now := metav1.Now()
+
+			deletedPod := v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              podName,
+					Namespace:         podNamespace,
+					DeletionTimestamp: &now,
+					Finalizers:        []string{constants.NodeLabelerFinalizer},
+					Labels: map[string]string{
+						constants.ModuleNameLabel: moduleName,
+						constants.DaemonSetRole:   "module-loader",
+					},
+				},
+				Spec: v1.PodSpec{NodeName: nodeName},
+				Status: v1.PodStatus{
+					ContainerStatuses: []v1.ContainerStatus{
+						{
+							Name:  constants.ModuleLoaderContainerName,
+							State: v1.ContainerState{Running: &v1.ContainerStateRunning{}},
+						},
+					},
+				},
+			}
+
+			gomock.InOrder(
+				kubeClient.
+					EXPECT().
+					Get(ctx, nn, &v1.Pod{}).
+					Do(func(_ context.Context, _ types.NamespacedName, o client.Object, _ ...client.GetOption) {
+						deletedPod.DeepCopyInto(o.(*v1.Pod))
+					}),
+				mockDC.EXPECT().GetNodeLabelFromPod(&deletedPod, moduleName).Return(nodeLabel),
+				kubeClient.EXPECT().Get(ctx, modNN, &kmmv1beta1.Module{}),
+				kubeClient.EXPECT().Get(ctx, types.NamespacedName{Name: nodeName}, &v1.Node{}),
+				kubeClient.EXPECT().Patch(ctx, gomock.AssignableToTypeOf(&v1.Node{}), gomock.Any()),
+				mockNC.EXPECT().SetModuleCondition(ctx, gomock.Any(), podNamespace, moduleName, v1.ConditionFalse, nodecondition.ReasonUnloading, gomock.Any()),
+			)
+
+			Expect(
+				r.Reconcile(ctx, req),
+			).To(
+				Equal(ctrl.Result{RequeueAfter: moduleLoaderTerminationRequeueAfter}),
+			)
+		})
+
+		It("should mark the node as requiring a reboot when the kernel module could not be unloaded", func() {
+			now := metav1.Now()
+
+			rebootRequiredLabel := utils.GetModuleRebootRequiredLabelName(podNamespace, moduleName)
+
+			deletedPod := v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              podName,
+					Namespace:         podNamespace,
+					DeletionTimestamp: &now,
+					Finalizers:        []string{constants.NodeLabelerFinalizer},
+					Labels: map[string]string{
+						constants.ModuleNameLabel: moduleName,
+						constants.DaemonSetRole:   "module-loader",
+					},
+				},
+				Spec: v1.PodSpec{NodeName: nodeName},
+				Status: v1.PodStatus{
+					ContainerStatuses: []v1.ContainerStatus{
+						{
+							Name: constants.ModuleLoaderContainerName,
+							State: v1.ContainerState{
+								Terminated: &v1.ContainerStateTerminated{
+									Message: constants.ModuleInUseTerminationMessage + "\n",
+								},
+							},
+						},
+					},
+				},
+			}
+
+			gomock.InOrder(
+				kubeClient.
+					EXPECT().
+					Get(ctx, nn, &v1.Pod{}).
+					Do(func(_ context.Context, _ types.NamespacedName, o client.Object, _ ...client.GetOption) {
+						deletedPod.DeepCopyInto(o.(*v1.Pod))
+					}),
+				mockDC.EXPECT().GetNodeLabelFromPod(&deletedPod, moduleName).Return(nodeLabel),
+				kubeClient.EXPECT().Get(ctx, modNN, &kmmv1beta1.Module{}),
+				kubeClient.
+					EXPECT().
+					Get(ctx, types.NamespacedName{Name: nodeName}, &v1.Node{}).
+					Do(func(_ context.Context, _ types.NamespacedName, o client.Object, _ ...client.GetOption) {
+						o.SetLabels(map[string]string{nodeLabel: ""})
+						o.(*v1.Node).Status.NodeInfo.BootID = "boot-1"
+					}),
+				kubeClient.
+					EXPECT().
+					Patch(ctx, gomock.Any(), gomock.Any()).
+					Do(func(_ context.Context, n client.Object, p client.Patch, _ ...client.PatchOption) {
+						Expect(n.GetLabels()).To(Equal(map[string]string{rebootRequiredLabel: "boot-1"}))
+					}),
+				mockNC.EXPECT().SetModuleCondition(ctx, gomock.Any(), podNamespace, moduleName, v1.ConditionFalse, nodecondition.ReasonUnloading, gomock.Any()),
+				kubeClient.EXPECT().Patch(ctx, gomock.AssignableToTypeOf(&v1.Pod{}), gomock.Any()),
+			)
+
+			Expect(
+				r.Reconcile(ctx, req),
+			).To(
+				Equal(ctrl.Result{}),
+			)
+		})
+
+		It("should mark the kernel module as unmanaged when the pod is deleted without unloading it", func() {
+			now := metav1.Now()
+
+			annotations := map[string]string{constants.SkipUnloadAnnotation: ""}
+			unmanagedLabel := utils.GetModuleUnmanagedLabelName(podNamespace, moduleName)
+
+			deletedPod := v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              podName,
+					Namespace:         podNamespace,
+					Annotations:       annotations,
+					DeletionTimestamp: &now,
+					Finalizers:        []string{constants.NodeLabelerFinalizer},
+					Labels:            map[string]string{constants.ModuleNameLabel: moduleName},
+				},
+				Spec: v1.PodSpec{NodeName: nodeName},
+			}
+
+			gomock.InOrder(
+				kubeClient.
+					EXPECT().
+					Get(ctx, nn, &v1.Pod{}).
+					Do(func(_ context.Context, _ types.NamespacedName, o client.Object, _ ...client.GetOption) {
+						deletedPod.DeepCopyInto(o.(*v1.Pod))
+					}),
+				mockDC.EXPECT().GetNodeLabelFromPod(&deletedPod, moduleName).Return(nodeLabel),
+				kubeClient.
+					EXPECT().
+					Get(ctx, types.NamespacedName{Name: nodeName}, &v1.Node{}).
+					Do(func(_ context.Context, _ types.NamespacedName, o client.Object, _ ...client.GetOption) {
+						o.SetLabels(map[string]string{nodeLabel: ""})
+					}),
+				kubeClient.
+					EXPECT().
+					Patch(ctx, gomock.Any(), gomock.Any()).
+					Do(func(_ context.Context, n client.Object, p client.Patch, _ ...client.PatchOption) {
+						Expect(n.GetLabels()).To(Equal(map[string]string{unmanagedLabel: ""}))
+					}),
+				kubeClient.EXPECT().Patch(ctx, gomock.AssignableToTypeOf(&v1.Pod{}), gomock.Any()),
+			)
+
+			_, err := r.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should mark the kernel module as unmanaged once the pod acknowledged that it will not unload it", func() {
+			unmanagedLabel := utils.GetModuleUnmanagedLabelName(podNamespace, moduleName)
+
+			pod := v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        podName,
+					Namespace:   podNamespace,
+					Annotations: map[string]string{constants.SkipUnloadAnnotation: ""},
+					Finalizers:  []string{constants.NodeLabelerFinalizer},
+					Labels:      map[string]string{constants.ModuleNameLabel: moduleName},
+				},
+				Spec: v1.PodSpec{NodeName: nodeName},
+				Status: v1.PodStatus{
+					ContainerStatuses: []v1.ContainerStatus{
+						{
+							Name:  constants.ModuleLoaderContainerName,
+							State: v1.ContainerState{Running: &v1.ContainerStateRunning{}},
+						},
+					},
+				},
+			}
+
+			gomock.InOrder(
+				kubeClient.
+					EXPECT().
+					Get(ctx, nn, &v1.Pod{}).
+					Do(func(_ context.Context, _ types.NamespacedName, o client.Object, _ ...client.GetOption) {
+						pod.DeepCopyInto(o.(*v1.Pod))
+					}),
+				mockDC.EXPECT().GetNodeLabelFromPod(&pod, moduleName).Return(nodeLabel),
+				kubeClient.
+					EXPECT().
+					Get(ctx, types.NamespacedName{Name: nodeName}, &v1.Node{}).
+					Do(func(_ context.Context, _ types.NamespacedName, o client.Object, _ ...client.GetOption) {
+						o.SetLabels(map[string]string{nodeLabel: ""})
+					}),
+				kubeClient.
+					EXPECT().
+					Patch(ctx, gomock.Any(), gomock.Any()).
+					Do(func(_ context.Context, n client.Object, p client.Patch, _ ...client.PatchOption) {
+						Expect(n.GetLabels()).To(Equal(map[string]string{unmanagedLabel: ""}))
+					}),
+			)
+
+			_, err := r.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should not manage the node condition nor the startup taint of a Module being deleted", func() {
+			readyPod := v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      podName,
+					Namespace: podNamespace,
+					Labels: map[string]string{
+						constants.ModuleNameLabel: moduleName,
+						constants.DaemonSetRole:   "module-loader",
+					},
+				},
+				Spec: v1.PodSpec{NodeName: nodeName},
+				Status: v1.PodStatus{
+					Conditions: []v1.PodCondition{
+						{Type: v1.PodReady, Status: v1.ConditionTrue},
+					},
+				},
+			}
+
+			now := metav1.Now()
+			taints := []v1.Taint{{Key: "not-ready", Effect: v1.TaintEffectNoSchedule}}
+
+			gomock.InOrder(
+				kubeClient.
+					EXPECT().
+					Get(ctx, nn, &v1.Pod{}).
+					Do(func(_ context.Context, _ types.NamespacedName, o client.Object, _ ...client.GetOption) {
+						readyPod.DeepCopyInto(o.(*v1.Pod))
+					}),
+				mockDC.EXPECT().GetNodeLabelFromPod(&readyPod, moduleName).Return(nodeLabel),
+				kubeClient.
+					EXPECT().
+					Get(ctx, modNN, &kmmv1beta1.Module{}).
+					Do(func(_ context.Context, _ types.NamespacedName, o client.Object, _ ...client.GetOption) {
+						o.SetDeletionTimestamp(&now)
+						o.(*kmmv1beta1.Module).Spec.StartupTaint = &kmmv1beta1.StartupTaint{Key: "not-ready"}
+					}),
+				kubeClient.
+					EXPECT().
+					Get(ctx, types.NamespacedName{Name: nodeName}, &v1.Node{}).
+					Do(func(_ context.Context, _ types.NamespacedName, o client.Object, _ ...client.GetOption) {
+						o.(*v1.Node).Spec.Taints = taints
+					}),
+				kubeClient.
+					EXPECT().
+					Patch(ctx, gomock.Any(), gomock.Any()).
+					Do(func(_ context.Context, n client.Object, p client.Patch, _ ...client.PatchOption) {
+						Expect(n.(*v1.Node).Spec.Taints).To(Equal(taints))
+					}),
+			)
+
+			_, err := r.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should remove the startup taint when the ModuleLoader pod is ready", func() {
+			readyPod := v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      podName,
+					Namespace: podNamespace,
+					Labels: map[string]string{
+						constants.ModuleNameLabel: moduleName,
+						constants.DaemonSetRole:   "module-loader",
+					},
+				},
+				Spec: v1.PodSpec{NodeName: nodeName},
+				Status: v1.PodStatus{
+					Conditions: []v1.PodCondition{
+						{Type: v1.PodReady, Status: v1.ConditionTrue},
+					},
+				},
+			}
+
+			otherTaint := v1.Taint{Key: "other", Effect: v1.TaintEffectNoSchedule}
+
+			gomock.InOrder(
+				kubeClient.
+					EXPECT().
+					Get(ctx, nn, &v1.Pod{}).
+					Do(func(_ context.Context, _ types.NamespacedName, o client.Object, _ ...client.GetOption) {
+						readyPod.DeepCopyInto(o.(*v1.Pod))
+					}),
+				mockDC.EXPECT().GetNodeLabelFromPod(&readyPod, moduleName).Return(nodeLabel),
+				kubeClient.
+					EXPECT().
+					Get(ctx, modNN, &kmmv1beta1.Module{}).
+					Do(func(_ context.Context, _ types.NamespacedName, o client.Object, _ ...client.GetOption) {
+						o.(*kmmv1beta1.Module).Spec.StartupTaint = &kmmv1beta1.StartupTaint{Key: "not-ready"}
+					}),
+				kubeClient.
+					EXPECT().
+					Get(ctx, types.NamespacedName{Name: nodeName}, &v1.Node{}).
+					Do(func(_ context.Context, _ types.NamespacedName, o client.Object, _ ...client.GetOption) {
+						o.(*v1.Node).Spec.Taints = []v1.Taint{
+							{Key: "not-ready", Effect: v1.TaintEffectNoSchedule},
+							otherTaint,
+						}
+					}),
+				kubeClient.
+					EXPECT().
+					Patch(ctx, gomock.Any(), gomock.Any()).
+					Do(func(_ context.Context, n client.Object, p client.Patch, _ ...client.PatchOption) {
+						Expect(n.GetLabels()).To(HaveKey(nodeLabel))
+						Expect(n.(*v1.Node).Spec.Taints).To(Equal([]v1.Taint{otherTaint}))
+					}),
+				mockNC.EXPECT().SetModuleCondition(ctx, gomock.Any(), podNamespace, moduleName, v1.ConditionTrue, nodecondition.ReasonLoaded, gomock.Any()),
+			)
+
+			_, err := r.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		DescribeTable("should only remove the reboot-required label once the node was rebooted",
+			func(bootID string, expectRemoved bool) {
+				rebootRequiredLabel := utils.GetModuleRebootRequiredLabelName(podNamespace, moduleName)
+
+				readyPod := v1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      podName,
+						Namespace: podNamespace,
+						Labels: map[string]string{
+							constants.ModuleNameLabel: moduleName,
+							constants.DaemonSetRole:   "module-loader",
+						},
+					},
+					Spec: v1.PodSpec{NodeName: nodeName},
+					Status: v1.PodStatus{
+						Conditions: []v1.PodCondition{
+							{Type: v1.PodReady, Status: v1.ConditionTrue},
+						},
+					},
+				}
+
+				gomock.InOrder(
+					kubeClient.
+						EXPECT().
+						Get(ctx, nn, &v1.Pod{}).
+						Do(func(_ context.Context, _ types.NamespacedName, o client.Object, _ ...client.GetOption) {
+							readyPod.DeepCopyInto(o.(*v1.Pod))
+						}),
+					mockDC.EXPECT().GetNodeLabelFromPod(&readyPod, moduleName).Return(nodeLabel),
+					kubeClient.EXPECT().Get(ctx, modNN, &kmmv1beta1.Module{}),
+					kubeClient.
+						EXPECT().
+						Get(ctx, types.NamespacedName{Name: nodeName}, &v1.Node{}).
+						Do(func(_ context.Context, _ types.NamespacedName, o client.Object, _ ...client.GetOption) {
+							o.SetLabels(map[string]string{rebootRequiredLabel: "boot-1"})
+							o.(*v1.Node).Status.NodeInfo.BootID = bootID
+						}),
+					kubeClient.
+						EXPECT().
+						Patch(ctx, gomock.Any(), gomock.Any()).
+						Do(func(_ context.Context, n client.Object, p client.Patch, _ ...client.PatchOption) {
+							if expectRemoved {
+								Expect(n.GetLabels()).NotTo(HaveKey(rebootRequiredLabel))
+							} else {
+								Expect(n.GetLabels()).To(HaveKeyWithValue(rebootRequiredLabel, "boot-1"))
+							}
+						}),
+					mockNC.EXPECT().SetModuleCondition(ctx, gomock.Any(), podNamespace, moduleName, v1.ConditionTrue, nodecondition.ReasonLoaded, gomock.Any()),
+				)
+
+				_, err := r.Reconcile(ctx, req)
+				Expect(err).NotTo(HaveOccurred())
+			},
+			Entry("same boot", "boot-1", false),
+			Entry("node rebooted", "boot-2", true),
+		)
+
+		It("should record the loaded kernel, version, version number and image digest when the ModuleLoader pod is ready", func() {
+			const (
+				kernelVersion = "1.2.3"
+				moduleVersion = "v2"
+				digest        = "sha256:0123456789abcdef"
+			)
+
+			readyPod := v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      podName,
+					Namespace: podNamespace,
+					Labels: map[string]string{
+						constants.ModuleNameLabel:    moduleName,
+						constants.DaemonSetRole:      "module-loader",
+						constants.KernelLabel:        kernelVersion,
+						constants.ModuleVersionLabel: moduleVersion,
+					},
+				},
+				Spec: v1.PodSpec{NodeName: nodeName},
+				Status: v1.PodStatus{
+					Conditions: []v1.PodCondition{
+						{Type: v1.PodReady, Status: v1.ConditionTrue},
+					},
+					ContainerStatuses: []v1.ContainerStatus{
+						{
+							Name:    constants.ModuleLoaderContainerName,
+							ImageID: "quay.io/org/image@" + digest,
+						},
+					},
+				},
+			}
+
+			gomock.InOrder(
+				kubeClient.
+					EXPECT().
+					Get(ctx, nn, &v1.Pod{}).
+					Do(func(_ context.Context, _ types.NamespacedName, o client.Object, _ ...client.GetOption) {
+						readyPod.DeepCopyInto(o.(*v1.Pod))
+					}),
+				mockDC.EXPECT().GetNodeLabelFromPod(&readyPod, moduleName).Return(nodeLabel),
+				kubeClient.EXPECT().Get(ctx, modNN, &kmmv1beta1.Module{}),
+				kubeClient.EXPECT().Get(ctx, types.NamespacedName{Name: nodeName}, &v1.Node{}),
+				kubeClient.
+					EXPECT().
+					Patch(ctx, gomock.Any(), gomock.Any()).
+					Do(func(_ context.Context, n client.Object, p client.Patch, _ ...client.PatchOption) {
+						Expect(n.GetLabels()).To(
+							Equal(map[string]string{
+								nodeLabel: "",
+								utils.GetModuleLoadedKernelLabelName(podNamespace, moduleName):        kernelVersion,
+								utils.GetModuleLoadedVersionLabelName(podNamespace, moduleName):       moduleVersion,
+								utils.GetModuleLoadedVersionNumberLabelName(podNamespace, moduleName): "2000000",
+							}),
+						)
+						Expect(n.GetAnnotations()).To(
+							Equal(map[string]string{
+								utils.GetModuleLoadedDigestAnnotationName(podNamespace, moduleName): digest,
+							}),
+						)
+					}),
+				mockNC.EXPECT().SetModuleCondition(ctx, gomock.Any(), podNamespace, moduleName, v1.ConditionTrue, nodecondition.ReasonLoaded, gomock.Any()),
+			)
+
+			_, err := r.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should remove the loaded kernel, version and image digest when the ModuleLoader pod is not ready", func() {
+			pod := v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      podName,
+					Namespace: podNamespace,
+					Labels: map[string]string{
+						constants.ModuleNameLabel:    moduleName,
+						constants.DaemonSetRole:      "module-loader",
+						constants.ModuleVersionLabel: "v2",
+					},
+				},
+				Spec: v1.PodSpec{NodeName: nodeName},
+			}
+
+			const otherLabel = "other-label"
+
+			gomock.InOrder(
+				kubeClient.
+					EXPECT().
+					Get(ctx, nn, &v1.Pod{}).
+					Do(func(_ context.Context, _ types.NamespacedName, o client.Object, _ ...client.GetOption) {
+						pod.DeepCopyInto(o.(*v1.Pod))
+					}),
+				mockDC.EXPECT().GetNodeLabelFromPod(&pod, moduleName).Return(nodeLabel),
+				kubeClient.EXPECT().Get(ctx, modNN, &kmmv1beta1.Module{}),
+				kubeClient.
+					EXPECT().
+					Get(ctx, types.NamespacedName{Name: nodeName}, &v1.Node{}).
+					Do(func(_ context.Context, _ types.NamespacedName, o client.Object, _ ...client.GetOption) {
+						o.SetLabels(map[string]string{
+							nodeLabel:  "",
+							otherLabel: "",
+							utils.GetModuleLoadedKernelLabelName(podNamespace, moduleName):        "1.2.3",
+							utils.GetModuleLoadedVersionLabelName(podNamespace, moduleName):       "v2",
+							utils.GetModuleLoadedVersionNumberLabelName(podNamespace, moduleName): "2000000",
+						})
+						o.SetAnnotations(map[string]string{
+							utils.GetModuleLoadedDigestAnnotationName(podNamespace, moduleName): "sha256:0123456789abcdef",
+						})
+					}),
+				kubeClient.
+					EXPECT().
+					Patch(ctx, gomock.Any(), gomock.Any()).
+					Do(func(_ context.Context, n client.Object, p client.Patch, _ ...client.PatchOption) {
+						Expect(n.GetLabels()).To(Equal(map[string]string{otherLabel: ""}))
+						Expect(n.GetAnnotations()).To(BeEmpty())
+					}),
+				mockNC.EXPECT().SetModuleCondition(ctx, gomock.Any(), podNamespace, moduleName, v1.ConditionFalse, nodecondition.ReasonLoadFailed, gomock.Any()),
+			)
+
+			_, err := r.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should keep the labels of the ModuleLoader pod of another version when the pod is being deleted", func() {
+			now := metav1.Now()
+
+			pod := v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              podName,
+					Namespace:         podNamespace,
+					DeletionTimestamp: &now,
+					Finalizers:        []string{constants.NodeLabelerFinalizer},
+					Labels: map[string]string{
+						constants.ModuleNameLabel:    moduleName,
+						constants.DaemonSetRole:      "module-loader",
+						constants.ModuleVersionLabel: "v1",
+					},
+				},
+				Spec: v1.PodSpec{NodeName: nodeName},
+			}
+
+			nodeLabels := map[string]string{
+				nodeLabel: "",
+				utils.GetModuleLoadedKernelLabelName(podNamespace, moduleName):  "1.2.3",
+				utils.GetModuleLoadedVersionLabelName(podNamespace, moduleName): "v2",
+			}
+
+			gomock.InOrder(
+				kubeClient.
+					EXPECT().
+					Get(ctx, nn, &v1.Pod{}).
+					Do(func(_ context.Context, _ types.NamespacedName, o client.Object, _ ...client.GetOption) {
+						pod.DeepCopyInto(o.(*v1.Pod))
+					}),
+				mockDC.EXPECT().GetNodeLabelFromPod(&pod, moduleName).Return(nodeLabel),
+				kubeClient.
+					EXPECT().
+					Get(ctx, modNN, &kmmv1beta1.Module{}).
+					Do(func(_ context.Context, _ types.NamespacedName, o client.Object, _ ...client.GetOption) {
+						o.(*kmmv1beta1.Module).Spec.StartupTaint = &kmmv1beta1.StartupTaint{
+							Key:    "not-ready",
+							Effect: v1.TaintEffectNoSchedule,
+						}
+					}),
+				kubeClient.
+					EXPECT().
+					Get(ctx, types.NamespacedName{Name: nodeName}, &v1.Node{}).
+					Do(func(_ context.Context, _ types.NamespacedName, o client.Object, _ ...client.GetOption) {
+						o.SetLabels(nodeLabels)
+					}),
+				kubeClient.
+					EXPECT().
+					Patch(ctx, gomock.Any(), gomock.Any()).
+					Do(func(_ context.Context, n client.Object, p client.Patch, _ ...client.PatchOption) {
+						Expect(n.GetLabels()).To(Equal(nodeLabels))
+						Expect(n.(*v1.Node).Spec.Taints).To(BeEmpty())
+					}),
+				kubeClient.EXPECT().Patch(ctx, gomock.Any(), gomock.Any()),
+			)
+
+			_, err := r.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should add the startup taint back when the ModuleLoader pod is not ready", func() {
+			pod := v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      podName,
+					Namespace: podNamespace,
+					Labels: map[string]string{
+						constants.ModuleNameLabel: moduleName,
+						constants.DaemonSetRole:   "module-loader",
+					},
+				},
+				Spec: v1.PodSpec{NodeName: nodeName},
+			}
+
+			gomock.InOrder(
+				kubeClient.
+					EXPECT().
+					Get(ctx, nn, &v1.Pod{}).
+					Do(func(_ context.Context, _ types.NamespacedName, o client.Object, _ ...client.GetOption) {
+						pod.DeepCopyInto(o.(*v1.Pod))
+					}),
+				mockDC.EXPECT().GetNodeLabelFromPod(&pod, moduleName).Return(nodeLabel),
+				kubeClient.
+					EXPECT().
+					Get(ctx, modNN, &kmmv1beta1.Module{}).
+					Do(func(_ context.Context, _ types.NamespacedName, o client.Object, _ ...client.GetOption) {
+						o.(*kmmv1beta1.Module).Spec.StartupTaint = &kmmv1beta1.StartupTaint{
+							Key:    "not-ready",
+							Effect: v1.TaintEffectNoExecute,
+						}
+					}),
+				kubeClient.EXPECT().Get(ctx, types.NamespacedName{Name: nodeName}, &v1.Node{}),
+				kubeClient.
+					EXPECT().
+					Patch(ctx, gomock.Any(), gomock.Any()).
+					Do(func(_ context.Context, n client.Object, p client.Patch, _ ...client.PatchOption) {
+						Expect(n.(*v1.Node).Spec.Taints).To(
+							Equal([]v1.Taint{
+								{Key: "not-ready", Effect: v1.TaintEffectNoExecute},
+							}),
+						)
+					}),
+				mockNC.EXPECT().SetModuleCondition(ctx, gomock.Any(), podNamespace, moduleName, v1.ConditionFalse, nodecondition.ReasonLoadFailed, gomock.Any()),
+			)
+
+			_, err := r.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+		})
 	})
 })
+
+var _ = Describe("versionNumber", func() {
+	DescribeTable("should convert the version to an integer following the order of versions",
+		func(version, expected string) {
+			Expect(versionNumber(version)).To(Equal(expected))
+		},
+		Entry("major only", "v2", "2000000"),
+		Entry("major and minor", "1.2", "1002000"),
+		Entry("full version", "v1.2.3", "1002003"),
+		Entry("large components", "v999.999.999", "999999999"),
+		Entry("empty", "", ""),
+		Entry("pre-release", "v1.2.3-rc1", ""),
+		Entry("component too large", "v1.1000.0", ""),
+		Entry("free-form", "latest", ""),
+	)
+})
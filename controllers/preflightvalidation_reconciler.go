@@ -128,13 +128,25 @@ func (r *PreflightValidationReconciler) runPreflightValidation(ctx context.Conte
 	}
 
 	for _, module := range modulesToCheck {
+		// preflight may build and sign images, which a suspended Module must not do
+		if module.Spec.Suspend {
+			log.Info("Module is suspended, skipping preflight validation", "name", module.Name)
+			r.updatePreflightStatus(ctx, pv, module.Name, "Module is suspended", v1beta12.VerificationSkipped)
+			continue
+		}
+
 		log.Info("start module preflight validation", "name", module.Name)
 
 		verified, message := r.preflight.PreflightUpgradeCheck(ctx, pv, &module)
 
 		log.Info("module preflight validation result", "name", module.Name, "verified", verified)
 
-		r.updatePreflightStatus(ctx, pv, module.Name, message, verified)
+		verificationStatus := v1beta12.VerificationFalse
+		if verified {
+			verificationStatus = v1beta12.VerificationTrue
+		}
+
+		r.updatePreflightStatus(ctx, pv, module.Name, message, verificationStatus)
 	}
 
 	return r.checkPreflightCompletion(ctx, pv.Name, pv.Namespace)
@@ -167,13 +179,12 @@ func (r *PreflightValidationReconciler) getModulesToCheck(ctx context.Context, p
 	return modulesToCheck, nil
 }
 
-func (r *PreflightValidationReconciler) updatePreflightStatus(ctx context.Context, pv *v1beta12.PreflightValidation, moduleName, message string, verified bool) {
+// updatePreflightStatus sets the verification status of moduleName; the verification is done unless it failed.
+func (r *PreflightValidationReconciler) updatePreflightStatus(ctx context.Context, pv *v1beta12.PreflightValidation, moduleName, message, verificationStatus string) {
 	log := ctrl.LoggerFrom(ctx)
-	verificationStatus := v1beta12.VerificationFalse
-	verificationStage := v1beta12.VerificationStageRequeued
-	if verified {
-		verificationStatus = v1beta12.VerificationTrue
-		verificationStage = v1beta12.VerificationStageDone
+	verificationStage := v1beta12.VerificationStageDone
+	if verificationStatus == v1beta12.VerificationFalse {
+		verificationStage = v1beta12.VerificationStageRequeued
 	}
 	err := r.statusUpdater.PreflightSetVerificationStatus(ctx, pv, moduleName, verificationStatus, message)
 	if err != nil {
@@ -213,7 +224,8 @@ func (r *PreflightValidationReconciler) checkPreflightCompletion(ctx context.Con
 	}
 
 	for modName, crStatus := range pv.Status.CRStatuses {
-		if crStatus.VerificationStatus != v1beta12.VerificationTrue {
+		// suspended Modules are verified again once resumed, which updates the PreflightValidation
+		if crStatus.VerificationStatus != v1beta12.VerificationTrue && crStatus.VerificationStatus != v1beta12.VerificationSkipped {
 			ctrl.LoggerFrom(ctx).Info("at least one Module is not verified yet", "module", modName, "status", crStatus.VerificationStatus)
 			return false, nil
 		}
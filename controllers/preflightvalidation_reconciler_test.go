@@ -171,6 +171,55 @@ var _ = Describe("PreflightValidationReconciler_Reconcile", func() {
 		Expect(res).To(Equal(reconcile.Result{RequeueAfter: time.Second * reconcileRequeueInSeconds}))
 	})
 
+	It("should skip suspended Modules without requeueing", func() {
+		mod := v1beta12.Module{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "moduleName",
+			},
+			Spec: v1beta12.ModuleSpec{Suspend: true},
+		}
+		pv := v1beta12.PreflightValidation{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      nsn.Name,
+				Namespace: nsn.Namespace,
+			},
+			Status: v1beta12.PreflightValidationStatus{
+				CRStatuses: map[string]*v1beta12.CRStatus{mod.Name: &v1beta12.CRStatus{}},
+			},
+		}
+		gomock.InOrder(
+			clnt.EXPECT().Get(context.Background(), nsn, &v1beta12.PreflightValidation{}).DoAndReturn(
+				func(_ interface{}, _ interface{}, m *v1beta12.PreflightValidation, _ ...ctrlclient.GetOption) error {
+					m.ObjectMeta = pv.ObjectMeta
+					m.Status = pv.Status
+					return nil
+				},
+			),
+			clnt.EXPECT().List(ctx, gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ interface{}, list *v1beta12.ModuleList, _ ...interface{}) error {
+					list.Items = []v1beta12.Module{mod}
+					return nil
+				},
+			),
+			mockSU.EXPECT().PreflightPresetStatuses(ctx, &pv, sets.New[string](mod.Name), []string{}).Return(nil),
+			mockSU.EXPECT().PreflightSetVerificationStatus(ctx, &pv, mod.Name, v1beta12.VerificationSkipped, "Module is suspended").Return(nil),
+			mockSU.EXPECT().PreflightSetVerificationStage(ctx, &pv, mod.Name, v1beta12.VerificationStageDone).Return(nil),
+			clnt.EXPECT().Get(context.Background(), nsn, &v1beta12.PreflightValidation{}).DoAndReturn(
+				func(_ interface{}, _ interface{}, m *v1beta12.PreflightValidation, _ ...ctrlclient.GetOption) error {
+					m.Status.CRStatuses = map[string]*v1beta12.CRStatus{
+						mod.Name: {VerificationStatus: v1beta12.VerificationSkipped},
+					}
+					return nil
+				},
+			),
+		)
+
+		res, err := pr.Reconcile(ctx, req)
+
+		Expect(err).To(BeNil())
+		Expect(res).To(Equal(reconcile.Result{}))
+	})
+
 })
 
 var _ = Describe("PreflightValidationReconciler_getModulesCheck", func() {
@@ -364,7 +413,7 @@ var _ = Describe("PreflightValidationReconciler_updatePreflightStatus", func() {
 			mockSU.EXPECT().PreflightSetVerificationStatus(ctx, &pv, "moduleName", v1beta12.VerificationTrue, "some message").Return(nil),
 			mockSU.EXPECT().PreflightSetVerificationStage(ctx, &pv, "moduleName", v1beta12.VerificationStageDone).Return(nil),
 		)
-		pr.updatePreflightStatus(context.Background(), &pv, "moduleName", "some message", true)
+		pr.updatePreflightStatus(context.Background(), &pv, "moduleName", "some message", v1beta12.VerificationTrue)
 	})
 
 	It("status not verified", func() {
@@ -379,7 +428,22 @@ var _ = Describe("PreflightValidationReconciler_updatePreflightStatus", func() {
 			mockSU.EXPECT().PreflightSetVerificationStatus(ctx, &pv, "moduleName", v1beta12.VerificationFalse, "some message").Return(nil),
 			mockSU.EXPECT().PreflightSetVerificationStage(ctx, &pv, "moduleName", v1beta12.VerificationStageRequeued).Return(nil),
 		)
-		pr.updatePreflightStatus(context.Background(), &pv, "moduleName", "some message", false)
+		pr.updatePreflightStatus(context.Background(), &pv, "moduleName", "some message", v1beta12.VerificationFalse)
+	})
+
+	It("status skipped", func() {
+		pv := v1beta12.PreflightValidation{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      preflightName,
+				Namespace: namespace,
+			},
+		}
+
+		gomock.InOrder(
+			mockSU.EXPECT().PreflightSetVerificationStatus(ctx, &pv, "moduleName", v1beta12.VerificationSkipped, "some message").Return(nil),
+			mockSU.EXPECT().PreflightSetVerificationStage(ctx, &pv, "moduleName", v1beta12.VerificationStageDone).Return(nil),
+		)
+		pr.updatePreflightStatus(context.Background(), &pv, "moduleName", "some message", v1beta12.VerificationSkipped)
 	})
 })
 
@@ -15,9 +15,19 @@ import (
 type ModuleLoaderData struct {
 	// kernel version
 	KernelVersion string
+
+	// Architecture of the nodes running the kernel, as reported in their NodeInfo.
+	// Empty if the ModuleLoaderData was not computed for a node.
+	Architecture string
 	// Repo secret for DS images
 	ImageRepoSecret *v1.LocalObjectReference
 
+	// ImagePullSecrets are used to pull the ModuleLoader image, in addition to ImageRepoSecret.
+	ImagePullSecrets []v1.LocalObjectReference
+
+	// PushSecret is used to push the images resulting from builds and signing, in addition to ImageRepoSecret.
+	PushSecret *v1.LocalObjectReference
+
 	// Selector for DS
 	Selector map[string]string
 
@@ -48,6 +58,45 @@ type ModuleLoaderData struct {
 	// RegistryTLS set the TLS configs for accessing the registry of the module-loader's image.
 	RegistryTLS *kmmv1beta1.TLSOptions
 
+	// ModuleVersion is the version of the module; it is empty if the Module is not versioned.
+	ModuleVersion string
+
+	// UpgradeStrategy, if set, means that outdated module-loader pods are replaced node by node by KMM.
+	UpgradeStrategy *kmmv1beta1.UpgradeStrategy
+
+	// StartupTaints are the startup taints of all Modules, which the ModuleLoader pods tolerate.
+	StartupTaints []v1.Taint
+
+	// UnloadPolicy is what KMM does with the kernel module when the Module is deleted.
+	UnloadPolicy kmmv1beta1.UnloadPolicy
+
+	// TemplateVars contains the variables, in the NAME=value form, that are substituted in the templated fields
+	// and passed as build arguments.
+	TemplateVars []string
+
 	// used for setting the owner field of jobs/buildconfigs
 	Owner metav1.Object
 }
+
+// DaemonSetKey returns the key identifying the ModuleLoader DaemonSet of a Module for a kernel version, an architecture
+// and a module version.
+// It is the same as KernelArchKey for Modules that are not versioned.
+func DaemonSetKey(kernelVersion, arch, moduleVersion string) string {
+	key := KernelArchKey(kernelVersion, arch)
+
+	if moduleVersion == "" {
+		return key
+	}
+
+	return key + "@" + moduleVersion
+}
+
+// KernelArchKey returns the key identifying the ModuleLoaderData and the ModuleLoader DaemonSet of a Module for
+// a kernel version and an architecture.
+func KernelArchKey(kernelVersion, arch string) string {
+	if arch == "" {
+		return kernelVersion
+	}
+
+	return kernelVersion + "/" + arch
+}
@@ -3,55 +3,185 @@ package auth
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/google/go-containerregistry/pkg/authn"
 	"github.com/google/go-containerregistry/pkg/authn/kubernetes"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/api"
 	v1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+const defaultServiceAccountName = "default"
+
 //go:generate mockgen -source=auth.go -package=auth -destination=mock_auth.go
 
 type RegistryAuthGetter interface {
 	GetKeyChain(ctx context.Context) (authn.Keychain, error)
+	// Scope identifies the credentials returned by GetKeyChain: getters with the same scope use the same secrets.
+	Scope() string
 }
 
 type registrySecretAuthGetter struct {
-	client         client.Client
-	namespacedName types.NamespacedName
+	client          client.Client
+	namespacedNames []types.NamespacedName
 }
 
 func NewRegistryAuthGetter(client client.Client, namespacedName types.NamespacedName) RegistryAuthGetter {
 	return &registrySecretAuthGetter{
-		client:         client,
-		namespacedName: namespacedName,
+		client:          client,
+		namespacedNames: []types.NamespacedName{namespacedName},
 	}
 }
 
 func (rsag *registrySecretAuthGetter) GetKeyChain(ctx context.Context) (authn.Keychain, error) {
+	secrets := make([]v1.Secret, 0, len(rsag.namespacedNames))
+
+	for _, nsn := range rsag.namespacedNames {
+		secret := v1.Secret{}
+		if err := rsag.client.Get(ctx, nsn, &secret); err != nil {
+			return nil, fmt.Errorf("cannot find secret %s: %w", nsn, err)
+		}
 
-	secret := v1.Secret{}
-	if err := rsag.client.Get(ctx, rsag.namespacedName, &secret); err != nil {
-		return nil, fmt.Errorf("cannot find secret %s: %w", rsag.namespacedName, err)
+		secrets = append(secrets, secret)
 	}
 
-	keychain, err := kubernetes.NewFromPullSecrets(ctx, []v1.Secret{secret})
+	keychain, err := kubernetes.NewFromPullSecrets(ctx, secrets)
 	if err != nil {
-		return nil, fmt.Errorf("could not create a keycahin from secret %v: %w", secret, err)
+		return nil, fmt.Errorf("could not create a keycahin from secrets %v: %w", rsag.namespacedNames, err)
 	}
 
 	return keychain, nil
 }
 
-func NewRegistryAuthGetterFrom(client client.Client, mld *api.ModuleLoaderData) RegistryAuthGetter {
+func (rsag *registrySecretAuthGetter) Scope() string {
+	names := make([]string, 0, len(rsag.namespacedNames))
+
+	for _, nsn := range rsag.namespacedNames {
+		names = append(names, nsn.String())
+	}
+
+	return strings.Join(names, ",")
+}
+
+// ModuleLoaderSecrets returns all secrets that may give access to the ModuleLoader image of mld, without duplicates:
+// the ones used to pull it, and the one used to push it after a build or signing.
+func ModuleLoaderSecrets(mld *api.ModuleLoaderData) []v1.LocalObjectReference {
+	candidates := make([]v1.LocalObjectReference, 0, len(mld.ImagePullSecrets)+2)
+
 	if mld.ImageRepoSecret != nil {
-		namespacedName := types.NamespacedName{
-			Name:      mld.ImageRepoSecret.Name,
-			Namespace: mld.Namespace,
+		candidates = append(candidates, *mld.ImageRepoSecret)
+	}
+
+	candidates = append(candidates, mld.ImagePullSecrets...)
+
+	if mld.PushSecret != nil {
+		candidates = append(candidates, *mld.PushSecret)
+	}
+
+	secrets := make([]v1.LocalObjectReference, 0, len(candidates))
+	seen := sets.NewString()
+
+	for _, s := range candidates {
+		if !seen.Has(s.Name) {
+			seen.Insert(s.Name)
+			secrets = append(secrets, s)
+		}
+	}
+
+	return secrets
+}
+
+// ServiceAccountPullSecrets returns the imagePullSecrets of the ServiceAccount, or of the default ServiceAccount if
+// name is empty. A missing ServiceAccount has no secrets.
+func ServiceAccountPullSecrets(ctx context.Context, clnt client.Client, namespace, name string) ([]v1.LocalObjectReference, error) {
+	if name == "" {
+		name = defaultServiceAccountName
+	}
+
+	sa := v1.ServiceAccount{}
+	nsn := types.NamespacedName{Name: name, Namespace: namespace}
+
+	if err := clnt.Get(ctx, nsn, &sa); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("could not get ServiceAccount %s: %v", nsn, err)
+	}
+
+	return sa.ImagePullSecrets, nil
+}
+
+type moduleLoaderAuthGetter struct {
+	client client.Client
+	mld    *api.ModuleLoaderData
+}
+
+// NewRegistryAuthGetterFrom returns a RegistryAuthGetter for the keychain made of the secrets returned by
+// ModuleLoaderSecrets and of the imagePullSecrets of the ModuleLoader's ServiceAccount.
+func NewRegistryAuthGetterFrom(client client.Client, mld *api.ModuleLoaderData) RegistryAuthGetter {
+	return &moduleLoaderAuthGetter{
+		client: client,
+		mld:    mld,
+	}
+}
+
+func (mlag *moduleLoaderAuthGetter) GetKeyChain(ctx context.Context) (authn.Keychain, error) {
+	refs := ModuleLoaderSecrets(mlag.mld)
+	secrets := make([]v1.Secret, 0, len(refs))
+
+	for _, ref := range refs {
+		secret := v1.Secret{}
+		nsn := types.NamespacedName{Name: ref.Name, Namespace: mlag.mld.Namespace}
+
+		if err := mlag.client.Get(ctx, nsn, &secret); err != nil {
+			return nil, fmt.Errorf("cannot find secret %s: %w", nsn, err)
 		}
-		return NewRegistryAuthGetter(client, namespacedName)
+
+		secrets = append(secrets, secret)
 	}
-	return nil
+
+	saRefs, err := ServiceAccountPullSecrets(ctx, mlag.client, mlag.mld.Namespace, mlag.mld.ServiceAccountName)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ref := range saRefs {
+		secret := v1.Secret{}
+		nsn := types.NamespacedName{Name: ref.Name, Namespace: mlag.mld.Namespace}
+
+		// like the kubelet, ignore the ServiceAccount's pull secrets that do not exist
+		if err = mlag.client.Get(ctx, nsn, &secret); err != nil {
+			if k8serrors.IsNotFound(err) {
+				continue
+			}
+
+			return nil, fmt.Errorf("cannot get secret %s: %w", nsn, err)
+		}
+
+		secrets = append(secrets, secret)
+	}
+
+	keychain, err := kubernetes.NewFromPullSecrets(ctx, secrets)
+	if err != nil {
+		return nil, fmt.Errorf("could not create a keychain from the secrets of %s/%s: %w", mlag.mld.Namespace, mlag.mld.Name, err)
+	}
+
+	return keychain, nil
+}
+
+func (mlag *moduleLoaderAuthGetter) Scope() string {
+	refs := ModuleLoaderSecrets(mlag.mld)
+	names := make([]string, 0, len(refs))
+
+	for _, ref := range refs {
+		names = append(names, ref.Name)
+	}
+
+	// the ServiceAccount's pull secrets are only known when the keychain is built
+	return fmt.Sprintf("%s/%s:%s", mlag.mld.Namespace, mlag.mld.ServiceAccountName, strings.Join(names, ","))
 }
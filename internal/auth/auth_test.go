@@ -5,10 +5,12 @@ import (
 	"errors"
 
 	"github.com/golang/mock/gomock"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/api"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/client"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	v1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
 	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -88,3 +90,125 @@ var _ = Describe("GetKeyChain", func() {
 		Expect(err).NotTo(HaveOccurred())
 	})
 })
+
+var _ = Describe("ModuleLoaderSecrets", func() {
+	It("should return the pull secrets and the push secret without duplicates", func() {
+		mld := api.ModuleLoaderData{
+			ImageRepoSecret:  &v1.LocalObjectReference{Name: "repo"},
+			ImagePullSecrets: []v1.LocalObjectReference{{Name: "pull"}, {Name: "repo"}},
+			PushSecret:       &v1.LocalObjectReference{Name: "push"},
+		}
+
+		Expect(
+			ModuleLoaderSecrets(&mld),
+		).To(
+			Equal([]v1.LocalObjectReference{{Name: "repo"}, {Name: "pull"}, {Name: "push"}}),
+		)
+	})
+})
+
+var _ = Describe("NewRegistryAuthGetterFrom", func() {
+	const namespace = "default"
+
+	var (
+		ctrl       *gomock.Controller
+		mockClient *client.MockClient
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		mockClient = client.NewMockClient(ctrl)
+	})
+
+	It("should use the module secrets and the ServiceAccount's pull secrets", func() {
+		ctx := context.TODO()
+
+		mld := api.ModuleLoaderData{
+			Namespace:          namespace,
+			ImageRepoSecret:    &v1.LocalObjectReference{Name: "repo"},
+			ServiceAccountName: "sa",
+		}
+
+		gomock.InOrder(
+			mockClient.EXPECT().Get(ctx, types.NamespacedName{Name: "repo", Namespace: namespace}, &v1.Secret{}),
+			mockClient.EXPECT().Get(ctx, types.NamespacedName{Name: "sa", Namespace: namespace}, &v1.ServiceAccount{}).DoAndReturn(
+				func(_ interface{}, _ interface{}, sa *v1.ServiceAccount, _ ...ctrlclient.GetOption) error {
+					sa.ImagePullSecrets = []v1.LocalObjectReference{{Name: "sa-pull"}, {Name: "missing"}}
+					return nil
+				},
+			),
+			mockClient.EXPECT().Get(ctx, types.NamespacedName{Name: "sa-pull", Namespace: namespace}, &v1.Secret{}),
+			mockClient.EXPECT().Get(ctx, types.NamespacedName{Name: "missing", Namespace: namespace}, &v1.Secret{}).Return(
+				k8serrors.NewNotFound(v1.Resource("secrets"), "missing"),
+			),
+		)
+
+		_, err := NewRegistryAuthGetterFrom(mockClient, &mld).GetKeyChain(ctx)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should scope the credentials to the namespace, the ServiceAccount and the secrets", func() {
+		mld := api.ModuleLoaderData{
+			Namespace:          namespace,
+			ImageRepoSecret:    &v1.LocalObjectReference{Name: "repo"},
+			ServiceAccountName: "sa",
+		}
+
+		scope := NewRegistryAuthGetterFrom(mockClient, &mld).Scope()
+		Expect(scope).To(Equal("default/sa:repo"))
+
+		otherNamespace := mld
+		otherNamespace.Namespace = "other"
+		Expect(NewRegistryAuthGetterFrom(mockClient, &otherNamespace).Scope()).NotTo(Equal(scope))
+	})
+
+	It("should fail if a module secret is missing", func() {
+		ctx := context.TODO()
+
+		mld := api.ModuleLoaderData{
+			Namespace:        namespace,
+			ImagePullSecrets: []v1.LocalObjectReference{{Name: "pull"}},
+		}
+
+		mockClient.EXPECT().Get(ctx, types.NamespacedName{Name: "pull", Namespace: namespace}, &v1.Secret{}).Return(
+			k8serrors.NewNotFound(v1.Resource("secrets"), "pull"),
+		)
+
+		_, err := NewRegistryAuthGetterFrom(mockClient, &mld).GetKeyChain(ctx)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("cannot find secret"))
+	})
+})
+
+var _ = Describe("ServiceAccountPullSecrets", func() {
+	var (
+		ctrl       *gomock.Controller
+		mockClient *client.MockClient
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		mockClient = client.NewMockClient(ctrl)
+	})
+
+	It("should use the default ServiceAccount and return no secrets if it does not exist", func() {
+		ctx := context.TODO()
+
+		mockClient.EXPECT().Get(ctx, types.NamespacedName{Name: "default", Namespace: "ns"}, &v1.ServiceAccount{}).Return(
+			k8serrors.NewNotFound(v1.Resource("serviceaccounts"), "default"),
+		)
+
+		secrets, err := ServiceAccountPullSecrets(ctx, mockClient, "ns", "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(secrets).To(BeEmpty())
+	})
+
+	It("should return an error for other errors", func() {
+		ctx := context.TODO()
+
+		mockClient.EXPECT().Get(ctx, types.NamespacedName{Name: "sa", Namespace: "ns"}, &v1.ServiceAccount{}).Return(errors.New("some error"))
+
+		_, err := ServiceAccountPullSecrets(ctx, mockClient, "ns", "sa")
+		Expect(err).To(HaveOccurred())
+	})
+})
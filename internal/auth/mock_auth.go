@@ -49,3 +49,17 @@ func (mr *MockRegistryAuthGetterMockRecorder) GetKeyChain(ctx interface{}) *gomo
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetKeyChain", reflect.TypeOf((*MockRegistryAuthGetter)(nil).GetKeyChain), ctx)
 }
+
+// Scope mocks base method.
+func (m *MockRegistryAuthGetter) Scope() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Scope")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// Scope indicates an expected call of Scope.
+func (mr *MockRegistryAuthGetterMockRecorder) Scope() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Scope", reflect.TypeOf((*MockRegistryAuthGetter)(nil).Scope))
+}
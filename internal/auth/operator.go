@@ -0,0 +1,113 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/docker/cli/cli/config"
+	dockertypes "github.com/docker/cli/cli/config/types"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/authn/kubernetes"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type operatorAuthGetter struct {
+	client           client.Client
+	globalPullSecret *types.NamespacedName
+	dockerConfigDir  string
+}
+
+// NewOperatorAuthGetter returns a RegistryAuthGetter for the credentials configured at the operator level: a global
+// pull secret and a directory containing a docker config.json file, which may reference credential helpers.
+// It returns nil if neither is configured.
+func NewOperatorAuthGetter(client client.Client, globalPullSecret *types.NamespacedName, dockerConfigDir string) RegistryAuthGetter {
+	if globalPullSecret == nil && dockerConfigDir == "" {
+		return nil
+	}
+
+	return &operatorAuthGetter{
+		client:           client,
+		globalPullSecret: globalPullSecret,
+		dockerConfigDir:  dockerConfigDir,
+	}
+}
+
+func (oag *operatorAuthGetter) GetKeyChain(ctx context.Context) (authn.Keychain, error) {
+	keychains := make([]authn.Keychain, 0, 2)
+
+	if oag.globalPullSecret != nil {
+		secret := v1.Secret{}
+		if err := oag.client.Get(ctx, *oag.globalPullSecret, &secret); err != nil {
+			return nil, fmt.Errorf("cannot find the global pull secret %s: %w", *oag.globalPullSecret, err)
+		}
+
+		keychain, err := kubernetes.NewFromPullSecrets(ctx, []v1.Secret{secret})
+		if err != nil {
+			return nil, fmt.Errorf("could not create a keychain from the global pull secret %s: %w", *oag.globalPullSecret, err)
+		}
+
+		keychains = append(keychains, keychain)
+	}
+
+	if oag.dockerConfigDir != "" {
+		keychains = append(keychains, NewDockerConfigKeychain(oag.dockerConfigDir))
+	}
+
+	return authn.NewMultiKeychain(keychains...), nil
+}
+
+func (oag *operatorAuthGetter) Scope() string {
+	var globalPullSecret string
+
+	if oag.globalPullSecret != nil {
+		globalPullSecret = oag.globalPullSecret.String()
+	}
+
+	return globalPullSecret + ":" + oag.dockerConfigDir
+}
+
+type dockerConfigKeychain struct {
+	dir string
+}
+
+// NewDockerConfigKeychain returns a keychain reading the config.json file in dir, including the credential helpers
+// configured in its credHelpers and credsStore fields.
+// The file is read on each resolution, so that credentials rotated on disk are picked up.
+func NewDockerConfigKeychain(dir string) authn.Keychain {
+	return &dockerConfigKeychain{dir: dir}
+}
+
+func (dck *dockerConfigKeychain) Resolve(target authn.Resource) (authn.Authenticator, error) {
+	cf, err := config.Load(dck.dir)
+	if err != nil {
+		return nil, fmt.Errorf("could not load the docker config from %s: %v", dck.dir, err)
+	}
+
+	key := target.RegistryStr()
+	if key == name.DefaultRegistry {
+		key = authn.DefaultAuthKey
+	}
+
+	cfg, err := cf.GetAuthConfig(key)
+	if err != nil {
+		return nil, fmt.Errorf("could not get the credentials for %s: %v", key, err)
+	}
+
+	// GetAuthConfig always sets ServerAddress, which is not used here
+	cfg.ServerAddress = ""
+
+	if cfg == (dockertypes.AuthConfig{}) {
+		return authn.Anonymous, nil
+	}
+
+	return authn.FromConfig(authn.AuthConfig{
+		Username:      cfg.Username,
+		Password:      cfg.Password,
+		Auth:          cfg.Auth,
+		IdentityToken: cfg.IdentityToken,
+		RegistryToken: cfg.RegistryToken,
+	}), nil
+}
@@ -0,0 +1,82 @@
+package auth
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kubernetes-sigs/kernel-module-management/internal/client"
+)
+
+var _ = Describe("NewOperatorAuthGetter", func() {
+	var (
+		ctrl       *gomock.Controller
+		mockClient *client.MockClient
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		mockClient = client.NewMockClient(ctrl)
+	})
+
+	It("should return nil if nothing is configured", func() {
+		Expect(
+			NewOperatorAuthGetter(mockClient, nil, ""),
+		).To(
+			BeNil(),
+		)
+	})
+
+	It("should resolve credentials from the global pull secret", func() {
+		ctx := context.TODO()
+		nsn := types.NamespacedName{Name: "global", Namespace: "operator"}
+
+		mockClient.EXPECT().Get(ctx, nsn, &v1.Secret{}).DoAndReturn(
+			func(_ interface{}, _ interface{}, s *v1.Secret, _ ...ctrlclient.GetOption) error {
+				s.Type = v1.SecretTypeDockerConfigJson
+				s.Data = map[string][]byte{
+					v1.DockerConfigJsonKey: []byte(`{"auths":{"my.registry":{"username":"user","password":"pass"}}}`),
+				}
+				return nil
+			},
+		)
+
+		keychain, err := NewOperatorAuthGetter(mockClient, &nsn, "").GetKeyChain(ctx)
+		Expect(err).NotTo(HaveOccurred())
+
+		a, err := keychain.Resolve(name.MustParseReference("my.registry/org/image:tag").Context())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(a.Authorization()).To(Equal(&authn.AuthConfig{Username: "user", Password: "pass"}))
+	})
+})
+
+var _ = Describe("NewDockerConfigKeychain", func() {
+	It("should resolve credentials from config.json", func() {
+		dir := GinkgoT().TempDir()
+
+		Expect(
+			os.WriteFile(filepath.Join(dir, "config.json"), []byte(`{"auths":{"my.registry":{"auth":"dXNlcjpwYXNz"}}}`), 0600),
+		).To(
+			Succeed(),
+		)
+
+		keychain := NewDockerConfigKeychain(dir)
+
+		a, err := keychain.Resolve(name.MustParseReference("my.registry/org/image:tag").Context())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(a.Authorization()).To(Equal(&authn.AuthConfig{Username: "user", Password: "pass"}))
+
+		a, err = keychain.Resolve(name.MustParseReference("other.registry/org/image:tag").Context())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(a).To(Equal(authn.Anonymous))
+	})
+})
@@ -63,5 +63,11 @@ func (m *helper) GetRelevantBuild(moduleBuild *kmmv1beta1.Build, mappingBuild *k
 	// [TODO] once MGMT-10832 is consolidated, this code must be revisited. We will decide which
 	// secret and how to use, and if we need to take care of repeated secrets names
 	buildConfig.Secrets = append(buildConfig.Secrets, mappingBuild.Secrets...)
+	buildConfig.BaseImagePullSecrets = append(buildConfig.BaseImagePullSecrets, mappingBuild.BaseImagePullSecrets...)
+
+	if mappingBuild.SkipRebuildOnInputChange {
+		buildConfig.SkipRebuildOnInputChange = true
+	}
+
 	return buildConfig
 }
@@ -50,6 +50,12 @@ var _ = Describe("GetRelevantBuild", func() {
 		Expect(res.DockerfileConfigMap).To(Equal(mappingBuild.DockerfileConfigMap))
 		Expect(res.BaseImageRegistryTLS).To(Equal(moduleBuild.BaseImageRegistryTLS))
 	})
+
+	It("should enable SkipRebuildOnInputChange if the mapping enables it", func() {
+		res := nh.GetRelevantBuild(&kmmv1beta1.Build{}, &kmmv1beta1.Build{SkipRebuildOnInputChange: true})
+
+		Expect(res.SkipRebuildOnInputChange).To(BeTrue())
+	})
 })
 
 var _ = Describe("ApplyBuildArgOverrides", func() {
@@ -2,17 +2,22 @@ package job
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/json"
 	"fmt"
 	"os"
 	"strings"
 
+	"github.com/google/go-containerregistry/pkg/name"
 	"github.com/mitchellh/hashstructure"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/utils/pointer"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -22,11 +27,18 @@ import (
 	"github.com/kubernetes-sigs/kernel-module-management/internal/build"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/constants"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/module"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/registry"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/utils"
 )
 
 const (
 	dockerfileVolumeName = "dockerfile"
+	kanikoCertsDir       = "/kaniko/ssl/certs"
+
+	// maxRegistryAuthSecretNameLength keeps the name of the volume of the merged registry credentials, prefixed by
+	// volumeNameFromSecretRef, within the 63 characters allowed.
+	maxRegistryAuthSecretNameLength = 56
+	registryAuthHashLength          = 10
 )
 
 //go:generate mockgen -source=maker.go -package=job -destination=mock_maker.go
@@ -37,6 +49,7 @@ type Maker interface {
 		mld *api.ModuleLoaderData,
 		owner metav1.Object,
 		pushImage bool) (*batchv1.Job, error)
+	BuildHash(ctx context.Context, mld *api.ModuleLoaderData) (string, error)
 }
 
 type maker struct {
@@ -44,23 +57,34 @@ type maker struct {
 	helper    build.Helper
 	jobHelper utils.JobHelper
 	scheme    *runtime.Scheme
+	mirrors   *registry.MirrorConfig
 }
 
 type hashData struct {
-	Dockerfile  string
-	PodTemplate *v1.PodTemplateSpec
+	Dockerfile    string
+	BuildArgsData map[string]string
+	PodTemplate   *v1.PodTemplateSpec
+}
+
+// buildInputs is what the built image depends on.
+type buildInputs struct {
+	Dockerfile    string
+	BuildArgs     []kmmv1beta1.BuildArg
+	BuildArgsData map[string]string
 }
 
 func NewMaker(
 	client client.Client,
 	helper build.Helper,
 	jobHelper utils.JobHelper,
-	scheme *runtime.Scheme) Maker {
+	scheme *runtime.Scheme,
+	mirrors *registry.MirrorConfig) Maker {
 	return &maker{
 		client:    client,
 		helper:    helper,
 		jobHelper: jobHelper,
 		scheme:    scheme,
+		mirrors:   mirrors,
 	}
 }
 
@@ -79,14 +103,34 @@ func (m *maker) MakeJobTemplate(
 		containerImage = module.IntermediateImageName(mld.Name, mld.Namespace, containerImage)
 	}
 
+	buildArgs := m.buildArgs(mld)
+
+	inputs, err := m.getBuildInputs(ctx, mld, buildArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	buildHash, err := getBuildHash(inputs)
+	if err != nil {
+		return nil, err
+	}
+
+	registrySecret, err := m.registrySecret(ctx, mld, owner)
+	if err != nil {
+		return nil, err
+	}
+
 	specTemplate := m.specTemplate(
 		mld,
 		buildConfig,
+		buildArgs,
 		containerImage,
 		mld.RegistryTLS,
+		registrySecret,
+		buildHash,
 		pushImage)
 
-	specTemplateHash, err := m.getHashAnnotationValue(ctx, buildConfig.DockerfileConfigMap.Name, mld.Namespace, &specTemplate)
+	specTemplateHash, err := getHashValue(&specTemplate, inputs.Dockerfile, inputs.BuildArgsData)
 	if err != nil {
 		return nil, fmt.Errorf("could not hash job's definitions: %v", err)
 	}
@@ -95,7 +139,7 @@ func (m *maker) MakeJobTemplate(
 		ObjectMeta: metav1.ObjectMeta{
 			GenerateName: mld.Name + "-build-",
 			Namespace:    mld.Namespace,
-			Labels:       m.jobHelper.JobLabels(mld.Name, mld.KernelVersion, utils.JobTypeBuild),
+			Labels:       m.jobHelper.JobLabels(mld.Name, mld.KernelVersion, mld.Architecture, utils.JobTypeBuild),
 			Annotations:  map[string]string{constants.JobHashAnnotation: fmt.Sprintf("%d", specTemplateHash)},
 		},
 		Spec: batchv1.JobSpec{
@@ -112,11 +156,35 @@ func (m *maker) MakeJobTemplate(
 	return job, nil
 }
 
+// BuildHash returns a hash of the inputs of the build of mld: the Dockerfile, the build arguments, the values of
+// those referencing a ConfigMap and the version of the Secrets referenced by the others.
+// Builds record it in the constants.BuildHashImageLabel label of the image, so that the image is built again when one
+// of its inputs changes, for example when a Secret is rotated.
+func (m *maker) BuildHash(ctx context.Context, mld *api.ModuleLoaderData) (string, error) {
+	inputs, err := m.getBuildInputs(ctx, mld, m.buildArgs(mld))
+	if err != nil {
+		return "", err
+	}
+
+	return getBuildHash(inputs)
+}
+
+// buildArgs returns the build arguments of mld, including those that are automatically passed to all builds.
+func (m *maker) buildArgs(mld *api.ModuleLoaderData) []kmmv1beta1.BuildArg {
+	return m.helper.ApplyBuildArgOverrides(
+		mld.Build.BuildArgs,
+		templateVarsAsBuildArgs(mld)...,
+	)
+}
+
 func (m *maker) specTemplate(
 	mld *api.ModuleLoaderData,
 	buildConfig *kmmv1beta1.Build,
+	buildArgs []kmmv1beta1.BuildArg,
 	containerImage string,
 	registryTLS *kmmv1beta1.TLSOptions,
+	registrySecret *v1.LocalObjectReference,
+	buildHash string,
 	pushImage bool) v1.PodTemplateSpec {
 
 	kanikoImage := os.Getenv("RELATED_IMAGES_BUILD")
@@ -129,28 +197,45 @@ func (m *maker) specTemplate(
 		kanikoImage += ":" + buildConfig.KanikoParams.Tag
 	}
 
+	vols := volumes(registrySecret, buildConfig)
+	mounts := volumeMounts(registrySecret, buildConfig)
+	env := buildArgsEnv(buildArgs)
+
+	if ref := buildConfig.BaseImageRegistryTLS.CABundle; ref != nil {
+		vols, mounts = utils.AppendCABundleVolume(vols, mounts, ref)
+		// kaniko only accepts per-registry certificates; base images may come from any registry, so trust the bundle
+		// for all of them.
+		env = append(env, v1.EnvVar{Name: "SSL_CERT_DIR", Value: kanikoCertsDir + ":" + utils.CABundleMountDir(*ref)})
+	}
+
+	if pushImage && registryTLS != nil {
+		vols, mounts = utils.AppendCABundleVolume(vols, mounts, registryTLS.CABundle)
+	}
+
 	return v1.PodTemplateSpec{
 		Spec: v1.PodSpec{
 			Containers: []v1.Container{
 				{
-					Args:         m.containerArgs(buildConfig, mld.KernelVersion, containerImage, registryTLS, pushImage),
+					Args:         m.containerArgs(buildConfig, buildArgs, containerImage, registryTLS, buildHash, pushImage),
+					Env:          env,
 					Name:         "kaniko",
 					Image:        kanikoImage,
-					VolumeMounts: volumeMounts(mld.ImageRepoSecret, buildConfig),
+					VolumeMounts: mounts,
 				},
 			},
-			NodeSelector:  mld.Selector,
+			NodeSelector:  utils.JobNodeSelector(mld.Selector, mld.Architecture),
 			RestartPolicy: v1.RestartPolicyNever,
-			Volumes:       volumes(mld.ImageRepoSecret, buildConfig),
+			Volumes:       vols,
 		},
 	}
 }
 
 func (m *maker) containerArgs(
 	buildConfig *kmmv1beta1.Build,
-	targetKernel string,
+	buildArgs []kmmv1beta1.BuildArg,
 	containerImage string,
 	registryTLS *kmmv1beta1.TLSOptions,
+	buildHash string,
 	pushImage bool) []string {
 
 	args := []string{}
@@ -160,15 +245,22 @@ func (m *maker) containerArgs(
 		args = append(args, "--no-push")
 	}
 
-	buildArgs := m.helper.ApplyBuildArgOverrides(
-		buildConfig.BuildArgs,
-		kmmv1beta1.BuildArg{Name: "KERNEL_VERSION", Value: targetKernel},
-	)
+	args = append(args, "--label", constants.BuildHashImageLabel+"="+buildHash)
 
 	for _, ba := range buildArgs {
+		// kaniko resolves build args without a value from the environment variable of the same name
+		if ba.ValueFrom != nil {
+			args = append(args, "--build-arg", ba.Name)
+			continue
+		}
+
 		args = append(args, "--build-arg", fmt.Sprintf("%s=%s", ba.Name, ba.Value))
 	}
 
+	for _, rm := range m.registryMaps() {
+		args = append(args, "--registry-map", rm)
+	}
+
 	if buildConfig.BaseImageRegistryTLS.Insecure {
 		args = append(args, "--insecure-pull")
 	}
@@ -185,38 +277,107 @@ func (m *maker) containerArgs(
 		if registryTLS.InsecureSkipTLSVerify {
 			args = append(args, "--skip-tls-verify")
 		}
+
+		if registryTLS.CABundle != nil {
+			args = append(
+				args,
+				"--registry-certificate",
+				fmt.Sprintf("%s=%s", registryHost(containerImage), utils.CABundleFile(*registryTLS.CABundle)),
+			)
+		}
 	}
 
 	return args
 }
 
-func (m *maker) getHashAnnotationValue(ctx context.Context, configMapName, namespace string, podTemplate *v1.PodTemplateSpec) (uint64, error) {
+// getBuildInputs returns the Dockerfile of mld and the values of buildArgs.
+func (m *maker) getBuildInputs(ctx context.Context, mld *api.ModuleLoaderData, buildArgs []kmmv1beta1.BuildArg) (*buildInputs, error) {
 	dockerfileCM := &corev1.ConfigMap{}
-	namespacedName := types.NamespacedName{Name: configMapName, Namespace: namespace}
+	namespacedName := types.NamespacedName{Name: mld.Build.DockerfileConfigMap.Name, Namespace: mld.Namespace}
 	if err := m.client.Get(ctx, namespacedName, dockerfileCM); err != nil {
-		return 0, fmt.Errorf("failed to get dockerfile ConfigMap %s: %v", namespacedName, err)
+		return nil, fmt.Errorf("failed to get dockerfile ConfigMap %s: %v", namespacedName, err)
 	}
 	data, ok := dockerfileCM.Data[constants.DockerfileCMKey]
 	if !ok {
-		return 0, fmt.Errorf("invalid Dockerfile ConfigMap %s format, %s key is missing", namespacedName, constants.DockerfileCMKey)
+		return nil, fmt.Errorf("invalid Dockerfile ConfigMap %s format, %s key is missing", namespacedName, constants.DockerfileCMKey)
 	}
 
-	return getHashValue(podTemplate, data)
+	buildArgsData, err := m.getBuildArgsData(ctx, mld.Namespace, buildArgs)
+	if err != nil {
+		return nil, fmt.Errorf("could not get the build arguments values: %v", err)
+	}
+
+	return &buildInputs{Dockerfile: data, BuildArgs: buildArgs, BuildArgsData: buildArgsData}, nil
 }
 
-func volumes(imageRepoSecret *v1.LocalObjectReference, buildConfig *kmmv1beta1.Build) []v1.Volume {
+// getBuildArgsData returns the values of all build arguments referencing a ConfigMap and the UID and resourceVersion of
+// the Secrets referenced by the others, so that rotating those values results in a different build hash, and the
+// image is built again.
+// Secret values are never returned: the build hash is recorded in the image, where anyone able to pull it could
+// brute-force them.
+func (m *maker) getBuildArgsData(ctx context.Context, namespace string, buildArgs []kmmv1beta1.BuildArg) (map[string]string, error) {
+	var data map[string]string
+
+	for _, ba := range buildArgs {
+		if ba.ValueFrom == nil {
+			continue
+		}
+
+		if data == nil {
+			data = make(map[string]string)
+		}
+
+		switch {
+		case ba.ValueFrom.SecretKeyRef != nil:
+			ref := ba.ValueFrom.SecretKeyRef
+			secret := corev1.Secret{}
+			namespacedName := types.NamespacedName{Name: ref.Name, Namespace: namespace}
+			if err := m.client.Get(ctx, namespacedName, &secret); err != nil {
+				if k8serrors.IsNotFound(err) && pointer.BoolDeref(ref.Optional, false) {
+					continue
+				}
+				return nil, fmt.Errorf("failed to get Secret %s for build arg %s: %v", namespacedName, ba.Name, err)
+			}
+			if _, ok := secret.Data[ref.Key]; !ok && !pointer.BoolDeref(ref.Optional, false) {
+				return nil, fmt.Errorf("invalid Secret %s format, %s key is missing", namespacedName, ref.Key)
+			}
+			data[ba.Name] = fmt.Sprintf("%s/%s", secret.UID, secret.ResourceVersion)
+		case ba.ValueFrom.ConfigMapKeyRef != nil:
+			ref := ba.ValueFrom.ConfigMapKeyRef
+			cm := corev1.ConfigMap{}
+			namespacedName := types.NamespacedName{Name: ref.Name, Namespace: namespace}
+			if err := m.client.Get(ctx, namespacedName, &cm); err != nil {
+				if k8serrors.IsNotFound(err) && pointer.BoolDeref(ref.Optional, false) {
+					continue
+				}
+				return nil, fmt.Errorf("failed to get ConfigMap %s for build arg %s: %v", namespacedName, ba.Name, err)
+			}
+			value, ok := cm.Data[ref.Key]
+			if !ok && !pointer.BoolDeref(ref.Optional, false) {
+				return nil, fmt.Errorf("invalid ConfigMap %s format, %s key is missing", namespacedName, ref.Key)
+			}
+			data[ba.Name] = value
+		default:
+			return nil, fmt.Errorf("build arg %s: valueFrom must reference a Secret or a ConfigMap", ba.Name)
+		}
+	}
+
+	return data, nil
+}
+
+func volumes(registrySecret *v1.LocalObjectReference, buildConfig *kmmv1beta1.Build) []v1.Volume {
 	volumes := []v1.Volume{dockerfileVolume(dockerfileVolumeName, buildConfig.DockerfileConfigMap)}
-	if imageRepoSecret != nil {
-		volumes = append(volumes, makeImagePullSecretVolume(imageRepoSecret))
+	if registrySecret != nil {
+		volumes = append(volumes, makeImagePullSecretVolume(registrySecret))
 	}
 	volumes = append(volumes, makeBuildSecretVolumes(buildConfig.Secrets)...)
 	return volumes
 }
 
-func volumeMounts(imageRepoSecret *v1.LocalObjectReference, buildConfig *kmmv1beta1.Build) []v1.VolumeMount {
+func volumeMounts(registrySecret *v1.LocalObjectReference, buildConfig *kmmv1beta1.Build) []v1.VolumeMount {
 	volumeMounts := []v1.VolumeMount{dockerfileVolumeMount(dockerfileVolumeName)}
-	if imageRepoSecret != nil {
-		volumeMounts = append(volumeMounts, makeImagePullSecretVolumeMount(imageRepoSecret))
+	if registrySecret != nil {
+		volumeMounts = append(volumeMounts, makeImagePullSecretVolumeMount(registrySecret))
 	}
 	volumeMounts = append(volumeMounts, makeBuildSecretVolumeMounts(buildConfig.Secrets)...)
 	return volumeMounts
@@ -247,10 +408,11 @@ func dockerfileVolumeMount(name string) v1.VolumeMount {
 	}
 }
 
-func getHashValue(podTemplate *v1.PodTemplateSpec, dockerfile string) (uint64, error) {
+func getHashValue(podTemplate *v1.PodTemplateSpec, dockerfile string, buildArgsData map[string]string) (uint64, error) {
 	dataToHash := hashData{
-		Dockerfile:  dockerfile,
-		PodTemplate: podTemplate,
+		Dockerfile:    dockerfile,
+		BuildArgsData: buildArgsData,
+		PodTemplate:   podTemplate,
 	}
 	hashValue, err := hashstructure.Hash(dataToHash, nil)
 	if err != nil {
@@ -259,6 +421,218 @@ func getHashValue(podTemplate *v1.PodTemplateSpec, dockerfile string) (uint64, e
 	return hashValue, nil
 }
 
+func getBuildHash(inputs *buildInputs) (string, error) {
+	hashValue, err := hashstructure.Hash(inputs, nil)
+	if err != nil {
+		return "", fmt.Errorf("could not hash the build inputs: %v", err)
+	}
+	return fmt.Sprintf("%d", hashValue), nil
+}
+
+// buildArgsEnv returns the environment variables holding the values of the build arguments that reference a Secret
+// or a ConfigMap.
+func buildArgsEnv(buildArgs []kmmv1beta1.BuildArg) []v1.EnvVar {
+	var env []v1.EnvVar
+
+	for _, ba := range buildArgs {
+		if ba.ValueFrom == nil {
+			continue
+		}
+
+		env = append(env, v1.EnvVar{
+			Name: ba.Name,
+			ValueFrom: &v1.EnvVarSource{
+				SecretKeyRef:    ba.ValueFrom.SecretKeyRef,
+				ConfigMapKeyRef: ba.ValueFrom.ConfigMapKeyRef,
+			},
+		})
+	}
+
+	return env
+}
+
+// templateVarsAsBuildArgs returns the build arguments that are automatically passed to all builds.
+func templateVarsAsBuildArgs(mld *api.ModuleLoaderData) []kmmv1beta1.BuildArg {
+	buildArgs := []kmmv1beta1.BuildArg{
+		{Name: "KERNEL_VERSION", Value: mld.KernelVersion},
+	}
+
+	for _, v := range mld.TemplateVars {
+		name, value, _ := strings.Cut(v, "=")
+		if name == "KERNEL_VERSION" {
+			continue
+		}
+
+		buildArgs = append(buildArgs, kmmv1beta1.BuildArg{Name: name, Value: value})
+	}
+
+	return buildArgs
+}
+
+// registryMaps returns the values of kaniko's --registry-map flag for the configured mirrors.
+// kaniko only maps whole registries, so entries whose prefix, mirrors or location contain a repository path are
+// ignored.
+func (m *maker) registryMaps() []string {
+	if m.mirrors == nil {
+		return nil
+	}
+
+	maps := make([]string, 0, len(m.mirrors.Registries))
+
+	for _, r := range m.mirrors.Registries {
+		if strings.Contains(r.Prefix, "/") {
+			continue
+		}
+
+		reg, err := name.NewRegistry(r.Prefix)
+		if err != nil {
+			continue
+		}
+
+		targets := make([]string, 0, len(r.Mirrors)+1)
+
+		for _, t := range r.Mirrors {
+			if !strings.Contains(t, "/") {
+				targets = append(targets, t)
+			}
+		}
+
+		if r.Location != "" && !strings.Contains(r.Location, "/") {
+			targets = append(targets, r.Location)
+		}
+
+		if len(targets) > 0 {
+			maps = append(maps, reg.RegistryStr()+"="+strings.Join(targets, ";"))
+		}
+	}
+
+	return maps
+}
+
+// buildRegistrySecrets returns the secrets giving access to the registries involved in a build, by order of
+// precedence: the push secret, the base images pull secrets and finally ImageRepoSecret.
+func buildRegistrySecrets(mld *api.ModuleLoaderData) []v1.LocalObjectReference {
+	candidates := make([]v1.LocalObjectReference, 0, len(mld.Build.BaseImagePullSecrets)+2)
+
+	if mld.PushSecret != nil {
+		candidates = append(candidates, *mld.PushSecret)
+	}
+
+	candidates = append(candidates, mld.Build.BaseImagePullSecrets...)
+
+	if mld.ImageRepoSecret != nil {
+		candidates = append(candidates, *mld.ImageRepoSecret)
+	}
+
+	secrets := make([]v1.LocalObjectReference, 0, len(candidates))
+	seen := sets.NewString()
+
+	for _, s := range candidates {
+		if !seen.Has(s.Name) {
+			seen.Insert(s.Name)
+			secrets = append(secrets, s)
+		}
+	}
+
+	return secrets
+}
+
+// registrySecret returns the secret holding the registry credentials of the build of mld, or nil if there are none.
+// kaniko only reads one docker configuration, so when more than one secret applies to the build, KMM merges their
+// credentials into a Secret owned by owner, which it updates when they change. For a registry present in several
+// secrets, the first one returned by buildRegistrySecrets wins.
+func (m *maker) registrySecret(ctx context.Context, mld *api.ModuleLoaderData, owner metav1.Object) (*v1.LocalObjectReference, error) {
+	registrySecrets := buildRegistrySecrets(mld)
+
+	switch len(registrySecrets) {
+	case 0:
+		return nil, nil
+	case 1:
+		return &registrySecrets[0], nil
+	}
+
+	auths := make(map[string]json.RawMessage)
+
+	for _, ref := range registrySecrets {
+		secret := v1.Secret{}
+
+		if err := m.client.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: mld.Namespace}, &secret); err != nil {
+			return nil, fmt.Errorf("could not get registry secret %s: %v", ref.Name, err)
+		}
+
+		cfg := dockerConfig{}
+
+		if err := json.Unmarshal(secret.Data[v1.DockerConfigJsonKey], &cfg); err != nil {
+			return nil, fmt.Errorf("could not parse the %s key of registry secret %s: %v", v1.DockerConfigJsonKey, ref.Name, err)
+		}
+
+		for reg, a := range cfg.Auths {
+			if _, ok := auths[reg]; !ok {
+				auths[reg] = a
+			}
+		}
+	}
+
+	b, err := json.Marshal(dockerConfig{Auths: auths})
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal the merged docker configuration: %v", err)
+	}
+
+	merged := v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      registryAuthSecretName(mld.Name, registrySecrets),
+			Namespace: mld.Namespace,
+		},
+	}
+
+	_, err = controllerutil.CreateOrPatch(ctx, m.client, &merged, func() error {
+		merged.Type = v1.SecretTypeDockerConfigJson
+		merged.Data = map[string][]byte{v1.DockerConfigJsonKey: b}
+
+		return controllerutil.SetControllerReference(owner, &merged, m.scheme)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not create or patch the merged registry secret %s: %v", merged.Name, err)
+	}
+
+	return &v1.LocalObjectReference{Name: merged.Name}, nil
+}
+
+type dockerConfig struct {
+	Auths map[string]json.RawMessage `json:"auths"`
+}
+
+// registryAuthSecretName returns the name of the Secret merging registrySecrets for the builds of the Module name.
+// It ends with a hash of the names of registrySecrets, as each kernel mapping can use different base image pull
+// secrets; longer names are truncated so that the name of its volume is valid.
+func registryAuthSecretName(name string, registrySecrets []v1.LocalObjectReference) string {
+	names := make([]string, 0, len(registrySecrets))
+
+	for _, ref := range registrySecrets {
+		names = append(names, ref.Name)
+	}
+
+	hash := fmt.Sprintf("%x", sha256.Sum256([]byte(strings.Join(names, ","))))[:registryAuthHashLength]
+
+	// volume names cannot contain dots
+	prefix := strings.ReplaceAll(name, ".", "-") + "-registry-auth"
+	if maxLen := maxRegistryAuthSecretNameLength - registryAuthHashLength - 1; len(prefix) > maxLen {
+		prefix = strings.TrimRight(prefix[:maxLen], "-")
+	}
+
+	return prefix + "-" + hash
+}
+
+// registryHost returns the host of the registry hosting image, as expected by kaniko's --registry-certificate flag.
+func registryHost(image string) string {
+	ref, err := name.ParseReference(image)
+	if err != nil {
+		return strings.Split(image, "/")[0]
+	}
+
+	return ref.Context().RegistryStr()
+}
+
 func makeImagePullSecretVolume(secretRef *v1.LocalObjectReference) v1.Volume {
 	if secretRef == nil {
 		return v1.Volume{}
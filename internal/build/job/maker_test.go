@@ -3,6 +3,7 @@ package job
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -12,6 +13,7 @@ import (
 	"golang.org/x/exp/slices"
 	batchv1 "k8s.io/api/batch/v1"
 	v1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/utils/pointer"
@@ -22,6 +24,7 @@ import (
 	"github.com/kubernetes-sigs/kernel-module-management/internal/build"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/client"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/constants"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/registry"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/utils"
 )
 
@@ -49,7 +52,7 @@ var _ = Describe("MakeJobTemplate", func() {
 		clnt = client.NewMockClient(ctrl)
 		mh = build.NewMockHelper(ctrl)
 		jobhelper = utils.NewMockJobHelper(ctrl)
-		m = NewMaker(clnt, mh, jobhelper, scheme)
+		m = NewMaker(clnt, mh, jobhelper, scheme, nil)
 	})
 
 	AfterEach(func() {
@@ -76,6 +79,11 @@ var _ = Describe("MakeJobTemplate", func() {
 		ctx := context.Background()
 		nodeSelector := map[string]string{"arch": "x64"}
 
+		override := kmmv1beta1.BuildArg{Name: "KERNEL_VERSION", Value: kernelVersion}
+
+		buildHash, err := getBuildHash(&buildInputs{Dockerfile: dockerfile, BuildArgs: append(slices.Clone(buildArgs), override)})
+		Expect(err).NotTo(HaveOccurred())
+
 		mld := api.ModuleLoaderData{
 			Owner:     &mod,
 			Name:      mod.Name,
@@ -120,6 +128,7 @@ var _ = Describe("MakeJobTemplate", func() {
 							{
 								Args: []string{
 									"--destination", image,
+									"--label", constants.BuildHashImageLabel + "=" + buildHash,
 									"--build-arg", "name1=value1",
 									"--build-arg", "KERNEL_VERSION=" + kernelVersion,
 								},
@@ -213,12 +222,11 @@ var _ = Describe("MakeJobTemplate", func() {
 					},
 				)
 		}
-		hash, err := getHashValue(&expected.Spec.Template, dockerfile)
+		hash, err := getHashValue(&expected.Spec.Template, dockerfile, nil)
 		Expect(err).NotTo(HaveOccurred())
 		annotations := map[string]string{constants.JobHashAnnotation: fmt.Sprintf("%d", hash)}
 		expected.SetAnnotations(annotations)
 
-		override := kmmv1beta1.BuildArg{Name: "KERNEL_VERSION", Value: kernelVersion}
 		gomock.InOrder(
 			mh.EXPECT().ApplyBuildArgOverrides(buildArgs, override).Return(append(slices.Clone(buildArgs), override)),
 			clnt.EXPECT().Get(ctx, types.NamespacedName{Name: dockerfileConfigMap.Name, Namespace: mld.Namespace}, gomock.Any()).DoAndReturn(
@@ -227,7 +235,7 @@ var _ = Describe("MakeJobTemplate", func() {
 					return nil
 				},
 			),
-			jobhelper.EXPECT().JobLabels(mld.Name, kernelVersion, utils.JobTypeBuild).Return(labels),
+			jobhelper.EXPECT().JobLabels(mld.Name, kernelVersion, "", utils.JobTypeBuild).Return(labels),
 		)
 
 		actual, err := m.MakeJobTemplate(ctx, &mld, mld.Owner, true)
@@ -282,7 +290,7 @@ var _ = Describe("MakeJobTemplate", func() {
 					return nil
 				},
 			),
-			jobhelper.EXPECT().JobLabels(mod.Name, kernelVersion, utils.JobTypeBuild).Return(map[string]string{}),
+			jobhelper.EXPECT().JobLabels(mod.Name, kernelVersion, "", utils.JobTypeBuild).Return(map[string]string{}),
 		)
 
 		actual, err := m.MakeJobTemplate(ctx, &mld, mld.Owner, pushImage)
@@ -330,8 +338,211 @@ var _ = Describe("MakeJobTemplate", func() {
 			"--skip-tls-verify",
 			true,
 		),
+		Entry(
+			"RegistryTLS.CABundle",
+			&kmmv1beta1.TLSOptions{CABundle: &v1.LocalObjectReference{Name: "registry-ca"}},
+			&kmmv1beta1.Build{DockerfileConfigMap: &dockerfileConfigMap},
+			"my.registry=/etc/kmm/ca-bundles/registry-ca/ca-bundle.crt",
+			true,
+		),
 	)
 
+	It("should mount the CA bundles and trust the base image one for all registries", func() {
+		ctx := context.Background()
+
+		mld := api.ModuleLoaderData{
+			Build: &kmmv1beta1.Build{
+				BaseImageRegistryTLS: kmmv1beta1.TLSOptions{CABundle: &v1.LocalObjectReference{Name: "base-ca"}},
+				DockerfileConfigMap:  &dockerfileConfigMap,
+			},
+			ContainerImage: image,
+			RegistryTLS:    &kmmv1beta1.TLSOptions{CABundle: &v1.LocalObjectReference{Name: "registry-ca"}},
+			Name:           mod.Name,
+			Namespace:      mod.Namespace,
+			Owner:          &mod,
+			KernelVersion:  kernelVersion,
+		}
+
+		gomock.InOrder(
+			mh.EXPECT().ApplyBuildArgOverrides(nil, kmmv1beta1.BuildArg{Name: "KERNEL_VERSION", Value: kernelVersion}),
+			clnt.EXPECT().Get(ctx, types.NamespacedName{Name: dockerfileConfigMap.Name, Namespace: mod.Namespace}, gomock.Any()).DoAndReturn(
+				func(_ interface{}, _ interface{}, cm *v1.ConfigMap, _ ...ctrlclient.GetOption) error {
+					cm.Data = dockerfileCMData
+					return nil
+				},
+			),
+			jobhelper.EXPECT().JobLabels(mod.Name, kernelVersion, "", utils.JobTypeBuild).Return(map[string]string{}),
+		)
+
+		actual, err := m.MakeJobTemplate(ctx, &mld, mld.Owner, true)
+		Expect(err).NotTo(HaveOccurred())
+
+		podSpec := actual.Spec.Template.Spec
+		Expect(podSpec.Containers[0].Env).To(ContainElement(v1.EnvVar{
+			Name:  "SSL_CERT_DIR",
+			Value: "/kaniko/ssl/certs:/etc/kmm/ca-bundles/base-ca",
+		}))
+		Expect(podSpec.Containers[0].VolumeMounts).To(ContainElements(
+			v1.VolumeMount{Name: "ca-bundle-base-ca", ReadOnly: true, MountPath: "/etc/kmm/ca-bundles/base-ca"},
+			v1.VolumeMount{Name: "ca-bundle-registry-ca", ReadOnly: true, MountPath: "/etc/kmm/ca-bundles/registry-ca"},
+		))
+		Expect(podSpec.Volumes).To(HaveLen(3))
+	})
+
+	It("should map the registries of the configured mirrors", func() {
+		ctx := context.Background()
+
+		m = NewMaker(clnt, mh, jobhelper, scheme, &registry.MirrorConfig{
+			Registries: []registry.MirrorRegistry{
+				{Prefix: "docker.io", Mirrors: []string{"mirror1.local", "mirror2.local/docker"}},
+				{Prefix: "quay.io", Location: "local.registry"},
+				{Prefix: "quay.io/org", Location: "local.registry/org"},
+			},
+		})
+
+		mld := api.ModuleLoaderData{
+			Build:          &kmmv1beta1.Build{DockerfileConfigMap: &dockerfileConfigMap},
+			ContainerImage: image,
+			RegistryTLS:    &kmmv1beta1.TLSOptions{},
+			Name:           mod.Name,
+			Namespace:      mod.Namespace,
+			Owner:          &mod,
+			KernelVersion:  kernelVersion,
+		}
+
+		gomock.InOrder(
+			mh.EXPECT().ApplyBuildArgOverrides(nil, kmmv1beta1.BuildArg{Name: "KERNEL_VERSION", Value: kernelVersion}),
+			clnt.EXPECT().Get(ctx, types.NamespacedName{Name: dockerfileConfigMap.Name, Namespace: mod.Namespace}, gomock.Any()).DoAndReturn(
+				func(_ interface{}, _ interface{}, cm *v1.ConfigMap, _ ...ctrlclient.GetOption) error {
+					cm.Data = dockerfileCMData
+					return nil
+				},
+			),
+			jobhelper.EXPECT().JobLabels(mod.Name, kernelVersion, "", utils.JobTypeBuild).Return(map[string]string{}),
+		)
+
+		buildHash, err := getBuildHash(&buildInputs{Dockerfile: dockerfile})
+		Expect(err).NotTo(HaveOccurred())
+
+		actual, err := m.MakeJobTemplate(ctx, &mld, mld.Owner, false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(actual.Spec.Template.Spec.Containers[0].Args).To(Equal([]string{
+			"--no-push",
+			"--label", constants.BuildHashImageLabel + "=" + buildHash,
+			"--registry-map", "index.docker.io=mirror1.local",
+			"--registry-map", "quay.io=local.registry",
+		}))
+	})
+
+	It("should inject build args referencing a Secret or a ConfigMap through environment variables", func() {
+		ctx := context.Background()
+
+		secretKeyRef := &v1.SecretKeySelector{
+			LocalObjectReference: v1.LocalObjectReference{Name: "license-secret"},
+			Key:                  "license",
+		}
+
+		configMapKeyRef := &v1.ConfigMapKeySelector{
+			LocalObjectReference: v1.LocalObjectReference{Name: "vendor-cm"},
+			Key:                  "url",
+		}
+
+		secretBuildArgs := []kmmv1beta1.BuildArg{
+			{Name: "LICENSE", ValueFrom: &kmmv1beta1.BuildArgSource{SecretKeyRef: secretKeyRef}},
+			{Name: "VENDOR_URL", ValueFrom: &kmmv1beta1.BuildArgSource{ConfigMapKeyRef: configMapKeyRef}},
+		}
+
+		mld := api.ModuleLoaderData{
+			Name:      mod.Name,
+			Namespace: mod.Namespace,
+			Owner:     &mod,
+			Build: &kmmv1beta1.Build{
+				BuildArgs:           secretBuildArgs,
+				DockerfileConfigMap: &dockerfileConfigMap,
+			},
+			ContainerImage: image,
+			RegistryTLS:    &kmmv1beta1.TLSOptions{},
+			KernelVersion:  kernelVersion,
+		}
+
+		override := kmmv1beta1.BuildArg{Name: "KERNEL_VERSION", Value: kernelVersion}
+
+		expectCalls := func(licenseValue, resourceVersion string) {
+			gomock.InOrder(
+				mh.EXPECT().ApplyBuildArgOverrides(secretBuildArgs, override).Return(append(slices.Clone(secretBuildArgs), override)),
+				clnt.EXPECT().Get(ctx, types.NamespacedName{Name: dockerfileConfigMap.Name, Namespace: mld.Namespace}, gomock.Any()).DoAndReturn(
+					func(_ interface{}, _ interface{}, cm *v1.ConfigMap, _ ...ctrlclient.GetOption) error {
+						cm.Data = dockerfileCMData
+						return nil
+					},
+				),
+				clnt.EXPECT().Get(ctx, types.NamespacedName{Name: secretKeyRef.Name, Namespace: mld.Namespace}, gomock.Any()).DoAndReturn(
+					func(_ interface{}, _ interface{}, s *v1.Secret, _ ...ctrlclient.GetOption) error {
+						s.UID = "license-secret-uid"
+						s.ResourceVersion = resourceVersion
+						s.Data = map[string][]byte{"license": []byte(licenseValue)}
+						return nil
+					},
+				),
+				clnt.EXPECT().Get(ctx, types.NamespacedName{Name: configMapKeyRef.Name, Namespace: mld.Namespace}, gomock.Any()).DoAndReturn(
+					func(_ interface{}, _ interface{}, cm *v1.ConfigMap, _ ...ctrlclient.GetOption) error {
+						cm.Data = map[string]string{"url": "https://vendor.example.com"}
+						return nil
+					},
+				),
+				jobhelper.EXPECT().JobLabels(mld.Name, kernelVersion, "", utils.JobTypeBuild).Return(map[string]string{}),
+			)
+		}
+
+		expectCalls("some-license", "1")
+
+		actual, err := m.MakeJobTemplate(ctx, &mld, mld.Owner, true)
+		Expect(err).NotTo(HaveOccurred())
+
+		container := actual.Spec.Template.Spec.Containers[0]
+		Expect(container.Args).To(ContainElements("LICENSE", "VENDOR_URL", "KERNEL_VERSION="+kernelVersion))
+		Expect(container.Args).NotTo(ContainElement(ContainSubstring("some-license")))
+		Expect(container.Env).To(Equal([]v1.EnvVar{
+			{
+				Name:      "LICENSE",
+				ValueFrom: &v1.EnvVarSource{SecretKeyRef: secretKeyRef},
+			},
+			{
+				Name:      "VENDOR_URL",
+				ValueFrom: &v1.EnvVarSource{ConfigMapKeyRef: configMapKeyRef},
+			},
+		}))
+
+		labelArg := func(args []string) string {
+			for _, a := range args {
+				if strings.HasPrefix(a, constants.BuildHashImageLabel+"=") {
+					return a
+				}
+			}
+			return ""
+		}
+
+		Expect(labelArg(actual.Spec.Template.Spec.Containers[0].Args)).NotTo(BeEmpty())
+
+		By("not deriving the hashes from the value of the Secret")
+
+		expectCalls("some-other-license", "1")
+
+		sameVersion, err := m.MakeJobTemplate(ctx, &mld, mld.Owner, true)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(sameVersion.Annotations[constants.JobHashAnnotation]).To(Equal(actual.Annotations[constants.JobHashAnnotation]))
+		Expect(labelArg(sameVersion.Spec.Template.Spec.Containers[0].Args)).To(Equal(labelArg(actual.Spec.Template.Spec.Containers[0].Args)))
+
+		By("changing the hashes when the Secret is rotated")
+
+		expectCalls("some-other-license", "2")
+
+		rotated, err := m.MakeJobTemplate(ctx, &mld, mld.Owner, true)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rotated.Annotations[constants.JobHashAnnotation]).NotTo(Equal(actual.Annotations[constants.JobHashAnnotation]))
+		Expect(labelArg(rotated.Spec.Template.Spec.Containers[0].Args)).NotTo(Equal(labelArg(actual.Spec.Template.Spec.Containers[0].Args)))
+	})
+
 	It("use a custom given tag", func() {
 		const customTag = "some-tag"
 		ctx := context.Background()
@@ -361,7 +572,7 @@ var _ = Describe("MakeJobTemplate", func() {
 					return nil
 				},
 			),
-			jobhelper.EXPECT().JobLabels(mld.Name, kernelVersion, utils.JobTypeBuild).Return(map[string]string{}),
+			jobhelper.EXPECT().JobLabels(mld.Name, kernelVersion, "", utils.JobTypeBuild).Return(map[string]string{}),
 		)
 
 		actual, err := m.MakeJobTemplate(ctx, &mld, mld.Owner, false)
@@ -398,7 +609,7 @@ var _ = Describe("MakeJobTemplate", func() {
 					return nil
 				},
 			),
-			jobhelper.EXPECT().JobLabels(mld.Name, kernelVersion, utils.JobTypeBuild).Return(map[string]string{}),
+			jobhelper.EXPECT().JobLabels(mld.Name, kernelVersion, "", utils.JobTypeBuild).Return(map[string]string{}),
 		)
 
 		actual, err := m.MakeJobTemplate(ctx, &mld, mld.Owner, true)
@@ -407,4 +618,109 @@ var _ = Describe("MakeJobTemplate", func() {
 		Expect(actual.Spec.Template.Spec.Containers[0].Args).To(ContainElement("--destination"))
 		Expect(actual.Spec.Template.Spec.Containers[0].Args).To(ContainElement(expectedImageName))
 	})
+
+	It("should merge the push and pull secrets into a Secret", func() {
+		ctx := context.Background()
+
+		mld := api.ModuleLoaderData{
+			Name:      mod.Name,
+			Namespace: mod.Namespace,
+			Owner:     &mod,
+			Build: &kmmv1beta1.Build{
+				BaseImagePullSecrets: []v1.LocalObjectReference{{Name: "base-pull"}},
+				DockerfileConfigMap:  &dockerfileConfigMap,
+			},
+			ContainerImage:  image,
+			ImageRepoSecret: &v1.LocalObjectReference{Name: "base-pull"},
+			PushSecret:      &v1.LocalObjectReference{Name: "push"},
+			RegistryTLS:     &kmmv1beta1.TLSOptions{},
+			KernelVersion:   kernelVersion,
+		}
+
+		mergedName := registryAuthSecretName(mod.Name, []v1.LocalObjectReference{{Name: "push"}, {Name: "base-pull"}})
+
+		expectSecret := func(name, config string) *gomock.Call {
+			return clnt.EXPECT().Get(ctx, types.NamespacedName{Name: name, Namespace: mod.Namespace}, gomock.Any()).DoAndReturn(
+				func(_ interface{}, _ interface{}, s *v1.Secret, _ ...ctrlclient.GetOption) error {
+					s.Data = map[string][]byte{v1.DockerConfigJsonKey: []byte(config)}
+					return nil
+				},
+			)
+		}
+
+		gomock.InOrder(
+			mh.EXPECT().ApplyBuildArgOverrides(nil, kmmv1beta1.BuildArg{Name: "KERNEL_VERSION", Value: kernelVersion}),
+			clnt.EXPECT().Get(ctx, types.NamespacedName{Name: dockerfileConfigMap.Name, Namespace: mod.Namespace}, gomock.Any()).DoAndReturn(
+				func(_ interface{}, _ interface{}, cm *v1.ConfigMap, _ ...ctrlclient.GetOption) error {
+					cm.Data = dockerfileCMData
+					return nil
+				},
+			),
+			expectSecret("push", `{"auths":{"my.registry":{"auth":"push"}}}`),
+			expectSecret("base-pull", `{"auths":{"my.registry":{"auth":"pull"},"base.registry":{"auth":"pull"}}}`),
+			clnt.EXPECT().Get(ctx, types.NamespacedName{Name: mergedName, Namespace: mod.Namespace}, gomock.Any()).Return(
+				k8serrors.NewNotFound(v1.Resource("secrets"), mergedName),
+			),
+			clnt.EXPECT().Create(ctx, gomock.Any()).DoAndReturn(
+				func(_ interface{}, s *v1.Secret, _ ...ctrlclient.CreateOption) error {
+					Expect(s.Type).To(Equal(v1.SecretTypeDockerConfigJson))
+					Expect(s.Data[v1.DockerConfigJsonKey]).To(MatchJSON(
+						`{"auths":{"my.registry":{"auth":"push"},"base.registry":{"auth":"pull"}}}`,
+					))
+					Expect(s.OwnerReferences).To(HaveLen(1))
+					return nil
+				},
+			),
+			jobhelper.EXPECT().JobLabels(mod.Name, kernelVersion, "", utils.JobTypeBuild).Return(map[string]string{}),
+		)
+
+		actual, err := m.MakeJobTemplate(ctx, &mld, mld.Owner, true)
+		Expect(err).NotTo(HaveOccurred())
+
+		podSpec := actual.Spec.Template.Spec
+
+		Expect(podSpec.InitContainers).To(BeEmpty())
+		Expect(podSpec.Volumes).To(
+			ContainElement(makeImagePullSecretVolume(&v1.LocalObjectReference{Name: mergedName})),
+		)
+		Expect(podSpec.Containers[0].VolumeMounts).To(
+			ContainElement(makeImagePullSecretVolumeMount(&v1.LocalObjectReference{Name: mergedName})),
+		)
+	})
+})
+
+var _ = Describe("registryAuthSecretName", func() {
+	secrets := []v1.LocalObjectReference{{Name: "push"}, {Name: "pull"}}
+
+	It("should end with a hash of the secret names", func() {
+		name := registryAuthSecretName("my.module", secrets)
+
+		Expect(name).To(HavePrefix("my-module-registry-auth-"))
+		Expect(name).NotTo(Equal(registryAuthSecretName("my.module", secrets[:1])))
+	})
+
+	It("should keep the name of the volume within 63 characters", func() {
+		name := registryAuthSecretName(strings.Repeat("a", 100), secrets)
+
+		Expect(name).To(HaveLen(maxRegistryAuthSecretNameLength))
+		Expect(volumeNameFromSecretRef(v1.LocalObjectReference{Name: name})).To(HaveLen(63))
+	})
+})
+
+var _ = Describe("templateVarsAsBuildArgs", func() {
+	It("should return KERNEL_VERSION and all template variables", func() {
+		mld := api.ModuleLoaderData{
+			KernelVersion: "1.2.3",
+			TemplateVars:  []string{"KERNEL_VERSION=1.2.3", "KERNEL_X=1", "MOD_NAME=some-module", "NODE_ARCH="},
+		}
+
+		expected := []kmmv1beta1.BuildArg{
+			{Name: "KERNEL_VERSION", Value: "1.2.3"},
+			{Name: "KERNEL_X", Value: "1"},
+			{Name: "MOD_NAME", Value: "some-module"},
+			{Name: "NODE_ARCH", Value: ""},
+		}
+
+		Expect(templateVarsAsBuildArgs(&mld)).To(Equal(expected))
+	})
 })
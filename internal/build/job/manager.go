@@ -4,12 +4,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	"github.com/kubernetes-sigs/kernel-module-management/internal/api"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/gc"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/module"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/registry"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/utils"
@@ -35,23 +37,32 @@ func NewBuildManager(
 	}
 }
 
-func (jbm *jobManager) GarbageCollect(ctx context.Context, modName, namespace string, owner metav1.Object) ([]string, error) {
+// GarbageCollect deletes the finished build jobs whose TTL has expired.
+// It returns the names of the deleted jobs, and how long until the TTL of the earliest kept job expires.
+func (jbm *jobManager) GarbageCollect(ctx context.Context, modName, namespace string, policy gc.Policy, owner metav1.Object) ([]string, time.Duration, error) {
 	jobs, err := jbm.jobHelper.GetModuleJobs(ctx, modName, namespace, utils.JobTypeBuild, owner)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get build jobs for module %s: %v", modName, err)
+		return nil, 0, fmt.Errorf("failed to get build jobs for module %s: %v", modName, err)
 	}
 
+	now := time.Now()
+
+	var requeueAfter time.Duration
+
 	deleteNames := make([]string, 0, len(jobs))
 	for _, job := range jobs {
-		if job.Status.Succeeded == 1 {
+		if policy.ShouldDeleteJob(&job, now) {
 			err = jbm.jobHelper.DeleteJob(ctx, &job)
 			if err != nil {
-				return nil, fmt.Errorf("failed to delete build job %s: %v", job.Name, err)
+				return nil, 0, fmt.Errorf("failed to delete build job %s: %v", job.Name, err)
 			}
 			deleteNames = append(deleteNames, job.Name)
+			continue
 		}
+
+		requeueAfter = gc.Earliest(requeueAfter, policy.JobRequeueAfter(&job, now))
 	}
-	return deleteNames, nil
+	return deleteNames, requeueAfter, nil
 }
 
 func (jbm *jobManager) ShouldSync(ctx context.Context, mld *api.ModuleLoaderData) (bool, error) {
@@ -61,23 +72,64 @@ func (jbm *jobManager) ShouldSync(ctx context.Context, mld *api.ModuleLoaderData
 		return false, nil
 	}
 
+	var buildHash string
+
+	if !mld.Build.SkipRebuildOnInputChange {
+		var err error
+
+		if buildHash, err = jbm.maker.BuildHash(ctx, mld); err != nil {
+			return false, fmt.Errorf("could not compute the build hash: %v", err)
+		}
+	}
+
 	targetImage := mld.ContainerImage
 
 	// if build AND sign are specified, then we will build an intermediate image
 	// and let sign produce the one specified in targetImage
 	if module.ShouldBeSigned(mld) {
+		// the intermediate image may have been deleted once signed: the signed image is all that is needed
+		upToDate, err := jbm.isImageUpToDate(ctx, mld, targetImage, buildHash)
+		if err != nil {
+			return false, err
+		}
+
+		if upToDate {
+			return false, nil
+		}
+
 		targetImage = module.IntermediateImageName(mld.Name, mld.Namespace, targetImage)
 	}
 
 	// build is specified and targetImage is either the final image or the intermediate image
 	// tag, depending on whether sign is specified or not. Either way, if targetImage exists
-	// we can skip building it
-	exists, err := module.ImageExists(ctx, jbm.client, jbm.registry, mld, mld.Namespace, targetImage)
+	// and, when requested, was built from the current inputs we can skip building it
+	upToDate, err := jbm.isImageUpToDate(ctx, mld, targetImage, buildHash)
 	if err != nil {
-		return false, fmt.Errorf("failed to check existence of image %s: %w", targetImage, err)
+		return false, err
 	}
 
-	return !exists, nil
+	return !upToDate, nil
+}
+
+// isImageUpToDate returns true if image exists and, unless the build of mld opted out of it, was built from the inputs
+// whose hash is buildHash.
+// Images without a build hash, such as those built by earlier versions of KMM, are considered up to date.
+func (jbm *jobManager) isImageUpToDate(ctx context.Context, mld *api.ModuleLoaderData, image, buildHash string) (bool, error) {
+	exists, err := module.ImageExists(ctx, jbm.client, jbm.registry, mld, mld.Namespace, image)
+	if err != nil {
+		return false, fmt.Errorf("failed to check existence of image %s: %w", image, err)
+	}
+
+	if !exists || mld.Build.SkipRebuildOnInputChange {
+		return exists, nil
+	}
+
+	imageHash, err := module.ImageBuildHash(ctx, jbm.client, jbm.registry, mld, mld.Namespace, image)
+	if err != nil {
+		return false, fmt.Errorf("failed to get the build hash of image %s: %w", image, err)
+	}
+
+	return imageHash == "" || imageHash == buildHash, nil
 }
 
 func (jbm *jobManager) Sync(
@@ -95,7 +147,7 @@ func (jbm *jobManager) Sync(
 		return "", fmt.Errorf("could not make Job template: %v", err)
 	}
 
-	job, err := jbm.jobHelper.GetModuleJobByKernel(ctx, mld.Name, mld.Namespace, mld.KernelVersion, utils.JobTypeBuild, owner)
+	job, err := jbm.jobHelper.GetModuleJobByKernel(ctx, mld.Name, mld.Namespace, mld.KernelVersion, mld.Architecture, utils.JobTypeBuild, owner)
 	if err != nil {
 		if !errors.Is(err, utils.ErrNoMatchingJob) {
 			return "", fmt.Errorf("error getting the build: %v", err)
@@ -131,5 +183,15 @@ func (jbm *jobManager) Sync(
 		return "", err
 	}
 
+	if statusmsg == utils.StatusCompleted && pushImage {
+		targetImage := mld.ContainerImage
+		if module.ShouldBeSigned(mld) {
+			targetImage = module.IntermediateImageName(mld.Name, mld.Namespace, targetImage)
+		}
+
+		// the image was just pushed; do not rely on a cached lookup from before the build
+		jbm.registry.Invalidate(targetImage)
+	}
+
 	return statusmsg, nil
 }
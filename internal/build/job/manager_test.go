@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	. "github.com/onsi/ginkgo/v2"
@@ -16,26 +17,31 @@ import (
 	"github.com/kubernetes-sigs/kernel-module-management/internal/api"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/client"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/constants"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/gc"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/module"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/registry"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/utils"
 )
 
 var _ = Describe("ShouldSync", func() {
 	var (
-		ctrl *gomock.Controller
-		clnt *client.MockClient
-		reg  *registry.MockRegistry
+		ctrl  *gomock.Controller
+		clnt  *client.MockClient
+		maker *MockMaker
+		reg   *registry.MockRegistry
 	)
 	const (
 		moduleName    = "module-name"
 		imageName     = "image-name"
 		namespace     = "some-namespace"
 		kernelVersion = "1.2.3"
+		buildHash     = "some-hash"
 	)
 
 	BeforeEach(func() {
 		ctrl = gomock.NewController(GinkgoT())
 		clnt = client.NewMockClient(ctrl)
+		maker = NewMockMaker(ctrl)
 		reg = registry.NewMockRegistry(ctrl)
 	})
 
@@ -44,7 +50,7 @@ var _ = Describe("ShouldSync", func() {
 
 		mld := &api.ModuleLoaderData{}
 
-		mgr := NewBuildManager(clnt, nil, nil, reg)
+		mgr := NewBuildManager(clnt, maker, nil, reg)
 
 		shouldSync, err := mgr.ShouldSync(ctx, mld)
 
@@ -52,22 +58,75 @@ var _ = Describe("ShouldSync", func() {
 		Expect(shouldSync).To(BeFalse())
 	})
 
-	It("should return false if image already exists", func() {
+	DescribeTable("should return false if image already exists and was built from the same inputs",
+		func(labels map[string]string) {
+			ctx := context.Background()
+
+			mld := &api.ModuleLoaderData{
+				Name:            moduleName,
+				Namespace:       namespace,
+				Build:           &kmmv1beta1.Build{},
+				ContainerImage:  imageName,
+				ImageRepoSecret: &v1.LocalObjectReference{Name: "pull-push-secret"},
+			}
+
+			gomock.InOrder(
+				maker.EXPECT().BuildHash(ctx, mld).Return(buildHash, nil),
+				reg.EXPECT().ImageExists(ctx, imageName, "", nil, nil, gomock.Any()).Return(true, nil),
+				reg.EXPECT().GetLabels(ctx, imageName, "", nil, nil, gomock.Any()).Return(labels, nil),
+			)
+
+			mgr := NewBuildManager(clnt, maker, nil, reg)
+
+			shouldSync, err := mgr.ShouldSync(ctx, mld)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(shouldSync).To(BeFalse())
+		},
+		Entry("same build hash", map[string]string{constants.BuildHashImageLabel: buildHash}),
+		Entry("no build hash", nil),
+	)
+
+	It("should return true if the image was built from other inputs", func() {
 		ctx := context.Background()
 
 		mld := &api.ModuleLoaderData{
-			Name:            moduleName,
-			Namespace:       namespace,
-			Build:           &kmmv1beta1.Build{},
-			ContainerImage:  imageName,
-			ImageRepoSecret: &v1.LocalObjectReference{Name: "pull-push-secret"},
+			Name:           moduleName,
+			Namespace:      namespace,
+			Build:          &kmmv1beta1.Build{},
+			ContainerImage: imageName,
 		}
 
 		gomock.InOrder(
-			reg.EXPECT().ImageExists(ctx, imageName, nil, gomock.Any()).Return(true, nil),
+			maker.EXPECT().BuildHash(ctx, mld).Return(buildHash, nil),
+			reg.EXPECT().ImageExists(ctx, imageName, "", nil, nil, gomock.Any()).Return(true, nil),
+			reg.
+				EXPECT().
+				GetLabels(ctx, imageName, "", nil, nil, gomock.Any()).
+				Return(map[string]string{constants.BuildHashImageLabel: "other-hash"}, nil),
 		)
 
-		mgr := NewBuildManager(clnt, nil, nil, reg)
+		mgr := NewBuildManager(clnt, maker, nil, reg)
+
+		shouldSync, err := mgr.ShouldSync(ctx, mld)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(shouldSync).To(BeTrue())
+	})
+
+	It("should not look up the build hash of the image if rebuilding is skipped", func() {
+		ctx := context.Background()
+
+		mld := &api.ModuleLoaderData{
+			Name:           moduleName,
+			Namespace:      namespace,
+			Build:          &kmmv1beta1.Build{SkipRebuildOnInputChange: true},
+			ContainerImage: imageName,
+		}
+
+		reg.EXPECT().ImageExists(ctx, imageName, "", nil, nil, gomock.Any()).Return(true, nil)
+
+		mgr := NewBuildManager(clnt, maker, nil, reg)
 
 		shouldSync, err := mgr.ShouldSync(ctx, mld)
 
@@ -87,10 +146,11 @@ var _ = Describe("ShouldSync", func() {
 		}
 
 		gomock.InOrder(
-			reg.EXPECT().ImageExists(ctx, imageName, nil, gomock.Any()).Return(false, errors.New("generic-registry-error")),
+			maker.EXPECT().BuildHash(ctx, mld).Return(buildHash, nil),
+			reg.EXPECT().ImageExists(ctx, imageName, "", nil, nil, gomock.Any()).Return(false, errors.New("generic-registry-error")),
 		)
 
-		mgr := NewBuildManager(clnt, nil, nil, reg)
+		mgr := NewBuildManager(clnt, maker, nil, reg)
 
 		shouldSync, err := mgr.ShouldSync(ctx, mld)
 
@@ -111,10 +171,11 @@ var _ = Describe("ShouldSync", func() {
 		}
 
 		gomock.InOrder(
-			reg.EXPECT().ImageExists(ctx, imageName, nil, gomock.Any()).Return(false, nil),
+			maker.EXPECT().BuildHash(ctx, mld).Return(buildHash, nil),
+			reg.EXPECT().ImageExists(ctx, imageName, "", nil, nil, gomock.Any()).Return(false, nil),
 		)
 
-		mgr := NewBuildManager(clnt, nil, nil, reg)
+		mgr := NewBuildManager(clnt, maker, nil, reg)
 
 		shouldSync, err := mgr.ShouldSync(ctx, mld)
 
@@ -123,6 +184,99 @@ var _ = Describe("ShouldSync", func() {
 	})
 })
 
+var _ = Describe("ShouldSync_signed", func() {
+	const (
+		moduleName = "module-name"
+		imageName  = "example.org/repo/image:tag"
+		namespace  = "some-namespace"
+	)
+
+	var (
+		ctrl  *gomock.Controller
+		clnt  *client.MockClient
+		maker *MockMaker
+		reg   *registry.MockRegistry
+
+		// build hashes of the images present in the registry
+		images map[string]string
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		clnt = client.NewMockClient(ctrl)
+		maker = NewMockMaker(ctrl)
+		reg = registry.NewMockRegistry(ctrl)
+
+		images = make(map[string]string)
+
+		reg.
+			EXPECT().
+			ImageExists(gomock.Any(), gomock.Any(), "", nil, nil, gomock.Any()).
+			DoAndReturn(func(_ context.Context, image string, _ string, _ *kmmv1beta1.TLSOptions, _ []byte, _ interface{}) (bool, error) {
+				_, ok := images[image]
+				return ok, nil
+			}).
+			AnyTimes()
+
+		reg.
+			EXPECT().
+			GetLabels(gomock.Any(), gomock.Any(), "", nil, nil, gomock.Any()).
+			DoAndReturn(func(_ context.Context, image string, _ string, _ *kmmv1beta1.TLSOptions, _ []byte, _ interface{}) (map[string]string, error) {
+				if hash, ok := images[image]; ok {
+					return map[string]string{constants.BuildHashImageLabel: hash}, nil
+				}
+				return nil, nil
+			}).
+			AnyTimes()
+	})
+
+	mld := &api.ModuleLoaderData{
+		Name:           moduleName,
+		Namespace:      namespace,
+		Build:          &kmmv1beta1.Build{},
+		Sign:           &kmmv1beta1.Sign{},
+		ContainerImage: imageName,
+	}
+
+	intermediateImage := module.IntermediateImageName(moduleName, namespace, imageName)
+
+	It("should not build again once the intermediate image was deleted after signing", func() {
+		ctx := context.Background()
+
+		maker.EXPECT().BuildHash(ctx, mld).Return("some-hash", nil).AnyTimes()
+
+		mgr := NewBuildManager(clnt, maker, nil, reg)
+
+		By("building the intermediate image")
+		Expect(mgr.ShouldSync(ctx, mld)).To(BeTrue())
+		images[intermediateImage] = "some-hash"
+		Expect(mgr.ShouldSync(ctx, mld)).To(BeFalse())
+
+		By("signing it and deleting the intermediate image")
+		images[imageName] = "some-hash"
+		delete(images, intermediateImage)
+
+		By("reconciling again")
+		Expect(mgr.ShouldSync(ctx, mld)).To(BeFalse())
+	})
+
+	It("should build the intermediate image again when the inputs of the signed image changed", func() {
+		ctx := context.Background()
+
+		maker.EXPECT().BuildHash(ctx, mld).Return("new-hash", nil).AnyTimes()
+
+		images[imageName] = "old-hash"
+
+		mgr := NewBuildManager(clnt, maker, nil, reg)
+
+		Expect(mgr.ShouldSync(ctx, mld)).To(BeTrue())
+
+		images[intermediateImage] = "new-hash"
+
+		Expect(mgr.ShouldSync(ctx, mld)).To(BeFalse())
+	})
+})
+
 var _ = Describe("Sync", func() {
 	var (
 		ctrl      *gomock.Controller
@@ -176,11 +330,15 @@ var _ = Describe("Sync", func() {
 
 			gomock.InOrder(
 				maker.EXPECT().MakeJobTemplate(ctx, mld, mld.Owner, true).Return(&j, nil),
-				jobhelper.EXPECT().GetModuleJobByKernel(ctx, mld.Name, mld.Namespace, kernelVersion, utils.JobTypeBuild, mld.Owner).Return(&j, nil),
+				jobhelper.EXPECT().GetModuleJobByKernel(ctx, mld.Name, mld.Namespace, kernelVersion, "", utils.JobTypeBuild, mld.Owner).Return(&j, nil),
 				jobhelper.EXPECT().IsJobChanged(&j, &j).Return(false, nil),
 				jobhelper.EXPECT().GetJobStatus(&j).Return(jobStatus, nil),
 			)
 
+			if jobStatus == utils.StatusCompleted {
+				reg.EXPECT().Invalidate(imageName)
+			}
+
 			mgr := NewBuildManager(clnt, maker, jobhelper, reg)
 
 			res, err := mgr.Sync(ctx, mld, true, mld.Owner)
@@ -228,7 +386,7 @@ var _ = Describe("Sync", func() {
 
 		gomock.InOrder(
 			maker.EXPECT().MakeJobTemplate(ctx, mld, mld.Owner, true).Return(&j, nil),
-			jobhelper.EXPECT().GetModuleJobByKernel(ctx, mld.Name, mld.Namespace, kernelVersion, utils.JobTypeBuild, mld.Owner).Return(nil, utils.ErrNoMatchingJob),
+			jobhelper.EXPECT().GetModuleJobByKernel(ctx, mld.Name, mld.Namespace, kernelVersion, "", utils.JobTypeBuild, mld.Owner).Return(nil, utils.ErrNoMatchingJob),
 			jobhelper.EXPECT().CreateJob(ctx, &j).Return(errors.New("some error")),
 		)
 
@@ -257,7 +415,7 @@ var _ = Describe("Sync", func() {
 
 		gomock.InOrder(
 			maker.EXPECT().MakeJobTemplate(ctx, mld, mld.Owner, true).Return(&j, nil),
-			jobhelper.EXPECT().GetModuleJobByKernel(ctx, mld.Name, mld.Namespace, kernelVersion, utils.JobTypeBuild, mld.Owner).Return(nil, utils.ErrNoMatchingJob),
+			jobhelper.EXPECT().GetModuleJobByKernel(ctx, mld.Name, mld.Namespace, kernelVersion, "", utils.JobTypeBuild, mld.Owner).Return(nil, utils.ErrNoMatchingJob),
 			jobhelper.EXPECT().CreateJob(ctx, &j).Return(nil),
 		)
 
@@ -299,7 +457,7 @@ var _ = Describe("Sync", func() {
 
 		gomock.InOrder(
 			maker.EXPECT().MakeJobTemplate(ctx, mld, mld.Owner, true).Return(&newJob, nil),
-			jobhelper.EXPECT().GetModuleJobByKernel(ctx, mld.Name, mld.Namespace, kernelVersion, utils.JobTypeBuild, mld.Owner).Return(&j, nil),
+			jobhelper.EXPECT().GetModuleJobByKernel(ctx, mld.Name, mld.Namespace, kernelVersion, "", utils.JobTypeBuild, mld.Owner).Return(&j, nil),
 			jobhelper.EXPECT().IsJobChanged(&j, &newJob).Return(true, nil),
 			jobhelper.EXPECT().DeleteJob(ctx, &j).Return(nil),
 		)
@@ -375,7 +533,7 @@ var _ = Describe("GarbageCollect", func() {
 				}
 			}
 
-			names, err := mgr.GarbageCollect(context.Background(), mod.Name, mod.Namespace, &mod)
+			names, _, err := mgr.GarbageCollect(context.Background(), mod.Name, mod.Namespace, gc.Policy{}, &mod)
 
 			if expectsErr {
 				Expect(err).To(HaveOccurred())
@@ -390,4 +548,46 @@ var _ = Describe("GarbageCollect", func() {
 		Entry("0 job succeeded", batchv1.JobStatus{Succeeded: 0}, batchv1.JobStatus{Succeeded: 0}, false),
 		Entry("error occured", batchv1.JobStatus{Succeeded: 0}, batchv1.JobStatus{Succeeded: 0}, true),
 	)
+
+	It("should apply the TTLs of the policy", func() {
+		ctx := context.Background()
+		now := time.Now()
+
+		recentSuccess := batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{Name: "recent-success"},
+			Status:     batchv1.JobStatus{Succeeded: 1, CompletionTime: &metav1.Time{Time: now.Add(-time.Minute)}},
+		}
+		oldSuccess := batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{Name: "old-success"},
+			Status:     batchv1.JobStatus{Succeeded: 1, CompletionTime: &metav1.Time{Time: now.Add(-time.Hour)}},
+		}
+		oldFailure := batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{Name: "old-failure"},
+			Status: batchv1.JobStatus{
+				Failed: 1,
+				Conditions: []batchv1.JobCondition{
+					{Type: batchv1.JobFailed, Status: v1.ConditionTrue, LastTransitionTime: metav1.Time{Time: now.Add(-2 * time.Hour)}},
+				},
+			},
+		}
+
+		failedJobsTTL := time.Hour
+
+		gomock.InOrder(
+			jobhelper.EXPECT().GetModuleJobs(ctx, mod.Name, mod.Namespace, utils.JobTypeBuild, &mod).Return(
+				[]batchv1.Job{recentSuccess, oldSuccess, oldFailure},
+				nil,
+			),
+			jobhelper.EXPECT().DeleteJob(ctx, &oldSuccess),
+			jobhelper.EXPECT().DeleteJob(ctx, &oldFailure),
+		)
+
+		policy := gc.Policy{SucceededJobsTTL: 10 * time.Minute, FailedJobsTTL: &failedJobsTTL}
+
+		names, requeueAfter, err := mgr.GarbageCollect(ctx, mod.Name, mod.Namespace, policy, &mod)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(names).To(Equal([]string{"old-success", "old-failure"}))
+		// recent-success expires 9 minutes from now
+		Expect(requeueAfter).To(BeNumerically("~", 9*time.Minute, time.Second))
+	})
 })
@@ -37,6 +37,21 @@ func (m *MockMaker) EXPECT() *MockMakerMockRecorder {
 	return m.recorder
 }
 
+// BuildHash mocks base method.
+func (m *MockMaker) BuildHash(ctx context.Context, mld *api.ModuleLoaderData) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BuildHash", ctx, mld)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BuildHash indicates an expected call of BuildHash.
+func (mr *MockMakerMockRecorder) BuildHash(ctx, mld interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BuildHash", reflect.TypeOf((*MockMaker)(nil).BuildHash), ctx, mld)
+}
+
 // MakeJobTemplate mocks base method.
 func (m *MockMaker) MakeJobTemplate(ctx context.Context, mld *api.ModuleLoaderData, owner v10.Object, pushImage bool) (*v1.Job, error) {
 	m.ctrl.T.Helper()
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	hubv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api-hub/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -18,6 +19,7 @@ import (
 	"github.com/kubernetes-sigs/kernel-module-management/internal/api"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/build"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/constants"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/gc"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/module"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/sign"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/utils"
@@ -29,7 +31,7 @@ type ClusterAPI interface {
 	RequestedManagedClusterModule(ctx context.Context, namespacedName types.NamespacedName) (*hubv1beta1.ManagedClusterModule, error)
 	SelectedManagedClusters(ctx context.Context, mcm *hubv1beta1.ManagedClusterModule) (*clusterv1.ManagedClusterList, error)
 	BuildAndSign(ctx context.Context, mcm hubv1beta1.ManagedClusterModule, cluster clusterv1.ManagedCluster) (bool, error)
-	GarbageCollectBuilds(ctx context.Context, mcm hubv1beta1.ManagedClusterModule) ([]string, error)
+	GarbageCollectBuilds(ctx context.Context, mcm hubv1beta1.ManagedClusterModule, policy gc.Policy) ([]string, time.Duration, error)
 }
 
 type clusterAPI struct {
@@ -140,8 +142,8 @@ func (c *clusterAPI) BuildAndSign(
 	return completedSuccessfully, nil
 }
 
-func (c *clusterAPI) GarbageCollectBuilds(ctx context.Context, mcm hubv1beta1.ManagedClusterModule) ([]string, error) {
-	return c.buildAPI.GarbageCollect(ctx, mcm.Name, c.namespace, &mcm)
+func (c *clusterAPI) GarbageCollectBuilds(ctx context.Context, mcm hubv1beta1.ManagedClusterModule, policy gc.Policy) ([]string, time.Duration, error) {
+	return c.buildAPI.GarbageCollect(ctx, mcm.Name, c.namespace, policy, &mcm)
 }
 
 func (c *clusterAPI) kernelMappingsByKernelVersion(
@@ -169,9 +171,9 @@ func (c *clusterAPI) kernelMappingsByKernelVersion(
 			continue
 		}
 
-		mld, err := c.kernelAPI.GetModuleLoaderDataForKernel(mod, kernelVersion)
+		mld, err := c.kernelAPI.GetModuleLoaderDataForKernel(ctx, mod, kernelVersion, nil)
 		if err != nil {
-			kernelVersionLogger.Info("no suitable container image found; skipping kernel version")
+			kernelVersionLogger.Info("no suitable container image found; skipping kernel version", "error", err)
 			continue
 		}
 
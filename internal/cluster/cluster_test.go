@@ -3,6 +3,7 @@ package cluster
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	. "github.com/onsi/ginkgo/v2"
@@ -18,6 +19,7 @@ import (
 	"github.com/kubernetes-sigs/kernel-module-management/internal/build"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/client"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/constants"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/gc"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/module"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/sign"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/utils"
@@ -222,7 +224,7 @@ var _ = Describe("ClusterAPI", func() {
 
 		It("should do nothing when no kernel mappings are found", func() {
 			gomock.InOrder(
-				mockKM.EXPECT().GetModuleLoaderDataForKernel(&mod, kernelVersion).Return(nil, errors.New("generic-error")),
+				mockKM.EXPECT().GetModuleLoaderDataForKernel(gomock.Any(), &mod, kernelVersion, nil).Return(nil, errors.New("generic-error")),
 			)
 
 			c := NewClusterAPI(clnt, mockKM, mockBM, mockSM, namespace)
@@ -253,7 +255,7 @@ var _ = Describe("ClusterAPI", func() {
 
 		It("should do nothing when Build and Sign are not needed", func() {
 			gomock.InOrder(
-				mockKM.EXPECT().GetModuleLoaderDataForKernel(&mod, kernelVersion).Return(&mld, nil),
+				mockKM.EXPECT().GetModuleLoaderDataForKernel(gomock.Any(), &mod, kernelVersion, nil).Return(&mld, nil),
 				mockBM.EXPECT().ShouldSync(gomock.Any(), &mld).Return(false, nil),
 				mockSM.EXPECT().ShouldSync(gomock.Any(), &mld).Return(false, nil),
 			)
@@ -267,7 +269,7 @@ var _ = Describe("ClusterAPI", func() {
 
 		It("should run build sync if needed", func() {
 			gomock.InOrder(
-				mockKM.EXPECT().GetModuleLoaderDataForKernel(&mod, kernelVersion).Return(&mld, nil),
+				mockKM.EXPECT().GetModuleLoaderDataForKernel(gomock.Any(), &mod, kernelVersion, nil).Return(&mld, nil),
 				mockBM.EXPECT().ShouldSync(gomock.Any(), &mld).Return(true, nil),
 				mockBM.EXPECT().Sync(gomock.Any(), &mld, true, mcm).Return(utils.Status(utils.StatusCompleted), nil),
 				mockSM.EXPECT().ShouldSync(gomock.Any(), &mld).Return(false, nil),
@@ -282,7 +284,7 @@ var _ = Describe("ClusterAPI", func() {
 
 		It("should return an error when build sync errors", func() {
 			gomock.InOrder(
-				mockKM.EXPECT().GetModuleLoaderDataForKernel(&mod, kernelVersion).Return(&mld, nil),
+				mockKM.EXPECT().GetModuleLoaderDataForKernel(gomock.Any(), &mod, kernelVersion, nil).Return(&mld, nil),
 				mockBM.EXPECT().ShouldSync(gomock.Any(), &mld).Return(true, nil),
 				mockBM.EXPECT().Sync(gomock.Any(), &mld, true, mcm).Return(utils.Status(""), errors.New("test-error")),
 			)
@@ -296,7 +298,7 @@ var _ = Describe("ClusterAPI", func() {
 
 		It("should run sign sync if needed", func() {
 			gomock.InOrder(
-				mockKM.EXPECT().GetModuleLoaderDataForKernel(&mod, kernelVersion).Return(&mld, nil),
+				mockKM.EXPECT().GetModuleLoaderDataForKernel(gomock.Any(), &mod, kernelVersion, nil).Return(&mld, nil),
 				mockBM.EXPECT().ShouldSync(gomock.Any(), &mld).Return(false, nil),
 				mockSM.EXPECT().ShouldSync(gomock.Any(), &mld).Return(true, nil),
 				mockSM.EXPECT().Sync(gomock.Any(), &mld, "", true, mcm).Return(utils.Status(utils.StatusInProgress), nil),
@@ -311,7 +313,7 @@ var _ = Describe("ClusterAPI", func() {
 
 		It("should return an error when sign sync errors", func() {
 			gomock.InOrder(
-				mockKM.EXPECT().GetModuleLoaderDataForKernel(&mod, kernelVersion).Return(&mld, nil),
+				mockKM.EXPECT().GetModuleLoaderDataForKernel(gomock.Any(), &mod, kernelVersion, nil).Return(&mld, nil),
 				mockBM.EXPECT().ShouldSync(gomock.Any(), &mld).Return(false, nil),
 				mockSM.EXPECT().ShouldSync(gomock.Any(), &mld).Return(true, nil),
 				mockSM.EXPECT().Sync(gomock.Any(), &mld, "", true, mcm).Return(utils.Status(""), errors.New("test-error")),
@@ -326,7 +328,7 @@ var _ = Describe("ClusterAPI", func() {
 
 		It("should not run sign sync when build sync does not complete", func() {
 			gomock.InOrder(
-				mockKM.EXPECT().GetModuleLoaderDataForKernel(&mod, kernelVersion).Return(&mld, nil),
+				mockKM.EXPECT().GetModuleLoaderDataForKernel(gomock.Any(), &mod, kernelVersion, nil).Return(&mld, nil),
 				mockBM.EXPECT().ShouldSync(gomock.Any(), &mld).Return(true, nil),
 				mockBM.EXPECT().Sync(gomock.Any(), &mld, true, mcm).Return(utils.Status(utils.StatusInProgress), nil),
 			)
@@ -340,7 +342,7 @@ var _ = Describe("ClusterAPI", func() {
 
 		It("should run both build sync and sign sync when build is completed", func() {
 			gomock.InOrder(
-				mockKM.EXPECT().GetModuleLoaderDataForKernel(&mod, kernelVersion).Return(&mld, nil),
+				mockKM.EXPECT().GetModuleLoaderDataForKernel(gomock.Any(), &mod, kernelVersion, nil).Return(&mld, nil),
 				mockBM.EXPECT().ShouldSync(gomock.Any(), &mld).Return(true, nil),
 				mockBM.EXPECT().Sync(gomock.Any(), &mld, true, mcm).Return(utils.Status(utils.StatusCompleted), nil),
 				mockSM.EXPECT().ShouldSync(gomock.Any(), &mld).Return(true, nil),
@@ -366,15 +368,16 @@ var _ = Describe("ClusterAPI", func() {
 			ctx := context.Background()
 
 			gomock.InOrder(
-				mockBM.EXPECT().GarbageCollect(ctx, mcm.Name, namespace, &mcm).Return(collectedBuilds, nil),
+				mockBM.EXPECT().GarbageCollect(ctx, mcm.Name, namespace, gc.Policy{}, &mcm).Return(collectedBuilds, time.Minute, nil),
 			)
 
 			c := NewClusterAPI(clnt, nil, mockBM, nil, namespace)
 
-			collected, err := c.GarbageCollectBuilds(ctx, mcm)
+			collected, requeueAfter, err := c.GarbageCollectBuilds(ctx, mcm, gc.Policy{})
 
 			Expect(err).ToNot(HaveOccurred())
 			Expect(collected).To(Equal(collectedBuilds))
+			Expect(requeueAfter).To(Equal(time.Minute))
 		})
 
 		It("should return an error when garbage collection fails", func() {
@@ -384,12 +387,12 @@ var _ = Describe("ClusterAPI", func() {
 			ctx := context.Background()
 
 			gomock.InOrder(
-				mockBM.EXPECT().GarbageCollect(ctx, mcm.Name, namespace, &mcm).Return(nil, errors.New("test")),
+				mockBM.EXPECT().GarbageCollect(ctx, mcm.Name, namespace, gc.Policy{}, &mcm).Return(nil, time.Duration(0), errors.New("test")),
 			)
 
 			c := NewClusterAPI(clnt, nil, mockBM, nil, namespace)
 
-			_, err := c.GarbageCollectBuilds(ctx, mcm)
+			_, _, err := c.GarbageCollectBuilds(ctx, mcm, gc.Policy{})
 
 			Expect(err).To(HaveOccurred())
 		})
@@ -7,9 +7,11 @@ package cluster
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	v1beta1 "github.com/kubernetes-sigs/kernel-module-management/api-hub/v1beta1"
+	gc "github.com/kubernetes-sigs/kernel-module-management/internal/gc"
 	types "k8s.io/apimachinery/pkg/types"
 	v1 "open-cluster-management.io/api/cluster/v1"
 )
@@ -53,18 +55,19 @@ func (mr *MockClusterAPIMockRecorder) BuildAndSign(ctx, mcm, cluster interface{}
 }
 
 // GarbageCollectBuilds mocks base method.
-func (m *MockClusterAPI) GarbageCollectBuilds(ctx context.Context, mcm v1beta1.ManagedClusterModule) ([]string, error) {
+func (m *MockClusterAPI) GarbageCollectBuilds(ctx context.Context, mcm v1beta1.ManagedClusterModule, policy gc.Policy) ([]string, time.Duration, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GarbageCollectBuilds", ctx, mcm)
+	ret := m.ctrl.Call(m, "GarbageCollectBuilds", ctx, mcm, policy)
 	ret0, _ := ret[0].([]string)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
+	ret1, _ := ret[1].(time.Duration)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
 }
 
 // GarbageCollectBuilds indicates an expected call of GarbageCollectBuilds.
-func (mr *MockClusterAPIMockRecorder) GarbageCollectBuilds(ctx, mcm interface{}) *gomock.Call {
+func (mr *MockClusterAPIMockRecorder) GarbageCollectBuilds(ctx, mcm, policy interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GarbageCollectBuilds", reflect.TypeOf((*MockClusterAPI)(nil).GarbageCollectBuilds), ctx, mcm)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GarbageCollectBuilds", reflect.TypeOf((*MockClusterAPI)(nil).GarbageCollectBuilds), ctx, mcm, policy)
 }
 
 // RequestedManagedClusterModule mocks base method.
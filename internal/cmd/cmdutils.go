@@ -5,8 +5,12 @@ import (
 	"fmt"
 	"os"
 	"runtime/debug"
+	"time"
 
 	"github.com/go-logr/logr"
+
+	"github.com/kubernetes-sigs/kernel-module-management/internal/constants"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/gc"
 )
 
 func FatalError(l logr.Logger, err error, msg string, fields ...interface{}) {
@@ -23,6 +27,49 @@ func GetEnvOrFatalError(name string, logger logr.Logger) string {
 	return val
 }
 
+// GetDurationEnv returns the duration in the environment variable name, or defaultValue if it is not set.
+func GetDurationEnv(name string, defaultValue time.Duration) (time.Duration, error) {
+	val := os.Getenv(name)
+	if val == "" {
+		return defaultValue, nil
+	}
+
+	d, err := time.ParseDuration(val)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration in %s: %v", name, err)
+	}
+
+	return d, nil
+}
+
+// GetGarbageCollectionPolicy returns the operator's default garbage-collection policy from the environment.
+// Succeeded jobs and orphaned DaemonSets are deleted immediately and failed jobs are kept, unless configured otherwise.
+func GetGarbageCollectionPolicy() (gc.Policy, error) {
+	var (
+		policy gc.Policy
+		err    error
+	)
+
+	if policy.SucceededJobsTTL, err = GetDurationEnv(constants.GCSucceededJobsTTLEnvVar, 0); err != nil {
+		return policy, err
+	}
+
+	if os.Getenv(constants.GCFailedJobsTTLEnvVar) != "" {
+		ttl, err := GetDurationEnv(constants.GCFailedJobsTTLEnvVar, 0)
+		if err != nil {
+			return policy, err
+		}
+
+		policy.FailedJobsTTL = &ttl
+	}
+
+	if policy.OrphanedDaemonSetGracePeriod, err = GetDurationEnv(constants.GCOrphanedDaemonSetGracePeriodEnvVar, 0); err != nil {
+		return policy, err
+	}
+
+	return policy, nil
+}
+
 func GitCommit() (string, error) {
 	bi, ok := debug.ReadBuildInfo()
 	if !ok {
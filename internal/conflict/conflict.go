@@ -0,0 +1,134 @@
+package conflict
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	kmmv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/utils"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Conflict is another Module loading the same kernel module on some of the nodes targeted by a Module.
+type Conflict struct {
+	// Module is the other Module.
+	Module types.NamespacedName
+
+	// Nodes are the names of the nodes claimed by Module.
+	Nodes []string
+}
+
+//go:generate mockgen -source=conflict.go -package=conflict -destination=mock_conflict.go
+
+// Detector finds the Modules that load the same kernel module as a Module on the same nodes.
+type Detector interface {
+	FindConflicts(ctx context.Context, mod *kmmv1beta1.Module, nodes []v1.Node) ([]Conflict, error)
+}
+
+type detector struct {
+	client client.Client
+}
+
+func NewDetector(client client.Client) Detector {
+	return &detector{client: client}
+}
+
+// FindConflicts returns the Modules that claim nodes for the kernel module of mod, sorted by namespace and name.
+// Another Module claims a node that both select if it is not suspended, not excluded from that node and:
+//   - its kernel module is loaded on the node, and the one of mod is not, or;
+//   - the kernel modules of both or of neither are loaded on the node, and the other Module was created first.
+func (d *detector) FindConflicts(ctx context.Context, mod *kmmv1beta1.Module, nodes []v1.Node) ([]Conflict, error) {
+	kmodName := KernelModuleName(mod)
+	if kmodName == "" || len(nodes) == 0 {
+		return nil, nil
+	}
+
+	mods := kmmv1beta1.ModuleList{}
+
+	if err := d.client.List(ctx, &mods); err != nil {
+		return nil, fmt.Errorf("could not list modules: %v", err)
+	}
+
+	conflicts := make([]Conflict, 0)
+
+	for i := range mods.Items {
+		other := &mods.Items[i]
+
+		if (other.Namespace == mod.Namespace && other.Name == mod.Name) || KernelModuleName(other) != kmodName {
+			continue
+		}
+
+		sel := labels.SelectorFromSet(other.Spec.Selector)
+		nodeNames := make([]string, 0)
+
+		for _, n := range nodes {
+			if sel.Matches(labels.Set(n.Labels)) && claims(other, mod, &n) {
+				nodeNames = append(nodeNames, n.Name)
+			}
+		}
+
+		if len(nodeNames) > 0 {
+			conflicts = append(conflicts, Conflict{
+				Module: types.NamespacedName{Namespace: other.Namespace, Name: other.Name},
+				Nodes:  nodeNames,
+			})
+		}
+	}
+
+	sort.Slice(conflicts, func(i, j int) bool {
+		return conflicts[i].Module.String() < conflicts[j].Module.String()
+	})
+
+	return conflicts, nil
+}
+
+// KernelModuleName returns the name of the kernel module loaded by mod.
+// The kernel does not distinguish dashes from underscores in module names, so neither does KernelModuleName.
+func KernelModuleName(mod *kmmv1beta1.Module) string {
+	return strings.ReplaceAll(mod.Spec.ModuleLoader.Container.Modprobe.ModuleName, "-", "_")
+}
+
+// IsLoaded returns true if node reports that the kernel module of mod is loaded on it.
+func IsLoaded(mod *kmmv1beta1.Module, node *v1.Node) bool {
+	_, ok := node.Labels[utils.GetModuleLoadedKernelLabelName(mod.Namespace, mod.Name)]
+	return ok
+}
+
+// claims returns true if other claims node, which both other and mod select.
+func claims(other, mod *kmmv1beta1.Module, node *v1.Node) bool {
+	// KMM does not reconcile a suspended Module, so it cannot take or keep nodes
+	if other.Spec.Suspend {
+		return false
+	}
+
+	// a Module excluded from the node cannot claim it, which keeps the decision stable while pods are replaced
+	if _, ok := node.Labels[utils.GetModuleConflictLabelName(other.Namespace, other.Name)]; ok {
+		return false
+	}
+
+	// both ModuleLoaders may run on the node, for example until the loaded labels were set after an upgrade of KMM
+	if otherLoaded, modLoaded := IsLoaded(other, node), IsLoaded(mod, node); otherLoaded != modLoaded {
+		return otherLoaded
+	}
+
+	return createdBefore(other, mod)
+}
+
+// createdBefore returns true if a was created before b.
+// Modules created at the same time are ordered by namespace and name.
+func createdBefore(a, b *kmmv1beta1.Module) bool {
+	if !a.CreationTimestamp.Equal(&b.CreationTimestamp) {
+		return a.CreationTimestamp.Before(&b.CreationTimestamp)
+	}
+
+	if a.Namespace != b.Namespace {
+		return a.Namespace < b.Namespace
+	}
+
+	return a.Name < b.Name
+}
@@ -0,0 +1,221 @@
+package conflict
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	kmmv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/client"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/utils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var _ = Describe("FindConflicts", func() {
+	var (
+		clnt *client.MockClient
+		d    Detector
+		ctx  = context.Background()
+	)
+
+	BeforeEach(func() {
+		ctrl := gomock.NewController(GinkgoT())
+		clnt = client.NewMockClient(ctrl)
+		d = NewDetector(clnt)
+	})
+
+	now := time.Now()
+
+	makeModule := func(namespace, name, kmodName string, created time.Time) kmmv1beta1.Module {
+		mod := kmmv1beta1.Module{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				Namespace:         namespace,
+				CreationTimestamp: metav1.NewTime(created),
+			},
+			Spec: kmmv1beta1.ModuleSpec{
+				Selector: map[string]string{"key": "value"},
+			},
+		}
+
+		mod.Spec.ModuleLoader.Container.Modprobe.ModuleName = kmodName
+
+		return mod
+	}
+
+	makeNode := func(name string, labels ...string) v1.Node {
+		nodeLabels := map[string]string{"key": "value"}
+
+		for _, l := range labels {
+			nodeLabels[l] = ""
+		}
+
+		return v1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: nodeLabels},
+		}
+	}
+
+	expectModules := func(mods ...kmmv1beta1.Module) {
+		clnt.EXPECT().List(ctx, &kmmv1beta1.ModuleList{}).DoAndReturn(
+			func(_ interface{}, list *kmmv1beta1.ModuleList, _ ...ctrlclient.ListOption) error {
+				list.Items = mods
+				return nil
+			},
+		)
+	}
+
+	It("should return an error if the Modules cannot be listed", func() {
+		mod := makeModule("ns", "mod", "kmod", now)
+
+		clnt.EXPECT().List(ctx, &kmmv1beta1.ModuleList{}).Return(errors.New("some error"))
+
+		_, err := d.FindConflicts(ctx, &mod, []v1.Node{makeNode("node")})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should not list the Modules if there are no nodes", func() {
+		mod := makeModule("ns", "mod", "kmod", now)
+
+		Expect(
+			d.FindConflicts(ctx, &mod, nil),
+		).To(
+			BeEmpty(),
+		)
+	})
+
+	It("should report the older Modules loading the same kernel module on the same nodes", func() {
+		mod := makeModule("ns", "mod", "my-kmod", now)
+		older := makeModule("other-ns", "older", "my_kmod", now.Add(-time.Hour))
+		newer := makeModule("other-ns", "newer", "my-kmod", now.Add(time.Hour))
+		otherKmod := makeModule("other-ns", "other-kmod", "other-kmod", now.Add(-time.Hour))
+		otherSelector := makeModule("other-ns", "other-selector", "my-kmod", now.Add(-time.Hour))
+		otherSelector.Spec.Selector = map[string]string{"key": "other-value"}
+
+		expectModules(mod, newer, otherKmod, otherSelector, older)
+
+		Expect(
+			d.FindConflicts(ctx, &mod, []v1.Node{makeNode("node1"), makeNode("node2")}),
+		).To(
+			Equal([]Conflict{
+				{
+					Module: types.NamespacedName{Namespace: "other-ns", Name: "older"},
+					Nodes:  []string{"node1", "node2"},
+				},
+			}),
+		)
+	})
+
+	It("should let the Module whose kernel module is loaded keep the node", func() {
+		mod := makeModule("ns", "mod", "kmod", now)
+		older := makeModule("ns", "older", "kmod", now.Add(-time.Hour))
+		newer := makeModule("ns", "newer", "kmod", now.Add(time.Hour))
+
+		expectModules(mod, older, newer)
+
+		nodes := []v1.Node{
+			makeNode("loaded-by-mod", utils.GetModuleLoadedKernelLabelName("ns", "mod")),
+			makeNode("loaded-by-newer", utils.GetModuleLoadedKernelLabelName("ns", "newer")),
+		}
+
+		Expect(
+			d.FindConflicts(ctx, &mod, nodes),
+		).To(
+			Equal([]Conflict{
+				{
+					Module: types.NamespacedName{Namespace: "ns", Name: "newer"},
+					Nodes:  []string{"loaded-by-newer"},
+				},
+				{
+					Module: types.NamespacedName{Namespace: "ns", Name: "older"},
+					Nodes:  []string{"loaded-by-newer"},
+				},
+			}),
+		)
+	})
+
+	It("should let the oldest Module keep a node on which several Modules load the kernel module", func() {
+		mod := makeModule("ns", "mod", "kmod", now)
+		older := makeModule("ns", "older", "kmod", now.Add(-time.Hour))
+		newer := makeModule("ns", "newer", "kmod", now.Add(time.Hour))
+
+		expectModules(mod, older, newer)
+
+		node := makeNode(
+			"node",
+			utils.GetModuleLoadedKernelLabelName("ns", "mod"),
+			utils.GetModuleLoadedKernelLabelName("ns", "older"),
+			utils.GetModuleLoadedKernelLabelName("ns", "newer"),
+		)
+
+		Expect(
+			d.FindConflicts(ctx, &mod, []v1.Node{node}),
+		).To(
+			Equal([]Conflict{
+				{
+					Module: types.NamespacedName{Namespace: "ns", Name: "older"},
+					Nodes:  []string{"node"},
+				},
+			}),
+		)
+	})
+
+	It("should not let a Module excluded from a node claim it", func() {
+		mod := makeModule("ns", "mod", "kmod", now)
+		older := makeModule("ns", "older", "kmod", now.Add(-time.Hour))
+
+		expectModules(mod, older)
+
+		nodes := []v1.Node{
+			makeNode("node", utils.GetModuleConflictLabelName("ns", "older")),
+		}
+
+		Expect(
+			d.FindConflicts(ctx, &mod, nodes),
+		).To(
+			BeEmpty(),
+		)
+	})
+
+	It("should not let a suspended Module claim nodes", func() {
+		mod := makeModule("ns", "mod", "kmod", now)
+		older := makeModule("ns", "older", "kmod", now.Add(-time.Hour))
+		older.Spec.Suspend = true
+
+		expectModules(mod, older)
+
+		nodes := []v1.Node{
+			makeNode("node", utils.GetModuleLoadedKernelLabelName("ns", "older")),
+		}
+
+		Expect(
+			d.FindConflicts(ctx, &mod, nodes),
+		).To(
+			BeEmpty(),
+		)
+	})
+
+	It("should order Modules created at the same time by namespace and name", func() {
+		mod := makeModule("ns", "b", "kmod", now)
+		a := makeModule("ns", "a", "kmod", now)
+		c := makeModule("ns", "c", "kmod", now)
+
+		expectModules(mod, a, c)
+
+		Expect(
+			d.FindConflicts(ctx, &mod, []v1.Node{makeNode("node")}),
+		).To(
+			Equal([]Conflict{
+				{
+					Module: types.NamespacedName{Namespace: "ns", Name: "a"},
+					Nodes:  []string{"node"},
+				},
+			}),
+		)
+	})
+})
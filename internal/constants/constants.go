@@ -1,19 +1,42 @@
 package constants
 
 const (
-	ModuleNameLabel      = "kmm.node.kubernetes.io/module.name"
-	NodeLabelerFinalizer = "kmm.node.kubernetes.io/node-labeler"
-	TargetKernelTarget   = "kmm.node.kubernetes.io/target-kernel"
-	DaemonSetRole        = "kmm.node.kubernetes.io/role"
-	JobType              = "kmm.node.kubernetes.io/job-type"
-	JobHashAnnotation    = "kmm.node.kubernetes.io/last-hash"
-	KernelLabel          = "kmm.node.kubernetes.io/kernel-version.full"
+	ModuleNameLabel           = "kmm.node.kubernetes.io/module.name"
+	NodeLabelerFinalizer      = "kmm.node.kubernetes.io/node-labeler"
+	TargetKernelTarget        = "kmm.node.kubernetes.io/target-kernel"
+	DaemonSetRole             = "kmm.node.kubernetes.io/role"
+	JobType                   = "kmm.node.kubernetes.io/job-type"
+	JobHashAnnotation         = "kmm.node.kubernetes.io/last-hash"
+	KernelLabel               = "kmm.node.kubernetes.io/kernel-version.full"
+	ArchitectureLabel         = "kmm.node.kubernetes.io/architecture"
+	OrphanedSinceAnnotation   = "kmm.node.kubernetes.io/orphaned-since"
+	PendingKernelAnnotation   = "kmm.node.kubernetes.io/pending-kernel-version"
+	PodTemplateHashAnnotation = "kmm.node.kubernetes.io/pod-template-hash"
+	ModuleVersionLabel        = "kmm.node.kubernetes.io/module-version"
+	ModuleLoaderContainerName = "module-loader"
+	BuildHashImageLabel       = "kmm.node.kubernetes.io/build-hash"
+
+	RefreshImageDigestsAnnotation = "kmm.node.kubernetes.io/refresh-image-digests"
+	ModuleFinalizer               = "kmm.node.kubernetes.io/module-finalizer"
+	RetiredUnversionedAnnotation  = "kmm.node.kubernetes.io/retired-unversioned"
+	SkipUnloadAnnotation          = "kmm.node.kubernetes.io/skip-unload"
+	ModuleInUseTerminationMessage = "KMM: the kernel module is in use and could not be unloaded"
 
 	ManagedClusterModuleNameLabel  = "kmm.node.kubernetes.io/managedclustermodule.name"
 	KernelVersionsClusterClaimName = "kernel-versions.kmm.node.kubernetes.io"
 	DockerfileCMKey                = "dockerfile"
+	CABundleCMKey                  = "ca-bundle.crt"
 	PublicSignDataKey              = "cert"
 	PrivateSignDataKey             = "key"
 
-	OperatorNamespaceEnvVar = "OPERATOR_NAMESPACE"
+	OperatorNamespaceEnvVar       = "OPERATOR_NAMESPACE"
+	RegistryMirrorsConfigEnvVar   = "REGISTRY_MIRRORS_CONFIG"
+	RegistryCacheTTLEnvVar        = "REGISTRY_CACHE_TTL"
+	GlobalPullSecretEnvVar        = "GLOBAL_PULL_SECRET"
+	RegistryDockerConfigEnvVar    = "REGISTRY_DOCKER_CONFIG"
+	PendingKernelAnnotationEnvVar = "PENDING_KERNEL_ANNOTATION"
+
+	GCSucceededJobsTTLEnvVar             = "GC_SUCCEEDED_JOBS_TTL"
+	GCFailedJobsTTLEnvVar                = "GC_FAILED_JOBS_TTL"
+	GCOrphanedDaemonSetGracePeriodEnvVar = "GC_ORPHANED_DAEMONSET_GRACE_PERIOD"
 )
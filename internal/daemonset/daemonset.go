@@ -5,12 +5,18 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	kmmv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/api"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/constants"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/gc"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/registry"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/utils"
+	"github.com/mitchellh/hashstructure"
 	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/sets"
@@ -25,49 +31,182 @@ const (
 	nodeLibModulesVolumeName       = "node-lib-modules"
 	nodeVarLibFirmwarePath         = "/var/lib/firmware"
 	nodeVarLibFirmwareVolumeName   = "node-var-lib-firmware"
+	podInfoPath                    = "/etc/podinfo"
+	podInfoVolumeName              = "pod-info"
+	podAnnotationsFile             = "annotations"
 	devicePluginKernelVersion      = ""
 )
 
 //go:generate mockgen -source=daemonset.go -package=daemonset -destination=mock_daemonset.go
 
 type DaemonSetCreator interface {
-	GarbageCollect(ctx context.Context, existingDS map[string]*appsv1.DaemonSet, validKernels sets.Set[string]) ([]string, error)
+	GarbageCollect(ctx context.Context, existingDS map[string]*appsv1.DaemonSet, validKernels sets.Set[string], policy gc.Policy) ([]string, time.Duration, error)
 	ModuleDaemonSetsByKernelVersion(ctx context.Context, name, namespace string) (map[string]*appsv1.DaemonSet, error)
 	SetDriverContainerAsDesired(ctx context.Context, ds *appsv1.DaemonSet, mld *api.ModuleLoaderData) error
 	SetDevicePluginAsDesired(ctx context.Context, ds *appsv1.DaemonSet, mod *kmmv1beta1.Module) error
 	GetNodeLabelFromPod(pod *v1.Pod, moduleName string) string
+	RetireUnversioned(ctx context.Context, existingDS map[string]*appsv1.DaemonSet, versionLabel string) error
 }
 
 type daemonSetGenerator struct {
 	client      client.Client
 	kernelLabel string
 	scheme      *runtime.Scheme
+	mirrors     *registry.MirrorConfig
 }
 
-func NewCreator(client client.Client, kernelLabel string, scheme *runtime.Scheme) DaemonSetCreator {
+func NewCreator(client client.Client, kernelLabel string, scheme *runtime.Scheme, mirrors *registry.MirrorConfig) DaemonSetCreator {
 	return &daemonSetGenerator{
 		client:      client,
 		kernelLabel: kernelLabel,
 		scheme:      scheme,
+		mirrors:     mirrors,
 	}
 }
 
-func (dc *daemonSetGenerator) GarbageCollect(ctx context.Context, existingDS map[string]*appsv1.DaemonSet, validKernels sets.Set[string]) ([]string, error) {
+// GarbageCollect deletes the ModuleLoader DaemonSets that have not been targeting any node for the grace period of
+// policy.
+// It returns the names of the deleted DaemonSets, and how long until the grace period of the earliest kept orphaned
+// DaemonSet expires.
+func (dc *daemonSetGenerator) GarbageCollect(
+	ctx context.Context,
+	existingDS map[string]*appsv1.DaemonSet,
+	validKernels sets.Set[string],
+	policy gc.Policy) ([]string, time.Duration, error) {
 	deleted := make([]string, 0)
+	now := time.Now()
+
+	var requeueAfter time.Duration
 
 	for kernelVersion, ds := range existingDS {
-		if !dc.isDevicePluginDaemonSet(ds) && !validKernels.Has(kernelVersion) {
-			if err := dc.client.Delete(ctx, ds); err != nil {
-				return nil, fmt.Errorf("could not delete DaemonSet %s: %v", ds.Name, err)
+		if dc.isDevicePluginDaemonSet(ds) {
+			continue
+		}
+
+		// DaemonSets of a previous module version, or created before the Module was versioned, are kept as long as
+		// nodes are labeled with that version or not labeled with any
+		retiring := ds.Labels[constants.ModuleVersionLabel] != "" || isRetiredUnversioned(ds)
+
+		if validKernels.Has(kernelVersion) || (retiring && ds.Status.DesiredNumberScheduled > 0) {
+			if err := dc.setOrphanedSince(ctx, ds, nil); err != nil {
+				return nil, 0, fmt.Errorf("could not unmark DaemonSet %s as orphaned: %v", ds.Name, err)
 			}
 
-			deleted = append(deleted, ds.Name)
+			continue
 		}
+
+		orphanedSince, err := time.Parse(time.RFC3339, ds.Annotations[constants.OrphanedSinceAnnotation])
+		if err != nil {
+			orphanedSince = now
+		}
+
+		if !policy.ShouldDeleteOrphanedDaemonSet(orphanedSince, now) {
+			if err = dc.setOrphanedSince(ctx, ds, &orphanedSince); err != nil {
+				return nil, 0, fmt.Errorf("could not mark DaemonSet %s as orphaned: %v", ds.Name, err)
+			}
+
+			requeueAfter = gc.Earliest(requeueAfter, policy.OrphanedDaemonSetRequeueAfter(orphanedSince, now))
+
+			continue
+		}
+
+		if err = dc.client.Delete(ctx, ds); err != nil {
+			return nil, 0, fmt.Errorf("could not delete DaemonSet %s: %v", ds.Name, err)
+		}
+
+		deleted = append(deleted, ds.Name)
 	}
 
-	return deleted, nil
+	return deleted, requeueAfter, nil
 }
 
+// RetireUnversioned makes the ModuleLoader DaemonSets in existingDS that were created before their Module was
+// versioned stop running on the nodes labeled with versionLabel, so that those nodes can move to a version.
+// Their update strategy is set to OnDelete first: their pods keep running on the nodes that are not labeled yet,
+// instead of being replaced because of the new node affinity.
+func (dc *daemonSetGenerator) RetireUnversioned(ctx context.Context, existingDS map[string]*appsv1.DaemonSet, versionLabel string) error {
+	for _, ds := range existingDS {
+		if dc.isDevicePluginDaemonSet(ds) || ds.Labels[constants.ModuleVersionLabel] != "" || isRetiredUnversioned(ds) {
+			continue
+		}
+
+		patchFrom := client.MergeFrom(ds.DeepCopy())
+
+		ds.Spec.UpdateStrategy = appsv1.DaemonSetUpdateStrategy{Type: appsv1.OnDeleteDaemonSetStrategyType}
+
+		withoutVersion := v1.NodeSelectorRequirement{Key: versionLabel, Operator: v1.NodeSelectorOpDoesNotExist}
+
+		podSpec := &ds.Spec.Template.Spec
+
+		if podSpec.Affinity == nil {
+			podSpec.Affinity = &v1.Affinity{}
+		}
+
+		if podSpec.Affinity.NodeAffinity == nil {
+			podSpec.Affinity.NodeAffinity = &v1.NodeAffinity{}
+		}
+
+		nodeAffinity := podSpec.Affinity.NodeAffinity
+
+		if nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+			nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution = &v1.NodeSelector{}
+		}
+
+		terms := nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+
+		if len(terms) == 0 {
+			terms = []v1.NodeSelectorTerm{{}}
+		}
+
+		// the terms are ORed: each of them must exclude the labeled nodes
+		for i := range terms {
+			terms[i].MatchExpressions = append(terms[i].MatchExpressions, withoutVersion)
+		}
+
+		nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms = terms
+
+		if ds.Annotations == nil {
+			ds.Annotations = make(map[string]string)
+		}
+
+		ds.Annotations[constants.RetiredUnversionedAnnotation] = "true"
+
+		if err := dc.client.Patch(ctx, ds, patchFrom); err != nil {
+			return fmt.Errorf("could not patch DaemonSet %s: %v", ds.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// isRetiredUnversioned returns true if ds was created before its Module was versioned, and has been made to stop
+// running on the nodes labeled with a version.
+func isRetiredUnversioned(ds *appsv1.DaemonSet) bool {
+	return ds.Annotations[constants.RetiredUnversionedAnnotation] != ""
+}
+
+// setOrphanedSince records since when ds has not been targeting any node, or removes that record if since is nil.
+// The DaemonSet is only patched if the record changes.
+func (dc *daemonSetGenerator) setOrphanedSince(ctx context.Context, ds *appsv1.DaemonSet, since *time.Time) error {
+	current, ok := ds.Annotations[constants.OrphanedSinceAnnotation]
+
+	if (since == nil && !ok) || (since != nil && current == since.UTC().Format(time.RFC3339)) {
+		return nil
+	}
+
+	patchFrom := client.MergeFrom(ds.DeepCopy())
+
+	if since == nil {
+		delete(ds.Annotations, constants.OrphanedSinceAnnotation)
+	} else {
+		metav1.SetMetaDataAnnotation(&ds.ObjectMeta, constants.OrphanedSinceAnnotation, since.UTC().Format(time.RFC3339))
+	}
+
+	return dc.client.Patch(ctx, ds, patchFrom)
+}
+
+// ModuleDaemonSetsByKernelVersion returns the DaemonSets of a Module, keyed by api.DaemonSetKey.
+// The device plugin DaemonSet is keyed by an empty string.
 func (dc *daemonSetGenerator) ModuleDaemonSetsByKernelVersion(ctx context.Context, name, namespace string) (map[string]*appsv1.DaemonSet, error) {
 	dsList, err := dc.moduleDaemonSets(ctx, name, namespace)
 	if err != nil {
@@ -79,12 +218,12 @@ func (dc *daemonSetGenerator) ModuleDaemonSetsByKernelVersion(ctx context.Contex
 	for i := 0; i < len(dsList); i++ {
 		ds := dsList[i]
 
-		kernelVersion := ds.Labels[dc.kernelLabel]
-		if dsByKernelVersion[kernelVersion] != nil {
-			return nil, fmt.Errorf("multiple DaemonSets found for kernel %q", kernelVersion)
+		key := api.DaemonSetKey(ds.Labels[dc.kernelLabel], ds.Labels[constants.ArchitectureLabel], ds.Labels[constants.ModuleVersionLabel])
+		if dsByKernelVersion[key] != nil {
+			return nil, fmt.Errorf("multiple DaemonSets found for kernel %q", key)
 		}
 
-		dsByKernelVersion[kernelVersion] = &ds
+		dsByKernelVersion[key] = &ds
 	}
 
 	return dsByKernelVersion, nil
@@ -114,22 +253,124 @@ func (dc *daemonSetGenerator) SetDriverContainerAsDesired(
 		constants.DaemonSetRole:   "module-loader",
 	}
 
-	ds.SetLabels(
-		OverrideLabels(ds.GetLabels(), standardLabels),
-	)
-
 	nodeSelector := CopyMapStringString(mld.Selector)
 	nodeSelector[dc.kernelLabel] = kernelVersion
 
-	nodeLibModulesPath := "/lib/modules/" + kernelVersion
+	// the DaemonSets created by previous versions of KMM, whose selector does not include the architecture label, are
+	// adopted by the architecture of the nodes running their kernel: their pods are not pinned to it, as changing the
+	// pod template would replace the pods on every node
+	adopted := ds.Spec.Selector != nil && ds.Spec.Selector.MatchLabels[constants.ArchitectureLabel] == ""
+
+	if arch := mld.Architecture; arch != "" && !adopted {
+		standardLabels[constants.ArchitectureLabel] = arch
+		nodeSelector[v1.LabelArchStable] = arch
+	}
+
+	if version := mld.ModuleVersion; version != "" {
+		standardLabels[constants.ModuleVersionLabel] = version
+		nodeSelector[utils.GetModuleScheduledVersionLabelName(mld.Namespace, mld.Name)] = version
+	}
+
+	// a DaemonSet retired when its Module was versioned runs on all its nodes again if the Module is not versioned
+	// anymore
+	delete(ds.Annotations, constants.RetiredUnversionedAnnotation)
+
+	dsLabels := OverrideLabels(ds.GetLabels(), standardLabels)
+	if arch := mld.Architecture; arch != "" {
+		// the architecture label of the DaemonSet only keys it, and does not change its pods
+		dsLabels[constants.ArchitectureLabel] = arch
+	}
+
+	ds.SetLabels(dsLabels)
+
+	image := mld.ContainerImage
+	if dc.mirrors != nil && dc.mirrors.RewriteLoaderImage {
+		image = dc.mirrors.Rewrite(image)
+	}
+
+	template := makeModuleLoaderTemplate(mld, image, standardLabels, nodeSelector, false)
+
+	if keepLegacyTemplate(ds, mld, image, standardLabels, nodeSelector) {
+		template = ds.Spec.Template
+	} else {
+		// KMM replaces the outdated pods itself when mld has an upgrade strategy; the hash lets it find them.
+		// It is always set, so that enabling the strategy does not make every pod outdated.
+		hash, err := hashstructure.Hash(template, nil)
+		if err != nil {
+			return fmt.Errorf("could not hash the pod template: %v", err)
+		}
+
+		template.Annotations = map[string]string{constants.PodTemplateHashAnnotation: fmt.Sprintf("%d", hash)}
+	}
+
+	selector := &metav1.LabelSelector{MatchLabels: standardLabels}
+
+	if ds.Spec.Selector != nil {
+		// the selector of a DaemonSet is immutable: the DaemonSets created by previous versions of KMM keep theirs,
+		// which does not include the architecture and version labels
+		selector = ds.Spec.Selector
+	}
+
+	ds.Spec = appsv1.DaemonSetSpec{
+		Template: template,
+		Selector: selector,
+	}
+
+	if mld.UpgradeStrategy != nil {
+		ds.Spec.UpdateStrategy = appsv1.DaemonSetUpdateStrategy{Type: appsv1.OnDeleteDaemonSetStrategyType}
+	}
+
+	return controllerutil.SetControllerReference(mld.Owner, ds, dc.scheme)
+}
+
+// keepLegacyTemplate returns true if the pod template of ds was rendered by a version of KMM that predates the
+// skip-unload annotation, and is otherwise up to date.
+// Such a template, whose PreStop hook always unloads the kernel module and which has no readiness probe, is kept until
+// it has to change anyway, so that upgrading KMM does not replace the ModuleLoader pods on every node.
+// The Keep unload policy needs the current template.
+func keepLegacyTemplate(ds *appsv1.DaemonSet, mld *api.ModuleLoaderData, image string, labels, nodeSelector map[string]string) bool {
+	if ds.Spec.Selector == nil || mld.UnloadPolicy == kmmv1beta1.UnloadPolicyKeep {
+		return false
+	}
+
+	existing := ds.Spec.Template
+
+	for _, v := range existing.Spec.Volumes {
+		if v.Name == podInfoVolumeName {
+			return false
+		}
+	}
+
+	// those pods run the image by the tag it was pinned from, until the DaemonSet is updated
+	if tag, _, ok := strings.Cut(image, "@"); ok && len(existing.Spec.Containers) > 0 && existing.Spec.Containers[0].Image == tag {
+		image = tag
+	}
+
+	legacy := makeModuleLoaderTemplate(mld, image, labels, nodeSelector, true)
+
+	// DeepDerivative ignores the fields that are unset in legacy, which the API server may have defaulted in the
+	// existing template; the fields that KMM may unset are compared on their own
+	return equality.Semantic.DeepDerivative(legacy, existing) &&
+		equality.Semantic.DeepEqual(legacy.Labels, existing.Labels) &&
+		equality.Semantic.DeepEqual(legacy.Spec.Affinity, existing.Spec.Affinity) &&
+		equality.Semantic.DeepEqual(legacy.Spec.NodeSelector, existing.Spec.NodeSelector) &&
+		equality.Semantic.DeepEqual(legacy.Spec.ImagePullSecrets, existing.Spec.ImagePullSecrets) &&
+		equality.Semantic.DeepEqual(legacy.Spec.Tolerations, existing.Spec.Tolerations) &&
+		legacy.Spec.ServiceAccountName == existing.Spec.ServiceAccountName
+}
+
+// makeModuleLoaderTemplate returns the pod template of the ModuleLoader DaemonSet for mld.
+// A legacy template is the one rendered by the versions of KMM that predate the skip-unload annotation.
+func makeModuleLoaderTemplate(mld *api.ModuleLoaderData, image string, labels, nodeSelector map[string]string, legacy bool) v1.PodTemplateSpec {
+	nodeLibModulesPath := "/lib/modules/" + mld.KernelVersion
 
 	hostPathDirectory := v1.HostPathDirectory
 	hostPathDirectoryOrCreate := v1.HostPathDirectoryOrCreate
 
 	container := v1.Container{
 		Command:         []string{"sleep", "infinity"},
-		Name:            "module-loader",
-		Image:           mld.ContainerImage,
+		Name:            constants.ModuleLoaderContainerName,
+		Image:           image,
 		ImagePullPolicy: mld.ImagePullPolicy,
 		Lifecycle: &v1.Lifecycle{
 			PostStart: &v1.LifecycleHandler{
@@ -139,10 +380,15 @@ func (dc *daemonSetGenerator) SetDriverContainerAsDesired(
 			},
 			PreStop: &v1.LifecycleHandler{
 				Exec: &v1.ExecAction{
-					Command: MakeUnloadCommand(mld.Modprobe, mld.Name),
+					Command: makePreStopCommand(mld.Modprobe, mld.Name),
 				},
 			},
 		},
+		ReadinessProbe: &v1.Probe{
+			ProbeHandler: v1.ProbeHandler{
+				Exec: &v1.ExecAction{Command: makeReadinessCommand()},
+			},
+		},
 		SecurityContext: &v1.SecurityContext{
 			AllowPrivilegeEscalation: pointer.Bool(false),
 			Capabilities: &v1.Capabilities{
@@ -159,6 +405,11 @@ func (dc *daemonSetGenerator) SetDriverContainerAsDesired(
 				ReadOnly:  true,
 				MountPath: nodeLibModulesPath,
 			},
+			{
+				Name:      podInfoVolumeName,
+				ReadOnly:  true,
+				MountPath: podInfoPath,
+			},
 		},
 	}
 
@@ -172,6 +423,48 @@ func (dc *daemonSetGenerator) SetDriverContainerAsDesired(
 				},
 			},
 		},
+		{
+			// the PreStop hook reads the annotations of the pod, which are updated in this volume
+			Name: podInfoVolumeName,
+			VolumeSource: v1.VolumeSource{
+				DownwardAPI: &v1.DownwardAPIVolumeSource{
+					Items: []v1.DownwardAPIVolumeFile{
+						{
+							Path:     podAnnotationsFile,
+							FieldRef: &v1.ObjectFieldSelector{FieldPath: "metadata.annotations"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	// another Module already loads the same kernel module on the nodes with the conflict label; the affinity is
+	// always set, so that the pod template does not change when conflicts appear or are resolved
+	affinity := &v1.Affinity{
+		NodeAffinity: &v1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &v1.NodeSelector{
+				NodeSelectorTerms: []v1.NodeSelectorTerm{
+					{
+						MatchExpressions: []v1.NodeSelectorRequirement{
+							{
+								Key:      utils.GetModuleConflictLabelName(mld.Namespace, mld.Name),
+								Operator: v1.NodeSelectorOpNotIn,
+								Values:   []string{""},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	if legacy {
+		container.Lifecycle.PreStop.Exec.Command = MakeUnloadCommand(mld.Modprobe, mld.Name)
+		container.ReadinessProbe = nil
+		container.VolumeMounts = container.VolumeMounts[:1]
+		volumes = volumes[:1]
+		affinity = nil
 	}
 
 	if fw := mld.Modprobe.FirmwarePath; fw != "" {
@@ -194,25 +487,34 @@ func (dc *daemonSetGenerator) SetDriverContainerAsDesired(
 		container.VolumeMounts = append(container.VolumeMounts, firmwareVolumeMount)
 	}
 
-	ds.Spec = appsv1.DaemonSetSpec{
-		Template: v1.PodTemplateSpec{
-			ObjectMeta: metav1.ObjectMeta{
-				Labels:     standardLabels,
-				Finalizers: []string{constants.NodeLabelerFinalizer},
-			},
-			Spec: v1.PodSpec{
-				Containers:         []v1.Container{container},
-				ImagePullSecrets:   GetPodPullSecrets(mld.ImageRepoSecret),
-				NodeSelector:       nodeSelector,
-				PriorityClassName:  "system-node-critical",
-				ServiceAccountName: mld.ServiceAccountName,
-				Volumes:            volumes,
-			},
-		},
-		Selector: &metav1.LabelSelector{MatchLabels: standardLabels},
+	var tolerations []v1.Toleration
+
+	// the ModuleLoader pod must run on the nodes that wait for the kernel module, including those that also wait for
+	// the kernel modules of other Modules
+	for _, taint := range mld.StartupTaints {
+		tolerations = append(tolerations, v1.Toleration{
+			Key:      taint.Key,
+			Operator: v1.TolerationOpExists,
+			Effect:   taint.Effect,
+		})
 	}
 
-	return controllerutil.SetControllerReference(mld.Owner, ds, dc.scheme)
+	return v1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels:     labels,
+			Finalizers: []string{constants.NodeLabelerFinalizer},
+		},
+		Spec: v1.PodSpec{
+			Affinity:           affinity,
+			Containers:         []v1.Container{container},
+			ImagePullSecrets:   GetPodPullSecrets(mld.ImageRepoSecret, mld.ImagePullSecrets),
+			NodeSelector:       nodeSelector,
+			PriorityClassName:  "system-node-critical",
+			ServiceAccountName: mld.ServiceAccountName,
+			Tolerations:        tolerations,
+			Volumes:            volumes,
+		},
+	}
 }
 
 func (dc *daemonSetGenerator) SetDevicePluginAsDesired(
@@ -278,7 +580,7 @@ func (dc *daemonSetGenerator) SetDevicePluginAsDesired(
 					},
 				},
 				PriorityClassName:  "system-node-critical",
-				ImagePullSecrets:   GetPodPullSecrets(mod.Spec.ImageRepoSecret),
+				ImagePullSecrets:   GetPodPullSecrets(mod.Spec.ImageRepoSecret, mod.Spec.DevicePlugin.ImagePullSecrets),
 				NodeSelector:       map[string]string{getDriverContainerNodeLabel(mod.Name): ""},
 				ServiceAccountName: mod.Spec.DevicePlugin.ServiceAccountName,
 				Volumes:            append([]v1.Volume{devicePluginVolume}, mod.Spec.DevicePlugin.Volumes...),
@@ -292,7 +594,7 @@ func (dc *daemonSetGenerator) SetDevicePluginAsDesired(
 func (dc *daemonSetGenerator) GetNodeLabelFromPod(pod *v1.Pod, moduleName string) string {
 	kernelVersion := pod.Labels[dc.kernelLabel]
 	if kernelVersion == devicePluginKernelVersion {
-		return getDevicePluginNodeLabel(moduleName)
+		return GetDevicePluginNodeLabel(moduleName)
 	}
 	return getDriverContainerNodeLabel(moduleName)
 }
@@ -328,7 +630,8 @@ func getDriverContainerNodeLabel(moduleName string) string {
 	return fmt.Sprintf("kmm.node.kubernetes.io/%s.ready", moduleName)
 }
 
-func getDevicePluginNodeLabel(moduleName string) string {
+// GetDevicePluginNodeLabel returns the label set on nodes where the device plugin of moduleName is ready.
+func GetDevicePluginNodeLabel(moduleName string) string {
 	return fmt.Sprintf("kmm.node.kubernetes.io/%s.device-plugin-ready", moduleName)
 }
 
@@ -340,12 +643,19 @@ func GetDevicePluginKernelVersion() string {
 	return devicePluginKernelVersion
 }
 
-func GetPodPullSecrets(secret *v1.LocalObjectReference) []v1.LocalObjectReference {
-	if secret == nil {
+// GetPodPullSecrets returns the image pull secrets of a pod: repoSecret, if set, followed by secrets.
+func GetPodPullSecrets(repoSecret *v1.LocalObjectReference, secrets []v1.LocalObjectReference) []v1.LocalObjectReference {
+	if repoSecret == nil && len(secrets) == 0 {
 		return nil
 	}
 
-	return []v1.LocalObjectReference{*secret}
+	pullSecrets := make([]v1.LocalObjectReference, 0, len(secrets)+1)
+
+	if repoSecret != nil {
+		pullSecrets = append(pullSecrets, *repoSecret)
+	}
+
+	return append(pullSecrets, secrets...)
 }
 
 func OverrideLabels(labels, overrides map[string]string) map[string]string {
@@ -407,6 +717,35 @@ func MakeLoadCommand(spec kmmv1beta1.ModprobeSpec, modName string) []string {
 	return append(loadCommandShell, loadCommand.String())
 }
 
+// makePreStopCommand returns the unload command, skipped if the pod has the skip-unload annotation.
+// If the kernel module is in use, or if it is still loaded after the unload command, the command does not unload it and
+// writes constants.ModuleInUseTerminationMessage to the termination log of the container.
+func makePreStopCommand(spec kmmv1beta1.ModprobeSpec, modName string) []string {
+	command := MakeUnloadCommand(spec, modName)
+
+	prefix := "if " + skipUnloadCheck + "; then echo 'Not unloading the module'; exit 0; fi; "
+
+	suffix := ""
+
+	if spec.ModuleName != "" {
+		// sysfs uses underscores in module names
+		sysfsModulePath := "/sys/module/" + strings.ReplaceAll(spec.ModuleName, "-", "_")
+		inUse := fmt.Sprintf("echo '%s' | tee %s", constants.ModuleInUseTerminationMessage, v1.TerminationMessagePathDefault)
+
+		prefix += fmt.Sprintf(
+			`if [ -e %[1]s/refcnt ] && { [ "$(cat %[1]s/refcnt)" != 0 ] || [ -n "$(ls -A %[1]s/holders 2>/dev/null)" ]; }; then %[2]s; exit 0; fi; `,
+			sysfsModulePath,
+			inUse,
+		)
+
+		suffix = fmt.Sprintf("; if [ -e %s/refcnt ]; then %s; fi", sysfsModulePath, inUse)
+	}
+
+	command[len(command)-1] = prefix + command[len(command)-1] + suffix
+
+	return command
+}
+
 func MakeUnloadCommand(spec kmmv1beta1.ModprobeSpec, modName string) []string {
 	unloadCommandShell := []string{
 		"/bin/sh",
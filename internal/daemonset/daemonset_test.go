@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/google/go-cmp/cmp"
@@ -11,14 +12,22 @@ import (
 	"github.com/kubernetes-sigs/kernel-module-management/internal/api"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/client"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/constants"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/gc"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/registry"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/utils"
+	"github.com/mitchellh/hashstructure"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/utils/pointer"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
 
 const (
@@ -35,7 +44,7 @@ var (
 )
 
 var _ = Describe("SetDriverContainerAsDesired", func() {
-	dg := NewCreator(nil, kernelLabel, scheme)
+	dg := NewCreator(nil, kernelLabel, scheme, nil)
 
 	BeforeEach(func() {
 		ctrl = gomock.NewController(GinkgoT())
@@ -79,7 +88,367 @@ var _ = Describe("SetDriverContainerAsDesired", func() {
 		err := dg.SetDriverContainerAsDesired(context.Background(), &ds, &mld)
 		Expect(err).NotTo(HaveOccurred())
 		Expect(ds.Spec.Template.Spec.Containers).To(HaveLen(1))
-		Expect(ds.Spec.Template.Spec.Volumes).To(HaveLen(1))
+		Expect(ds.Spec.Template.Spec.Volumes).To(HaveLen(2))
+	})
+
+	It("should pin the DaemonSet to the architecture if it is set", func() {
+		mld := api.ModuleLoaderData{
+			Selector:       map[string]string{"has-feature-x": "true"},
+			Owner:          &kmmv1beta1.Module{},
+			ContainerImage: "some images",
+			KernelVersion:  kernelVersion,
+			Architecture:   "arm64",
+		}
+
+		ds := appsv1.DaemonSet{}
+
+		err := dg.SetDriverContainerAsDesired(context.Background(), &ds, &mld)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ds.Labels).To(HaveKeyWithValue(constants.ArchitectureLabel, "arm64"))
+		Expect(ds.Spec.Selector.MatchLabels).To(HaveKeyWithValue(constants.ArchitectureLabel, "arm64"))
+		Expect(ds.Spec.Template.Spec.NodeSelector).To(HaveKeyWithValue(v1.LabelArchStable, "arm64"))
+		Expect(mld.Selector).NotTo(HaveKey(v1.LabelArchStable))
+	})
+
+	It("should only target the nodes labeled with the module version if it is set", func() {
+		mld := api.ModuleLoaderData{
+			Name:           moduleName,
+			Namespace:      namespace,
+			Selector:       map[string]string{"has-feature-x": "true"},
+			Owner:          &kmmv1beta1.Module{},
+			ContainerImage: "some images",
+			KernelVersion:  kernelVersion,
+			ModuleVersion:  "v2",
+		}
+
+		ds := appsv1.DaemonSet{}
+
+		err := dg.SetDriverContainerAsDesired(context.Background(), &ds, &mld)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ds.Labels).To(HaveKeyWithValue(constants.ModuleVersionLabel, "v2"))
+		Expect(ds.Spec.Selector.MatchLabels).To(HaveKeyWithValue(constants.ModuleVersionLabel, "v2"))
+		Expect(ds.Spec.Template.Spec.NodeSelector).To(
+			HaveKeyWithValue("kmm.node.kubernetes.io/scheduled-version-module.namespace.module-name", "v2"),
+		)
+		Expect(mld.Selector).To(HaveLen(1))
+	})
+
+	It("should tolerate the startup taints if they are set", func() {
+		mld := api.ModuleLoaderData{
+			Selector:       map[string]string{"has-feature-x": "true"},
+			Owner:          &kmmv1beta1.Module{},
+			ContainerImage: "some images",
+			KernelVersion:  kernelVersion,
+			StartupTaints: []v1.Taint{
+				{Key: "example.org/not-ready", Effect: v1.TaintEffectNoSchedule},
+				{Key: "example.org/other-not-ready", Effect: v1.TaintEffectNoExecute},
+			},
+		}
+
+		ds := appsv1.DaemonSet{}
+
+		err := dg.SetDriverContainerAsDesired(context.Background(), &ds, &mld)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ds.Spec.Template.Spec.Tolerations).To(
+			Equal([]v1.Toleration{
+				{Key: "example.org/not-ready", Operator: v1.TolerationOpExists, Effect: v1.TaintEffectNoSchedule},
+				{Key: "example.org/other-not-ready", Operator: v1.TolerationOpExists, Effect: v1.TaintEffectNoExecute},
+			}),
+		)
+	})
+
+	It("should use the rewritten image if configured to", func() {
+		mld := api.ModuleLoaderData{
+			Owner:          &kmmv1beta1.Module{},
+			ContainerImage: "quay.io/org/image:tag",
+			KernelVersion:  kernelVersion,
+		}
+
+		mirrors := &registry.MirrorConfig{
+			Registries: []registry.MirrorRegistry{
+				{Prefix: "quay.io", Location: "local.registry/quay"},
+			},
+		}
+
+		ds := appsv1.DaemonSet{}
+
+		err := NewCreator(nil, kernelLabel, scheme, mirrors).SetDriverContainerAsDesired(context.Background(), &ds, &mld)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ds.Spec.Template.Spec.Containers[0].Image).To(Equal("quay.io/org/image:tag"))
+
+		mirrors.RewriteLoaderImage = true
+
+		err = NewCreator(nil, kernelLabel, scheme, mirrors).SetDriverContainerAsDesired(context.Background(), &ds, &mld)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ds.Spec.Template.Spec.Containers[0].Image).To(Equal("local.registry/quay/org/image:tag"))
+	})
+
+	It("should let KMM replace the pods if an upgrade strategy is set", func() {
+		mld := api.ModuleLoaderData{
+			Owner:          &kmmv1beta1.Module{},
+			ContainerImage: "some image",
+			KernelVersion:  kernelVersion,
+		}
+
+		ds := appsv1.DaemonSet{}
+
+		err := dg.SetDriverContainerAsDesired(context.Background(), &ds, &mld)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ds.Spec.UpdateStrategy.Type).To(BeEmpty())
+		Expect(ds.Spec.Template.Annotations).To(HaveKey(constants.PodTemplateHashAnnotation))
+
+		hash := ds.Spec.Template.Annotations[constants.PodTemplateHashAnnotation]
+
+		mld.UpgradeStrategy = &kmmv1beta1.UpgradeStrategy{MaxParallelNodes: 1}
+
+		err = dg.SetDriverContainerAsDesired(context.Background(), &ds, &mld)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ds.Spec.UpdateStrategy.Type).To(Equal(appsv1.OnDeleteDaemonSetStrategyType))
+		Expect(ds.Spec.Template.Annotations).To(HaveKeyWithValue(constants.PodTemplateHashAnnotation, hash))
+
+		mld.ContainerImage = "other image"
+
+		err = dg.SetDriverContainerAsDesired(context.Background(), &ds, &mld)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ds.Spec.Template.Annotations[constants.PodTemplateHashAnnotation]).NotTo(Equal(hash))
+	})
+
+	It("should always avoid the nodes with the conflict label", func() {
+		mld := api.ModuleLoaderData{
+			Name:           moduleName,
+			Namespace:      namespace,
+			ContainerImage: "some image",
+			KernelVersion:  kernelVersion,
+			Owner:          &kmmv1beta1.Module{},
+		}
+
+		ds := appsv1.DaemonSet{}
+
+		err := dg.SetDriverContainerAsDesired(context.Background(), &ds, &mld)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ds.Spec.Template.Spec.Affinity).To(Equal(&v1.Affinity{
+			NodeAffinity: &v1.NodeAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution: &v1.NodeSelector{
+					NodeSelectorTerms: []v1.NodeSelectorTerm{
+						{
+							MatchExpressions: []v1.NodeSelectorRequirement{
+								{
+									Key:      utils.GetModuleConflictLabelName(namespace, moduleName),
+									Operator: v1.NodeSelectorOpNotIn,
+									Values:   []string{""},
+								},
+							},
+						},
+					},
+				},
+			},
+		}))
+	})
+
+	Context("with a DaemonSet created by an earlier version of KMM", func() {
+		const image = "example.org/repo/image:tag"
+
+		legacyLabels := map[string]string{
+			constants.ModuleNameLabel: moduleName,
+			kernelLabel:               kernelVersion,
+			constants.DaemonSetRole:   "module-loader",
+		}
+
+		var (
+			existing   appsv1.DaemonSet
+			fakeClient ctrlclient.Client
+			mld        api.ModuleLoaderData
+		)
+
+		BeforeEach(func() {
+			hostPathDirectory := v1.HostPathDirectory
+
+			modprobe := kmmv1beta1.ModprobeSpec{ModuleName: "kmod"}
+
+			// the template rendered by earlier versions of KMM, with the fields defaulted by the API server
+			existing = appsv1.DaemonSet{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "legacy",
+					Namespace: namespace,
+					Labels:    legacyLabels,
+				},
+				Spec: appsv1.DaemonSetSpec{
+					Selector: &metav1.LabelSelector{MatchLabels: legacyLabels},
+					Template: v1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{
+							Labels:     legacyLabels,
+							Finalizers: []string{constants.NodeLabelerFinalizer},
+						},
+						Spec: v1.PodSpec{
+							Containers: []v1.Container{
+								{
+									Command:         []string{"sleep", "infinity"},
+									Name:            constants.ModuleLoaderContainerName,
+									Image:           image,
+									ImagePullPolicy: v1.PullIfNotPresent,
+									Lifecycle: &v1.Lifecycle{
+										PostStart: &v1.LifecycleHandler{
+											Exec: &v1.ExecAction{Command: MakeLoadCommand(modprobe, moduleName)},
+										},
+										PreStop: &v1.LifecycleHandler{
+											Exec: &v1.ExecAction{Command: MakeUnloadCommand(modprobe, moduleName)},
+										},
+									},
+									SecurityContext: &v1.SecurityContext{
+										AllowPrivilegeEscalation: pointer.Bool(false),
+										Capabilities: &v1.Capabilities{
+											Add: []v1.Capability{"SYS_MODULE"},
+										},
+										RunAsUser:      pointer.Int64(0),
+										SELinuxOptions: &v1.SELinuxOptions{Type: "spc_t"},
+									},
+									TerminationMessagePath:   v1.TerminationMessagePathDefault,
+									TerminationMessagePolicy: v1.TerminationMessageReadFile,
+									VolumeMounts: []v1.VolumeMount{
+										{
+											Name:      nodeLibModulesVolumeName,
+											ReadOnly:  true,
+											MountPath: "/lib/modules/" + kernelVersion,
+										},
+									},
+								},
+							},
+							DNSPolicy:         v1.DNSClusterFirst,
+							NodeSelector:      map[string]string{"key": "value", kernelLabel: kernelVersion},
+							PriorityClassName: "system-node-critical",
+							RestartPolicy:     v1.RestartPolicyAlways,
+							Volumes: []v1.Volume{
+								{
+									Name: nodeLibModulesVolumeName,
+									VolumeSource: v1.VolumeSource{
+										HostPath: &v1.HostPathVolumeSource{
+											Path: "/lib/modules/" + kernelVersion,
+											Type: &hostPathDirectory,
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			}
+
+			fakeClient = fake.NewClientBuilder().WithScheme(scheme).WithObjects(&existing).Build()
+
+			mld = api.ModuleLoaderData{
+				Name:           moduleName,
+				Namespace:      namespace,
+				ContainerImage: image + "@sha256:1234",
+				KernelVersion:  kernelVersion,
+				Modprobe:       modprobe,
+				Selector:       map[string]string{"key": "value"},
+				Owner:          &kmmv1beta1.Module{ObjectMeta: metav1.ObjectMeta{Name: moduleName, Namespace: namespace}},
+			}
+		})
+
+		setAsDesired := func() appsv1.DaemonSet {
+			ds := appsv1.DaemonSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "legacy", Namespace: namespace},
+			}
+
+			_, err := controllerutil.CreateOrPatch(context.Background(), fakeClient, &ds, func() error {
+				return dg.SetDriverContainerAsDesired(context.Background(), &ds, &mld)
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			patched := appsv1.DaemonSet{}
+
+			err = fakeClient.Get(context.Background(), types.NamespacedName{Name: "legacy", Namespace: namespace}, &patched)
+			Expect(err).NotTo(HaveOccurred())
+
+			return patched
+		}
+
+		It("should keep the pod template unchanged after an upgrade of KMM", func() {
+			mld.UpgradeStrategy = &kmmv1beta1.UpgradeStrategy{MaxParallelNodes: 1}
+
+			patched := setAsDesired()
+			Expect(patched.Spec.Template).To(Equal(existing.Spec.Template))
+			Expect(patched.Spec.UpdateStrategy.Type).To(Equal(appsv1.OnDeleteDaemonSetStrategyType))
+		})
+
+		It("should render the current pod template once the DaemonSet has to change", func() {
+			mld.ContainerImage = "example.org/repo/image:other-tag"
+
+			patched := setAsDesired()
+			Expect(patched.Spec.Template.Annotations).To(HaveKey(constants.PodTemplateHashAnnotation))
+			Expect(patched.Spec.Template.Spec.Affinity).NotTo(BeNil())
+			Expect(patched.Spec.Template.Spec.Volumes).To(ContainElement(HaveField("Name", podInfoVolumeName)))
+			Expect(patched.Spec.Template.Spec.Containers[0].ReadinessProbe).NotTo(BeNil())
+			Expect(patched.Spec.Template.Spec.Containers[0].Lifecycle.PreStop.Exec.Command).To(
+				Equal(makePreStopCommand(mld.Modprobe, moduleName)),
+			)
+		})
+
+		It("should render the current pod template if the Module keeps the kernel module loaded", func() {
+			mld.UnloadPolicy = kmmv1beta1.UnloadPolicyKeep
+
+			patched := setAsDesired()
+			Expect(patched.Spec.Template.Spec.Volumes).To(ContainElement(HaveField("Name", podInfoVolumeName)))
+			Expect(patched.Spec.Template.Spec.Containers[0].Image).To(Equal(mld.ContainerImage))
+		})
+	})
+
+	It("should keep the selector and the pods of an existing DaemonSet", func() {
+		legacyLabels := map[string]string{
+			constants.ModuleNameLabel: moduleName,
+			kernelLabel:               kernelVersion,
+			constants.DaemonSetRole:   "module-loader",
+		}
+
+		existing := appsv1.DaemonSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "legacy",
+				Namespace: namespace,
+				Labels:    legacyLabels,
+			},
+			Spec: appsv1.DaemonSetSpec{
+				Selector: &metav1.LabelSelector{MatchLabels: legacyLabels},
+				Template: v1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: legacyLabels},
+				},
+			},
+		}
+
+		fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(&existing).Build()
+
+		mod := kmmv1beta1.Module{
+			ObjectMeta: metav1.ObjectMeta{Name: moduleName, Namespace: namespace},
+		}
+
+		mld := api.ModuleLoaderData{
+			Name:           moduleName,
+			Namespace:      namespace,
+			ContainerImage: "some image",
+			KernelVersion:  kernelVersion,
+			Architecture:   "amd64",
+			ModuleVersion:  "v1",
+			Owner:          &mod,
+		}
+
+		ds := appsv1.DaemonSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "legacy", Namespace: namespace},
+		}
+
+		_, err := controllerutil.CreateOrPatch(context.Background(), fakeClient, &ds, func() error {
+			return dg.SetDriverContainerAsDesired(context.Background(), &ds, &mld)
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		patched := appsv1.DaemonSet{}
+
+		err = fakeClient.Get(context.Background(), types.NamespacedName{Name: "legacy", Namespace: namespace}, &patched)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(patched.Spec.Selector).To(Equal(&metav1.LabelSelector{MatchLabels: legacyLabels}))
+		Expect(patched.Labels).To(HaveKeyWithValue(constants.ArchitectureLabel, "amd64"))
+		Expect(patched.Spec.Template.Labels).NotTo(HaveKey(constants.ArchitectureLabel))
+		Expect(patched.Spec.Template.Spec.NodeSelector).NotTo(HaveKey(v1.LabelArchStable))
+		Expect(patched.Spec.Template.Labels).To(HaveKeyWithValue(constants.ModuleVersionLabel, "v1"))
 	})
 
 	It("should add the volume and volume mount for firmware if FirmwarePath is set", func() {
@@ -113,10 +482,10 @@ var _ = Describe("SetDriverContainerAsDesired", func() {
 
 		err := dg.SetDriverContainerAsDesired(context.Background(), &ds, &mld)
 		Expect(err).NotTo(HaveOccurred())
-		Expect(ds.Spec.Template.Spec.Volumes).To(HaveLen(2))
-		Expect(ds.Spec.Template.Spec.Volumes[1]).To(Equal(vol))
-		Expect(ds.Spec.Template.Spec.Containers[0].VolumeMounts).To(HaveLen(2))
-		Expect(ds.Spec.Template.Spec.Containers[0].VolumeMounts[1]).To(Equal(volm))
+		Expect(ds.Spec.Template.Spec.Volumes).To(HaveLen(3))
+		Expect(ds.Spec.Template.Spec.Volumes[2]).To(Equal(vol))
+		Expect(ds.Spec.Template.Spec.Containers[0].VolumeMounts).To(HaveLen(3))
+		Expect(ds.Spec.Template.Spec.Containers[0].VolumeMounts[2]).To(Equal(volm))
 	})
 
 	It("should work as expected", func() {
@@ -191,6 +560,23 @@ var _ = Describe("SetDriverContainerAsDesired", func() {
 						Labels:     podLabels,
 					},
 					Spec: v1.PodSpec{
+						Affinity: &v1.Affinity{
+							NodeAffinity: &v1.NodeAffinity{
+								RequiredDuringSchedulingIgnoredDuringExecution: &v1.NodeSelector{
+									NodeSelectorTerms: []v1.NodeSelectorTerm{
+										{
+											MatchExpressions: []v1.NodeSelectorRequirement{
+												{
+													Key:      utils.GetModuleConflictLabelName(namespace, moduleName),
+													Operator: v1.NodeSelectorOpNotIn,
+													Values:   []string{""},
+												},
+											},
+										},
+									},
+								},
+							},
+						},
 						Containers: []v1.Container{
 							{
 								Name:  "module-loader",
@@ -203,10 +589,15 @@ var _ = Describe("SetDriverContainerAsDesired", func() {
 									},
 									PreStop: &v1.LifecycleHandler{
 										Exec: &v1.ExecAction{
-											Command: MakeUnloadCommand(mld.Modprobe, moduleName),
+											Command: makePreStopCommand(mld.Modprobe, moduleName),
 										},
 									},
 								},
+								ReadinessProbe: &v1.Probe{
+									ProbeHandler: v1.ProbeHandler{
+										Exec: &v1.ExecAction{Command: makeReadinessCommand()},
+									},
+								},
 								Command: []string{"sleep", "infinity"},
 								VolumeMounts: []v1.VolumeMount{
 									{
@@ -214,6 +605,11 @@ var _ = Describe("SetDriverContainerAsDesired", func() {
 										ReadOnly:  true,
 										MountPath: fullModulesPath,
 									},
+									{
+										Name:      "pod-info",
+										ReadOnly:  true,
+										MountPath: "/etc/podinfo",
+									},
 								},
 								SecurityContext: &v1.SecurityContext{
 									AllowPrivilegeEscalation: pointer.Bool(false),
@@ -246,12 +642,30 @@ var _ = Describe("SetDriverContainerAsDesired", func() {
 									},
 								},
 							},
+							{
+								Name: "pod-info",
+								VolumeSource: v1.VolumeSource{
+									DownwardAPI: &v1.DownwardAPIVolumeSource{
+										Items: []v1.DownwardAPIVolumeFile{
+											{
+												Path:     "annotations",
+												FieldRef: &v1.ObjectFieldSelector{FieldPath: "metadata.annotations"},
+											},
+										},
+									},
+								},
+							},
 						},
 					},
 				},
 			},
 		}
 
+		hash, err := hashstructure.Hash(expected.Spec.Template, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		expected.Spec.Template.Annotations = map[string]string{constants.PodTemplateHashAnnotation: fmt.Sprintf("%d", hash)}
+
 		Expect(
 			cmp.Equal(expected, ds),
 		).To(
@@ -263,7 +677,7 @@ var _ = Describe("SetDriverContainerAsDesired", func() {
 		It("should return an empty map if no DaemonSets are present", func() {
 			clnt.EXPECT().List(context.Background(), gomock.Any(), gomock.Any())
 
-			dc := NewCreator(clnt, kernelLabel, scheme)
+			dc := NewCreator(clnt, kernelLabel, scheme, nil)
 
 			m, err := dc.ModuleDaemonSetsByKernelVersion(context.Background(), moduleName, namespace)
 			Expect(err).NotTo(HaveOccurred())
@@ -273,7 +687,7 @@ var _ = Describe("SetDriverContainerAsDesired", func() {
 		It("should return an error if two DaemonSets are present for the same kernel", func() {
 			clnt.EXPECT().List(context.Background(), gomock.Any(), gomock.Any()).Return(errors.New("some error"))
 
-			dc := NewCreator(clnt, kernelLabel, scheme)
+			dc := NewCreator(clnt, kernelLabel, scheme, nil)
 
 			_, err := dc.ModuleDaemonSetsByKernelVersion(context.Background(), moduleName, namespace)
 			Expect(err).To(HaveOccurred())
@@ -312,7 +726,7 @@ var _ = Describe("SetDriverContainerAsDesired", func() {
 				},
 			)
 
-			dc := NewCreator(clnt, kernelLabel, scheme)
+			dc := NewCreator(clnt, kernelLabel, scheme, nil)
 
 			m, err := dc.ModuleDaemonSetsByKernelVersion(context.Background(), moduleName, namespace)
 			Expect(err).NotTo(HaveOccurred())
@@ -324,7 +738,7 @@ var _ = Describe("SetDriverContainerAsDesired", func() {
 })
 
 var _ = Describe("SetDevicePluginAsDesired", func() {
-	dg := NewCreator(nil, kernelLabel, scheme)
+	dg := NewCreator(nil, kernelLabel, scheme, nil)
 
 	It("should return an error if the DaemonSet is nil", func() {
 		Expect(
@@ -549,7 +963,7 @@ var _ = Describe("GarbageCollect", func() {
 
 		clnt.EXPECT().Delete(context.Background(), &dsNotLegit).AnyTimes()
 
-		dc := NewCreator(clnt, kernelLabel, scheme)
+		dc := NewCreator(clnt, kernelLabel, scheme, nil)
 
 		existingDS := map[string]*appsv1.DaemonSet{
 			legitKernelVersion:    &dsLegit,
@@ -558,17 +972,81 @@ var _ = Describe("GarbageCollect", func() {
 
 		validKernels := sets.New[string](legitKernelVersion)
 
-		res, err := dc.GarbageCollect(context.Background(), existingDS, validKernels)
+		res, _, err := dc.GarbageCollect(context.Background(), existingDS, validKernels, gc.Policy{})
 		Expect(err).NotTo(HaveOccurred())
 		Expect(res).To(Equal([]string{notLegitName}))
 	})
 
+	It("should keep the DaemonSets of a previous module version while nodes are labeled with it", func() {
+		ctx := context.Background()
+
+		dsInUse := appsv1.DaemonSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "in-use",
+				Namespace: namespace,
+				Labels:    map[string]string{kernelLabel: kernelVersion, constants.ModuleVersionLabel: "v1"},
+			},
+			Status: appsv1.DaemonSetStatus{DesiredNumberScheduled: 1},
+		}
+
+		dsUnused := appsv1.DaemonSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "unused",
+				Namespace: namespace,
+				Labels:    map[string]string{kernelLabel: kernelVersion, constants.ModuleVersionLabel: "v0"},
+			},
+		}
+
+		clnt.EXPECT().Delete(ctx, &dsUnused)
+
+		dc := NewCreator(clnt, kernelLabel, scheme, nil)
+
+		existingDS := map[string]*appsv1.DaemonSet{
+			api.DaemonSetKey(kernelVersion, "", "v1"): &dsInUse,
+			api.DaemonSetKey(kernelVersion, "", "v0"): &dsUnused,
+		}
+
+		res, _, err := dc.GarbageCollect(ctx, existingDS, sets.New[string](api.DaemonSetKey(kernelVersion, "", "v2")), gc.Policy{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).To(Equal([]string{"unused"}))
+	})
+
+	It("should keep an unversioned DaemonSet while nodes are not labeled with a version", func() {
+		ctx := context.Background()
+
+		dsRetired := appsv1.DaemonSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "retired",
+				Namespace:   namespace,
+				Labels:      map[string]string{kernelLabel: kernelVersion},
+				Annotations: map[string]string{constants.RetiredUnversionedAnnotation: "true"},
+			},
+			Status: appsv1.DaemonSetStatus{DesiredNumberScheduled: 1},
+		}
+
+		dc := NewCreator(clnt, kernelLabel, scheme, nil)
+
+		existingDS := map[string]*appsv1.DaemonSet{api.DaemonSetKey(kernelVersion, "", ""): &dsRetired}
+
+		res, _, err := dc.GarbageCollect(ctx, existingDS, sets.New[string](api.DaemonSetKey(kernelVersion, "", "v1")), gc.Policy{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).To(BeEmpty())
+
+		dsRetired.Status.DesiredNumberScheduled = 0
+
+		clnt.EXPECT().Delete(ctx, &dsRetired)
+
+		res, _, err = dc.GarbageCollect(ctx, existingDS, sets.New[string](api.DaemonSetKey(kernelVersion, "", "v1")), gc.Policy{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).To(Equal([]string{"retired"}))
+	})
+
 	It("should return an error if a deletion failed", func() {
 		clnt.EXPECT().Delete(context.Background(), gomock.Any()).Return(
 			errors.New("client returns some error"),
 		)
 
-		dc := NewCreator(clnt, kernelLabel, scheme)
+		dc := NewCreator(clnt, kernelLabel, scheme, nil)
 
 		dsNotLegit := appsv1.DaemonSet{
 			ObjectMeta: metav1.ObjectMeta{Name: "name", Namespace: "namespace", Labels: map[string]string{kernelLabel: "kernel version"}},
@@ -578,9 +1056,184 @@ var _ = Describe("GarbageCollect", func() {
 			"some-kernel-version": &dsNotLegit,
 		}
 
-		_, err := dc.GarbageCollect(context.Background(), existingDS, sets.New[string]())
+		_, _, err := dc.GarbageCollect(context.Background(), existingDS, sets.New[string](), gc.Policy{})
 		Expect(err).To(HaveOccurred())
 	})
+
+	It("should mark orphaned DaemonSets and keep them during the grace period", func() {
+		ctx := context.Background()
+
+		ds := appsv1.DaemonSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "name", Namespace: namespace, Labels: map[string]string{kernelLabel: kernelVersion}},
+		}
+
+		clnt.EXPECT().Patch(ctx, &ds, gomock.Any())
+
+		dc := NewCreator(clnt, kernelLabel, scheme, nil)
+
+		res, requeueAfter, err := dc.GarbageCollect(
+			ctx,
+			map[string]*appsv1.DaemonSet{kernelVersion: &ds},
+			sets.New[string](),
+			gc.Policy{OrphanedDaemonSetGracePeriod: time.Hour},
+		)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).To(BeEmpty())
+		Expect(ds.Annotations).To(HaveKey(constants.OrphanedSinceAnnotation))
+		Expect(requeueAfter).To(BeNumerically("~", time.Hour, time.Second))
+	})
+
+	It("should delete orphaned DaemonSets once the grace period has expired", func() {
+		ctx := context.Background()
+
+		ds := appsv1.DaemonSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "name",
+				Namespace:   namespace,
+				Labels:      map[string]string{kernelLabel: kernelVersion},
+				Annotations: map[string]string{constants.OrphanedSinceAnnotation: time.Now().Add(-2 * time.Hour).Format(time.RFC3339)},
+			},
+		}
+
+		clnt.EXPECT().Delete(ctx, &ds)
+
+		dc := NewCreator(clnt, kernelLabel, scheme, nil)
+
+		res, _, err := dc.GarbageCollect(
+			ctx,
+			map[string]*appsv1.DaemonSet{kernelVersion: &ds},
+			sets.New[string](),
+			gc.Policy{OrphanedDaemonSetGracePeriod: time.Hour},
+		)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).To(Equal([]string{"name"}))
+	})
+
+	It("should unmark DaemonSets whose kernel is in use again", func() {
+		ctx := context.Background()
+
+		ds := appsv1.DaemonSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "name",
+				Namespace:   namespace,
+				Labels:      map[string]string{kernelLabel: kernelVersion},
+				Annotations: map[string]string{constants.OrphanedSinceAnnotation: time.Now().Format(time.RFC3339)},
+			},
+		}
+
+		clnt.EXPECT().Patch(ctx, &ds, gomock.Any())
+
+		dc := NewCreator(clnt, kernelLabel, scheme, nil)
+
+		res, _, err := dc.GarbageCollect(
+			ctx,
+			map[string]*appsv1.DaemonSet{kernelVersion: &ds},
+			sets.New[string](kernelVersion),
+			gc.Policy{OrphanedDaemonSetGracePeriod: time.Hour},
+		)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).To(BeEmpty())
+		Expect(ds.Annotations).NotTo(HaveKey(constants.OrphanedSinceAnnotation))
+	})
+})
+
+var _ = Describe("RetireUnversioned", func() {
+	const versionLabel = "version-label"
+
+	ctx := context.Background()
+
+	It("should only keep the pods of the unversioned DaemonSets on the nodes without a version", func() {
+		conflictTerm := v1.NodeSelectorTerm{
+			MatchExpressions: []v1.NodeSelectorRequirement{
+				{Key: "conflict-label", Operator: v1.NodeSelectorOpDoesNotExist},
+			},
+		}
+
+		unversioned := appsv1.DaemonSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "unversioned",
+				Namespace: namespace,
+				Labels:    map[string]string{kernelLabel: kernelVersion},
+			},
+			Spec: appsv1.DaemonSetSpec{
+				Template: v1.PodTemplateSpec{
+					Spec: v1.PodSpec{
+						Affinity: &v1.Affinity{
+							NodeAffinity: &v1.NodeAffinity{
+								RequiredDuringSchedulingIgnoredDuringExecution: &v1.NodeSelector{
+									NodeSelectorTerms: []v1.NodeSelectorTerm{conflictTerm},
+								},
+							},
+						},
+						Containers: []v1.Container{{Name: "module-loader", Image: "some-image"}},
+					},
+				},
+			},
+		}
+
+		versioned := appsv1.DaemonSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "versioned",
+				Namespace: namespace,
+				Labels:    map[string]string{kernelLabel: kernelVersion, constants.ModuleVersionLabel: "v1"},
+			},
+		}
+
+		fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(&unversioned, &versioned).Build()
+
+		dc := NewCreator(fakeClient, kernelLabel, scheme, nil)
+
+		existingDS := map[string]*appsv1.DaemonSet{
+			api.DaemonSetKey(kernelVersion, "", ""):   &unversioned,
+			api.DaemonSetKey(kernelVersion, "", "v1"): &versioned,
+		}
+
+		Expect(
+			dc.RetireUnversioned(ctx, existingDS, versionLabel),
+		).NotTo(
+			HaveOccurred(),
+		)
+
+		retired := appsv1.DaemonSet{}
+
+		err := fakeClient.Get(ctx, types.NamespacedName{Name: "unversioned", Namespace: namespace}, &retired)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(retired.Annotations).To(HaveKeyWithValue(constants.RetiredUnversionedAnnotation, "true"))
+		Expect(retired.Spec.UpdateStrategy.Type).To(Equal(appsv1.OnDeleteDaemonSetStrategyType))
+		Expect(
+			retired.Spec.Template.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms,
+		).To(
+			Equal([]v1.NodeSelectorTerm{
+				{
+					MatchExpressions: []v1.NodeSelectorRequirement{
+						{Key: "conflict-label", Operator: v1.NodeSelectorOpDoesNotExist},
+						{Key: versionLabel, Operator: v1.NodeSelectorOpDoesNotExist},
+					},
+				},
+			}),
+		)
+		Expect(retired.Spec.Template.Spec.Containers).To(Equal(unversioned.Spec.Template.Spec.Containers))
+
+		unchanged := appsv1.DaemonSet{}
+
+		err = fakeClient.Get(ctx, types.NamespacedName{Name: "versioned", Namespace: namespace}, &unchanged)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(unchanged.Annotations).NotTo(HaveKey(constants.RetiredUnversionedAnnotation))
+		Expect(unchanged.Spec.Template.Spec.Affinity).To(BeNil())
+
+		// retiring is only done once
+		Expect(
+			dc.RetireUnversioned(ctx, existingDS, versionLabel),
+		).NotTo(
+			HaveOccurred(),
+		)
+
+		Expect(
+			existingDS[api.DaemonSetKey(kernelVersion, "", "")].Spec.Template.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms[0].MatchExpressions,
+		).To(
+			HaveLen(2),
+		)
+	})
 })
 
 var _ = Describe("ModuleDaemonSetsByKernelVersion", func() {
@@ -592,7 +1245,7 @@ var _ = Describe("ModuleDaemonSetsByKernelVersion", func() {
 	It("should return an empty map if no DaemonSets are present", func() {
 		clnt.EXPECT().List(context.Background(), gomock.Any(), gomock.Any())
 
-		dc := NewCreator(clnt, kernelLabel, scheme)
+		dc := NewCreator(clnt, kernelLabel, scheme, nil)
 
 		m, err := dc.ModuleDaemonSetsByKernelVersion(context.Background(), moduleName, namespace)
 		Expect(err).NotTo(HaveOccurred())
@@ -628,7 +1281,7 @@ var _ = Describe("ModuleDaemonSetsByKernelVersion", func() {
 				return nil
 			},
 		)
-		dc := NewCreator(clnt, kernelLabel, scheme)
+		dc := NewCreator(clnt, kernelLabel, scheme, nil)
 
 		_, err := dc.ModuleDaemonSetsByKernelVersion(ctx, moduleName, namespace)
 		Expect(err).To(HaveOccurred())
@@ -668,7 +1321,7 @@ var _ = Describe("ModuleDaemonSetsByKernelVersion", func() {
 			},
 		)
 
-		dc := NewCreator(clnt, kernelLabel, scheme)
+		dc := NewCreator(clnt, kernelLabel, scheme, nil)
 
 		m, err := dc.ModuleDaemonSetsByKernelVersion(ctx, moduleName, namespace)
 		Expect(err).NotTo(HaveOccurred())
@@ -677,6 +1330,92 @@ var _ = Describe("ModuleDaemonSetsByKernelVersion", func() {
 		Expect(m).To(HaveKeyWithValue(otherKernelVersion, &ds2))
 	})
 
+	It("should return a map if two DaemonSets are present for the same kernel on different architectures", func() {
+		ds1 := appsv1.DaemonSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "ds1",
+				Namespace: namespace,
+				Labels: map[string]string{
+					"kmm.node.kubernetes.io/module.name": moduleName,
+					kernelLabel:                          kernelVersion,
+					constants.ArchitectureLabel:          "amd64",
+				},
+			},
+		}
+
+		ds2 := appsv1.DaemonSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "ds2",
+				Namespace: namespace,
+				Labels: map[string]string{
+					"kmm.node.kubernetes.io/module.name": moduleName,
+					kernelLabel:                          kernelVersion,
+					constants.ArchitectureLabel:          "arm64",
+				},
+			},
+		}
+
+		ctx := context.Background()
+
+		clnt.EXPECT().List(ctx, gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ interface{}, list *appsv1.DaemonSetList, _ ...interface{}) error {
+				list.Items = []appsv1.DaemonSet{ds1, ds2}
+				return nil
+			},
+		)
+
+		dc := NewCreator(clnt, kernelLabel, scheme, nil)
+
+		m, err := dc.ModuleDaemonSetsByKernelVersion(ctx, moduleName, namespace)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(m).To(HaveLen(2))
+		Expect(m).To(HaveKeyWithValue(api.KernelArchKey(kernelVersion, "amd64"), &ds1))
+		Expect(m).To(HaveKeyWithValue(api.KernelArchKey(kernelVersion, "arm64"), &ds2))
+	})
+
+	It("should return a map if two DaemonSets are present for the same kernel with different module versions", func() {
+		ds1 := appsv1.DaemonSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "ds1",
+				Namespace: namespace,
+				Labels: map[string]string{
+					"kmm.node.kubernetes.io/module.name": moduleName,
+					kernelLabel:                          kernelVersion,
+					constants.ModuleVersionLabel:         "v1",
+				},
+			},
+		}
+
+		ds2 := appsv1.DaemonSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "ds2",
+				Namespace: namespace,
+				Labels: map[string]string{
+					"kmm.node.kubernetes.io/module.name": moduleName,
+					kernelLabel:                          kernelVersion,
+					constants.ModuleVersionLabel:         "v2",
+				},
+			},
+		}
+
+		ctx := context.Background()
+
+		clnt.EXPECT().List(ctx, gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ interface{}, list *appsv1.DaemonSetList, _ ...interface{}) error {
+				list.Items = []appsv1.DaemonSet{ds1, ds2}
+				return nil
+			},
+		)
+
+		dc := NewCreator(clnt, kernelLabel, scheme, nil)
+
+		m, err := dc.ModuleDaemonSetsByKernelVersion(ctx, moduleName, namespace)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(m).To(HaveLen(2))
+		Expect(m).To(HaveKeyWithValue(api.DaemonSetKey(kernelVersion, "", "v1"), &ds1))
+		Expect(m).To(HaveKeyWithValue(api.DaemonSetKey(kernelVersion, "", "v2"), &ds2))
+	})
+
 	It("should include a map entry for device plugin", func() {
 		ds1 := appsv1.DaemonSet{
 			ObjectMeta: metav1.ObjectMeta{
@@ -708,7 +1447,7 @@ var _ = Describe("ModuleDaemonSetsByKernelVersion", func() {
 			},
 		)
 
-		dc := NewCreator(clnt, kernelLabel, scheme)
+		dc := NewCreator(clnt, kernelLabel, scheme, nil)
 
 		m, err := dc.ModuleDaemonSetsByKernelVersion(context.Background(), moduleName, namespace)
 		Expect(err).NotTo(HaveOccurred())
@@ -719,9 +1458,9 @@ var _ = Describe("ModuleDaemonSetsByKernelVersion", func() {
 })
 
 var _ = Describe("GetPodPullSecrets", func() {
-	It("should return nil if the secret is nil", func() {
+	It("should return nil if there are no secrets", func() {
 		Expect(
-			GetPodPullSecrets(nil),
+			GetPodPullSecrets(nil, nil),
 		).To(
 			BeNil(),
 		)
@@ -731,11 +1470,27 @@ var _ = Describe("GetPodPullSecrets", func() {
 		lor := v1.LocalObjectReference{Name: "test"}
 
 		Expect(
-			GetPodPullSecrets(&lor),
+			GetPodPullSecrets(&lor, nil),
 		).To(
 			Equal([]v1.LocalObjectReference{lor}),
 		)
 	})
+
+	It("should return the repo secret followed by the other secrets", func() {
+		lor := v1.LocalObjectReference{Name: "test"}
+		others := []v1.LocalObjectReference{{Name: "pull1"}, {Name: "pull2"}}
+
+		Expect(
+			GetPodPullSecrets(&lor, others),
+		).To(
+			Equal([]v1.LocalObjectReference{lor, others[0], others[1]}),
+		)
+		Expect(
+			GetPodPullSecrets(nil, others),
+		).To(
+			Equal(others),
+		)
+	})
 })
 
 var _ = Describe("OverrideLabels", func() {
@@ -765,7 +1520,7 @@ var _ = Describe("GetNodeLabelFromPod", func() {
 	var dc DaemonSetCreator
 
 	BeforeEach(func() {
-		dc = NewCreator(clnt, kernelLabel, scheme)
+		dc = NewCreator(clnt, kernelLabel, scheme, nil)
 	})
 
 	It("should return a driver container label", func() {
@@ -790,7 +1545,7 @@ var _ = Describe("GetNodeLabelFromPod", func() {
 			},
 		}
 		res := dc.GetNodeLabelFromPod(&pod, "module-name")
-		Expect(res).To(Equal(getDevicePluginNodeLabel("module-name")))
+		Expect(res).To(Equal(GetDevicePluginNodeLabel("module-name")))
 	})
 })
 
@@ -880,6 +1635,43 @@ var _ = Describe("MakeLoadCommand", func() {
 	})
 })
 
+var _ = Describe("makePreStopCommand", func() {
+	It("should skip the unload command if the pod has the skip-unload annotation or the module is in use", func() {
+		spec := kmmv1beta1.ModprobeSpec{ModuleName: "some-module"}
+
+		Expect(
+			makePreStopCommand(spec, moduleName),
+		).To(
+			Equal([]string{
+				"/bin/sh",
+				"-c",
+				"if grep -qs '^kmm.node.kubernetes.io/skip-unload=' /etc/podinfo/annotations; then echo 'Not unloading the module'; exit 0; fi; " +
+					`if [ -e /sys/module/some_module/refcnt ] && { [ "$(cat /sys/module/some_module/refcnt)" != 0 ] || [ -n "$(ls -A /sys/module/some_module/holders 2>/dev/null)" ]; }; ` +
+					"then echo 'KMM: the kernel module is in use and could not be unloaded' | tee /dev/termination-log; exit 0; fi; " +
+					"modprobe -rv some-module" +
+					"; if [ -e /sys/module/some_module/refcnt ]; then echo 'KMM: the kernel module is in use and could not be unloaded' | tee /dev/termination-log; fi",
+			}),
+		)
+	})
+
+	It("should not check the sysfs if the module name is not known", func() {
+		spec := kmmv1beta1.ModprobeSpec{
+			RawArgs: &kmmv1beta1.ModprobeArgs{Unload: []string{"-r", "some-module"}},
+		}
+
+		Expect(
+			makePreStopCommand(spec, moduleName),
+		).To(
+			Equal([]string{
+				"/bin/sh",
+				"-c",
+				"if grep -qs '^kmm.node.kubernetes.io/skip-unload=' /etc/podinfo/annotations; then echo 'Not unloading the module'; exit 0; fi; " +
+					"modprobe -r some-module",
+			}),
+		)
+	})
+})
+
 var _ = Describe("MakeUnloadCommand", func() {
 	const (
 		kernelModuleName = "some-kmod"
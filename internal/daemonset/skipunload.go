@@ -0,0 +1,77 @@
+package daemonset
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kubernetes-sigs/kernel-module-management/internal/constants"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// skipUnloadCheck is a shell condition that is true once the kubelet wrote the skip-unload annotation to the
+// annotations file of the pod.
+var skipUnloadCheck = fmt.Sprintf("grep -qs '^%s=' %s/%s", constants.SkipUnloadAnnotation, podInfoPath, podAnnotationsFile)
+
+// makeReadinessCommand returns the readiness probe of the ModuleLoader container, which fails once the PreStop hook
+// would skip unloading the kernel module: KMM waits for that acknowledgement before deleting the pod.
+func makeReadinessCommand() []string {
+	return []string{"/bin/sh", "-c", "if " + skipUnloadCheck + "; then exit 1; fi"}
+}
+
+// SkipUnload asks the ModuleLoader pod not to unload the kernel module when it is deleted, by annotating it with the
+// skip-unload annotation.
+// It returns true once pod can be deleted without unloading the kernel module: either its ModuleLoader container
+// acknowledged the annotation by failing its readiness probe, or the container is not running, in which case its
+// PreStop hook does not run.
+// Pods are only annotated once ready, as a container that was not probed yet cannot be told from one acknowledging the
+// annotation; the pods created by earlier versions of KMM, which have no readiness probe, never acknowledge it.
+func SkipUnload(ctx context.Context, clnt client.Client, pod *v1.Pod) (bool, error) {
+	var status *v1.ContainerStatus
+
+	for i := range pod.Status.ContainerStatuses {
+		if cs := &pod.Status.ContainerStatuses[i]; cs.Name == constants.ModuleLoaderContainerName {
+			status = cs
+			break
+		}
+	}
+
+	if status == nil || status.State.Running == nil {
+		return true, nil
+	}
+
+	if _, ok := pod.Annotations[constants.SkipUnloadAnnotation]; ok {
+		return !status.Ready, nil
+	}
+
+	if !status.Ready {
+		return false, nil
+	}
+
+	patchFrom := client.MergeFrom(pod.DeepCopy())
+
+	metav1.SetMetaDataAnnotation(&pod.ObjectMeta, constants.SkipUnloadAnnotation, "")
+
+	if err := clnt.Patch(ctx, pod, patchFrom); err != nil {
+		return false, fmt.Errorf("could not annotate pod %s: %v", pod.Name, err)
+	}
+
+	return false, nil
+}
+
+// IsSkipUnloadAcknowledged returns true if pod has the skip-unload annotation and its ModuleLoader container
+// acknowledged it by failing its readiness probe.
+func IsSkipUnloadAcknowledged(pod *v1.Pod) bool {
+	if _, ok := pod.Annotations[constants.SkipUnloadAnnotation]; !ok {
+		return false
+	}
+
+	for _, cs := range pod.Status.ContainerStatuses {
+		if cs.Name == constants.ModuleLoaderContainerName {
+			return cs.State.Running != nil && !cs.Ready
+		}
+	}
+
+	return false
+}
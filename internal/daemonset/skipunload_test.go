@@ -0,0 +1,133 @@
+package daemonset
+
+import (
+	"context"
+
+	"github.com/golang/mock/gomock"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/client"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/constants"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// runningModuleLoader returns the status of a pod whose ModuleLoader container is running.
+func runningModuleLoader(ready bool) v1.PodStatus {
+	return v1.PodStatus{
+		ContainerStatuses: []v1.ContainerStatus{
+			{
+				Name:  constants.ModuleLoaderContainerName,
+				Ready: ready,
+				State: v1.ContainerState{Running: &v1.ContainerStateRunning{}},
+			},
+		},
+	}
+}
+
+var _ = Describe("makeReadinessCommand", func() {
+	It("should fail once the pod has the skip-unload annotation", func() {
+		Expect(
+			makeReadinessCommand(),
+		).To(
+			Equal([]string{
+				"/bin/sh",
+				"-c",
+				"if grep -qs '^kmm.node.kubernetes.io/skip-unload=' /etc/podinfo/annotations; then exit 1; fi",
+			}),
+		)
+	})
+})
+
+var _ = Describe("SkipUnload", func() {
+	var (
+		clnt *client.MockClient
+		ctx  = context.Background()
+	)
+
+	BeforeEach(func() {
+		clnt = client.NewMockClient(gomock.NewController(GinkgoT()))
+	})
+
+	It("should annotate a ready pod and wait", func() {
+		pod := v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "pod"},
+			Status:     runningModuleLoader(true),
+		}
+
+		clnt.EXPECT().Patch(ctx, &pod, gomock.Any()).DoAndReturn(
+			func(_ interface{}, p *v1.Pod, _ ctrlclient.Patch, _ ...ctrlclient.PatchOption) error {
+				Expect(p.Annotations).To(HaveKey(constants.SkipUnloadAnnotation))
+				return nil
+			},
+		)
+
+		Expect(
+			SkipUnload(ctx, clnt, &pod),
+		).To(
+			BeFalse(),
+		)
+	})
+
+	DescribeTable("should not patch the pod",
+		func(pod v1.Pod, expected bool) {
+			Expect(
+				SkipUnload(ctx, clnt, &pod),
+			).To(
+				Equal(expected),
+			)
+		},
+		Entry(
+			"container not running",
+			v1.Pod{},
+			true,
+		),
+		Entry(
+			"not ready yet",
+			v1.Pod{Status: runningModuleLoader(false)},
+			false,
+		),
+		Entry(
+			"annotation not acknowledged yet",
+			v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{constants.SkipUnloadAnnotation: ""},
+				},
+				Status: runningModuleLoader(true),
+			},
+			false,
+		),
+		Entry(
+			"annotation acknowledged",
+			v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{constants.SkipUnloadAnnotation: ""},
+				},
+				Status: runningModuleLoader(false),
+			},
+			true,
+		),
+	)
+})
+
+var _ = Describe("IsSkipUnloadAcknowledged", func() {
+	DescribeTable("should work as expected",
+		func(annotated, ready, expected bool) {
+			pod := v1.Pod{Status: runningModuleLoader(ready)}
+
+			if annotated {
+				pod.Annotations = map[string]string{constants.SkipUnloadAnnotation: ""}
+			}
+
+			Expect(
+				IsSkipUnloadAcknowledged(&pod),
+			).To(
+				Equal(expected),
+			)
+		},
+		Entry("no annotation", false, false, false),
+		Entry("annotated, ready", true, true, false),
+		Entry("annotated, not ready", true, false, true),
+	)
+})
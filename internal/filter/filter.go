@@ -18,6 +18,7 @@ import (
 
 	hubv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api-hub/v1beta1"
 	kmmv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/conflict"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/constants"
 )
 
@@ -74,11 +75,29 @@ func New(client client.Client, logger logr.Logger) *Filter {
 	}
 }
 
-func (f *Filter) ModuleReconcilerNodePredicate(kernelLabel string) predicate.Predicate {
+// annotationChanged returns a predicate that is true for updates changing the value of the annotation.
+func annotationChanged(annotation string) predicate.Predicate {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			if e.ObjectOld == nil || e.ObjectNew == nil {
+				return false
+			}
+
+			return e.ObjectOld.GetAnnotations()[annotation] != e.ObjectNew.GetAnnotations()[annotation]
+		},
+	}
+}
+
+// ModuleReconcilerNodePredicate selects the node events that may change the kernels a Module needs: changes to the
+// labels of nodes that have kernelLabel, and changes of the kernel pending in their pendingKernelAnnotation.
+func (f *Filter) ModuleReconcilerNodePredicate(kernelLabel, pendingKernelAnnotation string) predicate.Predicate {
 	return predicate.And(
 		skipDeletions,
 		HasLabel(kernelLabel),
-		predicate.LabelChangedPredicate{},
+		predicate.Or(
+			predicate.LabelChangedPredicate{},
+			annotationChanged(pendingKernelAnnotation),
+		),
 	)
 }
 
@@ -161,6 +180,85 @@ func (f *Filter) FindModulesForNode(node client.Object) []reconcile.Request {
 	return reqs
 }
 
+// FindModulesWithSameKernelModule returns the other Modules that load the same kernel module as mod, as changes to
+// mod may change the nodes they conflict on.
+func (f *Filter) FindModulesWithSameKernelModule(mod client.Object) []reconcile.Request {
+	logger := f.logger.WithValues("module", mod.GetName())
+
+	reqs := make([]reconcile.Request, 0)
+
+	m, ok := mod.(*kmmv1beta1.Module)
+	if !ok {
+		logger.Info("Unexpected object type; skipping", "type", reflect.TypeOf(mod))
+		return reqs
+	}
+
+	kmodName := conflict.KernelModuleName(m)
+	if kmodName == "" {
+		return reqs
+	}
+
+	mods := kmmv1beta1.ModuleList{}
+
+	if err := f.client.List(context.Background(), &mods); err != nil {
+		logger.Error(err, "could not list modules")
+		return reqs
+	}
+
+	for _, other := range mods.Items {
+		if (other.Namespace == m.Namespace && other.Name == m.Name) || conflict.KernelModuleName(&other) != kmodName {
+			continue
+		}
+
+		nsn := types.NamespacedName{Name: other.Name, Namespace: other.Namespace}
+
+		reqs = append(reqs, reconcile.Request{NamespacedName: nsn})
+	}
+
+	logger.V(1).Info("New requests", "requests", reqs)
+
+	return reqs
+}
+
+// FindModulesForStartupTaint returns the other Modules if mod has a startup taint, as the ModuleLoader pods of all
+// Modules tolerate the startup taints of all Modules.
+func (f *Filter) FindModulesForStartupTaint(mod client.Object) []reconcile.Request {
+	logger := f.logger.WithValues("module", mod.GetName())
+
+	reqs := make([]reconcile.Request, 0)
+
+	m, ok := mod.(*kmmv1beta1.Module)
+	if !ok {
+		logger.Info("Unexpected object type; skipping", "type", reflect.TypeOf(mod))
+		return reqs
+	}
+
+	if m.Spec.StartupTaint == nil {
+		return reqs
+	}
+
+	mods := kmmv1beta1.ModuleList{}
+
+	if err := f.client.List(context.Background(), &mods); err != nil {
+		logger.Error(err, "could not list modules")
+		return reqs
+	}
+
+	for _, other := range mods.Items {
+		if other.Namespace == m.Namespace && other.Name == m.Name {
+			continue
+		}
+
+		nsn := types.NamespacedName{Name: other.Name, Namespace: other.Namespace}
+
+		reqs = append(reqs, reconcile.Request{NamespacedName: nsn})
+	}
+
+	logger.V(1).Info("New requests", "requests", reqs)
+
+	return reqs
+}
+
 func (f *Filter) FindManagedClusterModulesForCluster(cluster client.Object) []reconcile.Request {
 	logger := f.logger.WithValues("managedcluster", cluster.GetName())
 
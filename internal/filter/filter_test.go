@@ -2,6 +2,7 @@ package filter
 
 import (
 	"context"
+	"errors"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -110,11 +111,15 @@ var _ = Describe("kmmClusterClaimChanged", func() {
 })
 
 var _ = Describe("ModuleReconcilerNodePredicate", func() {
-	const kernelLabel = "kernel-label"
+	const (
+		kernelLabel             = "kernel-label"
+		pendingKernelAnnotation = "pending-kernel"
+	)
+
 	var p predicate.Predicate
 
 	BeforeEach(func() {
-		p = New(nil, logr.Discard()).ModuleReconcilerNodePredicate(kernelLabel)
+		p = New(nil, logr.Discard()).ModuleReconcilerNodePredicate(kernelLabel, pendingKernelAnnotation)
 	})
 
 	It("should return true for creations", func() {
@@ -149,6 +154,50 @@ var _ = Describe("ModuleReconcilerNodePredicate", func() {
 			BeTrue(),
 		)
 	})
+	It("should return true for pending kernel annotation updates", func() {
+		labels := map[string]string{kernelLabel: "1.2.3"}
+
+		ev := event.UpdateEvent{
+			ObjectOld: &v1.Node{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+			},
+			ObjectNew: &v1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      labels,
+					Annotations: map[string]string{pendingKernelAnnotation: "4.5.6"},
+				},
+			},
+		}
+
+		Expect(
+			p.Update(ev),
+		).To(
+			BeTrue(),
+		)
+	})
+
+	It("should return false for updates of other annotations", func() {
+		labels := map[string]string{kernelLabel: "1.2.3"}
+
+		ev := event.UpdateEvent{
+			ObjectOld: &v1.Node{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+			},
+			ObjectNew: &v1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      labels,
+					Annotations: map[string]string{"other": "value"},
+				},
+			},
+		}
+
+		Expect(
+			p.Update(ev),
+		).To(
+			BeFalse(),
+		)
+	})
+
 	It("should return false for label updates without the expected label", func() {
 		ev := event.UpdateEvent{
 			ObjectOld: &v1.Node{
@@ -659,3 +708,110 @@ var _ = Describe("FindPreflightsForModule", func() {
 	})
 
 })
+
+var _ = Describe("FindModulesWithSameKernelModule", func() {
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		clnt = mockClient.NewMockClient(ctrl)
+	})
+
+	makeModule := func(namespace, name, kmodName string) kmmv1beta1.Module {
+		mod := kmmv1beta1.Module{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		}
+
+		mod.Spec.ModuleLoader.Container.Modprobe.ModuleName = kmodName
+
+		return mod
+	}
+
+	It("should return an empty list if the Modules cannot be listed", func() {
+		mod := makeModule("namespace", "name", "kmod")
+
+		clnt.EXPECT().List(context.Background(), gomock.Any()).Return(errors.New("some error"))
+
+		p := New(clnt, logr.Discard())
+
+		Expect(
+			p.FindModulesWithSameKernelModule(&mod),
+		).To(
+			BeEmpty(),
+		)
+	})
+
+	It("should return the other Modules loading the same kernel module", func() {
+		mod := makeModule("namespace", "name", "my-kmod")
+
+		clnt.EXPECT().List(context.Background(), gomock.Any()).DoAndReturn(
+			func(_ interface{}, list *kmmv1beta1.ModuleList, _ ...interface{}) error {
+				list.Items = []kmmv1beta1.Module{
+					mod,
+					makeModule("other-namespace", "name", "my_kmod"),
+					makeModule("namespace", "other-kmod", "other-kmod"),
+				}
+				return nil
+			},
+		)
+
+		p := New(clnt, logr.Discard())
+
+		Expect(
+			p.FindModulesWithSameKernelModule(&mod),
+		).To(
+			Equal([]reconcile.Request{
+				{NamespacedName: types.NamespacedName{Namespace: "other-namespace", Name: "name"}},
+			}),
+		)
+	})
+})
+
+var _ = Describe("FindModulesForStartupTaint", func() {
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		clnt = mockClient.NewMockClient(ctrl)
+	})
+
+	It("should return nothing if the Module has no startup taint", func() {
+		mod := kmmv1beta1.Module{
+			ObjectMeta: metav1.ObjectMeta{Name: "name", Namespace: "namespace"},
+		}
+
+		p := New(clnt, logr.Discard())
+
+		Expect(
+			p.FindModulesForStartupTaint(&mod),
+		).To(
+			BeEmpty(),
+		)
+	})
+
+	It("should return the other Modules if the Module has a startup taint", func() {
+		mod := kmmv1beta1.Module{
+			ObjectMeta: metav1.ObjectMeta{Name: "name", Namespace: "namespace"},
+			Spec: kmmv1beta1.ModuleSpec{
+				StartupTaint: &kmmv1beta1.StartupTaint{Key: "example.org/not-ready"},
+			},
+		}
+
+		other := kmmv1beta1.Module{
+			ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "namespace"},
+		}
+
+		clnt.EXPECT().List(context.Background(), gomock.Any()).DoAndReturn(
+			func(_ interface{}, list *kmmv1beta1.ModuleList, _ ...interface{}) error {
+				list.Items = []kmmv1beta1.Module{mod, other}
+				return nil
+			},
+		)
+
+		p := New(clnt, logr.Discard())
+
+		Expect(
+			p.FindModulesForStartupTaint(&mod),
+		).To(
+			Equal([]reconcile.Request{
+				{NamespacedName: types.NamespacedName{Namespace: "namespace", Name: "other"}},
+			}),
+		)
+	})
+})
@@ -0,0 +1,122 @@
+package gc
+
+import (
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+
+	kmmv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
+)
+
+// Policy is the garbage-collection policy applied to the jobs and DaemonSets of a Module.
+type Policy struct {
+	// SucceededJobsTTL is how long succeeded jobs are kept.
+	SucceededJobsTTL time.Duration
+
+	// FailedJobsTTL is how long failed jobs are kept; failed jobs are never deleted if it is nil.
+	FailedJobsTTL *time.Duration
+
+	// OrphanedDaemonSetGracePeriod is how long a DaemonSet is kept once no node runs its kernel.
+	OrphanedDaemonSetGracePeriod time.Duration
+}
+
+// WithOverrides returns a copy of p in which the fields set in override replace the corresponding values.
+func (p Policy) WithOverrides(override *kmmv1beta1.GarbageCollectionPolicy) Policy {
+	if override == nil {
+		return p
+	}
+
+	if override.SucceededJobsTTL != nil {
+		p.SucceededJobsTTL = override.SucceededJobsTTL.Duration
+	}
+
+	if override.FailedJobsTTL != nil {
+		ttl := override.FailedJobsTTL.Duration
+		p.FailedJobsTTL = &ttl
+	}
+
+	if override.OrphanedDaemonSetGracePeriod != nil {
+		p.OrphanedDaemonSetGracePeriod = override.OrphanedDaemonSetGracePeriod.Duration
+	}
+
+	return p
+}
+
+// Earliest returns the smallest non-zero duration of durations, or 0 if they are all zero.
+// It combines the delays after which objects kept by the garbage collection expire into a single requeue delay.
+func Earliest(durations ...time.Duration) time.Duration {
+	var min time.Duration
+
+	for _, d := range durations {
+		if d > 0 && (min == 0 || d < min) {
+			min = d
+		}
+	}
+
+	return min
+}
+
+// jobExpiry returns when job may be deleted, or false if it has not finished or is kept forever.
+func (p Policy) jobExpiry(job *batchv1.Job) (time.Time, bool) {
+	switch {
+	case job.Status.Succeeded == 1:
+		finishedAt := job.CreationTimestamp.Time
+
+		if job.Status.CompletionTime != nil {
+			finishedAt = job.Status.CompletionTime.Time
+		}
+
+		return finishedAt.Add(p.SucceededJobsTTL), true
+	case job.Status.Failed == 1:
+		if p.FailedJobsTTL == nil {
+			return time.Time{}, false
+		}
+
+		finishedAt := job.CreationTimestamp.Time
+
+		for _, c := range job.Status.Conditions {
+			if c.Type == batchv1.JobFailed && c.Status == v1.ConditionTrue {
+				finishedAt = c.LastTransitionTime.Time
+			}
+		}
+
+		return finishedAt.Add(*p.FailedJobsTTL), true
+	default:
+		return time.Time{}, false
+	}
+}
+
+// ShouldDeleteJob returns true if job has finished and its TTL has expired at now.
+func (p Policy) ShouldDeleteJob(job *batchv1.Job, now time.Time) bool {
+	expiry, ok := p.jobExpiry(job)
+
+	return ok && !now.Before(expiry)
+}
+
+// JobRequeueAfter returns how long after now the TTL of job expires.
+// It returns 0 if job has not finished, is kept forever or should already be deleted.
+func (p Policy) JobRequeueAfter(job *batchv1.Job, now time.Time) time.Duration {
+	expiry, ok := p.jobExpiry(job)
+	if !ok || !now.Before(expiry) {
+		return 0
+	}
+
+	return expiry.Sub(now)
+}
+
+// ShouldDeleteOrphanedDaemonSet returns true if a DaemonSet that has been orphaned since orphanedSince should be
+// deleted at now.
+func (p Policy) ShouldDeleteOrphanedDaemonSet(orphanedSince, now time.Time) bool {
+	return !now.Before(orphanedSince.Add(p.OrphanedDaemonSetGracePeriod))
+}
+
+// OrphanedDaemonSetRequeueAfter returns how long after now a DaemonSet that has been orphaned since orphanedSince
+// should be deleted, or 0 if it should already be.
+func (p Policy) OrphanedDaemonSetRequeueAfter(orphanedSince, now time.Time) time.Duration {
+	if p.ShouldDeleteOrphanedDaemonSet(orphanedSince, now) {
+		return 0
+	}
+
+	return orphanedSince.Add(p.OrphanedDaemonSetGracePeriod).Sub(now)
+}
@@ -0,0 +1,107 @@
+package gc
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	kmmv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
+)
+
+var _ = Describe("WithOverrides", func() {
+	It("should only override the fields that are set", func() {
+		defaults := Policy{SucceededJobsTTL: time.Minute, OrphanedDaemonSetGracePeriod: time.Hour}
+
+		policy := defaults.WithOverrides(&kmmv1beta1.GarbageCollectionPolicy{
+			FailedJobsTTL:                &metav1.Duration{Duration: 2 * time.Hour},
+			OrphanedDaemonSetGracePeriod: &metav1.Duration{Duration: 0},
+		})
+
+		failedJobsTTL := 2 * time.Hour
+
+		Expect(policy).To(Equal(Policy{SucceededJobsTTL: time.Minute, FailedJobsTTL: &failedJobsTTL}))
+		Expect(defaults.WithOverrides(nil)).To(Equal(defaults))
+	})
+})
+
+var _ = Describe("Earliest", func() {
+	It("should return the smallest non-zero duration", func() {
+		Expect(Earliest()).To(BeZero())
+		Expect(Earliest(0, 0)).To(BeZero())
+		Expect(Earliest(0, 10*time.Minute, 5*time.Minute)).To(Equal(5 * time.Minute))
+	})
+})
+
+var _ = Describe("JobRequeueAfter", func() {
+	now := time.Now()
+	hour := time.Hour
+
+	It("should return the time until the TTL of a finished job expires", func() {
+		job := &batchv1.Job{
+			Status: batchv1.JobStatus{Succeeded: 1, CompletionTime: &metav1.Time{Time: now.Add(-time.Minute)}},
+		}
+
+		Expect(Policy{SucceededJobsTTL: time.Hour}.JobRequeueAfter(job, now)).To(Equal(59 * time.Minute))
+	})
+
+	It("should return 0 for jobs that are running, kept forever or already expired", func() {
+		failedJob := &batchv1.Job{Status: batchv1.JobStatus{Failed: 1}}
+		succeededJob := &batchv1.Job{
+			Status: batchv1.JobStatus{Succeeded: 1, CompletionTime: &metav1.Time{Time: now.Add(-2 * time.Hour)}},
+		}
+
+		Expect(Policy{FailedJobsTTL: &hour}.JobRequeueAfter(&batchv1.Job{}, now)).To(BeZero())
+		Expect(Policy{}.JobRequeueAfter(failedJob, now)).To(BeZero())
+		Expect(Policy{SucceededJobsTTL: time.Hour}.JobRequeueAfter(succeededJob, now)).To(BeZero())
+	})
+})
+
+var _ = Describe("OrphanedDaemonSetRequeueAfter", func() {
+	It("should return the time until the grace period expires", func() {
+		now := time.Now()
+		policy := Policy{OrphanedDaemonSetGracePeriod: time.Hour}
+
+		Expect(policy.OrphanedDaemonSetRequeueAfter(now.Add(-time.Minute), now)).To(Equal(59 * time.Minute))
+		Expect(policy.OrphanedDaemonSetRequeueAfter(now.Add(-2*time.Hour), now)).To(BeZero())
+	})
+})
+
+var _ = Describe("ShouldDeleteJob", func() {
+	now := time.Now()
+
+	failedJob := func(failedAt time.Time) *batchv1.Job {
+		return &batchv1.Job{
+			Status: batchv1.JobStatus{
+				Failed: 1,
+				Conditions: []batchv1.JobCondition{
+					{Type: batchv1.JobFailed, Status: v1.ConditionTrue, LastTransitionTime: metav1.Time{Time: failedAt}},
+				},
+			},
+		}
+	}
+
+	succeededJob := func(completedAt time.Time) *batchv1.Job {
+		return &batchv1.Job{
+			Status: batchv1.JobStatus{Succeeded: 1, CompletionTime: &metav1.Time{Time: completedAt}},
+		}
+	}
+
+	hour := time.Hour
+
+	DescribeTable("should apply the TTLs",
+		func(policy Policy, job *batchv1.Job, expected bool) {
+			Expect(policy.ShouldDeleteJob(job, now)).To(Equal(expected))
+		},
+		Entry("succeeded, no TTL", Policy{}, succeededJob(now), true),
+		Entry("succeeded, TTL not expired", Policy{SucceededJobsTTL: time.Hour}, succeededJob(now.Add(-time.Minute)), false),
+		Entry("succeeded, TTL expired", Policy{SucceededJobsTTL: time.Hour}, succeededJob(now.Add(-2*time.Hour)), true),
+		Entry("failed, no TTL", Policy{}, failedJob(now.Add(-48*time.Hour)), false),
+		Entry("failed, TTL not expired", Policy{FailedJobsTTL: &hour}, failedJob(now.Add(-time.Minute)), false),
+		Entry("failed, TTL expired", Policy{FailedJobsTTL: &hour}, failedJob(now.Add(-2*time.Hour)), true),
+		Entry("running", Policy{FailedJobsTTL: &hour}, &batchv1.Job{}, false),
+	)
+})
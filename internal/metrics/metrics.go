@@ -9,6 +9,7 @@ import (
 const (
 	existingKMMOModulesQuery = "kmmo_module_total"
 	completedKMMOStageQuery  = "kmmo_completed_stage"
+	rebootRequiredQuery      = "kmmo_reboot_required_nodes"
 	BuildStage               = "build"
 	SignStage                = "sign"
 	ModuleLoaderStage        = "module-loader"
@@ -22,11 +23,14 @@ type Metrics interface {
 	Register()
 	SetExistingKMMOModules(value int)
 	SetCompletedStage(kmmoName, kmmoNamespace, kernelVersion, stage string, completed bool)
+	SetRebootRequiredNodes(kmmoName, kmmoNamespace string, nodes int)
+	DeleteRebootRequiredNodes(kmmoName, kmmoNamespace string)
 }
 
 type metrics struct {
 	kmmoResourcesNum   prometheus.Gauge
 	kmmoCompletedStage *prometheus.GaugeVec
+	rebootRequired     *prometheus.GaugeVec
 }
 
 func New() Metrics {
@@ -44,10 +48,18 @@ func New() Metrics {
 		},
 		[]string{"kmmo", "namespace", "kernel", "stage"},
 	)
+	rebootRequired := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: rebootRequiredQuery,
+			Help: "For a given kmmo,namespace, number of nodes from which the kernel module could not be unloaded and that need a reboot.",
+		},
+		[]string{"kmmo", "namespace"},
+	)
 
 	return &metrics{
 		kmmoResourcesNum:   kmmoResourcesNum,
 		kmmoCompletedStage: completedStages,
+		rebootRequired:     rebootRequired,
 	}
 }
 
@@ -55,6 +67,7 @@ func (m *metrics) Register() {
 	runtimemetrics.Registry.MustRegister(
 		m.kmmoResourcesNum,
 		m.kmmoCompletedStage,
+		m.rebootRequired,
 	)
 }
 
@@ -69,3 +82,13 @@ func (m *metrics) SetCompletedStage(kmmoName, kmmoNamespace, kernelVersion, stag
 	}
 	m.kmmoCompletedStage.WithLabelValues(kmmoName, kmmoNamespace, kernelVersion, stage).Set(value)
 }
+
+func (m *metrics) SetRebootRequiredNodes(kmmoName, kmmoNamespace string, nodes int) {
+	m.rebootRequired.WithLabelValues(kmmoName, kmmoNamespace).Set(float64(nodes))
+}
+
+// DeleteRebootRequiredNodes removes the number of nodes requiring a reboot of a deleted Module, which would otherwise
+// keep its last value.
+func (m *metrics) DeleteRebootRequiredNodes(kmmoName, kmmoNamespace string) {
+	m.rebootRequired.DeleteLabelValues(kmmoName, kmmoNamespace)
+}
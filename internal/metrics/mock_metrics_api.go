@@ -33,6 +33,18 @@ func (m *MockMetrics) EXPECT() *MockMetricsMockRecorder {
 	return m.recorder
 }
 
+// DeleteRebootRequiredNodes mocks base method.
+func (m *MockMetrics) DeleteRebootRequiredNodes(kmmoName, kmmoNamespace string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "DeleteRebootRequiredNodes", kmmoName, kmmoNamespace)
+}
+
+// DeleteRebootRequiredNodes indicates an expected call of DeleteRebootRequiredNodes.
+func (mr *MockMetricsMockRecorder) DeleteRebootRequiredNodes(kmmoName, kmmoNamespace interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRebootRequiredNodes", reflect.TypeOf((*MockMetrics)(nil).DeleteRebootRequiredNodes), kmmoName, kmmoNamespace)
+}
+
 // Register mocks base method.
 func (m *MockMetrics) Register() {
 	m.ctrl.T.Helper()
@@ -68,3 +80,15 @@ func (mr *MockMetricsMockRecorder) SetExistingKMMOModules(value interface{}) *go
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetExistingKMMOModules", reflect.TypeOf((*MockMetrics)(nil).SetExistingKMMOModules), value)
 }
+
+// SetRebootRequiredNodes mocks base method.
+func (m *MockMetrics) SetRebootRequiredNodes(kmmoName, kmmoNamespace string, nodes int) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetRebootRequiredNodes", kmmoName, kmmoNamespace, nodes)
+}
+
+// SetRebootRequiredNodes indicates an expected call of SetRebootRequiredNodes.
+func (mr *MockMetricsMockRecorder) SetRebootRequiredNodes(kmmoName, kmmoNamespace, nodes interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetRebootRequiredNodes", reflect.TypeOf((*MockMetrics)(nil).SetRebootRequiredNodes), kmmoName, kmmoNamespace, nodes)
+}
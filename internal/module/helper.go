@@ -5,11 +5,14 @@ import (
 	"fmt"
 	"strings"
 
+	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	kmmv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/api"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/auth"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/constants"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/registry"
 )
 
@@ -48,19 +51,68 @@ func ImageExists(
 	namespace string,
 	imageName string) (bool, error) {
 
-	var registryAuthGetter auth.RegistryAuthGetter
-	if mld.ImageRepoSecret != nil {
-		registryAuthGetter = auth.NewRegistryAuthGetter(client, types.NamespacedName{
-			Name:      mld.ImageRepoSecret.Name,
-			Namespace: namespace,
-		})
-	}
+	registryAuthGetter := auth.NewRegistryAuthGetterFrom(client, mld)
 
 	tlsOptions := mld.RegistryTLS
-	exists, err := reg.ImageExists(ctx, imageName, tlsOptions, registryAuthGetter)
+
+	caBundle, err := RegistryCABundle(ctx, client, namespace, tlsOptions)
+	if err != nil {
+		return false, fmt.Errorf("could not get the registry CA bundle: %v", err)
+	}
+
+	exists, err := reg.ImageExists(ctx, imageName, mld.Architecture, tlsOptions, caBundle, registryAuthGetter)
 	if err != nil {
 		return false, fmt.Errorf("could not check if the image is available: %v", err)
 	}
 
 	return exists, nil
 }
+
+// ImageBuildHash returns the hash of the build inputs that KMM recorded in the constants.BuildHashImageLabel label of
+// imageName, or an empty string if imageName does not exist or has no such label.
+func ImageBuildHash(
+	ctx context.Context,
+	client client.Client,
+	reg registry.Registry,
+	mld *api.ModuleLoaderData,
+	namespace string,
+	imageName string) (string, error) {
+
+	registryAuthGetter := auth.NewRegistryAuthGetterFrom(client, mld)
+
+	tlsOptions := mld.RegistryTLS
+
+	caBundle, err := RegistryCABundle(ctx, client, namespace, tlsOptions)
+	if err != nil {
+		return "", fmt.Errorf("could not get the registry CA bundle: %v", err)
+	}
+
+	labels, err := reg.GetLabels(ctx, imageName, mld.Architecture, tlsOptions, caBundle, registryAuthGetter)
+	if err != nil {
+		return "", fmt.Errorf("could not get the labels of the image: %v", err)
+	}
+
+	return labels[constants.BuildHashImageLabel], nil
+}
+
+// RegistryCABundle returns the PEM-encoded CA certificates from the ConfigMap referenced by tlsOptions, or nil if
+// tlsOptions does not reference any CA bundle.
+func RegistryCABundle(ctx context.Context, clnt client.Client, namespace string, tlsOptions *kmmv1beta1.TLSOptions) ([]byte, error) {
+	if tlsOptions == nil || tlsOptions.CABundle == nil {
+		return nil, nil
+	}
+
+	cm := v1.ConfigMap{}
+	nsn := types.NamespacedName{Name: tlsOptions.CABundle.Name, Namespace: namespace}
+
+	if err := clnt.Get(ctx, nsn, &cm); err != nil {
+		return nil, fmt.Errorf("could not get ConfigMap %s: %v", nsn, err)
+	}
+
+	data, ok := cm.Data[constants.CABundleCMKey]
+	if !ok {
+		return nil, fmt.Errorf("ConfigMap %s does not contain the %s key", nsn, constants.CABundleCMKey)
+	}
+
+	return []byte(data), nil
+}
@@ -9,9 +9,13 @@ import (
 
 	gomock "github.com/golang/mock/gomock"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
 
+	kmmv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/api"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/client"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/constants"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/registry"
 )
 
@@ -77,7 +81,7 @@ var _ = Describe("ImageExists", func() {
 
 	It("should return true if the image exists", func() {
 		gomock.InOrder(
-			mockRegistry.EXPECT().ImageExists(ctx, imageName, gomock.Any(), nil).Return(true, nil),
+			mockRegistry.EXPECT().ImageExists(ctx, imageName, "", gomock.Any(), nil, gomock.Any()).Return(true, nil),
 		)
 
 		exists, err := ImageExists(ctx, clnt, mockRegistry, &mld, namespace, imageName)
@@ -88,7 +92,7 @@ var _ = Describe("ImageExists", func() {
 
 	It("should return false if the image does not exist", func() {
 		gomock.InOrder(
-			mockRegistry.EXPECT().ImageExists(ctx, imageName, gomock.Any(), nil).Return(false, nil),
+			mockRegistry.EXPECT().ImageExists(ctx, imageName, "", gomock.Any(), nil, gomock.Any()).Return(false, nil),
 		)
 
 		exists, err := ImageExists(ctx, clnt, mockRegistry, &mld, namespace, imageName)
@@ -99,7 +103,7 @@ var _ = Describe("ImageExists", func() {
 
 	It("should return an error if the registry call fails", func() {
 		gomock.InOrder(
-			mockRegistry.EXPECT().ImageExists(ctx, imageName, gomock.Any(), nil).Return(false, errors.New("some-error")),
+			mockRegistry.EXPECT().ImageExists(ctx, imageName, "", gomock.Any(), nil, gomock.Any()).Return(false, errors.New("some-error")),
 		)
 
 		exists, err := ImageExists(ctx, clnt, mockRegistry, &mld, namespace, imageName)
@@ -115,7 +119,7 @@ var _ = Describe("ImageExists", func() {
 		}
 
 		gomock.InOrder(
-			mockRegistry.EXPECT().ImageExists(ctx, imageName, gomock.Any(), gomock.Not(gomock.Nil())).Return(false, nil),
+			mockRegistry.EXPECT().ImageExists(ctx, imageName, "", gomock.Any(), nil, gomock.Not(gomock.Nil())).Return(false, nil),
 		)
 
 		exists, err := ImageExists(ctx, clnt, mockRegistry, &mld, namespace, imageName)
@@ -123,4 +127,116 @@ var _ = Describe("ImageExists", func() {
 		Expect(err).ToNot(HaveOccurred())
 		Expect(exists).To(BeFalse())
 	})
+	It("should pass the CA bundle to the registry", func() {
+		mld.RegistryTLS = &kmmv1beta1.TLSOptions{
+			CABundle: &v1.LocalObjectReference{Name: "ca-bundle"},
+		}
+
+		gomock.InOrder(
+			clnt.EXPECT().Get(ctx, types.NamespacedName{Name: "ca-bundle", Namespace: namespace}, gomock.Any()).DoAndReturn(
+				func(_ interface{}, _ interface{}, cm *v1.ConfigMap, _ ...ctrlclient.GetOption) error {
+					cm.Data = map[string]string{constants.CABundleCMKey: "some-pem"}
+					return nil
+				},
+			),
+			mockRegistry.EXPECT().ImageExists(ctx, imageName, "", mld.RegistryTLS, []byte("some-pem"), gomock.Any()).Return(true, nil),
+		)
+
+		exists, err := ImageExists(ctx, clnt, mockRegistry, &mld, namespace, imageName)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(exists).To(BeTrue())
+	})
+})
+
+var _ = Describe("RegistryCABundle", func() {
+	const namespace = "test"
+
+	var (
+		ctrl *gomock.Controller
+		clnt *client.MockClient
+		ctx  context.Context
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		clnt = client.NewMockClient(ctrl)
+		ctx = context.Background()
+	})
+
+	It("should return nil if no CA bundle is referenced", func() {
+		Expect(RegistryCABundle(ctx, clnt, namespace, nil)).To(BeNil())
+		Expect(RegistryCABundle(ctx, clnt, namespace, &kmmv1beta1.TLSOptions{})).To(BeNil())
+	})
+
+	It("should return an error if the ConfigMap cannot be fetched", func() {
+		clnt.EXPECT().Get(ctx, gomock.Any(), gomock.Any()).Return(errors.New("some-error"))
+
+		_, err := RegistryCABundle(ctx, clnt, namespace, &kmmv1beta1.TLSOptions{CABundle: &v1.LocalObjectReference{Name: "ca-bundle"}})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should return an error if the ConfigMap does not contain the CA bundle key", func() {
+		clnt.EXPECT().Get(ctx, gomock.Any(), gomock.Any())
+
+		_, err := RegistryCABundle(ctx, clnt, namespace, &kmmv1beta1.TLSOptions{CABundle: &v1.LocalObjectReference{Name: "ca-bundle"}})
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("ImageBuildHash", func() {
+	const (
+		imageName = "image-name"
+		namespace = "test"
+	)
+
+	var (
+		ctrl *gomock.Controller
+		clnt *client.MockClient
+
+		mockRegistry *registry.MockRegistry
+
+		mld api.ModuleLoaderData
+		ctx context.Context
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		clnt = client.NewMockClient(ctrl)
+
+		mockRegistry = registry.NewMockRegistry(ctrl)
+
+		mld = api.ModuleLoaderData{}
+		ctx = context.Background()
+	})
+
+	It("should return the build hash label of the image", func() {
+		mockRegistry.
+			EXPECT().
+			GetLabels(ctx, imageName, "", gomock.Any(), nil, gomock.Any()).
+			Return(map[string]string{constants.BuildHashImageLabel: "some-hash", "other": "value"}, nil)
+
+		hash, err := ImageBuildHash(ctx, clnt, mockRegistry, &mld, namespace, imageName)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(hash).To(Equal("some-hash"))
+	})
+
+	It("should return an empty hash if the image does not exist", func() {
+		mockRegistry.EXPECT().GetLabels(ctx, imageName, "", gomock.Any(), nil, gomock.Any())
+
+		hash, err := ImageBuildHash(ctx, clnt, mockRegistry, &mld, namespace, imageName)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(hash).To(BeEmpty())
+	})
+
+	It("should return an error if the registry call fails", func() {
+		mockRegistry.EXPECT().GetLabels(ctx, imageName, "", gomock.Any(), nil, gomock.Any()).Return(nil, errors.New("some-error"))
+
+		_, err := ImageBuildHash(ctx, clnt, mockRegistry, &mld, namespace, imageName)
+
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("some-error"))
+	})
 })
@@ -1,34 +1,55 @@
 package module
 
 import (
+	"context"
+	"crypto/sha256"
 	"errors"
 	"fmt"
 	"regexp"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	kmmv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/api"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/build"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/constants"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/sign"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/utils"
 )
 
 //go:generate mockgen -source=kernelmapper.go -package=module -destination=mock_kernelmapper.go KernelMapper,kernelMapperHelperAPI
 
+const dockerfileHashLength = 12
+
+// nodeTemplateVars are the template variables whose value is read from the node.
+var nodeTemplateVars = []string{"NODE_ARCH", "OS_IMAGE_ID", "OS_IMAGE_VERSION"}
+
 type KernelMapper interface {
-	GetModuleLoaderDataForKernel(mod *kmmv1beta1.Module, kernelVersion string) (*api.ModuleLoaderData, error)
+	GetModuleLoaderDataForKernel(ctx context.Context, mod *kmmv1beta1.Module, kernelVersion string, nodeInfo *v1.NodeSystemInfo) (*api.ModuleLoaderData, error)
 }
 
 type kernelMapper struct {
 	helper kernelMapperHelperAPI
 }
 
-func NewKernelMapper(buildHelper build.Helper, signHelper sign.Helper) KernelMapper {
+func NewKernelMapper(client client.Client, buildHelper build.Helper, signHelper sign.Helper) KernelMapper {
 	return &kernelMapper{
-		helper: newKernelMapperHelper(buildHelper, signHelper),
+		helper: newKernelMapperHelper(client, buildHelper, signHelper),
 	}
 }
 
-func (k *kernelMapper) GetModuleLoaderDataForKernel(mod *kmmv1beta1.Module, kernelVersion string) (*api.ModuleLoaderData, error) {
+// GetModuleLoaderDataForKernel returns the ModuleLoaderData for the kernel passed as argument.
+// nodeInfo is used to populate the node-related template variables; it may be nil if no node is known, in which case
+// an error is returned if the mapping uses one of those variables.
+func (k *kernelMapper) GetModuleLoaderDataForKernel(
+	ctx context.Context,
+	mod *kmmv1beta1.Module,
+	kernelVersion string,
+	nodeInfo *v1.NodeSystemInfo) (*api.ModuleLoaderData, error) {
 	mappings := mod.Spec.ModuleLoader.Container.KernelMappings
 	foundMapping, err := k.helper.findKernelMapping(mappings, kernelVersion)
 	if err != nil {
@@ -39,6 +60,15 @@ func (k *kernelMapper) GetModuleLoaderDataForKernel(mod *kmmv1beta1.Module, kern
 		return nil, fmt.Errorf("failed to prepare module loader data for kernel %s: %v", kernelVersion, err)
 	}
 
+	if nodeInfo != nil {
+		mld.Architecture = nodeInfo.Architecture
+	}
+
+	mld.TemplateVars, err = k.helper.getTemplateVars(ctx, mld, nodeInfo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the template variables for kernel %s: %v", kernelVersion, err)
+	}
+
 	err = k.helper.replaceTemplates(mld)
 	if err != nil {
 		return nil, fmt.Errorf("failed to replace templates in module loader data for kernel %s: %v", kernelVersion, err)
@@ -49,16 +79,19 @@ func (k *kernelMapper) GetModuleLoaderDataForKernel(mod *kmmv1beta1.Module, kern
 type kernelMapperHelperAPI interface {
 	findKernelMapping(mappings []kmmv1beta1.KernelMapping, kernelVersion string) (*kmmv1beta1.KernelMapping, error)
 	prepareModuleLoaderData(mapping *kmmv1beta1.KernelMapping, mod *kmmv1beta1.Module, kernelVersion string) (*api.ModuleLoaderData, error)
+	getTemplateVars(ctx context.Context, mld *api.ModuleLoaderData, nodeInfo *v1.NodeSystemInfo) ([]string, error)
 	replaceTemplates(mld *api.ModuleLoaderData) error
 }
 
 type kernelMapperHelper struct {
+	client      client.Client
 	buildHelper build.Helper
 	signHelper  sign.Helper
 }
 
-func newKernelMapperHelper(buildHelper build.Helper, signHelper sign.Helper) kernelMapperHelperAPI {
+func newKernelMapperHelper(client client.Client, buildHelper build.Helper, signHelper sign.Helper) kernelMapperHelperAPI {
 	return &kernelMapperHelper{
+		client:      client,
 		buildHelper: buildHelper,
 		signHelper:  signHelper,
 	}
@@ -85,8 +118,6 @@ func (kh *kernelMapperHelper) findKernelMapping(mappings []kmmv1beta1.KernelMapp
 }
 
 func (kh *kernelMapperHelper) prepareModuleLoaderData(mapping *kmmv1beta1.KernelMapping, mod *kmmv1beta1.Module, kernelVersion string) (*api.ModuleLoaderData, error) {
-	var err error
-
 	mld := &api.ModuleLoaderData{}
 	// prepare the build
 	if mapping.Build != nil || mod.Spec.ModuleLoader.Container.Build != nil {
@@ -95,10 +126,7 @@ func (kh *kernelMapperHelper) prepareModuleLoaderData(mapping *kmmv1beta1.Kernel
 
 	// prepare the sign
 	if mapping.Sign != nil || mod.Spec.ModuleLoader.Container.Sign != nil {
-		mld.Sign, err = kh.signHelper.GetRelevantSign(mod.Spec.ModuleLoader.Container.Sign, mapping.Sign, kernelVersion)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get the relevant Sign configuration for kernel %s: %v", kernelVersion, err)
-		}
+		mld.Sign = kh.signHelper.GetRelevantSign(mod.Spec.ModuleLoader.Container.Sign, mapping.Sign)
 	}
 
 	// prepare TLS options
@@ -117,21 +145,186 @@ func (kh *kernelMapperHelper) prepareModuleLoaderData(mapping *kmmv1beta1.Kernel
 	mld.Name = mod.Name
 	mld.Namespace = mod.Namespace
 	mld.ImageRepoSecret = mod.Spec.ImageRepoSecret
+	mld.ImagePullSecrets = mod.Spec.ModuleLoader.ImagePullSecrets
+	mld.PushSecret = mod.Spec.ModuleLoader.Container.PushSecret
 	mld.Selector = mod.Spec.Selector
 	mld.ServiceAccountName = mod.Spec.ModuleLoader.ServiceAccountName
 	mld.Modprobe = mod.Spec.ModuleLoader.Container.Modprobe
+	mld.ModuleVersion = mod.Spec.ModuleLoader.Container.Version
+	mld.UpgradeStrategy = mod.Spec.ModuleLoader.UpgradeStrategy
+	mld.UnloadPolicy = mod.Spec.UnloadPolicy
 	mld.Owner = mod
 
 	return mld, nil
 }
 
+// getTemplateVars returns all variables that can be used in the templated fields of the ModuleLoaderData.
+func (kh *kernelMapperHelper) getTemplateVars(ctx context.Context, mld *api.ModuleLoaderData, nodeInfo *v1.NodeSystemInfo) ([]string, error) {
+	var arch, osImageID, osImageVersion string
+
+	if nodeInfo != nil {
+		arch = nodeInfo.Architecture
+		osImageID, osImageVersion = utils.OSImageComponents(nodeInfo.OSImage)
+	}
+
+	dockerfileHash, err := kh.getDockerfileHash(ctx, mld)
+	if err != nil {
+		return nil, fmt.Errorf("could not compute the Dockerfile hash: %v", err)
+	}
+
+	vars := append(
+		utils.KernelComponentsAsEnvVars(mld.KernelVersion),
+		"KERNEL_FLAVOR="+utils.KernelFlavor(mld.KernelVersion),
+		"MOD_NAME="+mld.Name,
+		"MOD_NAMESPACE="+mld.Namespace,
+		"NODE_ARCH="+arch,
+		"OS_IMAGE_ID="+osImageID,
+		"OS_IMAGE_VERSION="+osImageVersion,
+		"DOCKERFILE_HASH="+dockerfileHash,
+	)
+
+	return vars, nil
+}
+
+// getDockerfileHash returns a short hash of the Dockerfile used to build the image, or an empty string if there is no
+// build or if the Dockerfile ConfigMap does not exist yet.
+func (kh *kernelMapperHelper) getDockerfileHash(ctx context.Context, mld *api.ModuleLoaderData) (string, error) {
+	if mld.Build == nil || mld.Build.DockerfileConfigMap == nil {
+		return "", nil
+	}
+
+	cm := v1.ConfigMap{}
+	nsn := types.NamespacedName{Name: mld.Build.DockerfileConfigMap.Name, Namespace: mld.Namespace}
+
+	if err := kh.client.Get(ctx, nsn, &cm); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return "", nil
+		}
+
+		return "", fmt.Errorf("could not get the Dockerfile ConfigMap %s: %v", nsn, err)
+	}
+
+	sum := sha256.Sum256([]byte(cm.Data[constants.DockerfileCMKey]))
+
+	return fmt.Sprintf("%x", sum)[:dockerfileHashLength], nil
+}
+
 func (kh *kernelMapperHelper) replaceTemplates(mld *api.ModuleLoaderData) error {
-	osConfigEnvVars := utils.KernelComponentsAsEnvVars(mld.KernelVersion)
-	replacedContainerImage, err := utils.ReplaceInTemplates(osConfigEnvVars, mld.ContainerImage)
+	if err := checkNodeTemplateVars(mld.TemplateVars, templatedFields(mld)...); err != nil {
+		return err
+	}
+
+	replacedContainerImage, err := utils.ReplaceInTemplates(mld.TemplateVars, mld.ContainerImage)
 	if err != nil {
 		return fmt.Errorf("failed to substitute templates in the ContainerImage field: %v", err)
 	}
 	mld.ContainerImage = replacedContainerImage[0]
 
+	if mld.Sign != nil {
+		unsignedImage, err := utils.ReplaceInTemplates(mld.TemplateVars, mld.Sign.UnsignedImage)
+		if err != nil {
+			return fmt.Errorf("failed to substitute templates in the UnsignedImage field: %v", err)
+		}
+		mld.Sign.UnsignedImage = unsignedImage[0]
+
+		mld.Sign.FilesToSign, err = utils.ReplaceInTemplates(mld.TemplateVars, mld.Sign.FilesToSign...)
+		if err != nil {
+			return fmt.Errorf("failed to substitute templates in the FilesToSign field: %v", err)
+		}
+	}
+
+	if mld.Build != nil {
+		for i, ba := range mld.Build.BuildArgs {
+			if ba.ValueFrom != nil {
+				continue
+			}
+
+			value, err := utils.ReplaceInTemplates(mld.TemplateVars, ba.Value)
+			if err != nil {
+				return fmt.Errorf("failed to substitute templates in build argument %s: %v", ba.Name, err)
+			}
+			mld.Build.BuildArgs[i].Value = value[0]
+		}
+	}
+
+	if len(mld.Modprobe.Parameters) > 0 {
+		mld.Modprobe.Parameters, err = utils.ReplaceInTemplates(mld.TemplateVars, mld.Modprobe.Parameters...)
+		if err != nil {
+			return fmt.Errorf("failed to substitute templates in the modprobe parameters: %v", err)
+		}
+	}
+
+	replacedFirmwarePath, err := utils.ReplaceInTemplates(mld.TemplateVars, mld.Modprobe.FirmwarePath)
+	if err != nil {
+		return fmt.Errorf("failed to substitute templates in the FirmwarePath field: %v", err)
+	}
+	mld.Modprobe.FirmwarePath = replacedFirmwarePath[0]
+
+	return nil
+}
+
+// templatedFields returns the fields of mld in which templates are replaced.
+func templatedFields(mld *api.ModuleLoaderData) []string {
+	fields := []string{mld.ContainerImage, mld.Modprobe.FirmwarePath}
+
+	if mld.Sign != nil {
+		fields = append(fields, mld.Sign.UnsignedImage)
+		fields = append(fields, mld.Sign.FilesToSign...)
+	}
+
+	if mld.Build != nil {
+		for _, ba := range mld.Build.BuildArgs {
+			if ba.ValueFrom == nil {
+				fields = append(fields, ba.Value)
+			}
+		}
+	}
+
+	return append(fields, mld.Modprobe.Parameters...)
+}
+
+// checkNodeTemplateVars returns an error if templates use a node template variable that has no value in vars, which
+// happens when no node is known (for example on the hub).
+// A variable is used if replacing it with a placeholder changes the result.
+func checkNodeTemplateVars(vars []string, templates ...string) error {
+	const placeholder = "kmm-node-template-var-placeholder"
+
+	values := make(map[string]string, len(vars))
+
+	for _, v := range vars {
+		name, value, _ := strings.Cut(v, "=")
+		values[name] = value
+	}
+
+	replaced, err := utils.ReplaceInTemplates(vars, templates...)
+	if err != nil {
+		return fmt.Errorf("failed to substitute templates: %v", err)
+	}
+
+	for _, name := range nodeTemplateVars {
+		if values[name] != "" {
+			continue
+		}
+
+		varsWithPlaceholder := []string{name + "=" + placeholder}
+
+		for _, v := range vars {
+			if !strings.HasPrefix(v, name+"=") {
+				varsWithPlaceholder = append(varsWithPlaceholder, v)
+			}
+		}
+
+		withPlaceholder, err := utils.ReplaceInTemplates(varsWithPlaceholder, templates...)
+		if err != nil {
+			return fmt.Errorf("failed to substitute templates: %v", err)
+		}
+
+		for i := range replaced {
+			if replaced[i] != withPlaceholder[i] {
+				return fmt.Errorf("template variable %s is used, but its value is unknown without a node", name)
+			}
+		}
+	}
+
 	return nil
 }
@@ -1,15 +1,24 @@
 package module
 
 import (
+	"context"
 	"fmt"
+
 	"github.com/golang/mock/gomock"
 	kmmv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/api"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/build"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/client"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/constants"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/sign"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/utils"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	v1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 var _ = Describe("GetMergedMappingForKernel", func() {
@@ -25,6 +34,9 @@ var _ = Describe("GetMergedMappingForKernel", func() {
 		mod  kmmv1beta1.Module
 	)
 
+	ctx := context.Background()
+	nodeInfo := v1.NodeSystemInfo{Architecture: "amd64"}
+
 	BeforeEach(func() {
 		ctrl = gomock.NewController(GinkgoT())
 		kh = NewMockkernelMapperHelperAPI(ctrl)
@@ -41,15 +53,18 @@ var _ = Describe("GetMergedMappingForKernel", func() {
 		mld := api.ModuleLoaderData{KernelVersion: kernelVersion}
 		kh.EXPECT().findKernelMapping(mod.Spec.ModuleLoader.Container.KernelMappings, kernelVersion).Return(&mapping, nil)
 		kh.EXPECT().prepareModuleLoaderData(&mapping, &mod, kernelVersion).Return(&mld, nil)
+		kh.EXPECT().getTemplateVars(ctx, &mld, &nodeInfo).Return([]string{"KERNEL_VERSION=" + kernelVersion}, nil)
 		kh.EXPECT().replaceTemplates(&mld).Return(nil)
-		res, err := km.GetModuleLoaderDataForKernel(&mod, kernelVersion)
+		res, err := km.GetModuleLoaderDataForKernel(ctx, &mod, kernelVersion, &nodeInfo)
 		Expect(err).NotTo(HaveOccurred())
 		Expect(res).To(Equal(&mld))
+		Expect(res.TemplateVars).To(Equal([]string{"KERNEL_VERSION=" + kernelVersion}))
+		Expect(res.Architecture).To(Equal("amd64"))
 	})
 
 	It("failed to find kernel mapping", func() {
 		kh.EXPECT().findKernelMapping(mod.Spec.ModuleLoader.Container.KernelMappings, kernelVersion).Return(nil, fmt.Errorf("some error"))
-		res, err := km.GetModuleLoaderDataForKernel(&mod, kernelVersion)
+		res, err := km.GetModuleLoaderDataForKernel(ctx, &mod, kernelVersion, &nodeInfo)
 		Expect(err).To(HaveOccurred())
 		Expect(res).To(BeNil())
 	})
@@ -58,7 +73,18 @@ var _ = Describe("GetMergedMappingForKernel", func() {
 		mapping := kmmv1beta1.KernelMapping{}
 		kh.EXPECT().findKernelMapping(mod.Spec.ModuleLoader.Container.KernelMappings, kernelVersion).Return(&mapping, nil)
 		kh.EXPECT().prepareModuleLoaderData(&mapping, &mod, kernelVersion).Return(nil, fmt.Errorf("some error"))
-		res, err := km.GetModuleLoaderDataForKernel(&mod, kernelVersion)
+		res, err := km.GetModuleLoaderDataForKernel(ctx, &mod, kernelVersion, &nodeInfo)
+		Expect(err).To(HaveOccurred())
+		Expect(res).To(BeNil())
+	})
+
+	It("failed to get the template variables", func() {
+		mapping := kmmv1beta1.KernelMapping{}
+		mld := api.ModuleLoaderData{KernelVersion: kernelVersion}
+		kh.EXPECT().findKernelMapping(mod.Spec.ModuleLoader.Container.KernelMappings, kernelVersion).Return(&mapping, nil)
+		kh.EXPECT().prepareModuleLoaderData(&mapping, &mod, kernelVersion).Return(&mld, nil)
+		kh.EXPECT().getTemplateVars(ctx, &mld, &nodeInfo).Return(nil, fmt.Errorf("some error"))
+		res, err := km.GetModuleLoaderDataForKernel(ctx, &mod, kernelVersion, &nodeInfo)
 		Expect(err).To(HaveOccurred())
 		Expect(res).To(BeNil())
 	})
@@ -68,8 +94,9 @@ var _ = Describe("GetMergedMappingForKernel", func() {
 		mld := api.ModuleLoaderData{KernelVersion: kernelVersion}
 		kh.EXPECT().findKernelMapping(mod.Spec.ModuleLoader.Container.KernelMappings, kernelVersion).Return(&mapping, nil)
 		kh.EXPECT().prepareModuleLoaderData(&mapping, &mod, kernelVersion).Return(&mld, nil)
+		kh.EXPECT().getTemplateVars(ctx, &mld, &nodeInfo).Return(nil, nil)
 		kh.EXPECT().replaceTemplates(&mld).Return(fmt.Errorf("some error"))
-		res, err := km.GetModuleLoaderDataForKernel(&mod, kernelVersion)
+		res, err := km.GetModuleLoaderDataForKernel(ctx, &mod, kernelVersion, &nodeInfo)
 		Expect(err).To(HaveOccurred())
 		Expect(res).To(BeNil())
 	})
@@ -87,7 +114,7 @@ var _ = Describe("findKernelMapping", func() {
 
 	BeforeEach(func() {
 		ctrl = gomock.NewController(GinkgoT())
-		kh = newKernelMapperHelper(nil, nil)
+		kh = newKernelMapperHelper(nil, nil, nil)
 	})
 
 	AfterEach(func() {
@@ -159,9 +186,10 @@ var _ = Describe("prepareModuleLoaderData", func() {
 		ctrl = gomock.NewController(GinkgoT())
 		buildHelper = build.NewMockHelper(ctrl)
 		signHelper = sign.NewMockHelper(ctrl)
-		kh = newKernelMapperHelper(buildHelper, signHelper)
+		kh = newKernelMapperHelper(nil, buildHelper, signHelper)
 		mod = kmmv1beta1.Module{}
 		mod.Spec.ModuleLoader.Container.ContainerImage = "spec container image"
+		mod.Spec.ModuleLoader.Container.Version = "v1"
 		mapping = kmmv1beta1.KernelMapping{}
 	})
 
@@ -192,6 +220,7 @@ var _ = Describe("prepareModuleLoaderData", func() {
 			ServiceAccountName: mod.Spec.ModuleLoader.ServiceAccountName,
 			Modprobe:           mod.Spec.ModuleLoader.Container.Modprobe,
 			KernelVersion:      kernelVersion,
+			ModuleVersion:      "v1",
 		}
 
 		if buildExistsInMapping {
@@ -223,7 +252,7 @@ var _ = Describe("prepareModuleLoaderData", func() {
 		}
 		if signExistsInMapping || SignExistsInModuleSpec {
 			mld.Sign = sign
-			signHelper.EXPECT().GetRelevantSign(mod.Spec.ModuleLoader.Container.Sign, mapping.Sign, kernelVersion).Return(sign, nil)
+			signHelper.EXPECT().GetRelevantSign(mod.Spec.ModuleLoader.Container.Sign, mapping.Sign).Return(sign)
 		}
 
 		res, err := kh.prepareModuleLoaderData(&mapping, &mod, kernelVersion)
@@ -239,47 +268,199 @@ var _ = Describe("prepareModuleLoaderData", func() {
 	)
 })
 
+var _ = Describe("getTemplateVars", func() {
+	const (
+		kernelVersion = "5.14.0-284.el9.aarch64+64k"
+		dockerfile    = "FROM some-image"
+	)
+
+	var (
+		ctrl *gomock.Controller
+		clnt *client.MockClient
+		kh   kernelMapperHelperAPI
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		clnt = client.NewMockClient(ctrl)
+		kh = newKernelMapperHelper(clnt, nil, nil)
+	})
+
+	ctx := context.Background()
+
+	It("should return the module, kernel and node variables", func() {
+		mld := api.ModuleLoaderData{
+			Name:          "some-module",
+			Namespace:     "some-namespace",
+			KernelVersion: kernelVersion,
+		}
+		nodeInfo := v1.NodeSystemInfo{
+			Architecture: "arm64",
+			OSImage:      "Red Hat Enterprise Linux CoreOS 413.92.202303281804-0 (Plow)",
+		}
+
+		res, err := kh.getTemplateVars(ctx, &mld, &nodeInfo)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).To(ContainElements(
+			"KERNEL_FULL_VERSION="+kernelVersion,
+			"KERNEL_FLAVOR=64k",
+			"MOD_NAME=some-module",
+			"MOD_NAMESPACE=some-namespace",
+			"NODE_ARCH=arm64",
+			"OS_IMAGE_ID=red-hat-enterprise-linux-coreos",
+			"OS_IMAGE_VERSION=413.92.202303281804-0",
+			"DOCKERFILE_HASH=",
+		))
+	})
+
+	It("should return empty node variables if no node is known", func() {
+		mld := api.ModuleLoaderData{KernelVersion: kernelVersion}
+
+		res, err := kh.getTemplateVars(ctx, &mld, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).To(ContainElements("NODE_ARCH=", "OS_IMAGE_ID=", "OS_IMAGE_VERSION="))
+	})
+
+	It("should return a short hash of the Dockerfile", func() {
+		mld := api.ModuleLoaderData{
+			Namespace:     "some-namespace",
+			KernelVersion: kernelVersion,
+			Build: &kmmv1beta1.Build{
+				DockerfileConfigMap: &v1.LocalObjectReference{Name: "some-cm"},
+			},
+		}
+
+		clnt.
+			EXPECT().
+			Get(ctx, types.NamespacedName{Name: "some-cm", Namespace: "some-namespace"}, gomock.Any()).
+			DoAndReturn(func(_ interface{}, _ interface{}, cm *v1.ConfigMap, _ ...ctrlclient.GetOption) error {
+				cm.Data = map[string]string{constants.DockerfileCMKey: dockerfile}
+				return nil
+			})
+
+		res, err := kh.getTemplateVars(ctx, &mld, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).To(ContainElement("DOCKERFILE_HASH=66015d35e176"))
+	})
+
+	It("should return an empty Dockerfile hash if the ConfigMap does not exist", func() {
+		mld := api.ModuleLoaderData{
+			KernelVersion: kernelVersion,
+			Build: &kmmv1beta1.Build{
+				DockerfileConfigMap: &v1.LocalObjectReference{Name: "some-cm"},
+			},
+		}
+
+		clnt.
+			EXPECT().
+			Get(ctx, gomock.Any(), gomock.Any()).
+			Return(k8serrors.NewNotFound(schema.GroupResource{}, "some-cm"))
+
+		res, err := kh.getTemplateVars(ctx, &mld, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).To(ContainElement("DOCKERFILE_HASH="))
+	})
+
+	It("should return an error if the ConfigMap could not be fetched", func() {
+		mld := api.ModuleLoaderData{
+			KernelVersion: kernelVersion,
+			Build: &kmmv1beta1.Build{
+				DockerfileConfigMap: &v1.LocalObjectReference{Name: "some-cm"},
+			},
+		}
+
+		clnt.EXPECT().Get(ctx, gomock.Any(), gomock.Any()).Return(fmt.Errorf("some error"))
+
+		_, err := kh.getTemplateVars(ctx, &mld, nil)
+		Expect(err).To(HaveOccurred())
+	})
+})
+
 var _ = Describe("replaceTemplates", func() {
 	const kernelVersion = "5.8.18-100.fc31.x86_64"
 
-	kh := newKernelMapperHelper(nil, nil)
+	kh := newKernelMapperHelper(nil, nil, nil)
+
+	templateVars := append(utils.KernelComponentsAsEnvVars(kernelVersion), "MOD_NAME=some-module", "NODE_ARCH=amd64")
 
 	It("error input", func() {
 		mld := api.ModuleLoaderData{
 			ContainerImage: "some image:${KERNEL_XYZ",
 			KernelVersion:  kernelVersion,
+			TemplateVars:   templateVars,
 		}
 		err := kh.replaceTemplates(&mld)
 		Expect(err).To(HaveOccurred())
 	})
 
-	It("should only substitute the ContainerImage field", func() {
+	It("should substitute all templated fields", func() {
 		mld := api.ModuleLoaderData{
-			ContainerImage: "some image:${KERNEL_XYZ}",
+			ContainerImage: "some image:${KERNEL_XYZ}-${NODE_ARCH}",
 			Build: &kmmv1beta1.Build{
 				BuildArgs: []kmmv1beta1.BuildArg{
 					{Name: "name1", Value: "value1"},
 					{Name: "kernel version", Value: "${KERNEL_FULL_VERSION}"},
+					{Name: "secret", ValueFrom: &kmmv1beta1.BuildArgSource{}},
 				},
 				DockerfileConfigMap: &v1.LocalObjectReference{},
 			},
+			Sign: &kmmv1beta1.Sign{
+				UnsignedImage: "some unsigned image:${KERNEL_XYZ}",
+				FilesToSign:   []string{"/opt/lib/modules/${KERNEL_FULL_VERSION}/${MOD_NAME}.ko"},
+			},
+			Modprobe: kmmv1beta1.ModprobeSpec{
+				Parameters:   []string{"param=${KERNEL_X}"},
+				FirmwarePath: "/firmware/${NODE_ARCH}",
+			},
 			KernelVersion: kernelVersion,
+			TemplateVars:  templateVars,
 		}
 		expectMld := api.ModuleLoaderData{
-			ContainerImage: "some image:5.8.18",
+			ContainerImage: "some image:5.8.18-amd64",
 			Build: &kmmv1beta1.Build{
 				BuildArgs: []kmmv1beta1.BuildArg{
 					{Name: "name1", Value: "value1"},
-					{Name: "kernel version", Value: "${KERNEL_FULL_VERSION}"},
+					{Name: "kernel version", Value: kernelVersion},
+					{Name: "secret", ValueFrom: &kmmv1beta1.BuildArgSource{}},
 				},
 				DockerfileConfigMap: &v1.LocalObjectReference{},
 			},
+			Sign: &kmmv1beta1.Sign{
+				UnsignedImage: "some unsigned image:5.8.18",
+				FilesToSign:   []string{"/opt/lib/modules/" + kernelVersion + "/some-module.ko"},
+			},
+			Modprobe: kmmv1beta1.ModprobeSpec{
+				Parameters:   []string{"param=5"},
+				FirmwarePath: "/firmware/amd64",
+			},
 			KernelVersion: kernelVersion,
+			TemplateVars:  templateVars,
 		}
 
 		err := kh.replaceTemplates(&mld)
 		Expect(err).NotTo(HaveOccurred())
 		Expect(mld).To(Equal(expectMld))
 	})
+	It("should return an error if a node variable is used but no node is known", func() {
+		mld := api.ModuleLoaderData{
+			ContainerImage: "some image:${KERNEL_XYZ}",
+			Modprobe:       kmmv1beta1.ModprobeSpec{Parameters: []string{"os=${OS_IMAGE_ID}"}},
+			KernelVersion:  kernelVersion,
+			TemplateVars:   append(templateVars, "OS_IMAGE_ID=", "OS_IMAGE_VERSION="),
+		}
+
+		err := kh.replaceTemplates(&mld)
+		Expect(err).To(MatchError(ContainSubstring("OS_IMAGE_ID")))
+	})
 
+	It("should not return an error if the unknown node variables are not used", func() {
+		mld := api.ModuleLoaderData{
+			ContainerImage: "some image:${KERNEL_XYZ}-${NODE_ARCH}",
+			KernelVersion:  kernelVersion,
+			TemplateVars:   append(templateVars, "OS_IMAGE_ID=", "OS_IMAGE_VERSION="),
+		}
+
+		Expect(kh.replaceTemplates(&mld)).To(Succeed())
+		Expect(mld.ContainerImage).To(Equal("some image:5.8.18-amd64"))
+	})
 })
@@ -5,11 +5,13 @@
 package module
 
 import (
+	context "context"
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
 	v1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
 	api "github.com/kubernetes-sigs/kernel-module-management/internal/api"
+	v1 "k8s.io/api/core/v1"
 )
 
 // MockKernelMapper is a mock of KernelMapper interface.
@@ -36,18 +38,18 @@ func (m *MockKernelMapper) EXPECT() *MockKernelMapperMockRecorder {
 }
 
 // GetModuleLoaderDataForKernel mocks base method.
-func (m *MockKernelMapper) GetModuleLoaderDataForKernel(mod *v1beta1.Module, kernelVersion string) (*api.ModuleLoaderData, error) {
+func (m *MockKernelMapper) GetModuleLoaderDataForKernel(ctx context.Context, mod *v1beta1.Module, kernelVersion string, nodeInfo *v1.NodeSystemInfo) (*api.ModuleLoaderData, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetModuleLoaderDataForKernel", mod, kernelVersion)
+	ret := m.ctrl.Call(m, "GetModuleLoaderDataForKernel", ctx, mod, kernelVersion, nodeInfo)
 	ret0, _ := ret[0].(*api.ModuleLoaderData)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetModuleLoaderDataForKernel indicates an expected call of GetModuleLoaderDataForKernel.
-func (mr *MockKernelMapperMockRecorder) GetModuleLoaderDataForKernel(mod, kernelVersion interface{}) *gomock.Call {
+func (mr *MockKernelMapperMockRecorder) GetModuleLoaderDataForKernel(ctx, mod, kernelVersion, nodeInfo interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetModuleLoaderDataForKernel", reflect.TypeOf((*MockKernelMapper)(nil).GetModuleLoaderDataForKernel), mod, kernelVersion)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetModuleLoaderDataForKernel", reflect.TypeOf((*MockKernelMapper)(nil).GetModuleLoaderDataForKernel), ctx, mod, kernelVersion, nodeInfo)
 }
 
 // MockkernelMapperHelperAPI is a mock of kernelMapperHelperAPI interface.
@@ -88,6 +90,21 @@ func (mr *MockkernelMapperHelperAPIMockRecorder) findKernelMapping(mappings, ker
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "findKernelMapping", reflect.TypeOf((*MockkernelMapperHelperAPI)(nil).findKernelMapping), mappings, kernelVersion)
 }
 
+// getTemplateVars mocks base method.
+func (m *MockkernelMapperHelperAPI) getTemplateVars(ctx context.Context, mld *api.ModuleLoaderData, nodeInfo *v1.NodeSystemInfo) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "getTemplateVars", ctx, mld, nodeInfo)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// getTemplateVars indicates an expected call of getTemplateVars.
+func (mr *MockkernelMapperHelperAPIMockRecorder) getTemplateVars(ctx, mld, nodeInfo interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "getTemplateVars", reflect.TypeOf((*MockkernelMapperHelperAPI)(nil).getTemplateVars), ctx, mld, nodeInfo)
+}
+
 // prepareModuleLoaderData mocks base method.
 func (m *MockkernelMapperHelperAPI) prepareModuleLoaderData(mapping *v1beta1.KernelMapping, mod *v1beta1.Module, kernelVersion string) (*api.ModuleLoaderData, error) {
 	m.ctrl.T.Helper()
@@ -0,0 +1,121 @@
+package nodecondition
+
+import (
+	"context"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Reasons of the node condition of a Module.
+const (
+	ReasonLoaded       = "Loaded"
+	ReasonLoadFailed   = "LoadFailed"
+	ReasonUnloading    = "Unloading"
+	ReasonNoMapping    = "NoMapping"
+	ReasonBuildPending = "BuildPending"
+)
+
+//go:generate mockgen -source=nodecondition.go -package=nodecondition -destination=mock_nodecondition.go
+
+// Updater maintains, on each node, a condition reporting the health of the kernel module of a Module.
+type Updater interface {
+	SetModuleCondition(ctx context.Context, node *v1.Node, namespace, moduleName string, status v1.ConditionStatus, reason, message string) error
+	RemoveModuleCondition(ctx context.Context, node *v1.Node, namespace, moduleName string) error
+}
+
+type updater struct {
+	client client.Client
+}
+
+func NewUpdater(client client.Client) Updater {
+	return &updater{client: client}
+}
+
+// ConditionType returns the type of the node condition of the Module namespace/moduleName, for example
+// KernelModule.default.my-kmod.Loaded for default/my-kmod.
+// Namespaces cannot contain dots, so that different Modules always have different condition types.
+func ConditionType(namespace, moduleName string) v1.NodeConditionType {
+	return v1.NodeConditionType(fmt.Sprintf("KernelModule.%s.%s.Loaded", namespace, moduleName))
+}
+
+// withoutCondition returns the conditions whose type is not conditionType.
+func withoutCondition(conditions []v1.NodeCondition, conditionType v1.NodeConditionType) []v1.NodeCondition {
+	kept := make([]v1.NodeCondition, 0, len(conditions))
+
+	for _, c := range conditions {
+		if c.Type != conditionType {
+			kept = append(kept, c)
+		}
+	}
+
+	return kept
+}
+
+// SetModuleCondition sets the condition of the Module namespace/moduleName on node.
+// The node is only patched if the status, the reason or the message of the condition changed.
+func (u *updater) SetModuleCondition(ctx context.Context, node *v1.Node, namespace, moduleName string, status v1.ConditionStatus, reason, message string) error {
+	conditionType := ConditionType(namespace, moduleName)
+	now := metav1.Now()
+
+	condition := v1.NodeCondition{
+		Type:               conditionType,
+		Status:             status,
+		LastHeartbeatTime:  now,
+		LastTransitionTime: now,
+		Reason:             reason,
+		Message:            message,
+	}
+
+	patchFrom := client.StrategicMergeFrom(node.DeepCopy())
+
+	found := false
+
+	for i, c := range node.Status.Conditions {
+		if c.Type != conditionType {
+			continue
+		}
+
+		if c.Status == status && c.Reason == reason && c.Message == message {
+			return nil
+		}
+
+		if c.Status == status {
+			condition.LastTransitionTime = c.LastTransitionTime
+		}
+
+		node.Status.Conditions[i] = condition
+		found = true
+	}
+
+	if !found {
+		node.Status.Conditions = append(node.Status.Conditions, condition)
+	}
+
+	if err := u.client.Status().Patch(ctx, node, patchFrom); err != nil {
+		return fmt.Errorf("could not patch the status of node %s: %v", node.Name, err)
+	}
+
+	return nil
+}
+
+// RemoveModuleCondition removes the condition of the Module namespace/moduleName from node, if it is present.
+func (u *updater) RemoveModuleCondition(ctx context.Context, node *v1.Node, namespace, moduleName string) error {
+	conditions := withoutCondition(node.Status.Conditions, ConditionType(namespace, moduleName))
+
+	if len(conditions) == len(node.Status.Conditions) {
+		return nil
+	}
+
+	patchFrom := client.StrategicMergeFrom(node.DeepCopy())
+
+	node.Status.Conditions = conditions
+
+	if err := u.client.Status().Patch(ctx, node, patchFrom); err != nil {
+		return fmt.Errorf("could not patch the status of node %s: %v", node.Name, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,184 @@
+package nodecondition
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/client"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	moduleName = "my-kmod.v2"
+	namespace  = "some-namespace"
+)
+
+var _ = Describe("ConditionType", func() {
+	It("should include the namespace and the name of the Module", func() {
+		Expect(
+			ConditionType(namespace, moduleName),
+		).To(
+			Equal(v1.NodeConditionType("KernelModule.some-namespace.my-kmod.v2.Loaded")),
+		)
+	})
+
+	It("should differ for Modules with the same name in different namespaces", func() {
+		Expect(
+			ConditionType("a", moduleName),
+		).NotTo(
+			Equal(ConditionType("b", moduleName)),
+		)
+	})
+})
+
+var _ = Describe("SetModuleCondition", func() {
+	var (
+		clnt *client.MockClient
+		sw   *client.MockStatusWriter
+		u    Updater
+		ctx  = context.Background()
+	)
+
+	BeforeEach(func() {
+		ctrl := gomock.NewController(GinkgoT())
+		clnt = client.NewMockClient(ctrl)
+		sw = client.NewMockStatusWriter(ctrl)
+		u = NewUpdater(clnt)
+	})
+
+	It("should add the condition with a strategic merge patch", func() {
+		node := v1.Node{
+			Status: v1.NodeStatus{
+				Conditions: []v1.NodeCondition{{Type: v1.NodeReady, Status: v1.ConditionTrue}},
+			},
+		}
+
+		clnt.EXPECT().Status().Return(sw)
+		sw.EXPECT().Patch(ctx, &node, gomock.Any()).DoAndReturn(
+			func(_ context.Context, _ ctrlclient.Object, p ctrlclient.Patch, _ ...ctrlclient.SubResourcePatchOption) error {
+				Expect(p.Type()).To(Equal(types.StrategicMergePatchType))
+				return nil
+			},
+		)
+
+		Expect(
+			u.SetModuleCondition(ctx, &node, namespace, moduleName, v1.ConditionTrue, ReasonLoaded, "loaded"),
+		).NotTo(
+			HaveOccurred(),
+		)
+
+		Expect(node.Status.Conditions).To(HaveLen(2))
+		Expect(node.Status.Conditions[1].Type).To(Equal(ConditionType(namespace, moduleName)))
+		Expect(node.Status.Conditions[1].Reason).To(Equal(ReasonLoaded))
+	})
+
+	It("should do nothing if the condition did not change", func() {
+		node := v1.Node{
+			Status: v1.NodeStatus{
+				Conditions: []v1.NodeCondition{
+					{Type: ConditionType(namespace, moduleName), Status: v1.ConditionTrue, Reason: ReasonLoaded, Message: "loaded"},
+				},
+			},
+		}
+
+		Expect(
+			u.SetModuleCondition(ctx, &node, namespace, moduleName, v1.ConditionTrue, ReasonLoaded, "loaded"),
+		).NotTo(
+			HaveOccurred(),
+		)
+	})
+
+	It("should keep the transition time if only the reason changed", func() {
+		transitionTime := metav1.NewTime(time.Now().Add(-time.Hour).Truncate(time.Second))
+
+		node := v1.Node{
+			Status: v1.NodeStatus{
+				Conditions: []v1.NodeCondition{
+					{
+						Type:               ConditionType(namespace, moduleName),
+						Status:             v1.ConditionFalse,
+						Reason:             ReasonBuildPending,
+						LastTransitionTime: transitionTime,
+					},
+				},
+			},
+		}
+
+		clnt.EXPECT().Status().Return(sw)
+		sw.EXPECT().Patch(ctx, &node, gomock.Any())
+
+		Expect(
+			u.SetModuleCondition(ctx, &node, namespace, moduleName, v1.ConditionFalse, ReasonLoadFailed, "failed"),
+		).NotTo(
+			HaveOccurred(),
+		)
+
+		Expect(node.Status.Conditions).To(HaveLen(1))
+		Expect(node.Status.Conditions[0].Reason).To(Equal(ReasonLoadFailed))
+		Expect(node.Status.Conditions[0].LastTransitionTime).To(Equal(transitionTime))
+	})
+
+	It("should return an error if the patch failed", func() {
+		clnt.EXPECT().Status().Return(sw)
+		sw.EXPECT().Patch(ctx, gomock.Any(), gomock.Any()).Return(errors.New("some error"))
+
+		Expect(
+			u.SetModuleCondition(ctx, &v1.Node{}, namespace, moduleName, v1.ConditionTrue, ReasonLoaded, "loaded"),
+		).To(
+			HaveOccurred(),
+		)
+	})
+})
+
+var _ = Describe("RemoveModuleCondition", func() {
+	var (
+		clnt *client.MockClient
+		sw   *client.MockStatusWriter
+		u    Updater
+		ctx  = context.Background()
+	)
+
+	BeforeEach(func() {
+		ctrl := gomock.NewController(GinkgoT())
+		clnt = client.NewMockClient(ctrl)
+		sw = client.NewMockStatusWriter(ctrl)
+		u = NewUpdater(clnt)
+	})
+
+	It("should do nothing if the node does not have the condition", func() {
+		Expect(
+			u.RemoveModuleCondition(ctx, &v1.Node{}, namespace, moduleName),
+		).NotTo(
+			HaveOccurred(),
+		)
+	})
+
+	It("should remove the condition", func() {
+		node := v1.Node{
+			Status: v1.NodeStatus{
+				Conditions: []v1.NodeCondition{
+					{Type: v1.NodeReady},
+					{Type: ConditionType(namespace, moduleName)},
+				},
+			},
+		}
+
+		clnt.EXPECT().Status().Return(sw)
+		sw.EXPECT().Patch(ctx, &node, gomock.Any())
+
+		Expect(
+			u.RemoveModuleCondition(ctx, &node, namespace, moduleName),
+		).NotTo(
+			HaveOccurred(),
+		)
+
+		Expect(node.Status.Conditions).To(Equal([]v1.NodeCondition{{Type: v1.NodeReady}}))
+	})
+})
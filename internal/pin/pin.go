@@ -0,0 +1,161 @@
+package pin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	kmmv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/api"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/auth"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/constants"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/module"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/registry"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/utils"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+//go:generate mockgen -source=pin.go -package=pin -destination=mock_pin.go
+
+type Pinner interface {
+	PinModuleLoaderImage(ctx context.Context, mod *kmmv1beta1.Module, mld *api.ModuleLoaderData) (*kmmv1beta1.PinnedImage, error)
+	PinDevicePluginImage(ctx context.Context, mod *kmmv1beta1.Module) (*kmmv1beta1.PinnedImage, error)
+}
+
+type pinner struct {
+	client    client.Client
+	registry  registry.Registry
+	jobHelper utils.JobHelper
+}
+
+func NewPinner(client client.Client, registry registry.Registry, jobHelper utils.JobHelper) Pinner {
+	return &pinner{
+		client:    client,
+		registry:  registry,
+		jobHelper: jobHelper,
+	}
+}
+
+// PinModuleLoaderImage returns the digest that the ModuleLoader image of mld points to.
+// The digest pinned in the status of mod for the kernel of mld is returned as long as it is current; see isCurrent.
+// It is also resolved again once a build or sign Job of the kernel completes after it was pinned, as that Job pushed
+// the image again to the same tag.
+func (p *pinner) PinModuleLoaderImage(ctx context.Context, mod *kmmv1beta1.Module, mld *api.ModuleLoaderData) (*kmmv1beta1.PinnedImage, error) {
+	var pinned *kmmv1beta1.PinnedImage
+
+	for _, s := range mod.Status.ModuleLoaderImages {
+		if s.KernelVersion == mld.KernelVersion && s.Architecture == mld.Architecture && s.Version == mld.ModuleVersion {
+			pinned = s.Pinned
+			break
+		}
+	}
+
+	if pinned != nil {
+		pushed, err := p.pushedSince(ctx, mld, pinned.ResolvedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		if pushed {
+			log.FromContext(ctx).Info("The image was pushed again since it was pinned", "image", pinned.Image)
+			pinned = nil
+		}
+	}
+
+	return p.pin(ctx, mod, mld, mld.ContainerImage, pinned)
+}
+
+// pushedSince returns true if a build or sign Job of mld completed successfully after since, or at any time if since
+// is nil.
+func (p *pinner) pushedSince(ctx context.Context, mld *api.ModuleLoaderData, since *metav1.Time) (bool, error) {
+	if !module.ShouldBeBuilt(mld) && !module.ShouldBeSigned(mld) {
+		return false, nil
+	}
+
+	for _, jobType := range []string{utils.JobTypeBuild, utils.JobTypeSign} {
+		job, err := p.jobHelper.GetModuleJobByKernel(ctx, mld.Name, mld.Namespace, mld.KernelVersion, mld.Architecture, jobType, mld.Owner)
+		if errors.Is(err, utils.ErrNoMatchingJob) {
+			continue
+		}
+		if err != nil {
+			return false, fmt.Errorf("could not get the %s job: %v", jobType, err)
+		}
+
+		completed := job.Status.CompletionTime
+		if job.Status.Succeeded > 0 && completed != nil && (since == nil || completed.After(since.Time)) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// PinDevicePluginImage returns the digest that the device plugin image of mod points to.
+// The digest pinned in the status of mod is returned as long as it is current; see isCurrent.
+func (p *pinner) PinDevicePluginImage(ctx context.Context, mod *kmmv1beta1.Module) (*kmmv1beta1.PinnedImage, error) {
+	if mod.Spec.DevicePlugin == nil {
+		return nil, nil
+	}
+
+	// the device plugin is pulled with the same credentials as the ModuleLoader, except its own pull secrets
+	mld := &api.ModuleLoaderData{
+		ImageRepoSecret:    mod.Spec.ImageRepoSecret,
+		ImagePullSecrets:   mod.Spec.DevicePlugin.ImagePullSecrets,
+		Namespace:          mod.Namespace,
+		ServiceAccountName: mod.Spec.DevicePlugin.ServiceAccountName,
+	}
+
+	return p.pin(ctx, mod, mld, mod.Spec.DevicePlugin.Container.Image, mod.Status.DevicePluginImage)
+}
+
+func (p *pinner) pin(
+	ctx context.Context,
+	mod *kmmv1beta1.Module,
+	mld *api.ModuleLoaderData,
+	image string,
+	pinned *kmmv1beta1.PinnedImage) (*kmmv1beta1.PinnedImage, error) {
+	refresh := mod.GetAnnotations()[constants.RefreshImageDigestsAnnotation]
+
+	if isCurrent(pinned, image, refresh) {
+		return pinned, nil
+	}
+
+	caBundle, err := module.RegistryCABundle(ctx, p.client, mld.Namespace, mld.RegistryTLS)
+	if err != nil {
+		return nil, fmt.Errorf("could not get the registry CA bundle: %v", err)
+	}
+
+	digest, err := p.registry.GetDigest(ctx, image, mld.RegistryTLS, caBundle, auth.NewRegistryAuthGetterFrom(p.client, mld))
+	if err != nil {
+		return nil, fmt.Errorf("could not resolve the digest of image %s: %v", image, err)
+	}
+
+	log.FromContext(ctx).Info("Pinned image", "image", image, "digest", digest)
+
+	now := metav1.Now()
+
+	return &kmmv1beta1.PinnedImage{Image: image, Digest: digest, Refresh: refresh, ResolvedAt: &now}, nil
+}
+
+// isCurrent returns true if pinned was resolved from image, and if the refresh-image-digests annotation has not
+// changed since.
+func isCurrent(pinned *kmmv1beta1.PinnedImage, image, refresh string) bool {
+	return pinned != nil && pinned.Image == image && pinned.Refresh == refresh
+}
+
+// Reference returns the reference to the pinned image by its digest.
+// It returns an empty string if pinned is nil.
+func Reference(pinned *kmmv1beta1.PinnedImage) string {
+	if pinned == nil {
+		return ""
+	}
+
+	if strings.Contains(pinned.Image, "@") {
+		return pinned.Image
+	}
+
+	return pinned.Image + "@" + pinned.Digest
+}
@@ -0,0 +1,238 @@
+package pin
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	kmmv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/api"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/client"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/constants"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/registry"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/utils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	image         = "example.org/repo/image:tag"
+	digest        = "sha256:1234"
+	kernelVersion = "1.2.3"
+)
+
+// withoutResolvedAt returns a copy of pinned without the time it was resolved at, which tests cannot predict.
+func withoutResolvedAt(pinned *kmmv1beta1.PinnedImage) *kmmv1beta1.PinnedImage {
+	Expect(pinned.ResolvedAt).NotTo(BeNil())
+
+	c := *pinned
+	c.ResolvedAt = nil
+
+	return &c
+}
+
+var _ = Describe("PinModuleLoaderImage", func() {
+	var (
+		ctrl      *gomock.Controller
+		clnt      *client.MockClient
+		mockReg   *registry.MockRegistry
+		jobHelper *utils.MockJobHelper
+		p         Pinner
+		mod       *kmmv1beta1.Module
+		mld       *api.ModuleLoaderData
+		ctx       = context.Background()
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		clnt = client.NewMockClient(ctrl)
+		mockReg = registry.NewMockRegistry(ctrl)
+		jobHelper = utils.NewMockJobHelper(ctrl)
+		p = NewPinner(clnt, mockReg, jobHelper)
+		mod = &kmmv1beta1.Module{}
+		mld = &api.ModuleLoaderData{KernelVersion: kernelVersion, ContainerImage: image}
+	})
+
+	It("should resolve the digest of an image that was not pinned yet", func() {
+		mockReg.EXPECT().GetDigest(ctx, image, nil, nil, gomock.Any()).Return(digest, nil)
+
+		pinned, err := p.PinModuleLoaderImage(ctx, mod, mld)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(withoutResolvedAt(pinned)).To(Equal(&kmmv1beta1.PinnedImage{Image: image, Digest: digest}))
+	})
+
+	It("should return an error if the digest cannot be resolved", func() {
+		mockReg.EXPECT().GetDigest(ctx, image, nil, nil, gomock.Any()).Return("", errors.New("random error"))
+
+		_, err := p.PinModuleLoaderImage(ctx, mod, mld)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should keep the pinned digest while the image does not change", func() {
+		existing := &kmmv1beta1.PinnedImage{Image: image, Digest: "sha256:0000"}
+
+		mod.Status.ModuleLoaderImages = []kmmv1beta1.ModuleLoaderImageStatus{
+			{KernelVersion: kernelVersion, Pinned: existing},
+		}
+
+		pinned, err := p.PinModuleLoaderImage(ctx, mod, mld)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pinned).To(Equal(existing))
+	})
+
+	Context("the image is built", func() {
+		resolvedAt := metav1.Now()
+
+		BeforeEach(func() {
+			mld.Build = &kmmv1beta1.Build{}
+			mod.Status.ModuleLoaderImages = []kmmv1beta1.ModuleLoaderImageStatus{
+				{
+					KernelVersion: kernelVersion,
+					Pinned:        &kmmv1beta1.PinnedImage{Image: image, Digest: "sha256:0000", ResolvedAt: &resolvedAt},
+				},
+			}
+		})
+
+		completedJob := func(completedAt metav1.Time) *batchv1.Job {
+			return &batchv1.Job{
+				Status: batchv1.JobStatus{Succeeded: 1, CompletionTime: &completedAt},
+			}
+		}
+
+		It("should keep the pinned digest if the image was built before it was pinned", func() {
+			gomock.InOrder(
+				jobHelper.
+					EXPECT().
+					GetModuleJobByKernel(ctx, mld.Name, mld.Namespace, kernelVersion, "", utils.JobTypeBuild, mld.Owner).
+					Return(completedJob(metav1.NewTime(resolvedAt.Add(-time.Minute))), nil),
+				jobHelper.
+					EXPECT().
+					GetModuleJobByKernel(ctx, mld.Name, mld.Namespace, kernelVersion, "", utils.JobTypeSign, mld.Owner).
+					Return(nil, utils.ErrNoMatchingJob),
+			)
+
+			pinned, err := p.PinModuleLoaderImage(ctx, mod, mld)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(pinned).To(Equal(mod.Status.ModuleLoaderImages[0].Pinned))
+		})
+
+		It("should resolve the digest again if the image was built since it was pinned", func() {
+			gomock.InOrder(
+				jobHelper.
+					EXPECT().
+					GetModuleJobByKernel(ctx, mld.Name, mld.Namespace, kernelVersion, "", utils.JobTypeBuild, mld.Owner).
+					Return(completedJob(metav1.NewTime(resolvedAt.Add(time.Minute))), nil),
+				mockReg.EXPECT().GetDigest(ctx, image, nil, nil, gomock.Any()).Return(digest, nil),
+			)
+
+			pinned, err := p.PinModuleLoaderImage(ctx, mod, mld)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(withoutResolvedAt(pinned)).To(Equal(&kmmv1beta1.PinnedImage{Image: image, Digest: digest}))
+		})
+
+		It("should return an error if the jobs could not be listed", func() {
+			jobHelper.
+				EXPECT().
+				GetModuleJobByKernel(ctx, mld.Name, mld.Namespace, kernelVersion, "", utils.JobTypeBuild, mld.Owner).
+				Return(nil, errors.New("random error"))
+
+			_, err := p.PinModuleLoaderImage(ctx, mod, mld)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	It("should resolve the digest again if the image changed", func() {
+		mod.Status.ModuleLoaderImages = []kmmv1beta1.ModuleLoaderImageStatus{
+			{
+				KernelVersion: kernelVersion,
+				Pinned:        &kmmv1beta1.PinnedImage{Image: "example.org/repo/image:old", Digest: "sha256:0000"},
+			},
+		}
+
+		mockReg.EXPECT().GetDigest(ctx, image, nil, nil, gomock.Any()).Return(digest, nil)
+
+		pinned, err := p.PinModuleLoaderImage(ctx, mod, mld)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(withoutResolvedAt(pinned)).To(Equal(&kmmv1beta1.PinnedImage{Image: image, Digest: digest}))
+	})
+
+	It("should resolve the digest again if a refresh was requested", func() {
+		mod.SetAnnotations(map[string]string{constants.RefreshImageDigestsAnnotation: "2"})
+		mod.Status.ModuleLoaderImages = []kmmv1beta1.ModuleLoaderImageStatus{
+			{
+				KernelVersion: kernelVersion,
+				Pinned:        &kmmv1beta1.PinnedImage{Image: image, Digest: "sha256:0000", Refresh: "1"},
+			},
+		}
+
+		mockReg.EXPECT().GetDigest(ctx, image, nil, nil, gomock.Any()).Return(digest, nil)
+
+		pinned, err := p.PinModuleLoaderImage(ctx, mod, mld)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(withoutResolvedAt(pinned)).To(Equal(&kmmv1beta1.PinnedImage{Image: image, Digest: digest, Refresh: "2"}))
+	})
+})
+
+var _ = Describe("PinDevicePluginImage", func() {
+	var (
+		ctrl    *gomock.Controller
+		clnt    *client.MockClient
+		mockReg *registry.MockRegistry
+		p       Pinner
+		ctx     = context.Background()
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		clnt = client.NewMockClient(ctrl)
+		mockReg = registry.NewMockRegistry(ctrl)
+		p = NewPinner(clnt, mockReg, nil)
+	})
+
+	It("should do nothing if there is no device plugin", func() {
+		pinned, err := p.PinDevicePluginImage(ctx, &kmmv1beta1.Module{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pinned).To(BeNil())
+	})
+
+	It("should resolve the digest of the device plugin image", func() {
+		mod := &kmmv1beta1.Module{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "namespace"},
+			Spec: kmmv1beta1.ModuleSpec{
+				DevicePlugin: &kmmv1beta1.DevicePluginSpec{
+					Container: kmmv1beta1.DevicePluginContainerSpec{Image: image},
+				},
+			},
+			Status: kmmv1beta1.ModuleStatus{
+				DevicePluginImage: &kmmv1beta1.PinnedImage{Image: "example.org/repo/image:old", Digest: "sha256:0000"},
+			},
+		}
+
+		mockReg.EXPECT().GetDigest(ctx, image, nil, nil, gomock.Any()).Return(digest, nil)
+
+		pinned, err := p.PinDevicePluginImage(ctx, mod)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(withoutResolvedAt(pinned)).To(Equal(&kmmv1beta1.PinnedImage{Image: image, Digest: digest}))
+	})
+})
+
+var _ = Describe("Reference", func() {
+	It("should return an empty string if the image is not pinned", func() {
+		Expect(Reference(nil)).To(BeEmpty())
+	})
+
+	It("should append the digest to the image", func() {
+		Expect(
+			Reference(&kmmv1beta1.PinnedImage{Image: image, Digest: digest}),
+		).To(Equal(image + "@" + digest))
+	})
+
+	It("should not change images that are referenced by digest", func() {
+		Expect(
+			Reference(&kmmv1beta1.PinnedImage{Image: "example.org/repo/image@" + digest, Digest: digest}),
+		).To(Equal("example.org/repo/image@" + digest))
+	})
+})
@@ -3,6 +3,7 @@ package preflight
 import (
 	"context"
 	"fmt"
+	"runtime"
 
 	kmmv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/api"
@@ -14,6 +15,7 @@ import (
 	"github.com/kubernetes-sigs/kernel-module-management/internal/statusupdater"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/utils"
 
+	v1 "k8s.io/api/core/v1"
 	ctrlruntime "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -55,9 +57,16 @@ type preflight struct {
 func (p *preflight) PreflightUpgradeCheck(ctx context.Context, pv *kmmv1beta1.PreflightValidation, mod *kmmv1beta1.Module) (bool, string) {
 	log := ctrlruntime.LoggerFrom(ctx)
 	kernelVersion := pv.Spec.KernelVersion
-	mld, err := p.kernelAPI.GetModuleLoaderDataForKernel(mod, kernelVersion)
+
+	nodeInfo := &v1.NodeSystemInfo{Architecture: pv.Spec.Architecture}
+
+	if nodeInfo.Architecture == "" {
+		nodeInfo.Architecture = runtime.GOARCH
+	}
+
+	mld, err := p.kernelAPI.GetModuleLoaderDataForKernel(ctx, mod, kernelVersion, nodeInfo)
 	if err != nil {
-		return false, fmt.Sprintf("failed to process kernel mapping in the module %s for kernel version %s", mod.Name, kernelVersion)
+		return false, fmt.Sprintf("failed to process kernel mapping in the module %s for kernel version %s: %v", mod.Name, kernelVersion, err)
 	}
 
 	err = p.statusUpdater.PreflightSetVerificationStage(ctx, pv, mld.Name, kmmv1beta1.VerificationStageImage)
@@ -127,8 +136,14 @@ func (p *preflightHelper) verifyImage(ctx context.Context, mld *api.ModuleLoader
 	baseDir := mld.Modprobe.DirName
 	kernelVersion := mld.KernelVersion
 
+	caBundle, err := module.RegistryCABundle(ctx, p.client, mld.Namespace, mld.RegistryTLS)
+	if err != nil {
+		log.Info("could not get the registry CA bundle", "module name", mld.Name, "error", err)
+		return false, fmt.Sprintf("could not get the CA bundle for image %s: %v", image, err)
+	}
+
 	registryAuthGetter := auth.NewRegistryAuthGetterFrom(p.client, mld)
-	digests, repoConfig, err := p.registryAPI.GetLayersDigests(ctx, image, mld.RegistryTLS, registryAuthGetter)
+	digests, repoConfig, err := p.registryAPI.GetLayersDigests(ctx, image, mld.Architecture, mld.RegistryTLS, caBundle, registryAuthGetter)
 	if err != nil {
 		log.Info("image layers inaccessible, image probably does not exists", "module name", mld.Name, "image", image)
 		return false, fmt.Sprintf("image %s inaccessible or does not exists", image)
@@ -3,6 +3,7 @@ package preflight
 import (
 	context "context"
 	"fmt"
+	"runtime"
 	"testing"
 
 	"github.com/golang/mock/gomock"
@@ -18,6 +19,7 @@ import (
 	"github.com/kubernetes-sigs/kernel-module-management/internal/utils"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -100,12 +102,37 @@ var _ = Describe("preflight_PreflightUpgradeCheck", func() {
 
 	It("Failed to process mapping", func() {
 		mod.Spec.ModuleLoader.Container.KernelMappings = []kmmv1beta1.KernelMapping{}
-		mockKernelAPI.EXPECT().GetModuleLoaderDataForKernel(mod, kernelVersion).Return(nil, fmt.Errorf("some error"))
+		mockKernelAPI.EXPECT().GetModuleLoaderDataForKernel(context.Background(), mod, kernelVersion, &v1.NodeSystemInfo{Architecture: runtime.GOARCH}).Return(nil, fmt.Errorf("some error"))
 
 		res, message := p.PreflightUpgradeCheck(context.Background(), pv, mod)
 
 		Expect(res).To(BeFalse())
-		Expect(message).To(Equal(fmt.Sprintf("failed to process kernel mapping in the module %s for kernel version %s", mod.Name, kernelVersion)))
+		Expect(message).To(HavePrefix(fmt.Sprintf("failed to process kernel mapping in the module %s for kernel version %s: ", mod.Name, kernelVersion)))
+	})
+
+	It("should map the kernel for the architecture of the PreflightValidation", func() {
+		ctx := context.Background()
+
+		pvWithArch := pv.DeepCopy()
+		pvWithArch.Spec.Architecture = "arm64"
+
+		mld := api.ModuleLoaderData{
+			Name:           mod.Name,
+			Namespace:      mod.Namespace,
+			ContainerImage: containerImage,
+			KernelVersion:  kernelVersion,
+			Architecture:   "arm64",
+		}
+
+		gomock.InOrder(
+			mockKernelAPI.EXPECT().GetModuleLoaderDataForKernel(ctx, mod, kernelVersion, &v1.NodeSystemInfo{Architecture: "arm64"}).Return(&mld, nil),
+			mockStatusUpdater.EXPECT().PreflightSetVerificationStage(ctx, pvWithArch, mld.Name, kmmv1beta1.VerificationStageImage),
+			preflightHelper.EXPECT().verifyImage(ctx, &mld).Return(true, "image message"),
+		)
+
+		res, msg := p.PreflightUpgradeCheck(ctx, pvWithArch, mod)
+		Expect(res).To(BeTrue())
+		Expect(msg).To(Equal("image message"))
 	})
 
 	DescribeTable("correct flow of the image/build/sign verification", func(buildExists, signExists, imageVerified, buildVerified, signVerified,
@@ -125,7 +152,7 @@ var _ = Describe("preflight_PreflightUpgradeCheck", func() {
 			mld.Sign = &kmmv1beta1.Sign{}
 		}
 
-		mockKernelAPI.EXPECT().GetModuleLoaderDataForKernel(mod, kernelVersion).Return(&mld, nil)
+		mockKernelAPI.EXPECT().GetModuleLoaderDataForKernel(ctx, mod, kernelVersion, &v1.NodeSystemInfo{Architecture: runtime.GOARCH}).Return(&mld, nil)
 		mockStatusUpdater.EXPECT().PreflightSetVerificationStage(context.Background(), pv, mld.Name, kmmv1beta1.VerificationStageImage).Return(nil)
 		preflightHelper.EXPECT().verifyImage(ctx, &mld).Return(imageVerified, "image message")
 		if !imageVerified {
@@ -229,7 +256,7 @@ var _ = Describe("preflightHelper_verifyImage", func() {
 		repoConfig := &registry.RepoPullConfig{}
 		digestLayer := v1stream.Layer{}
 		gomock.InOrder(
-			mockRegistryAPI.EXPECT().GetLayersDigests(context.Background(), containerImage, gomock.Any(),
+			mockRegistryAPI.EXPECT().GetLayersDigests(context.Background(), containerImage, "", gomock.Any(), nil,
 				gomock.Any()).Return(digests, repoConfig, nil),
 			mockRegistryAPI.EXPECT().GetLayerByDigest(digests[1], repoConfig).Return(&digestLayer, nil),
 			mockRegistryAPI.EXPECT().VerifyModuleExists(&digestLayer, "/opt", kernelVersion, "simple-kmod.ko").Return(true),
@@ -247,7 +274,7 @@ var _ = Describe("preflightHelper_verifyImage", func() {
 			KernelVersion:  kernelVersion,
 		}
 
-		mockRegistryAPI.EXPECT().GetLayersDigests(context.Background(), containerImage, gomock.Any(),
+		mockRegistryAPI.EXPECT().GetLayersDigests(context.Background(), containerImage, "", gomock.Any(), nil,
 			gomock.Any()).Return(nil, nil, fmt.Errorf("some error"))
 
 		res, message := ph.verifyImage(context.Background(), &mld)
@@ -264,7 +291,7 @@ var _ = Describe("preflightHelper_verifyImage", func() {
 		digests := []string{"digest0", "digest1"}
 		repoConfig := &registry.RepoPullConfig{}
 		gomock.InOrder(
-			mockRegistryAPI.EXPECT().GetLayersDigests(context.Background(), containerImage, gomock.Any(),
+			mockRegistryAPI.EXPECT().GetLayersDigests(context.Background(), containerImage, "", gomock.Any(), nil,
 				gomock.Any()).Return(digests, repoConfig, nil),
 			mockRegistryAPI.EXPECT().GetLayerByDigest(digests[1], repoConfig).Return(nil, fmt.Errorf("some error")),
 		)
@@ -285,7 +312,7 @@ var _ = Describe("preflightHelper_verifyImage", func() {
 		repoConfig := &registry.RepoPullConfig{}
 		digestLayer := v1stream.Layer{}
 		gomock.InOrder(
-			mockRegistryAPI.EXPECT().GetLayersDigests(context.Background(), containerImage, gomock.Any(), gomock.Any()).Return(digests, repoConfig, nil),
+			mockRegistryAPI.EXPECT().GetLayersDigests(context.Background(), containerImage, "", gomock.Any(), nil, gomock.Any()).Return(digests, repoConfig, nil),
 			mockRegistryAPI.EXPECT().GetLayerByDigest(digests[0], repoConfig).Return(&digestLayer, nil),
 			mockRegistryAPI.EXPECT().VerifyModuleExists(&digestLayer, "/opt", kernelVersion, "simple-kmod.ko").Return(false),
 		)
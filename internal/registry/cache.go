@@ -0,0 +1,169 @@
+package registry
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	kmmv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/auth"
+)
+
+// manifestCacheKey identifies a lookup.
+// scope is a hash of the credentials and TLS options used for the lookup, so that a lookup made with some credentials
+// is not served to a caller using other ones.
+type manifestCacheKey struct {
+	image string
+	arch  string
+	scope string
+}
+
+type manifestCacheEntry struct {
+	manifest []byte
+	// labels is only set for the entries of image configs, which are keyed by digest.
+	labels map[string]string
+	// err is only set for lookups that returned "not found"; other errors are never cached.
+	err error
+	// expires is zero for the entries that never expire.
+	expires time.Time
+}
+
+// manifestCache stores the manifests looked up by the operator, so that the reconciliation of many nodes and Modules
+// does not result in as many registry round-trips.
+// It also stores the labels of image configs, keyed by the digest of the config.
+// Entries expire after ttl, except for the manifests of images and the configs referenced by digest, which cannot
+// change; expired entries are swept at most once per ttl, when an entry is added.
+type manifestCache struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	entries   map[manifestCacheKey]manifestCacheEntry
+	nextSweep time.Time
+	now       func() time.Time
+}
+
+func (e manifestCacheEntry) expired(now time.Time) bool {
+	return !e.expires.IsZero() && !now.Before(e.expires)
+}
+
+func newManifestCache(ttl time.Duration) *manifestCache {
+	return &manifestCache{
+		ttl:     ttl,
+		entries: make(map[manifestCacheKey]manifestCacheEntry),
+		now:     time.Now,
+	}
+}
+
+func (c *manifestCache) enabled() bool {
+	return c != nil && c.ttl > 0
+}
+
+func (c *manifestCache) get(key manifestCacheKey) (manifestCacheEntry, bool) {
+	if !c.enabled() {
+		return manifestCacheEntry{}, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok {
+		return manifestCacheEntry{}, false
+	}
+
+	if e.expired(c.now()) {
+		delete(c.entries, key)
+		return manifestCacheEntry{}, false
+	}
+
+	return e, true
+}
+
+func (c *manifestCache) setManifest(key manifestCacheKey, manifest []byte) {
+	c.set(key, manifestCacheEntry{manifest: manifest})
+}
+
+func (c *manifestCache) setLabels(key manifestCacheKey, labels map[string]string) {
+	c.set(key, manifestCacheEntry{labels: labels})
+}
+
+func (c *manifestCache) setNotFound(key manifestCacheKey, err error) {
+	c.set(key, manifestCacheEntry{err: err})
+}
+
+func (c *manifestCache) set(key manifestCacheKey, e manifestCacheEntry) {
+	if !c.enabled() {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+
+	if !now.Before(c.nextSweep) {
+		c.sweep(now)
+	}
+
+	if e.err != nil || !isDigestReference(key.image) {
+		e.expires = now.Add(c.ttl)
+	}
+
+	c.entries[key] = e
+}
+
+// sweep removes the expired entries.
+// It must be called with the lock held.
+func (c *manifestCache) sweep(now time.Time) {
+	for key, e := range c.entries {
+		if e.expired(now) {
+			delete(c.entries, key)
+		}
+	}
+
+	c.nextSweep = now.Add(c.ttl)
+}
+
+// invalidate removes the entries of image, for all architectures and scopes.
+func (c *manifestCache) invalidate(image string) {
+	if !c.enabled() {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key := range c.entries {
+		if key.image == image {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// cacheScope returns a hash of what, besides the image and the architecture, a lookup depends on: the TLS options,
+// the CA bundle and the credentials.
+func cacheScope(tlsOptions *kmmv1beta1.TLSOptions, caBundle []byte, registryAuthGetter auth.RegistryAuthGetter) string {
+	h := sha256.New()
+
+	var insecure, skipTLSVerify bool
+
+	if tlsOptions != nil {
+		insecure, skipTLSVerify = tlsOptions.Insecure, tlsOptions.InsecureSkipTLSVerify
+	}
+
+	fmt.Fprintf(h, "insecure=%t,skipTLSVerify=%t\n", insecure, skipTLSVerify)
+
+	fmt.Fprintf(h, "caBundle=%x\n", sha256.Sum256(caBundle))
+
+	if registryAuthGetter != nil {
+		fmt.Fprintf(h, "auth=%s\n", registryAuthGetter.Scope())
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func isDigestReference(image string) bool {
+	return strings.Contains(image, "@")
+}
@@ -0,0 +1,147 @@
+package registry
+
+import (
+	"errors"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	kmmv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/auth"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("manifestCache", func() {
+	const (
+		taggedImage   = "quay.io/org/image:tag"
+		digestedImage = "quay.io/org/image@sha256:1234"
+	)
+
+	var (
+		c   *manifestCache
+		now time.Time
+	)
+
+	BeforeEach(func() {
+		now = time.Now()
+		c = newManifestCache(time.Minute)
+		c.now = func() time.Time { return now }
+	})
+
+	It("should expire tagged images after the TTL", func() {
+		c.setManifest(manifestCacheKey{image: taggedImage, arch: "amd64"}, []byte("manifest"))
+
+		e, ok := c.get(manifestCacheKey{image: taggedImage, arch: "amd64"})
+		Expect(ok).To(BeTrue())
+		Expect(e.manifest).To(Equal([]byte("manifest")))
+
+		_, ok = c.get(manifestCacheKey{image: taggedImage, arch: "arm64"})
+		Expect(ok).To(BeFalse())
+
+		now = now.Add(time.Minute)
+
+		_, ok = c.get(manifestCacheKey{image: taggedImage, arch: "amd64"})
+		Expect(ok).To(BeFalse())
+	})
+
+	It("should not expire images referenced by digest", func() {
+		c.setManifest(manifestCacheKey{image: digestedImage}, []byte("manifest"))
+
+		now = now.Add(time.Hour)
+
+		c.setManifest(manifestCacheKey{image: taggedImage}, []byte("manifest"))
+
+		e, ok := c.get(manifestCacheKey{image: digestedImage})
+		Expect(ok).To(BeTrue())
+		Expect(e.manifest).To(Equal([]byte("manifest")))
+	})
+
+	It("should expire images that were not found", func() {
+		c.setNotFound(manifestCacheKey{image: digestedImage}, errors.New("not found"))
+
+		e, ok := c.get(manifestCacheKey{image: digestedImage})
+		Expect(ok).To(BeTrue())
+		Expect(e.err).To(HaveOccurred())
+
+		now = now.Add(time.Minute)
+
+		_, ok = c.get(manifestCacheKey{image: digestedImage})
+		Expect(ok).To(BeFalse())
+	})
+
+	It("should not serve an entry to another scope", func() {
+		c.setManifest(manifestCacheKey{image: taggedImage, scope: "a"}, []byte("manifest"))
+
+		_, ok := c.get(manifestCacheKey{image: taggedImage, scope: "b"})
+		Expect(ok).To(BeFalse())
+	})
+
+	It("should sweep the expired entries that are never looked up again", func() {
+		c.setManifest(manifestCacheKey{image: taggedImage}, []byte("manifest"))
+
+		now = now.Add(30 * time.Second)
+
+		c.setManifest(manifestCacheKey{image: digestedImage}, []byte("manifest"))
+		Expect(c.entries).To(HaveLen(2))
+
+		now = now.Add(30 * time.Second)
+
+		c.setManifest(manifestCacheKey{image: "quay.io/org/other:tag"}, []byte("manifest"))
+		Expect(c.entries).To(HaveLen(2))
+		Expect(c.entries).NotTo(HaveKey(manifestCacheKey{image: taggedImage}))
+	})
+
+	It("should invalidate all architectures and scopes of an image", func() {
+		c.setManifest(manifestCacheKey{image: taggedImage, arch: "amd64"}, []byte("manifest"))
+		c.setManifest(manifestCacheKey{image: taggedImage, arch: "arm64", scope: "a"}, []byte("manifest"))
+		c.setManifest(manifestCacheKey{image: digestedImage}, []byte("manifest"))
+
+		c.invalidate(taggedImage)
+
+		_, ok := c.get(manifestCacheKey{image: taggedImage, arch: "amd64"})
+		Expect(ok).To(BeFalse())
+		_, ok = c.get(manifestCacheKey{image: taggedImage, arch: "arm64", scope: "a"})
+		Expect(ok).To(BeFalse())
+		_, ok = c.get(manifestCacheKey{image: digestedImage})
+		Expect(ok).To(BeTrue())
+	})
+
+	It("should not store anything if the TTL is zero", func() {
+		c = newManifestCache(0)
+
+		c.setManifest(manifestCacheKey{image: taggedImage}, []byte("manifest"))
+
+		_, ok := c.get(manifestCacheKey{image: taggedImage})
+		Expect(ok).To(BeFalse())
+	})
+})
+
+var _ = Describe("cacheScope", func() {
+	var (
+		ctrl *gomock.Controller
+		a, b *auth.MockRegistryAuthGetter
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		a = auth.NewMockRegistryAuthGetter(ctrl)
+		b = auth.NewMockRegistryAuthGetter(ctrl)
+	})
+
+	It("should depend on the credentials", func() {
+		a.EXPECT().Scope().Return("ns-a/sa:secret")
+		b.EXPECT().Scope().Return("ns-b/sa:secret")
+
+		Expect(cacheScope(nil, nil, a)).NotTo(Equal(cacheScope(nil, nil, b)))
+	})
+
+	It("should depend on the TLS options and the CA bundle", func() {
+		a.EXPECT().Scope().Return("ns/sa:secret").Times(3)
+
+		scope := cacheScope(nil, nil, a)
+
+		Expect(cacheScope(&kmmv1beta1.TLSOptions{}, nil, a)).To(Equal(scope))
+		Expect(cacheScope(&kmmv1beta1.TLSOptions{InsecureSkipTLSVerify: true}, nil, a)).NotTo(Equal(scope))
+		Expect(cacheScope(nil, []byte("ca"), nil)).NotTo(Equal(cacheScope(nil, nil, nil)))
+	})
+})
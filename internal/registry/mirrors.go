@@ -0,0 +1,109 @@
+package registry
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// MirrorConfig holds the operator-wide registry mirrors and rewrite rules.
+// It is modelled after the [[registry]] tables of containers-registries.conf(5).
+type MirrorConfig struct {
+	// RewriteLoaderImage makes the ModuleLoader DaemonSets use the rewritten image instead of the one in the Module.
+	RewriteLoaderImage bool `json:"rewriteLoaderImage,omitempty"`
+
+	Registries []MirrorRegistry `json:"registries,omitempty"`
+}
+
+// MirrorRegistry applies to all images whose name starts with Prefix.
+type MirrorRegistry struct {
+	// Prefix is matched against the image name as written, e.g. "quay.io" or "quay.io/org/repo".
+	Prefix string `json:"prefix"`
+
+	// Location, if set, replaces Prefix in the image name.
+	// The rewritten image is the last one tried when pulling.
+	Location string `json:"location,omitempty"`
+
+	// Mirrors replace Prefix in the image name and are tried in order before Location.
+	Mirrors []string `json:"mirrors,omitempty"`
+}
+
+// LoadMirrorConfig reads the YAML mirror configuration at path.
+func LoadMirrorConfig(path string) (*MirrorConfig, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read %s: %v", path, err)
+	}
+
+	cfg := MirrorConfig{}
+
+	if err = yaml.UnmarshalStrict(b, &cfg); err != nil {
+		return nil, fmt.Errorf("could not parse %s: %v", path, err)
+	}
+
+	for i, r := range cfg.Registries {
+		if r.Prefix == "" {
+			return nil, fmt.Errorf("registry entry %d: prefix cannot be empty", i)
+		}
+	}
+
+	return &cfg, nil
+}
+
+// Rewrite returns image with the longest matching prefix replaced by its location.
+// image is returned as is if no prefix with a location matches.
+func (c *MirrorConfig) Rewrite(image string) string {
+	r := c.match(image)
+	if r == nil || r.Location == "" {
+		return image
+	}
+
+	return r.Location + strings.TrimPrefix(image, r.Prefix)
+}
+
+// Candidates returns the names under which image should be looked for, in order: first the mirrors and then the
+// rewritten image.
+func (c *MirrorConfig) Candidates(image string) []string {
+	r := c.match(image)
+	if r == nil {
+		return []string{image}
+	}
+
+	candidates := make([]string, 0, len(r.Mirrors)+1)
+
+	for _, m := range r.Mirrors {
+		candidates = append(candidates, m+strings.TrimPrefix(image, r.Prefix))
+	}
+
+	return append(candidates, c.Rewrite(image))
+}
+
+// match returns the entry with the longest prefix matching image, or nil if there is none.
+// A prefix only matches on a path, tag or digest boundary.
+func (c *MirrorConfig) match(image string) *MirrorRegistry {
+	if c == nil {
+		return nil
+	}
+
+	var best *MirrorRegistry
+
+	for i := 0; i < len(c.Registries); i++ {
+		r := &c.Registries[i]
+
+		if !strings.HasPrefix(image, r.Prefix) {
+			continue
+		}
+
+		if rest := image[len(r.Prefix):]; rest != "" && !strings.ContainsAny(rest[:1], "/:@") {
+			continue
+		}
+
+		if best == nil || len(r.Prefix) > len(best.Prefix) {
+			best = r
+		}
+	}
+
+	return best
+}
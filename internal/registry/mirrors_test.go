@@ -0,0 +1,110 @@
+package registry
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MirrorConfig", func() {
+	cfg := &MirrorConfig{
+		Registries: []MirrorRegistry{
+			{
+				Prefix:   "quay.io",
+				Location: "local.registry/quay",
+			},
+			{
+				Prefix:   "quay.io/org/repo",
+				Location: "local.registry/repo",
+				Mirrors:  []string{"mirror1.local/repo", "mirror2.local/repo"},
+			},
+			{
+				Prefix:  "docker.io",
+				Mirrors: []string{"mirror.local/docker"},
+			},
+		},
+	}
+
+	DescribeTable("Rewrite", func(image, expected string) {
+		Expect(cfg.Rewrite(image)).To(Equal(expected))
+	},
+		Entry("no matching prefix", "gcr.io/org/repo:tag", "gcr.io/org/repo:tag"),
+		Entry("registry prefix", "quay.io/other/repo:tag", "local.registry/quay/other/repo:tag"),
+		Entry("longest prefix wins", "quay.io/org/repo:tag", "local.registry/repo:tag"),
+		Entry("digest boundary", "quay.io/org/repo@sha256:1234", "local.registry/repo@sha256:1234"),
+		Entry("not a path boundary", "quay.io/org/repository:tag", "local.registry/quay/org/repository:tag"),
+		Entry("no location", "docker.io/library/ubuntu:22.04", "docker.io/library/ubuntu:22.04"),
+	)
+
+	DescribeTable("Candidates", func(image string, expected []string) {
+		Expect(cfg.Candidates(image)).To(Equal(expected))
+	},
+		Entry("no matching prefix", "gcr.io/org/repo:tag", []string{"gcr.io/org/repo:tag"}),
+		Entry(
+			"mirrors and location",
+			"quay.io/org/repo:tag",
+			[]string{"mirror1.local/repo:tag", "mirror2.local/repo:tag", "local.registry/repo:tag"},
+		),
+		Entry(
+			"mirrors only",
+			"docker.io/library/ubuntu:22.04",
+			[]string{"mirror.local/docker/library/ubuntu:22.04", "docker.io/library/ubuntu:22.04"},
+		),
+	)
+
+	It("should work on a nil config", func() {
+		var nilCfg *MirrorConfig
+
+		Expect(nilCfg.Rewrite("quay.io/org/repo:tag")).To(Equal("quay.io/org/repo:tag"))
+		Expect(nilCfg.Candidates("quay.io/org/repo:tag")).To(Equal([]string{"quay.io/org/repo:tag"}))
+	})
+})
+
+var _ = Describe("LoadMirrorConfig", func() {
+	writeConfig := func(contents string) string {
+		path := filepath.Join(GinkgoT().TempDir(), "mirrors.yaml")
+		Expect(os.WriteFile(path, []byte(contents), 0600)).To(Succeed())
+		return path
+	}
+
+	It("should load a valid config", func() {
+		path := writeConfig(`
+rewriteLoaderImage: true
+registries:
+  - prefix: quay.io
+    location: local.registry/quay
+    mirrors:
+      - mirror.local/quay
+`)
+
+		cfg, err := LoadMirrorConfig(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cfg).To(Equal(&MirrorConfig{
+			RewriteLoaderImage: true,
+			Registries: []MirrorRegistry{
+				{
+					Prefix:   "quay.io",
+					Location: "local.registry/quay",
+					Mirrors:  []string{"mirror.local/quay"},
+				},
+			},
+		}))
+	})
+
+	It("should return an error for unknown fields", func() {
+		_, err := LoadMirrorConfig(writeConfig("unknown: true"))
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should return an error for an empty prefix", func() {
+		_, err := LoadMirrorConfig(writeConfig("registries: [{location: local.registry}]"))
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should return an error if the file does not exist", func() {
+		_, err := LoadMirrorConfig("/non/existent")
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -56,6 +56,34 @@ func (mr *MockRegistryMockRecorder) AddLayerToImage(tarfile, image interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddLayerToImage", reflect.TypeOf((*MockRegistry)(nil).AddLayerToImage), tarfile, image)
 }
 
+// DeleteManifest mocks base method.
+func (m *MockRegistry) DeleteManifest(ctx context.Context, image string, tlsOptions *v1beta1.TLSOptions, caBundle []byte, registryAuthGetter auth.RegistryAuthGetter) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteManifest", ctx, image, tlsOptions, caBundle, registryAuthGetter)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteManifest indicates an expected call of DeleteManifest.
+func (mr *MockRegistryMockRecorder) DeleteManifest(ctx, image, tlsOptions, caBundle, registryAuthGetter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteManifest", reflect.TypeOf((*MockRegistry)(nil).DeleteManifest), ctx, image, tlsOptions, caBundle, registryAuthGetter)
+}
+
+// DeleteTag mocks base method.
+func (m *MockRegistry) DeleteTag(ctx context.Context, image string, tlsOptions *v1beta1.TLSOptions, caBundle []byte, registryAuthGetter auth.RegistryAuthGetter) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTag", ctx, image, tlsOptions, caBundle, registryAuthGetter)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteTag indicates an expected call of DeleteTag.
+func (mr *MockRegistryMockRecorder) DeleteTag(ctx, image, tlsOptions, caBundle, registryAuthGetter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTag", reflect.TypeOf((*MockRegistry)(nil).DeleteTag), ctx, image, tlsOptions, caBundle, registryAuthGetter)
+}
+
 // ExtractBytesFromTar mocks base method.
 func (m *MockRegistry) ExtractBytesFromTar(size int64, tarreader io.Reader) ([]byte, error) {
 	m.ctrl.T.Helper()
@@ -85,19 +113,49 @@ func (mr *MockRegistryMockRecorder) ExtractFileToFile(destination, header, tarre
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExtractFileToFile", reflect.TypeOf((*MockRegistry)(nil).ExtractFileToFile), destination, header, tarreader)
 }
 
+// GetDigest mocks base method.
+func (m *MockRegistry) GetDigest(ctx context.Context, image string, tlsOptions *v1beta1.TLSOptions, caBundle []byte, registryAuthGetter auth.RegistryAuthGetter) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDigest", ctx, image, tlsOptions, caBundle, registryAuthGetter)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDigest indicates an expected call of GetDigest.
+func (mr *MockRegistryMockRecorder) GetDigest(ctx, image, tlsOptions, caBundle, registryAuthGetter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDigest", reflect.TypeOf((*MockRegistry)(nil).GetDigest), ctx, image, tlsOptions, caBundle, registryAuthGetter)
+}
+
 // GetImageByName mocks base method.
-func (m *MockRegistry) GetImageByName(imageName string, auth authn.Authenticator, insecure, skipTLSVerify bool) (v1.Image, error) {
+func (m *MockRegistry) GetImageByName(imageName string, auth authn.Authenticator, insecure, skipTLSVerify bool, caBundle []byte) (v1.Image, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetImageByName", imageName, auth, insecure, skipTLSVerify)
+	ret := m.ctrl.Call(m, "GetImageByName", imageName, auth, insecure, skipTLSVerify, caBundle)
 	ret0, _ := ret[0].(v1.Image)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetImageByName indicates an expected call of GetImageByName.
-func (mr *MockRegistryMockRecorder) GetImageByName(imageName, auth, insecure, skipTLSVerify interface{}) *gomock.Call {
+func (mr *MockRegistryMockRecorder) GetImageByName(imageName, auth, insecure, skipTLSVerify, caBundle interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetImageByName", reflect.TypeOf((*MockRegistry)(nil).GetImageByName), imageName, auth, insecure, skipTLSVerify)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetImageByName", reflect.TypeOf((*MockRegistry)(nil).GetImageByName), imageName, auth, insecure, skipTLSVerify, caBundle)
+}
+
+// GetLabels mocks base method.
+func (m *MockRegistry) GetLabels(ctx context.Context, image, arch string, tlsOptions *v1beta1.TLSOptions, caBundle []byte, registryAuthGetter auth.RegistryAuthGetter) (map[string]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLabels", ctx, image, arch, tlsOptions, caBundle, registryAuthGetter)
+	ret0, _ := ret[0].(map[string]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLabels indicates an expected call of GetLabels.
+func (mr *MockRegistryMockRecorder) GetLabels(ctx, image, arch, tlsOptions, caBundle, registryAuthGetter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLabels", reflect.TypeOf((*MockRegistry)(nil).GetLabels), ctx, image, arch, tlsOptions, caBundle, registryAuthGetter)
 }
 
 // GetLayerByDigest mocks base method.
@@ -131,9 +189,9 @@ func (mr *MockRegistryMockRecorder) GetLayerMediaType(image interface{}) *gomock
 }
 
 // GetLayersDigests mocks base method.
-func (m *MockRegistry) GetLayersDigests(ctx context.Context, image string, tlsOptions *v1beta1.TLSOptions, registryAuthGetter auth.RegistryAuthGetter) ([]string, *RepoPullConfig, error) {
+func (m *MockRegistry) GetLayersDigests(ctx context.Context, image, arch string, tlsOptions *v1beta1.TLSOptions, caBundle []byte, registryAuthGetter auth.RegistryAuthGetter) ([]string, *RepoPullConfig, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetLayersDigests", ctx, image, tlsOptions, registryAuthGetter)
+	ret := m.ctrl.Call(m, "GetLayersDigests", ctx, image, arch, tlsOptions, caBundle, registryAuthGetter)
 	ret0, _ := ret[0].([]string)
 	ret1, _ := ret[1].(*RepoPullConfig)
 	ret2, _ := ret[2].(error)
@@ -141,24 +199,36 @@ func (m *MockRegistry) GetLayersDigests(ctx context.Context, image string, tlsOp
 }
 
 // GetLayersDigests indicates an expected call of GetLayersDigests.
-func (mr *MockRegistryMockRecorder) GetLayersDigests(ctx, image, tlsOptions, registryAuthGetter interface{}) *gomock.Call {
+func (mr *MockRegistryMockRecorder) GetLayersDigests(ctx, image, arch, tlsOptions, caBundle, registryAuthGetter interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLayersDigests", reflect.TypeOf((*MockRegistry)(nil).GetLayersDigests), ctx, image, tlsOptions, registryAuthGetter)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLayersDigests", reflect.TypeOf((*MockRegistry)(nil).GetLayersDigests), ctx, image, arch, tlsOptions, caBundle, registryAuthGetter)
 }
 
 // ImageExists mocks base method.
-func (m *MockRegistry) ImageExists(ctx context.Context, image string, tlsOptions *v1beta1.TLSOptions, registryAuthGetter auth.RegistryAuthGetter) (bool, error) {
+func (m *MockRegistry) ImageExists(ctx context.Context, image, arch string, tlsOptions *v1beta1.TLSOptions, caBundle []byte, registryAuthGetter auth.RegistryAuthGetter) (bool, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ImageExists", ctx, image, tlsOptions, registryAuthGetter)
+	ret := m.ctrl.Call(m, "ImageExists", ctx, image, arch, tlsOptions, caBundle, registryAuthGetter)
 	ret0, _ := ret[0].(bool)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // ImageExists indicates an expected call of ImageExists.
-func (mr *MockRegistryMockRecorder) ImageExists(ctx, image, tlsOptions, registryAuthGetter interface{}) *gomock.Call {
+func (mr *MockRegistryMockRecorder) ImageExists(ctx, image, arch, tlsOptions, caBundle, registryAuthGetter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImageExists", reflect.TypeOf((*MockRegistry)(nil).ImageExists), ctx, image, arch, tlsOptions, caBundle, registryAuthGetter)
+}
+
+// Invalidate mocks base method.
+func (m *MockRegistry) Invalidate(image string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Invalidate", image)
+}
+
+// Invalidate indicates an expected call of Invalidate.
+func (mr *MockRegistryMockRecorder) Invalidate(image interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImageExists", reflect.TypeOf((*MockRegistry)(nil).ImageExists), ctx, image, tlsOptions, registryAuthGetter)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Invalidate", reflect.TypeOf((*MockRegistry)(nil).Invalidate), image)
 }
 
 // VerifyModuleExists mocks base method.
@@ -195,15 +265,15 @@ func (mr *MockRegistryMockRecorder) WalkFilesInImage(image, fn interface{}, data
 }
 
 // WriteImageByName mocks base method.
-func (m *MockRegistry) WriteImageByName(imageName string, image v1.Image, auth authn.Authenticator, insecure, skipTLSVerify bool) error {
+func (m *MockRegistry) WriteImageByName(imageName string, image v1.Image, auth authn.Authenticator, insecure, skipTLSVerify bool, caBundle []byte) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "WriteImageByName", imageName, image, auth, insecure, skipTLSVerify)
+	ret := m.ctrl.Call(m, "WriteImageByName", imageName, image, auth, insecure, skipTLSVerify, caBundle)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // WriteImageByName indicates an expected call of WriteImageByName.
-func (mr *MockRegistryMockRecorder) WriteImageByName(imageName, image, auth, insecure, skipTLSVerify interface{}) *gomock.Call {
+func (mr *MockRegistryMockRecorder) WriteImageByName(imageName, image, auth, insecure, skipTLSVerify, caBundle interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteImageByName", reflect.TypeOf((*MockRegistry)(nil).WriteImageByName), imageName, image, auth, insecure, skipTLSVerify)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteImageByName", reflect.TypeOf((*MockRegistry)(nil).WriteImageByName), imageName, image, auth, insecure, skipTLSVerify, caBundle)
 }
@@ -2,8 +2,13 @@ package registry
 
 import (
 	"archive/tar"
+	"bufio"
+	"bytes"
 	"compress/gzip"
 	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -13,7 +18,10 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/google/go-containerregistry/pkg/compression"
 	"github.com/google/go-containerregistry/pkg/crane"
 	v1 "github.com/google/go-containerregistry/pkg/v1"
 
@@ -26,10 +34,17 @@ import (
 	"github.com/google/go-containerregistry/pkg/v1/mutate"
 	"github.com/google/go-containerregistry/pkg/v1/tarball"
 	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/klauspost/compress/zstd"
 )
 
 const (
 	modulesLocationPath = "lib/modules"
+	linuxOS             = "linux"
+)
+
+var (
+	gzipMagicHeader = []byte{0x1f, 0x8b}
+	zstdMagicHeader = []byte{0x28, 0xb5, 0x2f, 0xfd}
 )
 
 type DriverToolkitEntry struct {
@@ -47,31 +62,163 @@ type RepoPullConfig struct {
 //go:generate mockgen -source=registry.go -package=registry -destination=mock_registry_api.go
 
 type Registry interface {
-	ImageExists(ctx context.Context, image string, tlsOptions *kmmv1beta1.TLSOptions, registryAuthGetter auth.RegistryAuthGetter) (bool, error)
+	ImageExists(ctx context.Context, image string, arch string, tlsOptions *kmmv1beta1.TLSOptions, caBundle []byte, registryAuthGetter auth.RegistryAuthGetter) (bool, error)
+	GetLabels(ctx context.Context, image string, arch string, tlsOptions *kmmv1beta1.TLSOptions, caBundle []byte, registryAuthGetter auth.RegistryAuthGetter) (map[string]string, error)
 	VerifyModuleExists(layer v1.Layer, pathPrefix, kernelVersion, moduleFileName string) bool
-	GetLayersDigests(ctx context.Context, image string, tlsOptions *kmmv1beta1.TLSOptions, registryAuthGetter auth.RegistryAuthGetter) ([]string, *RepoPullConfig, error)
+	GetDigest(ctx context.Context, image string, tlsOptions *kmmv1beta1.TLSOptions, caBundle []byte, registryAuthGetter auth.RegistryAuthGetter) (string, error)
+	GetLayersDigests(ctx context.Context, image string, arch string, tlsOptions *kmmv1beta1.TLSOptions, caBundle []byte, registryAuthGetter auth.RegistryAuthGetter) ([]string, *RepoPullConfig, error)
 	GetLayerByDigest(digest string, pullConfig *RepoPullConfig) (v1.Layer, error)
 	WalkFilesInImage(image v1.Image, fn func(filename string, header *tar.Header, tarreader io.Reader, data []interface{}) error, data ...interface{}) error
 	GetLayerMediaType(image v1.Image) (types.MediaType, error)
 	AddLayerToImage(tarfile string, image v1.Image) (v1.Image, error)
 	ExtractBytesFromTar(size int64, tarreader io.Reader) ([]byte, error)
 	ExtractFileToFile(destination string, header *tar.Header, tarreader io.Reader) error
-	WriteImageByName(imageName string, image v1.Image, auth authn.Authenticator, insecure bool, skipTLSVerify bool) error
-	GetImageByName(imageName string, auth authn.Authenticator, insecure bool, skipTLSVerify bool) (v1.Image, error)
+	WriteImageByName(imageName string, image v1.Image, auth authn.Authenticator, insecure bool, skipTLSVerify bool, caBundle []byte) error
+	GetImageByName(imageName string, auth authn.Authenticator, insecure bool, skipTLSVerify bool, caBundle []byte) (v1.Image, error)
+	Invalidate(image string)
+	DeleteTag(ctx context.Context, image string, tlsOptions *kmmv1beta1.TLSOptions, caBundle []byte, registryAuthGetter auth.RegistryAuthGetter) error
+	DeleteManifest(ctx context.Context, image string, tlsOptions *kmmv1beta1.TLSOptions, caBundle []byte, registryAuthGetter auth.RegistryAuthGetter) error
 }
 
+// ErrDeletionUnsupported is returned when the registry does not support deleting the requested reference.
+var ErrDeletionUnsupported = errors.New("the registry does not support this deletion")
+
+// errArchitectureNotFound is returned when an image exists, but not for the requested architecture.
+var errArchitectureNotFound = errors.New("no image for the architecture")
+
 type registry struct {
+	mirrors      *MirrorConfig
+	cache        *manifestCache
+	operatorAuth auth.RegistryAuthGetter
+
+	transportsMu sync.Mutex
+	// transports are reused across lookups, so that connections to the registries are kept alive.
+	transports map[transportKey]http.RoundTripper
+}
+
+// transportKey identifies the TLS configuration of a transport.
+type transportKey struct {
+	skipTLSVerify bool
+	caBundleHash  [sha256.Size]byte
+}
+
+// NewRegistry returns a Registry that looks for images in the mirrors configured in mirrors, if any.
+// mirrors may be nil.
+// Manifest lookups are cached for cacheTTL, or until Invalidate is called for images found by digest; a zero cacheTTL
+// disables the cache.
+// The credentials from operatorAuth, if not nil, are used after the ones passed to each lookup.
+func NewRegistry(mirrors *MirrorConfig, cacheTTL time.Duration, operatorAuth auth.RegistryAuthGetter) Registry {
+	return &registry{
+		mirrors:      mirrors,
+		cache:        newManifestCache(cacheTTL),
+		operatorAuth: operatorAuth,
+		transports:   make(map[transportKey]http.RoundTripper),
+	}
+}
+
+// Invalidate removes all cached lookups for image and its mirrors.
+// It should be called whenever the operator pushes image.
+func (r *registry) Invalidate(image string) {
+	for _, candidate := range r.mirrors.Candidates(image) {
+		r.cache.invalidate(candidate)
+	}
+}
+
+// existenceCandidates returns the mirrors and the rewritten image, followed by image itself if it was rewritten.
+// Builds and signing push to image, not to the rewritten one; image must therefore be looked up too, or the images
+// pushed by KMM would never be found.
+func (r *registry) existenceCandidates(image string) []string {
+	candidates := r.mirrors.Candidates(image)
+
+	if candidates[len(candidates)-1] != image {
+		candidates = append(candidates, image)
+	}
+
+	return candidates
 }
 
-func NewRegistry() Registry {
-	return &registry{}
+// DeleteTag deletes the tag of image, which must be referenced by tag.
+// Not all registries support deleting tags; ErrDeletionUnsupported is returned in that case.
+func (r *registry) DeleteTag(ctx context.Context, image string, tlsOptions *kmmv1beta1.TLSOptions, caBundle []byte, registryAuthGetter auth.RegistryAuthGetter) error {
+	if isDigestReference(image) {
+		return fmt.Errorf("image %s is not referenced by tag", image)
+	}
+
+	return r.delete(ctx, image, image, tlsOptions, caBundle, registryAuthGetter)
 }
 
-func (r *registry) ImageExists(ctx context.Context, image string, tlsOptions *kmmv1beta1.TLSOptions, registryAuthGetter auth.RegistryAuthGetter) (bool, error) {
-	_, _, err := r.getImageManifest(ctx, image, tlsOptions, registryAuthGetter)
+// DeleteManifest deletes the manifest that image points to.
+// All tags pointing to that manifest are deleted with it.
+func (r *registry) DeleteManifest(ctx context.Context, image string, tlsOptions *kmmv1beta1.TLSOptions, caBundle []byte, registryAuthGetter auth.RegistryAuthGetter) error {
+	pullConfig, err := r.getPullOptions(ctx, image, tlsOptions, caBundle, registryAuthGetter)
+	if err != nil {
+		return fmt.Errorf("failed to get pull options for image %s: %w", image, err)
+	}
+
+	digest, err := crane.Digest(image, pullConfig.authOptions...)
 	if err != nil {
+		return fmt.Errorf("could not get the digest of image %s: %w", image, err)
+	}
+
+	return r.delete(ctx, image, pullConfig.repo+"@"+digest, tlsOptions, caBundle, registryAuthGetter)
+}
+
+func (r *registry) delete(ctx context.Context, image, ref string, tlsOptions *kmmv1beta1.TLSOptions, caBundle []byte, registryAuthGetter auth.RegistryAuthGetter) error {
+	pullConfig, err := r.getPullOptions(ctx, ref, tlsOptions, caBundle, registryAuthGetter)
+	if err != nil {
+		return fmt.Errorf("failed to get pull options for image %s: %w", ref, err)
+	}
+
+	defer r.Invalidate(image)
+
+	if err = crane.Delete(ref, pullConfig.authOptions...); err != nil {
 		te := &transport.Error{}
-		if errors.As(err, &te) && te.StatusCode == http.StatusNotFound {
+		if errors.As(err, &te) {
+			if te.StatusCode == http.StatusNotFound {
+				// already deleted
+				return nil
+			}
+
+			if te.StatusCode == http.StatusMethodNotAllowed || isUnsupported(te) {
+				return fmt.Errorf("could not delete %s: %w: %v", ref, ErrDeletionUnsupported, err)
+			}
+		}
+
+		return fmt.Errorf("could not delete %s: %w", ref, err)
+	}
+
+	return nil
+}
+
+func isUnsupported(te *transport.Error) bool {
+	for _, d := range te.Errors {
+		if d.Code == transport.UnsupportedErrorCode {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ImageExists returns true if the image exists for the arch architecture.
+// If arch is empty, the architecture of the operator is used.
+// caBundle may contain PEM-encoded CA certificates trusted in addition to the system ones.
+// Mirrors and the rewritten image are tried first; image itself, to which KMM pushes the images it builds and signs,
+// has the final say.
+func (r *registry) ImageExists(ctx context.Context, image string, arch string, tlsOptions *kmmv1beta1.TLSOptions, caBundle []byte, registryAuthGetter auth.RegistryAuthGetter) (bool, error) {
+	candidates := r.existenceCandidates(image)
+
+	for _, mirror := range candidates[:len(candidates)-1] {
+		if _, _, err := r.getImageManifest(ctx, mirror, arch, tlsOptions, caBundle, registryAuthGetter); err == nil {
+			return true, nil
+		}
+	}
+
+	image = candidates[len(candidates)-1]
+
+	_, _, err := r.getImageManifest(ctx, image, arch, tlsOptions, caBundle, registryAuthGetter)
+	if err != nil {
+		if isNotFound(err) {
 			return false, nil
 		}
 		return false, fmt.Errorf("could not get image %s: %w", image, err)
@@ -79,12 +226,127 @@ func (r *registry) ImageExists(ctx context.Context, image string, tlsOptions *km
 	return true, nil
 }
 
-func (r *registry) GetLayersDigests(ctx context.Context, image string, tlsOptions *kmmv1beta1.TLSOptions, registryAuthGetter auth.RegistryAuthGetter) ([]string, *RepoPullConfig, error) {
-	manifest, pullConfig, err := r.getImageManifest(ctx, image, tlsOptions, registryAuthGetter)
+// GetDigest returns the digest of the manifest, or of the manifest list, that image points to.
+// Lookups are never cached, as the digest of a tag changes whenever an image is pushed to it.
+// Mirrors are tried first; the rewritten image has the final say.
+func (r *registry) GetDigest(ctx context.Context, image string, tlsOptions *kmmv1beta1.TLSOptions, caBundle []byte, registryAuthGetter auth.RegistryAuthGetter) (string, error) {
+	if isDigestReference(image) {
+		_, digest, _ := strings.Cut(image, "@")
+		return digest, nil
+	}
+
+	var err error
+
+	for _, candidate := range r.mirrors.Candidates(image) {
+		image = candidate
+
+		var pullConfig *RepoPullConfig
+
+		pullConfig, err = r.getPullOptions(ctx, image, tlsOptions, caBundle, registryAuthGetter)
+		if err != nil {
+			err = fmt.Errorf("failed to get pull options for image %s: %w", image, err)
+			continue
+		}
+
+		var digest string
+
+		if digest, err = crane.Digest(image, pullConfig.authOptions...); err == nil {
+			return digest, nil
+		}
+	}
+
+	return "", fmt.Errorf("could not get the digest of image %s: %w", image, err)
+}
+
+// GetLabels returns the labels in the config of image for the arch architecture, or nil if the image does not exist.
+// If arch is empty, the architecture of the operator is used.
+// The manifest lookup is cached like the ones of ImageExists; the labels are cached by config digest, as a config
+// cannot change, so that cached lookups do not contact the registry.
+func (r *registry) GetLabels(ctx context.Context, image string, arch string, tlsOptions *kmmv1beta1.TLSOptions, caBundle []byte, registryAuthGetter auth.RegistryAuthGetter) (map[string]string, error) {
+	manifestStream, _, err := r.getImageManifest(ctx, image, arch, tlsOptions, caBundle, registryAuthGetter)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("could not get image %s: %w", image, err)
+	}
+
+	manifest := v1.Manifest{}
+
+	if err = json.Unmarshal(manifestStream, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal the manifest of image %s: %w", image, err)
+	}
+
+	if manifest.Config.Digest.Hex == "" {
+		// schema 1 manifests have no config
+		return nil, nil
+	}
+
+	var cacheKey manifestCacheKey
+
+	if r.cache.enabled() {
+		cacheKey = manifestCacheKey{
+			image: imageRepo(image) + "@" + manifest.Config.Digest.String(),
+			scope: cacheScope(tlsOptions, caBundle, registryAuthGetter),
+		}
+	}
+
+	if e, ok := r.cache.get(cacheKey); ok {
+		return e.labels, nil
+	}
+
+	pullConfig, err := r.getPullOptions(ctx, image, tlsOptions, caBundle, registryAuthGetter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pull options for image %s: %w", image, err)
+	}
+
+	config, err := r.getImageConfig(manifestStream, pullConfig.repo, pullConfig.authOptions)
+	if err != nil {
+		return nil, fmt.Errorf("could not get the config of image %s: %w", image, err)
+	}
+
+	r.cache.setLabels(cacheKey, config.Config.Labels)
+
+	return config.Config.Labels, nil
+}
+
+// isNotFound returns true if err means that the image does not exist, or does not exist for the requested
+// architecture.
+func isNotFound(err error) bool {
+	te := &transport.Error{}
+
+	return errors.Is(err, errArchitectureNotFound) || (errors.As(err, &te) && te.StatusCode == http.StatusNotFound)
+}
+
+// GetLayersDigests returns the digests of the layers of the image for the arch architecture.
+// If arch is empty, the architecture of the operator is used.
+// The returned RepoPullConfig points at the first mirror, or the rewritten image, that serves the image.
+func (r *registry) GetLayersDigests(ctx context.Context, image string, arch string, tlsOptions *kmmv1beta1.TLSOptions, caBundle []byte, registryAuthGetter auth.RegistryAuthGetter) ([]string, *RepoPullConfig, error) {
+	var (
+		manifest   []byte
+		pullConfig *RepoPullConfig
+		err        error
+	)
+
+	for _, candidate := range r.mirrors.Candidates(image) {
+		image = candidate
+
+		manifest, pullConfig, err = r.getImageManifest(ctx, image, arch, tlsOptions, caBundle, registryAuthGetter)
+		if err == nil {
+			break
+		}
+	}
+
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to get manifest from image %s: %w", image, err)
 	}
 
+	if pullConfig == nil {
+		if pullConfig, err = r.getPullOptions(ctx, image, tlsOptions, caBundle, registryAuthGetter); err != nil {
+			return nil, nil, fmt.Errorf("failed to get pull options for image %s: %w", image, err)
+		}
+	}
+
 	digests, err := r.getLayersDigestsFromManifestStream(manifest)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to get layers digests from manifest of the image %s: %w", image, err)
@@ -104,14 +366,22 @@ func (r *registry) VerifyModuleExists(layer v1.Layer, pathPrefix, kernelVersion,
 	return err == nil
 }
 
-func (r *registry) getPullOptions(ctx context.Context, image string, tlsOptions *kmmv1beta1.TLSOptions, registryAuthGetter auth.RegistryAuthGetter) (*RepoPullConfig, error) {
-	var repo string
+// imageRepo returns the repository of image, or an empty string if image is referenced neither by tag nor by digest.
+func imageRepo(image string) string {
 	if hash := strings.Split(image, "@"); len(hash) > 1 {
-		repo = hash[0]
-	} else if tag := strings.Split(image, ":"); len(tag) > 1 {
-		repo = tag[0]
+		return hash[0]
+	}
+
+	// the registry host may contain a port, so only consider a colon after the last slash as a tag separator
+	if idx := strings.LastIndex(image, ":"); idx > strings.LastIndex(image, "/") {
+		return image[:idx]
 	}
 
+	return ""
+}
+
+func (r *registry) getPullOptions(ctx context.Context, image string, tlsOptions *kmmv1beta1.TLSOptions, caBundle []byte, registryAuthGetter auth.RegistryAuthGetter) (*RepoPullConfig, error) {
+	repo := imageRepo(image)
 	if repo == "" {
 		return nil, fmt.Errorf("image url %s is not valid, does not contain hash or tag", image)
 	}
@@ -120,50 +390,85 @@ func (r *registry) getPullOptions(ctx context.Context, image string, tlsOptions
 		crane.WithContext(ctx),
 	}
 
+	insecure := false
+	skipTLSVerify := false
+
 	if tlsOptions != nil {
-		if tlsOptions.Insecure {
-			options = append(options, crane.Insecure)
-		}
+		insecure = tlsOptions.Insecure
+		skipTLSVerify = tlsOptions.InsecureSkipTLSVerify
+	}
+
+	transportOptions, err := r.getTransportOptions(insecure, skipTLSVerify, caBundle)
+	if err != nil {
+		return nil, err
+	}
 
-		if tlsOptions.InsecureSkipTLSVerify {
-			rt := http.DefaultTransport.(*http.Transport).Clone()
-			rt.TLSClientConfig.InsecureSkipVerify = true
+	options = append(options, transportOptions...)
 
-			options = append(
-				options,
-				crane.WithTransport(rt),
-			)
+	keychains := make([]authn.Keychain, 0, 2)
+
+	for _, getter := range []auth.RegistryAuthGetter{registryAuthGetter, r.operatorAuth} {
+		if getter == nil {
+			continue
 		}
-	}
 
-	if registryAuthGetter != nil {
-		keyChain, err := registryAuthGetter.GetKeyChain(ctx)
+		keyChain, err := getter.GetKeyChain(ctx)
 		if err != nil {
 			return nil, fmt.Errorf("cannot get keychain from the registry auth getter: %w", err)
 		}
+
+		keychains = append(keychains, keyChain)
+	}
+
+	if len(keychains) > 0 {
 		options = append(
 			options,
-			crane.WithAuthFromKeychain(keyChain),
+			crane.WithAuthFromKeychain(authn.NewMultiKeychain(keychains...)),
 		)
 	}
 
 	return &RepoPullConfig{repo: repo, authOptions: options}, nil
 }
 
-func (r *registry) getImageManifest(ctx context.Context, image string, tlsOptions *kmmv1beta1.TLSOptions, registryAuthGetter auth.RegistryAuthGetter) ([]byte, *RepoPullConfig, error) {
-	pullConfig, err := r.getPullOptions(ctx, image, tlsOptions, registryAuthGetter)
+// getImageManifest returns the manifest of image for the arch architecture, and the options used to pull it.
+// Cached lookups neither read the secrets nor contact the registry; the returned RepoPullConfig is nil for them.
+func (r *registry) getImageManifest(ctx context.Context, image string, arch string, tlsOptions *kmmv1beta1.TLSOptions, caBundle []byte, registryAuthGetter auth.RegistryAuthGetter) ([]byte, *RepoPullConfig, error) {
+	var cacheKey manifestCacheKey
+
+	if r.cache.enabled() {
+		cacheKey = manifestCacheKey{image: image, arch: arch, scope: cacheScope(tlsOptions, caBundle, registryAuthGetter)}
+	}
+
+	if e, ok := r.cache.get(cacheKey); ok {
+		if e.err != nil {
+			return nil, nil, e.err
+		}
+
+		return e.manifest, nil, nil
+	}
+
+	pullConfig, err := r.getPullOptions(ctx, image, tlsOptions, caBundle, registryAuthGetter)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to get pull options for image %s: %w", image, err)
 	}
-	manifest, err := r.getManifestStreamFromImage(image, pullConfig.repo, pullConfig.authOptions)
+
+	manifest, err := r.getManifestStreamFromImage(image, arch, pullConfig.repo, pullConfig.authOptions)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to get manifest stream from image %s: %w", image, err)
+		err = fmt.Errorf("failed to get manifest stream from image %s: %w", image, err)
+
+		if te := (&transport.Error{}); errors.As(err, &te) && te.StatusCode == http.StatusNotFound {
+			r.cache.setNotFound(cacheKey, err)
+		}
+
+		return nil, nil, err
 	}
 
+	r.cache.setManifest(cacheKey, manifest)
+
 	return manifest, pullConfig, nil
 }
 
-func (r *registry) getManifestStreamFromImage(image, repo string, options []crane.Option) ([]byte, error) {
+func (r *registry) getManifestStreamFromImage(image, arch, repo string, options []crane.Option) ([]byte, error) {
 	manifest, err := crane.Manifest(image, options...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get crane manifest from image %s: %w", image, err)
@@ -182,8 +487,9 @@ func (r *registry) getManifestStreamFromImage(image, repo string, options []cran
 		return nil, fmt.Errorf("mediaType is missing from the image %s manifest", image)
 	}
 
-	if strings.Contains(imageMediaType, "manifest.list") {
-		archDigest, err := r.getImageDigestFromMultiImage(manifest)
+	// both Docker manifest lists and OCI image indexes reference one manifest per platform
+	if types.MediaType(imageMediaType).IsIndex() {
+		archDigest, err := r.getImageDigestFromMultiImage(manifest, arch)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get arch digets from multi arch image: %w", err)
 		}
@@ -192,10 +498,75 @@ func (r *registry) getManifestStreamFromImage(image, repo string, options []cran
 		if err != nil {
 			return nil, fmt.Errorf("failed to get crane manifest for the arch image: %w", err)
 		}
+
+		return manifest, nil
+	}
+
+	// a single-platform image only declares its platform in its config
+	if err = r.checkImagePlatform(manifest, arch, repo, options); err != nil {
+		return nil, fmt.Errorf("image %s: %w", image, err)
 	}
+
 	return manifest, nil
 }
 
+// checkImagePlatform returns errArchitectureNotFound if the config of the single-platform image whose manifest is
+// manifestStream is not for linux on the arch architecture.
+// If arch is empty, the architecture of the operator is used.
+func (r *registry) checkImagePlatform(manifestStream []byte, arch, repo string, options []crane.Option) error {
+	if arch == "" {
+		arch = runtime.GOARCH
+	}
+
+	config, err := r.getImageConfig(manifestStream, repo, options)
+	if err != nil {
+		return err
+	}
+
+	if config == nil {
+		return nil
+	}
+
+	if (config.OS != "" && config.OS != linuxOS) || config.Architecture != arch {
+		return fmt.Errorf("%w %s: the image is for %s/%s", errArchitectureNotFound, arch, config.OS, config.Architecture)
+	}
+
+	return nil
+}
+
+// getImageConfig returns the config of the single-platform image whose manifest is manifestStream, or nil if the
+// manifest has no config.
+func (r *registry) getImageConfig(manifestStream []byte, repo string, options []crane.Option) (*v1.ConfigFile, error) {
+	manifest := v1.Manifest{}
+
+	if err := json.Unmarshal(manifestStream, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal manifest stream: %w", err)
+	}
+
+	if manifest.Config.Digest.Hex == "" {
+		// schema 1 manifests have no config
+		return nil, nil
+	}
+
+	configBlob, err := crane.PullLayer(repo+"@"+manifest.Config.Digest.String(), options...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the config blob: %w", err)
+	}
+
+	rc, err := configBlob.Compressed()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the config blob: %w", err)
+	}
+	defer rc.Close()
+
+	config, err := v1.ParseConfigFile(rc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the config: %w", err)
+	}
+
+	return config, nil
+}
+
 func (r *registry) getLayersDigestsFromManifestStream(manifestStream []byte) ([]string, error) {
 	manifest := v1.Manifest{}
 
@@ -212,21 +583,14 @@ func (r *registry) getLayersDigestsFromManifestStream(manifestStream []byte) ([]
 
 func (r *registry) getHeaderStreamFromLayer(layer v1.Layer, headerName string) (io.Reader, error) {
 
-	targz, err := layer.Compressed()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get targz from layer: %w", err)
-	}
-	// err ignored because we're only reading
-	defer targz.Close()
-
-	gr, err := gzip.NewReader(targz)
+	layerReader, err := r.getLayerTarReader(layer)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create reader from targz: %w", err)
+		return nil, fmt.Errorf("failed to get the tar stream from layer: %w", err)
 	}
 	// err ignored because we're only reading
-	defer gr.Close()
+	defer layerReader.Close()
 
-	tr := tar.NewReader(gr)
+	tr := tar.NewReader(layerReader)
 
 	for {
 		header, err := tr.Next()
@@ -235,7 +599,7 @@ func (r *registry) getHeaderStreamFromLayer(layer v1.Layer, headerName string) (
 				break
 			}
 
-			return nil, fmt.Errorf("failed to get next entry from targz: %w", err)
+			return nil, fmt.Errorf("failed to get next entry from the layer: %w", err)
 		}
 		if header.Name == headerName {
 			return tr, nil
@@ -245,19 +609,82 @@ func (r *registry) getHeaderStreamFromLayer(layer v1.Layer, headerName string) (
 	return nil, fmt.Errorf("header %s not found in the layer", headerName)
 }
 
-func (r *registry) getImageDigestFromMultiImage(manifestListStream []byte) (string, error) {
-	arch := runtime.GOARCH
+// getLayerTarReader returns the uncompressed tar stream of the layer.
+// The compression is detected from the content of the layer rather than from its media type, as the latter is not
+// always accurate; gzip, zstd and uncompressed layers are supported.
+func (r *registry) getLayerTarReader(layer v1.Layer) (io.ReadCloser, error) {
+	compressed, err := layer.Compressed()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the compressed stream from layer: %w", err)
+	}
+
+	br := bufio.NewReader(compressed)
+
+	header, err := br.Peek(len(zstdMagicHeader))
+	if err != nil && !errors.Is(err, io.EOF) {
+		compressed.Close()
+		return nil, fmt.Errorf("failed to read the layer header: %w", err)
+	}
+
+	switch {
+	case bytes.HasPrefix(header, gzipMagicHeader):
+		gr, err := gzip.NewReader(br)
+		if err != nil {
+			compressed.Close()
+			return nil, fmt.Errorf("failed to create gzip reader: %w", err)
+		}
+
+		return &layerReadCloser{Reader: gr, closers: []io.Closer{gr, compressed}}, nil
+	case bytes.HasPrefix(header, zstdMagicHeader):
+		zr, err := zstd.NewReader(br)
+		if err != nil {
+			compressed.Close()
+			return nil, fmt.Errorf("failed to create zstd reader: %w", err)
+		}
+
+		return &layerReadCloser{Reader: zr, closers: []io.Closer{zr.IOReadCloser(), compressed}}, nil
+	default:
+		return &layerReadCloser{Reader: br, closers: []io.Closer{compressed}}, nil
+	}
+}
+
+type layerReadCloser struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (l *layerReadCloser) Close() error {
+	var err error
+
+	for _, c := range l.closers {
+		if cerr := c.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+
+	return err
+}
+
+func (r *registry) getImageDigestFromMultiImage(manifestListStream []byte, arch string) (string, error) {
+	if arch == "" {
+		arch = runtime.GOARCH
+	}
+
 	manifestList := v1.IndexManifest{}
 
 	if err := json.Unmarshal(manifestListStream, &manifestList); err != nil {
 		return "", fmt.Errorf("failed to unmarshal manifest stream: %w", err)
 	}
 	for _, manifest := range manifestList.Manifests {
-		if manifest.Platform != nil && manifest.Platform.Architecture == arch {
+		if manifest.Platform == nil || (manifest.Platform.OS != "" && manifest.Platform.OS != linuxOS) {
+			continue
+		}
+
+		if manifest.Platform.Architecture == arch {
 			return manifest.Digest.Algorithm + ":" + manifest.Digest.Hex, nil
 		}
 	}
-	return "", fmt.Errorf("Failed to find manifest for architecture %s", arch)
+	return "", fmt.Errorf("%w %s in the manifest list", errArchitectureNotFound, arch)
 }
 
 func (r *registry) AddLayerToImage(tarfile string, image v1.Image) (v1.Image, error) {
@@ -268,7 +695,17 @@ func (r *registry) AddLayerToImage(tarfile string, image v1.Image) (v1.Image, er
 		return nil, err
 	}
 
-	signedLayer, err := tarball.LayerFromFile(tarfile, tarball.WithMediaType(mt))
+	layerOptions := []tarball.LayerOption{tarball.WithMediaType(mt)}
+
+	switch mt {
+	case types.OCILayerZStd:
+		layerOptions = append(layerOptions, tarball.WithCompression(compression.ZStd))
+	case types.OCIUncompressedLayer:
+		// tarball layers are always compressed
+		layerOptions = []tarball.LayerOption{tarball.WithMediaType(types.OCILayer)}
+	}
+
+	signedLayer, err := tarball.LayerFromFile(tarfile, layerOptions...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate layer from tar: %v", err)
 	}
@@ -313,10 +750,11 @@ func (r *registry) WalkFilesInImage(image v1.Image, fn func(filename string, hea
 	for i := len(layers) - 1; i >= 0; i-- {
 		currentlayer := layers[i]
 
-		layerreader, err := currentlayer.Uncompressed()
+		layerreader, err := r.getLayerTarReader(currentlayer)
 		if err != nil {
 			return fmt.Errorf("could not get layer: %v", err)
 		}
+		defer layerreader.Close()
 
 		/*
 		** call fn on all the files in the layer
@@ -382,41 +820,96 @@ func (r *registry) ExtractFileToFile(destination string, header *tar.Header, tar
 
 }
 
-func (r *registry) getTransportOptions(insecure bool, skipTLSVerify bool) []crane.Option {
+func (r *registry) getTransportOptions(insecure bool, skipTLSVerify bool, caBundle []byte) ([]crane.Option, error) {
 	options := []crane.Option{}
 
 	if insecure {
 		options = append(options, crane.Insecure)
 	}
 
-	if skipTLSVerify {
-		rt := http.DefaultTransport.(*http.Transport).Clone()
-		rt.TLSClientConfig.InsecureSkipVerify = true
-		options = append(
-			options,
-			crane.WithTransport(rt),
-		)
+	rt, err := r.getTransport(skipTLSVerify, caBundle)
+	if err != nil {
+		return nil, fmt.Errorf("could not create the registry transport: %v", err)
 	}
 
-	return options
+	options = append(
+		options,
+		crane.WithTransport(rt),
+	)
+
+	return options, nil
 }
 
-func (r *registry) WriteImageByName(imageName string, image v1.Image, auth authn.Authenticator, insecure bool, skipTLSVerify bool) error {
-	options := r.getTransportOptions(insecure, skipTLSVerify)
+// getTransport returns the transport for the TLS configuration, creating it on first use.
+func (r *registry) getTransport(skipTLSVerify bool, caBundle []byte) (http.RoundTripper, error) {
+	key := transportKey{skipTLSVerify: skipTLSVerify, caBundleHash: sha256.Sum256(caBundle)}
+
+	r.transportsMu.Lock()
+	defer r.transportsMu.Unlock()
+
+	if rt, ok := r.transports[key]; ok {
+		return rt, nil
+	}
+
+	rt, err := newTransport(skipTLSVerify, caBundle)
+	if err != nil {
+		return nil, err
+	}
+
+	r.transports[key] = newRateLimitTransport(rt)
+
+	return r.transports[key], nil
+}
+
+// newTransport returns a copy of http.DefaultTransport that trusts the certificates in caBundle in addition to the
+// system ones, and that accepts any certificate if skipTLSVerify is true.
+func newTransport(skipTLSVerify bool, caBundle []byte) (*http.Transport, error) {
+	rt := http.DefaultTransport.(*http.Transport).Clone()
+
+	if rt.TLSClientConfig == nil {
+		rt.TLSClientConfig = &tls.Config{}
+	}
+
+	rt.TLSClientConfig.InsecureSkipVerify = skipTLSVerify
+
+	if len(caBundle) > 0 {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+
+		if !pool.AppendCertsFromPEM(caBundle) {
+			return nil, errors.New("no valid PEM-encoded certificate found in the CA bundle")
+		}
+
+		rt.TLSClientConfig.RootCAs = pool
+	}
+
+	return rt, nil
+}
+
+func (r *registry) WriteImageByName(imageName string, image v1.Image, auth authn.Authenticator, insecure bool, skipTLSVerify bool, caBundle []byte) error {
+	options, err := r.getTransportOptions(insecure, skipTLSVerify, caBundle)
+	if err != nil {
+		return err
+	}
 	options = append(
 		options,
 		crane.WithAuth(auth),
 	)
 
-	err := crane.Push(image, imageName, options...)
+	err = crane.Push(image, imageName, options...)
 	if err != nil {
 		return fmt.Errorf("failed to push signed image: %v", err)
 	}
 	return nil
 }
 
-func (r *registry) GetImageByName(imageName string, auth authn.Authenticator, insecure bool, skipTLSVerify bool) (v1.Image, error) {
-	options := r.getTransportOptions(insecure, skipTLSVerify)
+func (r *registry) GetImageByName(imageName string, auth authn.Authenticator, insecure bool, skipTLSVerify bool, caBundle []byte) (v1.Image, error) {
+	options, err := r.getTransportOptions(insecure, skipTLSVerify, caBundle)
+	if err != nil {
+		return nil, err
+	}
 	options = append(
 		options,
 		crane.WithAuth(auth),
@@ -5,11 +5,18 @@ import (
 	"bytes"
 	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
 	"io"
+	"net/http"
+	"os"
+	"strings"
 
 	v1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/google/go-containerregistry/pkg/v1/partial"
+	"github.com/google/go-containerregistry/pkg/v1/static"
 	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/klauspost/compress/zstd"
+	. "github.com/onsi/gomega"
 )
 
 type uncompressedLayer struct {
@@ -63,3 +70,80 @@ func prepareLayer(fileName string, data []byte) (v1.Layer, error) {
 		content:   b.Bytes(),
 	})
 }
+
+// prepareStaticLayer returns a layer whose compressed stream is exactly the tar archive, compressed with zstd if
+// compressZstd is true or left uncompressed otherwise.
+func prepareStaticLayer(fileName string, data []byte, compressZstd bool) (v1.Layer, error) {
+	var tarBuf bytes.Buffer
+
+	tw := tar.NewWriter(&tarBuf)
+	if err := tw.WriteHeader(&tar.Header{
+		Name:     fileName,
+		Size:     int64(len(data)),
+		Typeflag: tar.TypeRegA,
+	}); err != nil {
+		return nil, err
+	}
+	if _, err := tw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+
+	if !compressZstd {
+		return static.NewLayer(tarBuf.Bytes(), types.OCIUncompressedLayer), nil
+	}
+
+	var zstdBuf bytes.Buffer
+
+	zw, err := zstd.NewWriter(&zstdBuf)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = zw.Write(tarBuf.Bytes()); err != nil {
+		return nil, err
+	}
+	if err = zw.Close(); err != nil {
+		return nil, err
+	}
+
+	return static.NewLayer(zstdBuf.Bytes(), types.OCILayerZStd), nil
+}
+
+// serveTestImage serves the manifest in testdata/image_manifest.json, with a config for linux on the arch
+// architecture, and that config.
+func serveTestImage(w http.ResponseWriter, r *http.Request, arch string) {
+	serveTestImageWithConfig(w, r, v1.ConfigFile{Architecture: arch, OS: "linux"})
+}
+
+// serveTestImageWithConfig serves the manifest in testdata/image_manifest.json, with configFile as its config, and
+// that config.
+func serveTestImageWithConfig(w http.ResponseWriter, r *http.Request, configFile v1.ConfigFile) {
+	config, err := json.Marshal(configFile)
+	Expect(err).NotTo(HaveOccurred())
+
+	configHash, _, err := v1.SHA256(bytes.NewReader(config))
+	Expect(err).NotTo(HaveOccurred())
+
+	if strings.Contains(r.URL.Path, "/blobs/") {
+		_, err = w.Write(config)
+		Expect(err).NotTo(HaveOccurred())
+		return
+	}
+
+	b, err := os.ReadFile("testdata/image_manifest.json")
+	Expect(err).NotTo(HaveOccurred())
+
+	manifest := v1.Manifest{}
+	Expect(json.Unmarshal(b, &manifest)).To(Succeed())
+
+	manifest.Config.Digest = configHash
+	manifest.Config.Size = int64(len(config))
+
+	b, err = json.Marshal(manifest)
+	Expect(err).NotTo(HaveOccurred())
+
+	_, err = w.Write(b)
+	Expect(err).NotTo(HaveOccurred())
+}
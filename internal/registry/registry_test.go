@@ -3,15 +3,20 @@ package registry
 import (
 	context "context"
 	"encoding/json"
+	"encoding/pem"
 	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"os"
+	"runtime"
+	"strings"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/google/go-containerregistry/pkg/authn"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
 	kmmv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/auth"
 	. "github.com/onsi/ginkgo/v2"
@@ -41,7 +46,7 @@ var _ = Describe("ImageExists", func() {
 		ctrl = gomock.NewController(GinkgoT())
 		ctx = context.TODO()
 		mockRegistryAuthGetter = auth.NewMockRegistryAuthGetter(ctrl)
-		reg = NewRegistry()
+		reg = NewRegistry(nil, 0, nil)
 	})
 
 	AfterEach(func() {
@@ -58,7 +63,7 @@ var _ = Describe("ImageExists", func() {
 
 		It("should fail if the image name isn't valid", func() {
 
-			_, err = reg.ImageExists(ctx, invalidImage, &kmmv1beta1.TLSOptions{}, nil)
+			_, err = reg.ImageExists(ctx, invalidImage, "", &kmmv1beta1.TLSOptions{}, nil, nil)
 
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("does not contain hash or tag"))
@@ -68,7 +73,22 @@ var _ = Describe("ImageExists", func() {
 
 			mockRegistryAuthGetter.EXPECT().GetKeyChain(ctx).Return(nil, errors.New("some error"))
 
-			_, err = reg.ImageExists(ctx, validImage, &kmmv1beta1.TLSOptions{}, mockRegistryAuthGetter)
+			_, err = reg.ImageExists(ctx, validImage, "", &kmmv1beta1.TLSOptions{}, nil, mockRegistryAuthGetter)
+
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("cannot get keychain from the registry auth getter"))
+		})
+
+		It("should fail if it cannot get key chain from the operator credentials", func() {
+			operatorAuthGetter := auth.NewMockRegistryAuthGetter(ctrl)
+			reg = NewRegistry(nil, 0, operatorAuthGetter)
+
+			gomock.InOrder(
+				mockRegistryAuthGetter.EXPECT().GetKeyChain(ctx).Return(authn.DefaultKeychain, nil),
+				operatorAuthGetter.EXPECT().GetKeyChain(ctx).Return(nil, errors.New("some error")),
+			)
+
+			_, err = reg.ImageExists(ctx, validImage, "", &kmmv1beta1.TLSOptions{}, nil, mockRegistryAuthGetter)
 
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("cannot get keychain from the registry auth getter"))
@@ -93,7 +113,7 @@ var _ = Describe("ImageExists", func() {
 			u := mustParseURL(server.URL)
 
 			image := fmt.Sprintf("%s/%s/%s:%s", u.Host, validImageOrg, validImageName, validImageTag)
-			_, err = reg.ImageExists(ctx, image, &kmmv1beta1.TLSOptions{}, nil)
+			_, err = reg.ImageExists(ctx, image, "", &kmmv1beta1.TLSOptions{}, nil, nil)
 
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("failed to get crane manifest from image"))
@@ -108,7 +128,7 @@ var _ = Describe("ImageExists", func() {
 			u := mustParseURL(server.URL)
 
 			image := fmt.Sprintf("%s/%s/%s:%s", u.Host, validImageOrg, validImageName, validImageTag)
-			_, err = reg.ImageExists(ctx, image, &kmmv1beta1.TLSOptions{}, nil)
+			_, err = reg.ImageExists(ctx, image, "", &kmmv1beta1.TLSOptions{}, nil, nil)
 
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("failed to unmarshal crane manifest"))
@@ -134,7 +154,7 @@ var _ = Describe("ImageExists", func() {
 			u := mustParseURL(server.URL)
 
 			image := fmt.Sprintf("%s/%s/%s:%s", u.Host, validImageOrg, validImageName, validImageTag)
-			_, err = reg.ImageExists(ctx, image, &kmmv1beta1.TLSOptions{}, nil)
+			_, err = reg.ImageExists(ctx, image, "", &kmmv1beta1.TLSOptions{}, nil, nil)
 
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("mediaType is missing from the image"))
@@ -150,18 +170,189 @@ var _ = Describe("ImageExists", func() {
 		u := mustParseURL(server.URL)
 
 		image := fmt.Sprintf("%s/%s/%s:%s", u.Host, validImageOrg, validImageName, validImageTag)
-		_, err := reg.ImageExists(ctx, image, &kmmv1beta1.TLSOptions{}, nil)
+		_, err := reg.ImageExists(ctx, image, "", &kmmv1beta1.TLSOptions{}, nil, nil)
 
 		Expect(err).ToNot(HaveOccurred())
 	})
 
-	DescribeTable("should work as expected", func(withRegistryAuthGetter bool) {
+	It("should not find a single-platform image built for another architecture", func() {
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			serveTestImage(w, r, "amd64")
+		}))
+		defer server.Close()
+		u := mustParseURL(server.URL)
+
+		image := fmt.Sprintf("%s/%s/%s:%s", u.Host, validImageOrg, validImageName, validImageTag)
+
+		exists, err := reg.ImageExists(ctx, image, "amd64", &kmmv1beta1.TLSOptions{}, nil, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(exists).To(BeTrue())
+
+		exists, err = reg.ImageExists(ctx, image, "arm64", &kmmv1beta1.TLSOptions{}, nil, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(exists).To(BeFalse())
+	})
+
+	It("should cache lookups until the image is invalidated", func() {
+		found := false
+		manifestRequests := 0
 
 		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			manifest, err := os.ReadFile("testdata/image_manifest.json")
+			if !strings.Contains(r.URL.Path, "/manifests/") {
+				serveTestImage(w, r, runtime.GOARCH)
+				return
+			}
+
+			manifestRequests++
+
+			if !found {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+
+			serveTestImage(w, r, runtime.GOARCH)
+		}))
+		defer server.Close()
+		u := mustParseURL(server.URL)
+
+		reg = NewRegistry(nil, time.Hour, nil)
+
+		image := fmt.Sprintf("%s/%s/%s:%s", u.Host, validImageOrg, validImageName, validImageTag)
+
+		for i := 0; i < 2; i++ {
+			exists, err := reg.ImageExists(ctx, image, "", &kmmv1beta1.TLSOptions{}, nil, nil)
 			Expect(err).NotTo(HaveOccurred())
-			_, err = w.Write(manifest)
+			Expect(exists).To(BeFalse())
+		}
+
+		Expect(manifestRequests).To(Equal(1))
+
+		found = true
+		reg.Invalidate(image)
+
+		for i := 0; i < 2; i++ {
+			exists, err := reg.ImageExists(ctx, image, "", &kmmv1beta1.TLSOptions{}, nil, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(exists).To(BeTrue())
+		}
+
+		Expect(manifestRequests).To(Equal(2))
+	})
+
+	It("should not read the credentials for cached lookups", func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			serveTestImage(w, r, runtime.GOARCH)
+		}))
+		defer server.Close()
+		u := mustParseURL(server.URL)
+
+		reg = NewRegistry(nil, time.Hour, nil)
+
+		mockRegistryAuthGetter.EXPECT().Scope().Return("ns/sa:secret").Times(2)
+		mockRegistryAuthGetter.EXPECT().GetKeyChain(ctx).Return(authn.DefaultKeychain, nil)
+
+		image := fmt.Sprintf("%s/%s/%s:%s", u.Host, validImageOrg, validImageName, validImageTag)
+
+		for i := 0; i < 2; i++ {
+			exists, err := reg.ImageExists(ctx, image, "", &kmmv1beta1.TLSOptions{}, nil, mockRegistryAuthGetter)
 			Expect(err).NotTo(HaveOccurred())
+			Expect(exists).To(BeTrue())
+		}
+	})
+
+	It("should find the image in a mirror", func() {
+
+		mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			serveTestImage(w, r, runtime.GOARCH)
+		}))
+		defer mirror.Close()
+
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		}))
+		defer upstream.Close()
+
+		upstreamHost := mustParseURL(upstream.URL).Host
+
+		reg = NewRegistry(&MirrorConfig{
+			Registries: []MirrorRegistry{
+				{
+					Prefix:  upstreamHost,
+					Mirrors: []string{mustParseURL(mirror.URL).Host},
+				},
+			},
+		}, 0, nil)
+
+		image := fmt.Sprintf("%s/%s/%s:%s", upstreamHost, validImageOrg, validImageName, validImageTag)
+
+		exists, err := reg.ImageExists(ctx, image, "", &kmmv1beta1.TLSOptions{}, nil, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(exists).To(BeTrue())
+	})
+
+	It("should look for the image pushed by KMM if the image is rewritten", func() {
+
+		pushed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			serveTestImage(w, r, runtime.GOARCH)
+		}))
+		defer pushed.Close()
+
+		location := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		}))
+		defer location.Close()
+
+		pushedHost := mustParseURL(pushed.URL).Host
+
+		reg = NewRegistry(&MirrorConfig{
+			Registries: []MirrorRegistry{
+				{
+					Prefix:   pushedHost,
+					Location: mustParseURL(location.URL).Host,
+				},
+			},
+		}, 0, nil)
+
+		image := fmt.Sprintf("%s/%s/%s:%s", pushedHost, validImageOrg, validImageName, validImageTag)
+
+		exists, err := reg.ImageExists(ctx, image, "", &kmmv1beta1.TLSOptions{}, nil, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(exists).To(BeTrue())
+	})
+
+	It("should trust the certificates from the CA bundle", func() {
+
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			serveTestImage(w, r, runtime.GOARCH)
+		}))
+		defer server.Close()
+		u := mustParseURL(server.URL)
+
+		caBundle := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+
+		image := fmt.Sprintf("%s/%s/%s:%s", u.Host, validImageOrg, validImageName, validImageTag)
+
+		_, err := reg.ImageExists(ctx, image, "", &kmmv1beta1.TLSOptions{}, nil, nil)
+		Expect(err).To(HaveOccurred())
+
+		exists, err := reg.ImageExists(ctx, image, "", &kmmv1beta1.TLSOptions{}, caBundle, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(exists).To(BeTrue())
+	})
+
+	It("should fail if the CA bundle does not contain any certificate", func() {
+		image := fmt.Sprintf("%s/%s/%s:%s", validImageHost, validImageOrg, validImageName, validImageTag)
+
+		_, err := reg.ImageExists(ctx, image, "", &kmmv1beta1.TLSOptions{}, []byte("not a certificate"), nil)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("no valid PEM-encoded certificate found in the CA bundle"))
+	})
+
+	DescribeTable("should work as expected", func(withRegistryAuthGetter bool) {
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			serveTestImage(w, r, runtime.GOARCH)
 		}))
 		defer server.Close()
 		u := mustParseURL(server.URL)
@@ -173,9 +364,9 @@ var _ = Describe("ImageExists", func() {
 		var err error
 		image := fmt.Sprintf("%s/%s/%s:%s", u.Host, validImageOrg, validImageName, validImageTag)
 		if withRegistryAuthGetter {
-			_, err = reg.ImageExists(ctx, image, &kmmv1beta1.TLSOptions{}, mockRegistryAuthGetter)
+			_, err = reg.ImageExists(ctx, image, "", &kmmv1beta1.TLSOptions{}, nil, mockRegistryAuthGetter)
 		} else {
-			_, err = reg.ImageExists(ctx, image, &kmmv1beta1.TLSOptions{}, nil)
+			_, err = reg.ImageExists(ctx, image, "", &kmmv1beta1.TLSOptions{}, nil, nil)
 		}
 		Expect(err).ToNot(HaveOccurred())
 	},
@@ -206,7 +397,7 @@ var _ = Describe("GetLayersDigests", func() {
 		ctrl = gomock.NewController(GinkgoT())
 		ctx = context.TODO()
 		mockRegistryAuthGetter = auth.NewMockRegistryAuthGetter(ctrl)
-		reg = NewRegistry()
+		reg = NewRegistry(nil, 0, nil)
 	})
 
 	AfterEach(func() {
@@ -223,7 +414,7 @@ var _ = Describe("GetLayersDigests", func() {
 
 		It("should fail if the image name isn't valid", func() {
 
-			_, err = reg.ImageExists(ctx, invalidImage, &kmmv1beta1.TLSOptions{}, nil)
+			_, err = reg.ImageExists(ctx, invalidImage, "", &kmmv1beta1.TLSOptions{}, nil, nil)
 
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("does not contain hash or tag"))
@@ -233,7 +424,7 @@ var _ = Describe("GetLayersDigests", func() {
 
 			mockRegistryAuthGetter.EXPECT().GetKeyChain(ctx).Return(nil, errors.New("some error"))
 
-			_, err = reg.ImageExists(ctx, validImage, &kmmv1beta1.TLSOptions{}, mockRegistryAuthGetter)
+			_, err = reg.ImageExists(ctx, validImage, "", &kmmv1beta1.TLSOptions{}, nil, mockRegistryAuthGetter)
 
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("cannot get keychain from the registry auth getter"))
@@ -258,7 +449,7 @@ var _ = Describe("GetLayersDigests", func() {
 			u := mustParseURL(server.URL)
 
 			image := fmt.Sprintf("%s/%s/%s:%s", u.Host, validImageOrg, validImageName, validImageTag)
-			_, _, err = reg.GetLayersDigests(ctx, image, &kmmv1beta1.TLSOptions{}, nil)
+			_, _, err = reg.GetLayersDigests(ctx, image, "", &kmmv1beta1.TLSOptions{}, nil, nil)
 
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("failed to get crane manifest from image"))
@@ -273,7 +464,7 @@ var _ = Describe("GetLayersDigests", func() {
 			u := mustParseURL(server.URL)
 
 			image := fmt.Sprintf("%s/%s/%s:%s", u.Host, validImageOrg, validImageName, validImageTag)
-			_, _, err = reg.GetLayersDigests(ctx, image, &kmmv1beta1.TLSOptions{}, nil)
+			_, _, err = reg.GetLayersDigests(ctx, image, "", &kmmv1beta1.TLSOptions{}, nil, nil)
 
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("failed to unmarshal crane manifest"))
@@ -299,24 +490,47 @@ var _ = Describe("GetLayersDigests", func() {
 			u := mustParseURL(server.URL)
 
 			image := fmt.Sprintf("%s/%s/%s:%s", u.Host, validImageOrg, validImageName, validImageTag)
-			_, _, err = reg.GetLayersDigests(ctx, image, &kmmv1beta1.TLSOptions{}, nil)
+			_, _, err = reg.GetLayersDigests(ctx, image, "", &kmmv1beta1.TLSOptions{}, nil, nil)
 
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("mediaType is missing from the image"))
 		})
 	})
 
-	DescribeTable("should work as expected", func(withRegistryAuthGetter bool) {
+	It("should resolve the manifest of the architecture from an OCI image index", func() {
+		const archManifestDigest = "sha256:cad9d00ef6de9ad37ead1b397df7517b561868b6e68c08fd2b41211fd661347d"
 
 		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			manifest, err := os.ReadFile("testdata/image_manifest.json")
+			if strings.HasSuffix(r.URL.Path, "/manifests/"+archManifestDigest) {
+				manifest, err := os.ReadFile("testdata/image_manifest.json")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = w.Write(manifest)
+				Expect(err).NotTo(HaveOccurred())
+				return
+			}
+
+			index, err := os.ReadFile("testdata/image_index.json")
 			Expect(err).NotTo(HaveOccurred())
-			_, err = w.Write(manifest)
+			_, err = w.Write(index)
 			Expect(err).NotTo(HaveOccurred())
 		}))
 		defer server.Close()
 		u := mustParseURL(server.URL)
 
+		image := fmt.Sprintf("%s/%s/%s:%s", u.Host, validImageOrg, validImageName, validImageTag)
+		digests, _, err := reg.GetLayersDigests(ctx, image, "arm64", &kmmv1beta1.TLSOptions{}, nil, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(digests).To(Equal([]string{"sha256:51a135b580ac3e28b20b3df03eebd1085e1d82796a7bb9d08acb3ee20221b04c"}))
+	})
+
+	DescribeTable("should work as expected", func(withRegistryAuthGetter bool) {
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			serveTestImage(w, r, runtime.GOARCH)
+		}))
+		defer server.Close()
+		u := mustParseURL(server.URL)
+
 		if withRegistryAuthGetter {
 			mockRegistryAuthGetter.EXPECT().GetKeyChain(ctx).Return(authn.DefaultKeychain, nil)
 		}
@@ -324,9 +538,9 @@ var _ = Describe("GetLayersDigests", func() {
 		var err error
 		image := fmt.Sprintf("%s/%s/%s:%s", u.Host, validImageOrg, validImageName, validImageTag)
 		if withRegistryAuthGetter {
-			_, _, err = reg.GetLayersDigests(ctx, image, &kmmv1beta1.TLSOptions{}, mockRegistryAuthGetter)
+			_, _, err = reg.GetLayersDigests(ctx, image, "", &kmmv1beta1.TLSOptions{}, nil, mockRegistryAuthGetter)
 		} else {
-			_, _, err = reg.GetLayersDigests(ctx, image, &kmmv1beta1.TLSOptions{}, nil)
+			_, _, err = reg.GetLayersDigests(ctx, image, "", &kmmv1beta1.TLSOptions{}, nil, nil)
 		}
 		Expect(err).ToNot(HaveOccurred())
 	},
@@ -335,8 +549,146 @@ var _ = Describe("GetLayersDigests", func() {
 	)
 })
 
+var _ = Describe("getTransport", func() {
+	It("should reuse the transport of a TLS configuration", func() {
+		r := NewRegistry(nil, 0, nil).(*registry)
+
+		rt, err := r.getTransport(false, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		same, err := r.getTransport(false, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(same).To(BeIdenticalTo(rt))
+
+		other, err := r.getTransport(true, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(other).NotTo(BeIdenticalTo(rt))
+	})
+})
+
+var _ = Describe("GetLabels", func() {
+	const repo = "org/image"
+
+	var (
+		ctx context.Context
+		reg Registry
+	)
+
+	BeforeEach(func() {
+		ctx = context.TODO()
+		reg = NewRegistry(nil, 0, nil)
+	})
+
+	It("should return the labels of the image", func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			serveTestImageWithConfig(w, r, v1.ConfigFile{
+				Architecture: runtime.GOARCH,
+				OS:           "linux",
+				Config:       v1.Config{Labels: map[string]string{"key": "value"}},
+			})
+		}))
+		defer server.Close()
+
+		labels, err := reg.GetLabels(ctx, mustParseURL(server.URL).Host+"/"+repo+":tag", "", &kmmv1beta1.TLSOptions{}, nil, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(labels).To(Equal(map[string]string{"key": "value"}))
+	})
+
+	It("should return no labels if the image does not exist", func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		}))
+		defer server.Close()
+
+		labels, err := reg.GetLabels(ctx, mustParseURL(server.URL).Host+"/"+repo+":tag", "", &kmmv1beta1.TLSOptions{}, nil, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(labels).To(BeNil())
+	})
+
+	It("should not contact the registry for cached lookups", func() {
+		var manifestRequests, blobRequests int
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch {
+			case strings.Contains(r.URL.Path, "/manifests/"):
+				manifestRequests++
+			case strings.Contains(r.URL.Path, "/blobs/"):
+				blobRequests++
+			}
+
+			serveTestImageWithConfig(w, r, v1.ConfigFile{
+				Architecture: runtime.GOARCH,
+				OS:           "linux",
+				Config:       v1.Config{Labels: map[string]string{"key": "value"}},
+			})
+		}))
+		defer server.Close()
+
+		reg = NewRegistry(nil, time.Hour, nil)
+
+		image := mustParseURL(server.URL).Host + "/" + repo + ":tag"
+
+		for i := 0; i < 2; i++ {
+			labels, err := reg.GetLabels(ctx, image, "", &kmmv1beta1.TLSOptions{}, nil, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(labels).To(Equal(map[string]string{"key": "value"}))
+		}
+
+		Expect(manifestRequests).To(Equal(1))
+		// one for the platform check of the manifest lookup, one for the labels
+		Expect(blobRequests).To(Equal(2))
+
+		By("only fetching the manifest again once the image was invalidated")
+
+		reg.Invalidate(image)
+
+		labels, err := reg.GetLabels(ctx, image, "", &kmmv1beta1.TLSOptions{}, nil, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(labels).To(Equal(map[string]string{"key": "value"}))
+		Expect(manifestRequests).To(Equal(2))
+		Expect(blobRequests).To(Equal(3))
+	})
+})
+
+var _ = Describe("getImageDigestFromMultiImage", func() {
+	const manifestList = `{
+  "schemaVersion": 2,
+  "mediaType": "application/vnd.docker.distribution.manifest.list.v2+json",
+  "manifests": [
+    {
+      "mediaType": "application/vnd.docker.distribution.manifest.v2+json",
+      "size": 528,
+      "digest": "sha256:1111111111111111111111111111111111111111111111111111111111111111",
+      "platform": {"architecture": "amd64", "os": "linux"}
+    },
+    {
+      "mediaType": "application/vnd.docker.distribution.manifest.v2+json",
+      "size": 528,
+      "digest": "sha256:cad9d00ef6de9ad37ead1b397df7517b561868b6e68c08fd2b41211fd661347d",
+      "platform": {"architecture": "arm64", "os": "linux"}
+    }
+  ]
+}`
+
+	r := &registry{}
+
+	DescribeTable("should return the digest for the requested architecture", func(arch, expected string) {
+		digest, err := r.getImageDigestFromMultiImage([]byte(manifestList), arch)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(digest).To(Equal(expected))
+	},
+		Entry("amd64", "amd64", "sha256:1111111111111111111111111111111111111111111111111111111111111111"),
+		Entry("arm64", "arm64", "sha256:cad9d00ef6de9ad37ead1b397df7517b561868b6e68c08fd2b41211fd661347d"),
+	)
+
+	It("should return an error if the architecture is not available", func() {
+		_, err := r.getImageDigestFromMultiImage([]byte(manifestList), "s390x")
+		Expect(err).To(HaveOccurred())
+	})
+})
+
 var _ = Describe("VerifyModuleExists", func() {
-	reg := NewRegistry()
+	reg := NewRegistry(nil, 0, nil)
 
 	It("file is not present", func() {
 		const fileName = "etc/fileName"
@@ -355,6 +707,26 @@ var _ = Describe("VerifyModuleExists", func() {
 		res := reg.VerifyModuleExists(layer, "/opt", "somekernel", "module_name.ko")
 		Expect(res).To(BeTrue())
 	})
+
+	DescribeTable("file is present in a layer that is not gzip-compressed", func(compressZstd bool) {
+		const fileName = "opt/lib/modules/somekernel/module_name.ko"
+		layer, err := prepareStaticLayer(fileName, []byte("some data"), compressZstd)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(
+			reg.VerifyModuleExists(layer, "/opt", "somekernel", "module_name.ko"),
+		).To(
+			BeTrue(),
+		)
+		Expect(
+			reg.VerifyModuleExists(layer, "/opt", "somekernel", "other_module.ko"),
+		).To(
+			BeFalse(),
+		)
+	},
+		Entry("zstd", true),
+		Entry("uncompressed", false),
+	)
 })
 
 func mustParseURL(rawURL string) *url.URL {
@@ -362,3 +734,138 @@ func mustParseURL(rawURL string) *url.URL {
 	Expect(err).ToNot(HaveOccurred())
 	return u
 }
+
+var _ = Describe("GetDigest", func() {
+	const (
+		digest = "sha256:0000000000000000000000000000000000000000000000000000000000000000"
+		repo   = "org/image"
+	)
+
+	var (
+		ctx context.Context
+		reg Registry
+	)
+
+	BeforeEach(func() {
+		ctx = context.TODO()
+		reg = NewRegistry(nil, time.Hour, nil)
+	})
+
+	It("should return the digest of a tag without caching it", func() {
+		requests := 0
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !strings.Contains(r.URL.Path, "/manifests/") {
+				return
+			}
+
+			requests++
+
+			w.Header().Set("Docker-Content-Digest", digest)
+			w.Header().Set("Content-Type", "application/vnd.docker.distribution.manifest.v2+json")
+			w.Header().Set("Content-Length", "1")
+		}))
+		defer server.Close()
+
+		image := mustParseURL(server.URL).Host + "/" + repo + ":tag"
+
+		for i := 0; i < 2; i++ {
+			res, err := reg.GetDigest(ctx, image, &kmmv1beta1.TLSOptions{}, nil, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res).To(Equal(digest))
+		}
+
+		Expect(requests).To(Equal(2))
+	})
+
+	It("should not look up images that are referenced by digest", func() {
+		res, err := reg.GetDigest(ctx, "example.org/"+repo+"@"+digest, nil, nil, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).To(Equal(digest))
+	})
+
+	It("should return an error if the image does not exist", func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		}))
+		defer server.Close()
+
+		_, err := reg.GetDigest(ctx, mustParseURL(server.URL).Host+"/"+repo+":tag", &kmmv1beta1.TLSOptions{}, nil, nil)
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("Delete", func() {
+	const (
+		digest = "sha256:0000000000000000000000000000000000000000000000000000000000000000"
+		repo   = "org/image"
+	)
+
+	var (
+		ctx      context.Context
+		reg      Registry
+		requests []string
+	)
+
+	BeforeEach(func() {
+		ctx = context.TODO()
+		reg = NewRegistry(nil, 0, nil)
+		requests = nil
+	})
+
+	newServer := func(deleteStatus int) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !strings.Contains(r.URL.Path, "/manifests/") {
+				return
+			}
+
+			requests = append(requests, r.Method+" "+r.URL.Path)
+
+			switch r.Method {
+			case http.MethodDelete:
+				w.WriteHeader(deleteStatus)
+			case http.MethodHead:
+				w.Header().Set("Docker-Content-Digest", digest)
+				w.Header().Set("Content-Type", "application/vnd.docker.distribution.manifest.v2+json")
+				w.Header().Set("Content-Length", "1")
+			}
+		}))
+	}
+
+	It("should delete a tag", func() {
+		server := newServer(http.StatusAccepted)
+		defer server.Close()
+
+		err := reg.DeleteTag(ctx, mustParseURL(server.URL).Host+"/"+repo+":tag", &kmmv1beta1.TLSOptions{}, nil, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(requests).To(Equal([]string{"DELETE /v2/" + repo + "/manifests/tag"}))
+	})
+
+	It("should return ErrDeletionUnsupported if the registry does not support deleting tags", func() {
+		server := newServer(http.StatusMethodNotAllowed)
+		defer server.Close()
+
+		err := reg.DeleteTag(ctx, mustParseURL(server.URL).Host+"/"+repo+":tag", &kmmv1beta1.TLSOptions{}, nil, nil)
+		Expect(errors.Is(err, ErrDeletionUnsupported)).To(BeTrue())
+	})
+
+	It("should not fail if the tag does not exist", func() {
+		server := newServer(http.StatusNotFound)
+		defer server.Close()
+
+		err := reg.DeleteTag(ctx, mustParseURL(server.URL).Host+"/"+repo+":tag", &kmmv1beta1.TLSOptions{}, nil, nil)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should delete the manifest by digest", func() {
+		server := newServer(http.StatusAccepted)
+		defer server.Close()
+
+		err := reg.DeleteManifest(ctx, mustParseURL(server.URL).Host+"/"+repo+":tag", &kmmv1beta1.TLSOptions{}, nil, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(requests).To(Equal([]string{
+			"HEAD /v2/" + repo + "/manifests/tag",
+			"DELETE /v2/" + repo + "/manifests/" + digest,
+		}))
+	})
+})
@@ -0,0 +1,104 @@
+package registry
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultRateLimitRetries = 3
+	defaultRateLimitBackoff = time.Second
+	maxRateLimitWait        = 5 * time.Second
+)
+
+// rateLimitTransport retries the requests that were rejected with HTTP 429 Too Many Requests.
+// It waits for the delay in the Retry-After header if there is one, or backs off exponentially otherwise.
+// It waits for at most maxRateLimitWait in total, so that a rate-limited registry does not block the reconciliation
+// workers: past that, the request fails and the reconciliation is requeued with a backoff.
+type rateLimitTransport struct {
+	inner   http.RoundTripper
+	retries int
+	backoff time.Duration
+	after   func(time.Duration) <-chan time.Time
+	now     func() time.Time
+}
+
+func newRateLimitTransport(inner http.RoundTripper) *rateLimitTransport {
+	return &rateLimitTransport{
+		inner:   inner,
+		retries: defaultRateLimitRetries,
+		backoff: defaultRateLimitBackoff,
+		after:   time.After,
+		now:     time.Now,
+	}
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	backoff := t.backoff
+
+	var waited time.Duration
+
+	for attempt := 0; ; attempt++ {
+		res, err := t.inner.RoundTrip(req)
+		if err != nil || res.StatusCode != http.StatusTooManyRequests || attempt >= t.retries {
+			return res, err
+		}
+
+		// only requests whose body can be replayed are retried
+		if req.Body != nil && req.GetBody == nil {
+			return res, nil
+		}
+
+		wait := t.retryAfter(res.Header.Get("Retry-After"))
+		if wait < 0 {
+			wait = backoff
+			backoff *= 2
+		}
+
+		if waited+wait > maxRateLimitWait {
+			return res, nil
+		}
+
+		waited += wait
+
+		res.Body.Close()
+
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-t.after(wait):
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
+
+// retryAfter parses the Retry-After header, which contains either a number of seconds or an HTTP date.
+// It returns a negative duration if the header is missing or invalid.
+func (t *rateLimitTransport) retryAfter(value string) time.Duration {
+	if value == "" {
+		return -1
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		if d := date.Sub(t.now()); d > 0 {
+			return d
+		}
+		return 0
+	}
+
+	return -1
+}
@@ -0,0 +1,112 @@
+package registry
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("rateLimitTransport", func() {
+	var (
+		requests int
+		waits    []time.Duration
+		rt       *rateLimitTransport
+	)
+
+	newServer := func(rateLimited int, retryAfter string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+
+			if requests <= rateLimited {
+				if retryAfter != "" {
+					w.Header().Set("Retry-After", retryAfter)
+				}
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+
+			w.WriteHeader(http.StatusOK)
+		}))
+	}
+
+	BeforeEach(func() {
+		requests = 0
+		waits = nil
+
+		rt = newRateLimitTransport(http.DefaultTransport)
+		rt.after = func(d time.Duration) <-chan time.Time {
+			waits = append(waits, d)
+
+			ch := make(chan time.Time, 1)
+			ch <- time.Now()
+			return ch
+		}
+	})
+
+	It("should honour the Retry-After header", func() {
+		server := newServer(2, "2")
+		defer server.Close()
+
+		res, err := (&http.Client{Transport: rt}).Get(server.URL)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.StatusCode).To(Equal(http.StatusOK))
+		Expect(requests).To(Equal(3))
+		Expect(waits).To(Equal([]time.Duration{2 * time.Second, 2 * time.Second}))
+	})
+
+	It("should back off exponentially without a Retry-After header", func() {
+		server := newServer(2, "")
+		defer server.Close()
+
+		res, err := (&http.Client{Transport: rt}).Get(server.URL)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.StatusCode).To(Equal(http.StatusOK))
+		Expect(waits).To(Equal([]time.Duration{time.Second, 2 * time.Second}))
+	})
+
+	It("should give up after the maximum number of retries", func() {
+		server := newServer(10, "1")
+		defer server.Close()
+
+		res, err := (&http.Client{Transport: rt}).Get(server.URL)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.StatusCode).To(Equal(http.StatusTooManyRequests))
+		Expect(requests).To(Equal(defaultRateLimitRetries + 1))
+	})
+
+	It("should not wait longer than the maximum delay", func() {
+		server := newServer(10, "3600")
+		defer server.Close()
+
+		res, err := (&http.Client{Transport: rt}).Get(server.URL)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.StatusCode).To(Equal(http.StatusTooManyRequests))
+		Expect(requests).To(Equal(1))
+		Expect(waits).To(BeEmpty())
+	})
+
+	It("should not wait longer than the maximum delay in total", func() {
+		server := newServer(10, "3")
+		defer server.Close()
+
+		res, err := (&http.Client{Transport: rt}).Get(server.URL)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.StatusCode).To(Equal(http.StatusTooManyRequests))
+		Expect(requests).To(Equal(2))
+		Expect(waits).To(Equal([]time.Duration{3 * time.Second}))
+	})
+
+	DescribeTable("retryAfter", func(value string, expected time.Duration) {
+		rt.now = func() time.Time { return time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC) }
+		Expect(rt.retryAfter(value)).To(Equal(expected))
+	},
+		Entry("empty", "", time.Duration(-1)),
+		Entry("seconds", "10", 10*time.Second),
+		Entry("HTTP date", "Sun, 01 Jan 2023 00:00:30 GMT", 30*time.Second),
+		Entry("date in the past", "Sat, 31 Dec 2022 00:00:00 GMT", time.Duration(0)),
+		Entry("invalid", "soon", time.Duration(-1)),
+	)
+})
@@ -0,0 +1,189 @@
+package rollback
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	kmmv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/api"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/constants"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kubectl/pkg/util/podutils"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+//go:generate mockgen -source=rollback.go -package=rollback -destination=mock_rollback.go
+
+type ImageTracker interface {
+	SyncImage(ctx context.Context, mod *kmmv1beta1.Module, mld *api.ModuleLoaderData, ds *appsv1.DaemonSet) (kmmv1beta1.ModuleLoaderImageStatus, error)
+}
+
+type imageTracker struct {
+	client client.Client
+}
+
+func NewImageTracker(client client.Client) ImageTracker {
+	return &imageTracker{client: client}
+}
+
+// SyncImage records the image run by ds as the last known good image of the kernel and module version of mld once all
+// its pods are available.
+// Images are never rolled back to one run for another module version.
+// If spec.moduleLoader.rollback is set and the pods of ds fail to load the module, it rolls back to the last known
+// good image: the returned status has RolledBackFrom set for as long as the image in the spec does not change, and
+// the DaemonSet should run LastKnownGoodImage instead of mld.ContainerImage.
+// ds is the current ModuleLoader DaemonSet for mld and may be nil.
+// It returns the updated image status of the kernel.
+func (it *imageTracker) SyncImage(
+	ctx context.Context,
+	mod *kmmv1beta1.Module,
+	mld *api.ModuleLoaderData,
+	ds *appsv1.DaemonSet) (kmmv1beta1.ModuleLoaderImageStatus, error) {
+	logger := log.FromContext(ctx).WithValues(
+		"kernel version", mld.KernelVersion,
+		"architecture", mld.Architecture,
+		"module version", mld.ModuleVersion,
+	)
+
+	status := kmmv1beta1.ModuleLoaderImageStatus{
+		KernelVersion: mld.KernelVersion,
+		Architecture:  mld.Architecture,
+		Version:       mld.ModuleVersion,
+	}
+
+	for _, s := range mod.Status.ModuleLoaderImages {
+		if s.KernelVersion == mld.KernelVersion && s.Architecture == mld.Architecture && s.Version == mld.ModuleVersion {
+			status = s
+			break
+		}
+	}
+
+	// the image in the spec changed since the rollback: try the new one
+	if status.RolledBackFrom != "" && status.RolledBackFrom != mld.ContainerImage {
+		status.RolledBackFrom = ""
+	}
+
+	if ds != nil {
+		pods, err := it.daemonSetPods(ctx, ds)
+		if err != nil {
+			return status, fmt.Errorf("could not list the pods of DaemonSet %s: %v", ds.Name, err)
+		}
+
+		policy := mod.Spec.ModuleLoader.Rollback
+
+		if status.RolledBackFrom == "" && policy != nil && status.LastKnownGoodImage != "" && isFailing(pods, policy.FailureThreshold) {
+			logger.Info("ModuleLoader pods are failing; rolling back", "image", mld.ContainerImage, "last known good image", status.LastKnownGoodImage)
+			status.RolledBackFrom = mld.ContainerImage
+		}
+
+		if status.RolledBackFrom == "" && isAvailable(ds) {
+			if image := pinnedImage(pods); image != "" {
+				status.LastKnownGoodImage = image
+			}
+		}
+	}
+
+	return status, nil
+}
+
+func (it *imageTracker) daemonSetPods(ctx context.Context, ds *appsv1.DaemonSet) ([]v1.Pod, error) {
+	podList := v1.PodList{}
+
+	opts := []client.ListOption{
+		client.InNamespace(ds.Namespace),
+		client.MatchingLabels(ds.Spec.Selector.MatchLabels),
+	}
+
+	if err := it.client.List(ctx, &podList, opts...); err != nil {
+		return nil, err
+	}
+
+	pods := make([]v1.Pod, 0, len(podList.Items))
+
+	for _, p := range podList.Items {
+		if owner := metav1.GetControllerOf(&p); owner != nil && owner.UID == ds.UID {
+			pods = append(pods, p)
+		}
+	}
+
+	return pods, nil
+}
+
+// isAvailable returns true if all pods of ds run its current template and are available.
+func isAvailable(ds *appsv1.DaemonSet) bool {
+	s := ds.Status
+
+	return s.ObservedGeneration >= ds.Generation &&
+		s.DesiredNumberScheduled > 0 &&
+		s.UpdatedNumberScheduled == s.DesiredNumberScheduled &&
+		s.NumberAvailable == s.DesiredNumberScheduled
+}
+
+// isFailing returns true if a ModuleLoader container that is not ready restarted at least threshold times.
+func isFailing(pods []v1.Pod, threshold int32) bool {
+	if threshold < 1 {
+		threshold = 1
+	}
+
+	for i := range pods {
+		if podutils.IsPodReady(&pods[i]) {
+			continue
+		}
+
+		for _, cs := range pods[i].Status.ContainerStatuses {
+			if cs.Name == constants.ModuleLoaderContainerName && cs.RestartCount >= threshold {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// pinnedImage returns the image run by the ready pods, referenced by its digest.
+// It returns an empty string if the digest is not reported by the container runtime.
+func pinnedImage(pods []v1.Pod) string {
+	for i := range pods {
+		pod := &pods[i]
+
+		if !podutils.IsPodReady(pod) {
+			continue
+		}
+
+		for _, cs := range pod.Status.ContainerStatuses {
+			if cs.Name != constants.ModuleLoaderContainerName {
+				continue
+			}
+
+			_, digest, ok := strings.Cut(cs.ImageID, "@")
+			if !ok {
+				continue
+			}
+
+			// the image ID may not contain the registry the image was pulled from
+			ref, err := name.ParseReference(containerImage(pod, cs.Name))
+			if err != nil {
+				continue
+			}
+
+			return ref.Context().Name() + "@" + digest
+		}
+	}
+
+	return ""
+}
+
+func containerImage(pod *v1.Pod, containerName string) string {
+	for _, c := range pod.Spec.Containers {
+		if c.Name == containerName {
+			return c.Image
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,206 @@
+package rollback
+
+import (
+	"context"
+	"errors"
+
+	"github.com/golang/mock/gomock"
+	kmmv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/api"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/client"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/constants"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
+	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	kernelVersion = "1.2.3"
+	arch          = "x86_64"
+	goodImage     = "example.org/repo/image@sha256:1234"
+	newImage      = "example.org/repo/image:new"
+)
+
+var _ = Describe("SyncImage", func() {
+	var (
+		ctrl  *gomock.Controller
+		clnt  *client.MockClient
+		it    ImageTracker
+		mod   *kmmv1beta1.Module
+		mld   *api.ModuleLoaderData
+		ds    *appsv1.DaemonSet
+		ctx   = context.Background()
+		dsUID = types.UID("ds-uid")
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		clnt = client.NewMockClient(ctrl)
+		it = NewImageTracker(clnt)
+
+		mod = &kmmv1beta1.Module{
+			Spec: kmmv1beta1.ModuleSpec{
+				ModuleLoader: kmmv1beta1.ModuleLoaderSpec{
+					Rollback: &kmmv1beta1.RollbackPolicy{FailureThreshold: 3},
+				},
+			},
+		}
+
+		mld = &api.ModuleLoaderData{KernelVersion: kernelVersion, Architecture: arch, ContainerImage: newImage}
+
+		ds = &appsv1.DaemonSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "ds", Namespace: "namespace", UID: dsUID},
+			Spec: appsv1.DaemonSetSpec{
+				Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"key": "value"}},
+			},
+		}
+	})
+
+	pod := func(ready bool, restarts int32) v1.Pod {
+		cond := v1.ConditionFalse
+		if ready {
+			cond = v1.ConditionTrue
+		}
+
+		return v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				OwnerReferences: []metav1.OwnerReference{
+					{UID: dsUID, Controller: pointer.Bool(true)},
+				},
+			},
+			Spec: v1.PodSpec{
+				Containers: []v1.Container{
+					{Name: constants.ModuleLoaderContainerName, Image: newImage},
+				},
+			},
+			Status: v1.PodStatus{
+				Conditions: []v1.PodCondition{{Type: v1.PodReady, Status: cond}},
+				ContainerStatuses: []v1.ContainerStatus{
+					{
+						Name:         constants.ModuleLoaderContainerName,
+						ImageID:      "docker.io/some/other/name@sha256:5678",
+						RestartCount: restarts,
+					},
+				},
+			},
+		}
+	}
+
+	expectPods := func(pods ...v1.Pod) {
+		clnt.
+			EXPECT().
+			List(ctx, &v1.PodList{}, gomock.Any()).
+			Do(func(_ context.Context, pl *v1.PodList, _ ...runtimeclient.ListOption) {
+				pl.Items = pods
+			})
+	}
+
+	It("should not list pods if there is no DaemonSet", func() {
+		status, err := it.SyncImage(ctx, mod, mld, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status).To(Equal(kmmv1beta1.ModuleLoaderImageStatus{KernelVersion: kernelVersion, Architecture: arch}))
+	})
+
+	It("should return an error if the pods could not be listed", func() {
+		clnt.EXPECT().List(ctx, &v1.PodList{}, gomock.Any()).Return(errors.New("random error"))
+
+		_, err := it.SyncImage(ctx, mod, mld, ds)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should record the last known good image once the DaemonSet is available", func() {
+		ds.Status = appsv1.DaemonSetStatus{DesiredNumberScheduled: 1, UpdatedNumberScheduled: 1, NumberAvailable: 1}
+		expectPods(pod(true, 0))
+
+		status, err := it.SyncImage(ctx, mod, mld, ds)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status.LastKnownGoodImage).To(Equal("example.org/repo/image@sha256:5678"))
+		Expect(status.RolledBackFrom).To(BeEmpty())
+	})
+
+	It("should not record an image if the DaemonSet is not available", func() {
+		ds.Status = appsv1.DaemonSetStatus{DesiredNumberScheduled: 2, UpdatedNumberScheduled: 2, NumberAvailable: 1}
+		expectPods(pod(true, 0), pod(false, 0))
+
+		status, err := it.SyncImage(ctx, mod, mld, ds)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status.LastKnownGoodImage).To(BeEmpty())
+	})
+
+	It("should roll back if the ModuleLoader pods are failing", func() {
+		mod.Status.ModuleLoaderImages = []kmmv1beta1.ModuleLoaderImageStatus{
+			{KernelVersion: kernelVersion, Architecture: arch, LastKnownGoodImage: goodImage},
+		}
+		expectPods(pod(false, 3))
+
+		status, err := it.SyncImage(ctx, mod, mld, ds)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status.LastKnownGoodImage).To(Equal(goodImage))
+		Expect(status.RolledBackFrom).To(Equal(newImage))
+	})
+
+	It("should not roll back to an image of another module version", func() {
+		mld.ModuleVersion = "v2"
+		mod.Status.ModuleLoaderImages = []kmmv1beta1.ModuleLoaderImageStatus{
+			{KernelVersion: kernelVersion, Architecture: arch, Version: "v1", LastKnownGoodImage: goodImage},
+		}
+		expectPods(pod(false, 3))
+
+		status, err := it.SyncImage(ctx, mod, mld, ds)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status).To(Equal(kmmv1beta1.ModuleLoaderImageStatus{KernelVersion: kernelVersion, Architecture: arch, Version: "v2"}))
+	})
+
+	It("should not roll back under the failure threshold", func() {
+		mod.Status.ModuleLoaderImages = []kmmv1beta1.ModuleLoaderImageStatus{
+			{KernelVersion: kernelVersion, Architecture: arch, LastKnownGoodImage: goodImage},
+		}
+		expectPods(pod(false, 2))
+
+		status, err := it.SyncImage(ctx, mod, mld, ds)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status.RolledBackFrom).To(BeEmpty())
+	})
+
+	It("should not roll back if spec.moduleLoader.rollback is not set", func() {
+		mod.Spec.ModuleLoader.Rollback = nil
+		mod.Status.ModuleLoaderImages = []kmmv1beta1.ModuleLoaderImageStatus{
+			{KernelVersion: kernelVersion, Architecture: arch, LastKnownGoodImage: goodImage},
+		}
+		expectPods(pod(false, 10))
+
+		status, err := it.SyncImage(ctx, mod, mld, ds)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status.RolledBackFrom).To(BeEmpty())
+	})
+
+	It("should stay rolled back while the image in the spec does not change", func() {
+		mod.Status.ModuleLoaderImages = []kmmv1beta1.ModuleLoaderImageStatus{
+			{KernelVersion: kernelVersion, Architecture: arch, LastKnownGoodImage: goodImage, RolledBackFrom: newImage},
+		}
+		ds.Status = appsv1.DaemonSetStatus{DesiredNumberScheduled: 1, UpdatedNumberScheduled: 1, NumberAvailable: 1}
+		expectPods(pod(true, 0))
+
+		status, err := it.SyncImage(ctx, mod, mld, ds)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status.LastKnownGoodImage).To(Equal(goodImage))
+		Expect(status.RolledBackFrom).To(Equal(newImage))
+	})
+
+	It("should try the new image if the image in the spec changed", func() {
+		mod.Status.ModuleLoaderImages = []kmmv1beta1.ModuleLoaderImageStatus{
+			{KernelVersion: kernelVersion, Architecture: arch, LastKnownGoodImage: goodImage, RolledBackFrom: "example.org/repo/image:broken"},
+		}
+		expectPods()
+
+		status, err := it.SyncImage(ctx, mod, mld, ds)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status.LastKnownGoodImage).To(Equal(goodImage))
+		Expect(status.RolledBackFrom).To(BeEmpty())
+	})
+})
@@ -17,7 +17,6 @@ var _ = Describe("GetRelevantSign", func() {
 		keySecret     = "securebootkey"
 		certSecret    = "securebootcert"
 		filesToSign   = "/modules/simple-kmod.ko:/modules/simple-procfs-kmod.ko"
-		kernelVersion = "1.2.3"
 	)
 
 	var (
@@ -36,8 +35,7 @@ var _ = Describe("GetRelevantSign", func() {
 	}
 
 	DescribeTable("should set fields correctly", func(moduleSign *kmmv1beta1.Sign, mappingSign *kmmv1beta1.Sign) {
-		actual, err := h.GetRelevantSign(moduleSign, mappingSign, kernelVersion)
-		Expect(err).NotTo(HaveOccurred())
+		actual := h.GetRelevantSign(moduleSign, mappingSign)
 		Expect(
 			cmp.Diff(expected, actual),
 		).To(
@@ -110,59 +108,12 @@ var _ = Describe("GetRelevantSign", func() {
 		),
 	)
 
-})
-var _ = Describe("GetRelevantSign", func() {
-
-	const (
-		unsignedImage = "my.registry/my/image"
-		keySecret     = "securebootkey"
-		certSecret    = "securebootcert"
-		filesToSign   = "/modules/${KERNEL_VERSION}/simple-kmod.ko:/modules/${KERNEL_VERSION}/simple-procfs-kmod.ko"
-		kernelVersion = "1.2.3"
-	)
-
-	var (
-		h Helper
-	)
+	It("should enable DeleteIntermediateImage if the mapping enables it", func() {
+		actual := h.GetRelevantSign(
+			&kmmv1beta1.Sign{UnsignedImage: unsignedImage},
+			&kmmv1beta1.Sign{DeleteIntermediateImage: true},
+		)
 
-	BeforeEach(func() {
-		h = NewSignerHelper()
+		Expect(actual.DeleteIntermediateImage).To(BeTrue())
 	})
-
-	expected := &kmmv1beta1.Sign{
-		UnsignedImage: unsignedImage + ":" + kernelVersion,
-		KeySecret:     &v1.LocalObjectReference{Name: keySecret},
-		CertSecret:    &v1.LocalObjectReference{Name: certSecret},
-		FilesToSign:   strings.Split("/modules/"+kernelVersion+"/simple-kmod.ko:/modules/"+kernelVersion+"/simple-procfs-kmod.ko", ":"),
-	}
-
-	DescribeTable("should set fields correctly", func(moduleSign *kmmv1beta1.Sign, mappingSign *kmmv1beta1.Sign) {
-		actual, _ := h.GetRelevantSign(moduleSign, mappingSign, kernelVersion)
-		Expect(
-			cmp.Diff(expected, actual),
-		).To(
-			BeEmpty(),
-		)
-	},
-		Entry(
-			"no km.Sign",
-			&kmmv1beta1.Sign{
-				UnsignedImage: unsignedImage + ":${KERNEL_VERSION}",
-				KeySecret:     &v1.LocalObjectReference{Name: keySecret},
-				CertSecret:    &v1.LocalObjectReference{Name: certSecret},
-				FilesToSign:   strings.Split(filesToSign, ":"),
-			},
-			nil,
-		),
-		Entry(
-			"no container.Sign",
-			nil,
-			&kmmv1beta1.Sign{
-				UnsignedImage: unsignedImage + ":${KERNEL_VERSION}",
-				KeySecret:     &v1.LocalObjectReference{Name: keySecret},
-				CertSecret:    &v1.LocalObjectReference{Name: certSecret},
-				FilesToSign:   strings.Split(filesToSign, ":"),
-			},
-		),
-	)
 })
@@ -4,54 +4,147 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
+	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	"github.com/kubernetes-sigs/kernel-module-management/internal/api"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/auth"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/constants"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/gc"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/module"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/registry"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/utils"
 )
 
+const (
+	reasonIntermediateImageDeleted     = "IntermediateImageDeleted"
+	reasonIntermediateImageNotDeleted  = "IntermediateImageNotDeleted"
+	reasonIntermediateImageDeleteError = "IntermediateImageDeletionFailed"
+)
+
 type signJobManager struct {
 	client    client.Client
 	signer    Signer
 	jobHelper utils.JobHelper
 	registry  registry.Registry
+	recorder  record.EventRecorder
 }
 
 func NewSignJobManager(
 	client client.Client,
 	signer Signer,
 	jobHelper utils.JobHelper,
-	registry registry.Registry) *signJobManager {
+	registry registry.Registry,
+	recorder record.EventRecorder) *signJobManager {
 	return &signJobManager{
 		client:    client,
 		signer:    signer,
 		jobHelper: jobHelper,
 		registry:  registry,
+		recorder:  recorder,
 	}
 }
 
-func (jbm *signJobManager) GarbageCollect(ctx context.Context, modName, namespace string, owner metav1.Object) ([]string, error) {
+func (jbm *signJobManager) GarbageCollect(
+	ctx context.Context,
+	modName, namespace string,
+	mldMappings map[string]*api.ModuleLoaderData,
+	policy gc.Policy,
+	owner metav1.Object) ([]string, time.Duration, error) {
 	jobs, err := jbm.jobHelper.GetModuleJobs(ctx, modName, namespace, utils.JobTypeSign, owner)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get sign jobs for module %s: %v", modName, err)
+		return nil, 0, fmt.Errorf("failed to get sign jobs for module %s: %v", modName, err)
 	}
 
+	now := time.Now()
+
+	var requeueAfter time.Duration
+
 	deleteNames := make([]string, 0, len(jobs))
 	for _, job := range jobs {
+		if !policy.ShouldDeleteJob(&job, now) {
+			requeueAfter = gc.Earliest(requeueAfter, policy.JobRequeueAfter(&job, now))
+			continue
+		}
+
 		if job.Status.Succeeded == 1 {
-			err = jbm.jobHelper.DeleteJob(ctx, &job)
-			if err != nil {
-				return nil, fmt.Errorf("failed to delete build job %s: %v", job.Name, err)
+			key := api.KernelArchKey(job.Labels[constants.TargetKernelTarget], job.Labels[constants.ArchitectureLabel])
+
+			if mld, ok := mldMappings[key]; ok {
+				jbm.deleteIntermediateImage(ctx, mld, owner)
 			}
-			deleteNames = append(deleteNames, job.Name)
 		}
+
+		err = jbm.jobHelper.DeleteJob(ctx, &job)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to delete build job %s: %v", job.Name, err)
+		}
+		deleteNames = append(deleteNames, job.Name)
+	}
+	return deleteNames, requeueAfter, nil
+}
+
+// deleteIntermediateImage deletes the unsigned image built for mld, if its Sign configuration requests it and the
+// signed image exists.
+// The outcome is reported through events on owner only, so that registry errors do not block the garbage collection.
+func (jbm *signJobManager) deleteIntermediateImage(ctx context.Context, mld *api.ModuleLoaderData, owner metav1.Object) {
+	if !module.ShouldBeBuilt(mld) || !module.ShouldBeSigned(mld) || !mld.Sign.DeleteIntermediateImage {
+		return
+	}
+
+	logger := log.FromContext(ctx)
+	intermediateImage := module.IntermediateImageName(mld.Name, mld.Namespace, mld.ContainerImage)
+
+	exists, err := module.ImageExists(ctx, jbm.client, jbm.registry, mld, mld.Namespace, mld.ContainerImage)
+	if err != nil {
+		logger.Info(utils.WarnString("could not check if the signed image exists"), "image", mld.ContainerImage, "error", err)
+		jbm.event(owner, v1.EventTypeWarning, reasonIntermediateImageDeleteError,
+			"Could not check if signed image %s exists before deleting %s: %v", mld.ContainerImage, intermediateImage, err)
+		return
+	}
+
+	if !exists {
+		jbm.event(owner, v1.EventTypeWarning, reasonIntermediateImageNotDeleted,
+			"Not deleting %s: signed image %s does not exist", intermediateImage, mld.ContainerImage)
+		return
+	}
+
+	caBundle, err := module.RegistryCABundle(ctx, jbm.client, mld.Namespace, mld.RegistryTLS)
+	if err != nil {
+		jbm.event(owner, v1.EventTypeWarning, reasonIntermediateImageDeleteError,
+			"Could not get the registry CA bundle to delete %s: %v", intermediateImage, err)
+		return
+	}
+
+	registryAuthGetter := auth.NewRegistryAuthGetterFrom(jbm.client, mld)
+
+	err = jbm.registry.DeleteTag(ctx, intermediateImage, mld.RegistryTLS, caBundle, registryAuthGetter)
+	if errors.Is(err, registry.ErrDeletionUnsupported) {
+		// the intermediate tag is specific to the Module and the kernel, so no other image should share its manifest
+		logger.Info("The registry does not support deleting tags; deleting the manifest", "image", intermediateImage)
+		err = jbm.registry.DeleteManifest(ctx, intermediateImage, mld.RegistryTLS, caBundle, registryAuthGetter)
+	}
+
+	if err != nil {
+		logger.Info(utils.WarnString("could not delete the intermediate image"), "image", intermediateImage, "error", err)
+		jbm.event(owner, v1.EventTypeWarning, reasonIntermediateImageDeleteError, "Could not delete %s: %v", intermediateImage, err)
+		return
+	}
+
+	logger.Info("Deleted the intermediate image", "image", intermediateImage)
+	jbm.event(owner, v1.EventTypeNormal, reasonIntermediateImageDeleted, "Deleted %s after signing", intermediateImage)
+}
+
+func (jbm *signJobManager) event(owner metav1.Object, eventType, reason, messageFmt string, args ...interface{}) {
+	if obj, ok := owner.(runtime.Object); ok && jbm.recorder != nil {
+		jbm.recorder.Eventf(obj, eventType, reason, messageFmt, args...)
 	}
-	return deleteNames, nil
 }
 
 func (jbm *signJobManager) ShouldSync(ctx context.Context, mld *api.ModuleLoaderData) (bool, error) {
@@ -66,7 +159,39 @@ func (jbm *signJobManager) ShouldSync(ctx context.Context, mld *api.ModuleLoader
 		return false, fmt.Errorf("failed to check existence of image %s: %w", mld.ContainerImage, err)
 	}
 
-	return !exists, nil
+	if !exists {
+		return true, nil
+	}
+
+	return jbm.isSignedImageOutdated(ctx, mld)
+}
+
+// isSignedImageOutdated returns true if the intermediate image built for mld was rebuilt from other inputs than
+// those of the signed image, as recorded in their constants.BuildHashImageLabel label.
+// It always returns false if the build of mld opted out of rebuilding images when their inputs change.
+func (jbm *signJobManager) isSignedImageOutdated(ctx context.Context, mld *api.ModuleLoaderData) (bool, error) {
+	if !module.ShouldBeBuilt(mld) || mld.Build.SkipRebuildOnInputChange {
+		return false, nil
+	}
+
+	intermediateImage := module.IntermediateImageName(mld.Name, mld.Namespace, mld.ContainerImage)
+
+	intermediateHash, err := module.ImageBuildHash(ctx, jbm.client, jbm.registry, mld, mld.Namespace, intermediateImage)
+	if err != nil {
+		return false, fmt.Errorf("failed to get the build hash of image %s: %w", intermediateImage, err)
+	}
+
+	// the intermediate image may have been deleted once signed
+	if intermediateHash == "" {
+		return false, nil
+	}
+
+	signedHash, err := module.ImageBuildHash(ctx, jbm.client, jbm.registry, mld, mld.Namespace, mld.ContainerImage)
+	if err != nil {
+		return false, fmt.Errorf("failed to get the build hash of image %s: %w", mld.ContainerImage, err)
+	}
+
+	return intermediateHash != signedHash, nil
 }
 
 func (jbm *signJobManager) Sync(
@@ -80,14 +205,14 @@ func (jbm *signJobManager) Sync(
 
 	logger.Info("Signing in-cluster")
 
-	labels := jbm.jobHelper.JobLabels(mld.Name, mld.KernelVersion, "sign")
+	labels := jbm.jobHelper.JobLabels(mld.Name, mld.KernelVersion, mld.Architecture, "sign")
 
 	jobTemplate, err := jbm.signer.MakeJobTemplate(ctx, mld, labels, imageToSign, pushImage, owner)
 	if err != nil {
 		return "", fmt.Errorf("could not make Job template: %v", err)
 	}
 
-	job, err := jbm.jobHelper.GetModuleJobByKernel(ctx, mld.Name, mld.Namespace, mld.KernelVersion, utils.JobTypeSign, owner)
+	job, err := jbm.jobHelper.GetModuleJobByKernel(ctx, mld.Name, mld.Namespace, mld.KernelVersion, mld.Architecture, utils.JobTypeSign, owner)
 	if err != nil {
 		if !errors.Is(err, utils.ErrNoMatchingJob) {
 			return "", fmt.Errorf("error getting the signing job: %v", err)
@@ -123,5 +248,26 @@ func (jbm *signJobManager) Sync(
 		return "", err
 	}
 
+	if statusmsg == utils.StatusCompleted && pushImage && imageToSign != "" {
+		// the job template does not change when the image to sign is rebuilt: sign the new image again
+		outdated, err := jbm.isSignedImageOutdated(ctx, mld)
+		if err != nil {
+			return "", fmt.Errorf("could not check if the signed image is outdated: %v", err)
+		}
+
+		if outdated {
+			logger.Info("The image to sign was rebuilt, deleting the current job so a new one can be created", "name", job.Name)
+			if err = jbm.jobHelper.DeleteJob(ctx, job); err != nil {
+				logger.Info(utils.WarnString(fmt.Sprintf("failed to delete signing job %s: %v", job.Name, err)))
+			}
+			return utils.StatusInProgress, nil
+		}
+	}
+
+	if statusmsg == utils.StatusCompleted && pushImage {
+		// the image was just pushed; do not rely on a cached lookup from before the signing
+		jbm.registry.Invalidate(mld.ContainerImage)
+	}
+
 	return statusmsg, nil
 }
@@ -10,6 +10,8 @@ import (
 	"github.com/kubernetes-sigs/kernel-module-management/internal/api"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/client"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/constants"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/gc"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/module"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/registry"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/utils"
 	. "github.com/onsi/ginkgo/v2"
@@ -17,6 +19,7 @@ import (
 	batchv1 "k8s.io/api/batch/v1"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
 )
 
 var _ = Describe("ShouldSync", func() {
@@ -38,7 +41,7 @@ var _ = Describe("ShouldSync", func() {
 		ctrl = gomock.NewController(GinkgoT())
 		clnt = client.NewMockClient(ctrl)
 		reg = registry.NewMockRegistry(ctrl)
-		mgr = NewSignJobManager(clnt, nil, nil, reg)
+		mgr = NewSignJobManager(clnt, nil, nil, reg, nil)
 	})
 
 	It("should return false if there was not sign section", func() {
@@ -63,7 +66,7 @@ var _ = Describe("ShouldSync", func() {
 		}
 
 		gomock.InOrder(
-			reg.EXPECT().ImageExists(ctx, imageName, nil, gomock.Any()).Return(true, nil),
+			reg.EXPECT().ImageExists(ctx, imageName, "", nil, nil, gomock.Any()).Return(true, nil),
 		)
 
 		shouldSync, err := mgr.ShouldSync(ctx, mld)
@@ -84,7 +87,7 @@ var _ = Describe("ShouldSync", func() {
 		}
 
 		gomock.InOrder(
-			reg.EXPECT().ImageExists(ctx, imageName, nil, gomock.Any()).Return(false, errors.New("generic-registry-error")),
+			reg.EXPECT().ImageExists(ctx, imageName, "", nil, nil, gomock.Any()).Return(false, errors.New("generic-registry-error")),
 		)
 
 		shouldSync, err := mgr.ShouldSync(ctx, mld)
@@ -106,7 +109,7 @@ var _ = Describe("ShouldSync", func() {
 		}
 
 		gomock.InOrder(
-			reg.EXPECT().ImageExists(ctx, imageName, nil, gomock.Any()).Return(false, nil),
+			reg.EXPECT().ImageExists(ctx, imageName, "", nil, nil, gomock.Any()).Return(false, nil),
 		)
 
 		shouldSync, err := mgr.ShouldSync(ctx, mld)
@@ -114,6 +117,69 @@ var _ = Describe("ShouldSync", func() {
 		Expect(err).ToNot(HaveOccurred())
 		Expect(shouldSync).To(BeTrue())
 	})
+
+	DescribeTable("should compare the build hashes of the intermediate and signed images",
+		func(intermediateHash, signedHash string, expected bool) {
+			ctx := context.Background()
+
+			mld := &api.ModuleLoaderData{
+				Name:           moduleName,
+				Namespace:      namespace,
+				ContainerImage: imageName,
+				Build:          &kmmv1beta1.Build{},
+				Sign:           &kmmv1beta1.Sign{},
+			}
+
+			intermediateImage := module.IntermediateImageName(moduleName, namespace, imageName)
+
+			calls := []*gomock.Call{
+				reg.EXPECT().ImageExists(ctx, imageName, "", nil, nil, gomock.Any()).Return(true, nil),
+				reg.EXPECT().
+					GetLabels(ctx, intermediateImage, "", nil, nil, gomock.Any()).
+					Return(map[string]string{constants.BuildHashImageLabel: intermediateHash}, nil),
+			}
+
+			if intermediateHash != "" {
+				calls = append(
+					calls,
+					reg.EXPECT().
+						GetLabels(ctx, imageName, "", nil, nil, gomock.Any()).
+						Return(map[string]string{constants.BuildHashImageLabel: signedHash}, nil),
+				)
+			}
+
+			gomock.InOrder(calls...)
+
+			Expect(
+				mgr.ShouldSync(ctx, mld),
+			).To(
+				Equal(expected),
+			)
+		},
+		Entry("same hash", "hash", "hash", false),
+		Entry("intermediate image rebuilt", "new-hash", "hash", true),
+		Entry("intermediate image deleted", "", "hash", false),
+	)
+
+	It("should not compare the build hashes if rebuilding is skipped", func() {
+		ctx := context.Background()
+
+		mld := &api.ModuleLoaderData{
+			Name:           moduleName,
+			Namespace:      namespace,
+			ContainerImage: imageName,
+			Build:          &kmmv1beta1.Build{SkipRebuildOnInputChange: true},
+			Sign:           &kmmv1beta1.Sign{},
+		}
+
+		reg.EXPECT().ImageExists(ctx, imageName, "", nil, nil, gomock.Any()).Return(true, nil)
+
+		Expect(
+			mgr.ShouldSync(ctx, mld),
+		).To(
+			BeFalse(),
+		)
+	})
 })
 
 var _ = Describe("Sync", func() {
@@ -121,6 +187,7 @@ var _ = Describe("Sync", func() {
 		ctrl      *gomock.Controller
 		maker     *MockSigner
 		jobhelper *utils.MockJobHelper
+		reg       *registry.MockRegistry
 		mgr       *signJobManager
 	)
 
@@ -138,7 +205,8 @@ var _ = Describe("Sync", func() {
 		ctrl = gomock.NewController(GinkgoT())
 		maker = NewMockSigner(ctrl)
 		jobhelper = utils.NewMockJobHelper(ctrl)
-		mgr = NewSignJobManager(nil, maker, jobhelper, nil)
+		reg = registry.NewMockRegistry(ctrl)
+		mgr = NewSignJobManager(nil, maker, jobhelper, reg, nil)
 	})
 
 	labels := map[string]string{"kmm.node.kubernetes.io/job-type": "sign",
@@ -154,6 +222,8 @@ var _ = Describe("Sync", func() {
 		KernelVersion:  kernelVersion,
 	}
 
+	intermediateImage := module.IntermediateImageName(moduleName, "", imageName)
+
 	DescribeTable("should return the correct status depending on the job status",
 		func(s batchv1.JobStatus, jobStatus utils.Status, expectsErr bool) {
 			j := batchv1.Job{
@@ -186,13 +256,23 @@ var _ = Describe("Sync", func() {
 			}
 
 			gomock.InOrder(
-				jobhelper.EXPECT().JobLabels(mld.Name, kernelVersion, "sign").Return(labels),
+				jobhelper.EXPECT().JobLabels(mld.Name, kernelVersion, "", "sign").Return(labels),
 				maker.EXPECT().MakeJobTemplate(ctx, mld, labels, previousImageName, true, mld.Owner).Return(&j, nil),
-				jobhelper.EXPECT().GetModuleJobByKernel(ctx, mld.Name, mld.Namespace, kernelVersion, utils.JobTypeSign, mld.Owner).Return(&newJob, nil),
+				jobhelper.EXPECT().GetModuleJobByKernel(ctx, mld.Name, mld.Namespace, kernelVersion, "", utils.JobTypeSign, mld.Owner).Return(&newJob, nil),
 				jobhelper.EXPECT().IsJobChanged(&j, &newJob).Return(false, nil),
 				jobhelper.EXPECT().GetJobStatus(&newJob).Return(jobStatus, joberr),
 			)
 
+			if jobStatus == utils.StatusCompleted {
+				buildHashLabels := map[string]string{constants.BuildHashImageLabel: "hash"}
+
+				gomock.InOrder(
+					reg.EXPECT().GetLabels(ctx, intermediateImage, "", nil, nil, gomock.Any()).Return(buildHashLabels, nil),
+					reg.EXPECT().GetLabels(ctx, imageName, "", nil, nil, gomock.Any()).Return(buildHashLabels, nil),
+					reg.EXPECT().Invalidate(imageName),
+				)
+			}
+
 			res, err := mgr.Sync(ctx, mld, previousImageName, true, mld.Owner)
 
 			if expectsErr {
@@ -212,7 +292,7 @@ var _ = Describe("Sync", func() {
 		ctx := context.Background()
 
 		gomock.InOrder(
-			jobhelper.EXPECT().JobLabels(mld.Name, kernelVersion, "sign").Return(labels),
+			jobhelper.EXPECT().JobLabels(mld.Name, kernelVersion, "", "sign").Return(labels),
 			maker.EXPECT().MakeJobTemplate(ctx, mld, labels, previousImageName, true, mld.Owner).
 				Return(nil, errors.New("random error")),
 		)
@@ -238,9 +318,9 @@ var _ = Describe("Sync", func() {
 		}
 
 		gomock.InOrder(
-			jobhelper.EXPECT().JobLabels(mld.Name, kernelVersion, "sign").Return(labels),
+			jobhelper.EXPECT().JobLabels(mld.Name, kernelVersion, "", "sign").Return(labels),
 			maker.EXPECT().MakeJobTemplate(ctx, mld, labels, previousImageName, true, mld.Owner).Return(&j, nil),
-			jobhelper.EXPECT().GetModuleJobByKernel(ctx, mld.Name, mld.Namespace, kernelVersion, utils.JobTypeSign, mld.Owner).Return(nil, errors.New("random error")),
+			jobhelper.EXPECT().GetModuleJobByKernel(ctx, mld.Name, mld.Namespace, kernelVersion, "", utils.JobTypeSign, mld.Owner).Return(nil, errors.New("random error")),
 		)
 
 		Expect(
@@ -264,9 +344,9 @@ var _ = Describe("Sync", func() {
 		}
 
 		gomock.InOrder(
-			jobhelper.EXPECT().JobLabels(mld.Name, kernelVersion, "sign").Return(labels),
+			jobhelper.EXPECT().JobLabels(mld.Name, kernelVersion, "", "sign").Return(labels),
 			maker.EXPECT().MakeJobTemplate(ctx, mld, labels, previousImageName, true, mld.Owner).Return(&j, nil),
-			jobhelper.EXPECT().GetModuleJobByKernel(ctx, mld.Name, mld.Namespace, kernelVersion, utils.JobTypeSign, mld.Owner).Return(nil, utils.ErrNoMatchingJob),
+			jobhelper.EXPECT().GetModuleJobByKernel(ctx, mld.Name, mld.Namespace, kernelVersion, "", utils.JobTypeSign, mld.Owner).Return(nil, utils.ErrNoMatchingJob),
 			jobhelper.EXPECT().CreateJob(ctx, &j).Return(errors.New("unable to create job")),
 		)
 
@@ -292,9 +372,9 @@ var _ = Describe("Sync", func() {
 		}
 
 		gomock.InOrder(
-			jobhelper.EXPECT().JobLabels(mld.Name, kernelVersion, "sign").Return(labels),
+			jobhelper.EXPECT().JobLabels(mld.Name, kernelVersion, "", "sign").Return(labels),
 			maker.EXPECT().MakeJobTemplate(ctx, mld, labels, previousImageName, true, mld.Owner).Return(&j, nil),
-			jobhelper.EXPECT().GetModuleJobByKernel(ctx, mld.Name, mld.Namespace, kernelVersion, utils.JobTypeSign, mld.Owner).Return(nil, utils.ErrNoMatchingJob),
+			jobhelper.EXPECT().GetModuleJobByKernel(ctx, mld.Name, mld.Namespace, kernelVersion, "", utils.JobTypeSign, mld.Owner).Return(nil, utils.ErrNoMatchingJob),
 			jobhelper.EXPECT().CreateJob(ctx, &j).Return(nil),
 		)
 
@@ -321,9 +401,9 @@ var _ = Describe("Sync", func() {
 		}
 
 		gomock.InOrder(
-			jobhelper.EXPECT().JobLabels(mld.Name, kernelVersion, "sign").Return(labels),
+			jobhelper.EXPECT().JobLabels(mld.Name, kernelVersion, "", "sign").Return(labels),
 			maker.EXPECT().MakeJobTemplate(ctx, mld, labels, previousImageName, true, mld.Owner).Return(&newJob, nil),
-			jobhelper.EXPECT().GetModuleJobByKernel(ctx, mld.Name, mld.Namespace, kernelVersion, utils.JobTypeSign, mld.Owner).Return(&newJob, nil),
+			jobhelper.EXPECT().GetModuleJobByKernel(ctx, mld.Name, mld.Namespace, kernelVersion, "", utils.JobTypeSign, mld.Owner).Return(&newJob, nil),
 			jobhelper.EXPECT().IsJobChanged(&newJob, &newJob).Return(true, nil),
 			jobhelper.EXPECT().DeleteJob(ctx, &newJob).Return(nil),
 		)
@@ -334,6 +414,39 @@ var _ = Describe("Sync", func() {
 			Equal(utils.Status(utils.StatusInProgress)),
 		)
 	})
+
+	It("should delete the completed job if the image to sign was rebuilt", func() {
+		ctx := context.Background()
+
+		j := batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      jobName,
+				Namespace: namespace,
+			},
+			Status: batchv1.JobStatus{Succeeded: 1},
+		}
+
+		gomock.InOrder(
+			jobhelper.EXPECT().JobLabels(mld.Name, kernelVersion, "", "sign").Return(labels),
+			maker.EXPECT().MakeJobTemplate(ctx, mld, labels, previousImageName, true, mld.Owner).Return(&j, nil),
+			jobhelper.EXPECT().GetModuleJobByKernel(ctx, mld.Name, mld.Namespace, kernelVersion, "", utils.JobTypeSign, mld.Owner).Return(&j, nil),
+			jobhelper.EXPECT().IsJobChanged(&j, &j).Return(false, nil),
+			jobhelper.EXPECT().GetJobStatus(&j).Return(utils.Status(utils.StatusCompleted), nil),
+			reg.EXPECT().
+				GetLabels(ctx, intermediateImage, "", nil, nil, gomock.Any()).
+				Return(map[string]string{constants.BuildHashImageLabel: "new-hash"}, nil),
+			reg.EXPECT().
+				GetLabels(ctx, imageName, "", nil, nil, gomock.Any()).
+				Return(map[string]string{constants.BuildHashImageLabel: "hash"}, nil),
+			jobhelper.EXPECT().DeleteJob(ctx, &j).Return(nil),
+		)
+
+		Expect(
+			mgr.Sync(ctx, mld, previousImageName, true, mld.Owner),
+		).To(
+			Equal(utils.Status(utils.StatusInProgress)),
+		)
+	})
 })
 
 var _ = Describe("GarbageCollect", func() {
@@ -348,7 +461,7 @@ var _ = Describe("GarbageCollect", func() {
 		ctrl = gomock.NewController(GinkgoT())
 		clnt = client.NewMockClient(ctrl)
 		jobhelper = utils.NewMockJobHelper(ctrl)
-		mgr = NewSignJobManager(clnt, nil, jobhelper, nil)
+		mgr = NewSignJobManager(clnt, nil, jobhelper, nil, nil)
 	})
 
 	mld := api.ModuleLoaderData{
@@ -394,7 +507,7 @@ var _ = Describe("GarbageCollect", func() {
 				}
 			}
 
-			names, err := mgr.GarbageCollect(context.Background(), mld.Name, mld.Namespace, mld.Owner)
+			names, _, err := mgr.GarbageCollect(context.Background(), mld.Name, mld.Namespace, nil, gc.Policy{}, mld.Owner)
 
 			if expectsErr {
 				Expect(err).To(HaveOccurred())
@@ -410,3 +523,113 @@ var _ = Describe("GarbageCollect", func() {
 		Entry("error occured", batchv1.JobStatus{Succeeded: 0}, batchv1.JobStatus{Succeeded: 0}, true),
 	)
 })
+
+var _ = Describe("GarbageCollect intermediate images", func() {
+	const (
+		image         = "registry.example.com/org/image:tag"
+		kernelVersion = "1.2.3"
+		namespace     = "some-namespace"
+	)
+
+	var (
+		ctrl      *gomock.Controller
+		clnt      *client.MockClient
+		jobhelper *utils.MockJobHelper
+		reg       *registry.MockRegistry
+		recorder  *record.FakeRecorder
+		mgr       *signJobManager
+		mld       *api.ModuleLoaderData
+		job       batchv1.Job
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		clnt = client.NewMockClient(ctrl)
+		jobhelper = utils.NewMockJobHelper(ctrl)
+		reg = registry.NewMockRegistry(ctrl)
+		recorder = record.NewFakeRecorder(10)
+		mgr = NewSignJobManager(clnt, nil, jobhelper, reg, recorder)
+
+		mld = &api.ModuleLoaderData{
+			Name:           "moduleName",
+			Namespace:      namespace,
+			ContainerImage: image,
+			KernelVersion:  kernelVersion,
+			Build:          &kmmv1beta1.Build{},
+			Sign:           &kmmv1beta1.Sign{DeleteIntermediateImage: true},
+			Owner:          &kmmv1beta1.Module{},
+		}
+
+		job = batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "jobName",
+				Labels: map[string]string{constants.TargetKernelTarget: kernelVersion},
+			},
+			Status: batchv1.JobStatus{Succeeded: 1},
+		}
+	})
+
+	intermediateImage := image + "_" + namespace + "_moduleName_kmm_unsigned"
+
+	It("should delete the manifest if the registry does not support deleting tags", func() {
+		ctx := context.Background()
+
+		gomock.InOrder(
+			jobhelper.EXPECT().GetModuleJobs(ctx, mld.Name, namespace, utils.JobTypeSign, mld.Owner).Return([]batchv1.Job{job}, nil),
+			reg.EXPECT().ImageExists(ctx, image, "", nil, nil, gomock.Any()).Return(true, nil),
+			reg.EXPECT().DeleteTag(ctx, intermediateImage, nil, nil, gomock.Any()).Return(
+				fmt.Errorf("some error: %w", registry.ErrDeletionUnsupported),
+			),
+			reg.EXPECT().DeleteManifest(ctx, intermediateImage, nil, nil, gomock.Any()),
+			jobhelper.EXPECT().DeleteJob(ctx, &job),
+		)
+
+		names, _, err := mgr.GarbageCollect(ctx, mld.Name, namespace, map[string]*api.ModuleLoaderData{kernelVersion: mld}, gc.Policy{}, mld.Owner)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(names).To(Equal([]string{"jobName"}))
+		Expect(recorder.Events).To(Receive(ContainSubstring("Normal IntermediateImageDeleted")))
+	})
+
+	It("should keep the intermediate image if the signed image does not exist", func() {
+		ctx := context.Background()
+
+		gomock.InOrder(
+			jobhelper.EXPECT().GetModuleJobs(ctx, mld.Name, namespace, utils.JobTypeSign, mld.Owner).Return([]batchv1.Job{job}, nil),
+			reg.EXPECT().ImageExists(ctx, image, "", nil, nil, gomock.Any()).Return(false, nil),
+			jobhelper.EXPECT().DeleteJob(ctx, &job),
+		)
+
+		_, _, err := mgr.GarbageCollect(ctx, mld.Name, namespace, map[string]*api.ModuleLoaderData{kernelVersion: mld}, gc.Policy{}, mld.Owner)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(recorder.Events).To(Receive(ContainSubstring("Warning IntermediateImageNotDeleted")))
+	})
+
+	It("should report deletion errors in events without failing", func() {
+		ctx := context.Background()
+
+		gomock.InOrder(
+			jobhelper.EXPECT().GetModuleJobs(ctx, mld.Name, namespace, utils.JobTypeSign, mld.Owner).Return([]batchv1.Job{job}, nil),
+			reg.EXPECT().ImageExists(ctx, image, "", nil, nil, gomock.Any()).Return(true, nil),
+			reg.EXPECT().DeleteTag(ctx, intermediateImage, nil, nil, gomock.Any()).Return(errors.New("some error")),
+			jobhelper.EXPECT().DeleteJob(ctx, &job),
+		)
+
+		_, _, err := mgr.GarbageCollect(ctx, mld.Name, namespace, map[string]*api.ModuleLoaderData{kernelVersion: mld}, gc.Policy{}, mld.Owner)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(recorder.Events).To(Receive(ContainSubstring("Warning IntermediateImageDeletionFailed")))
+	})
+
+	It("should not delete anything if not requested", func() {
+		ctx := context.Background()
+		mld.Sign.DeleteIntermediateImage = false
+
+		gomock.InOrder(
+			jobhelper.EXPECT().GetModuleJobs(ctx, mld.Name, namespace, utils.JobTypeSign, mld.Owner).Return([]batchv1.Job{job}, nil),
+			jobhelper.EXPECT().DeleteJob(ctx, &job),
+		)
+
+		_, _, err := mgr.GarbageCollect(ctx, mld.Name, namespace, map[string]*api.ModuleLoaderData{kernelVersion: mld}, gc.Policy{}, mld.Owner)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(recorder.Events).NotTo(Receive())
+	})
+})
@@ -12,12 +12,15 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/utils/pointer"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
 	"github.com/kubernetes-sigs/kernel-module-management/internal/api"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/auth"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/constants"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/registry"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/utils"
 )
 
@@ -44,16 +47,19 @@ type signer struct {
 	client    client.Client
 	scheme    *runtime.Scheme
 	jobHelper utils.JobHelper
+	mirrors   *registry.MirrorConfig
 }
 
 func NewSigner(
 	client client.Client,
 	scheme *runtime.Scheme,
-	jobHelper utils.JobHelper) Signer {
+	jobHelper utils.JobHelper,
+	mirrors *registry.MirrorConfig) Signer {
 	return &signer{
 		client:    client,
 		scheme:    scheme,
 		jobHelper: jobHelper,
+		mirrors:   mirrors,
 	}
 }
 
@@ -85,7 +91,13 @@ func (s *signer) MakeJobTemplate(
 	if imageToSign != "" {
 		args = append(args, "-unsignedimage", imageToSign)
 	} else if signConfig.UnsignedImage != "" {
-		args = append(args, "-unsignedimage", signConfig.UnsignedImage)
+		// the intermediate image above is pushed by KMM itself; only images provided by the user are mirrored
+		candidates := s.mirrors.Candidates(signConfig.UnsignedImage)
+		args = append(args, "-unsignedimage", candidates[len(candidates)-1])
+
+		if len(candidates) > 1 {
+			args = append(args, "-unsignedimage-mirrors", strings.Join(candidates[:len(candidates)-1], ","))
+		}
 	} else {
 		return nil, fmt.Errorf("no image to sign given")
 	}
@@ -113,11 +125,50 @@ func (s *signer) MakeJobTemplate(
 		utils.MakeSecretVolumeMount(signConfig.KeySecret, "/signingkey"),
 	}
 
+	if ref := signConfig.UnsignedImageRegistryTLS.CABundle; ref != nil {
+		args = append(args, "-ca-bundle-pull", utils.CABundleFile(*ref))
+		volumes, volumeMounts = utils.AppendCABundleVolume(volumes, volumeMounts, ref)
+	}
+
+	if pushImage && mld.RegistryTLS != nil && mld.RegistryTLS.CABundle != nil {
+		ref := mld.RegistryTLS.CABundle
+		args = append(args, "-ca-bundle", utils.CABundleFile(*ref))
+		volumes, volumeMounts = utils.AppendCABundleVolume(volumes, volumeMounts, ref)
+	}
+
 	args = append(args, "-secretdir", "/docker_config/")
-	imageSecret := mld.ImageRepoSecret
-	if imageSecret != nil {
-		volumes = append(volumes, utils.MakeSecretVolume(imageSecret, "", ""))
-		volumeMounts = append(volumeMounts, utils.MakeSecretVolumeMount(imageSecret, "/docker_config/"+imageSecret.Name))
+	// the unsigned image may have been pushed by a build with the push secret: make it available for pulling too
+	seenSecrets := sets.NewString()
+
+	for _, secret := range auth.ModuleLoaderSecrets(mld) {
+		imageSecret := secret
+		seenSecrets.Insert(imageSecret.Name)
+		volumes = append(volumes, utils.MakeSecretVolume(&imageSecret, "", ""))
+		volumeMounts = append(volumeMounts, utils.MakeSecretVolumeMount(&imageSecret, "/docker_config/"+imageSecret.Name))
+	}
+
+	saSecrets, err := auth.ServiceAccountPullSecrets(ctx, s.client, mld.Namespace, mld.ServiceAccountName)
+	if err != nil {
+		return nil, fmt.Errorf("could not get the ServiceAccount's pull secrets: %v", err)
+	}
+
+	for _, secret := range saSecrets {
+		if seenSecrets.Has(secret.Name) {
+			continue
+		}
+
+		imageSecret := secret
+		seenSecrets.Insert(imageSecret.Name)
+		vol := utils.MakeSecretVolume(&imageSecret, "", "")
+		// like the kubelet, tolerate the ServiceAccount's pull secrets that do not exist
+		vol.Secret.Optional = pointer.Bool(true)
+		volumes = append(volumes, vol)
+		volumeMounts = append(volumeMounts, utils.MakeSecretVolumeMount(&imageSecret, "/docker_config/"+imageSecret.Name))
+	}
+
+	if pushImage && mld.PushSecret != nil {
+		args = append(args, "-pushsecretdir", "/docker_config_push/")
+		volumeMounts = append(volumeMounts, utils.MakeSecretVolumeMount(mld.PushSecret, "/docker_config_push/"+mld.PushSecret.Name))
 	}
 
 	specTemplate := v1.PodTemplateSpec{
@@ -132,7 +183,7 @@ func (s *signer) MakeJobTemplate(
 			},
 			RestartPolicy: v1.RestartPolicyNever,
 			Volumes:       volumes,
-			NodeSelector:  mld.Selector,
+			NodeSelector:  utils.JobNodeSelector(mld.Selector, mld.Architecture),
 		},
 	}
 
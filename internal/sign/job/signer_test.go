@@ -21,6 +21,7 @@ import (
 	"github.com/kubernetes-sigs/kernel-module-management/internal/api"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/client"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/constants"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/registry"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/utils"
 )
 
@@ -49,7 +50,7 @@ var _ = Describe("MakeJobTemplate", func() {
 		ctrl = gomock.NewController(GinkgoT())
 		clnt = client.NewMockClient(ctrl)
 		jobhelper = utils.NewMockJobHelper(ctrl)
-		m = NewSigner(clnt, scheme, jobhelper)
+		m = NewSigner(clnt, scheme, jobhelper, nil)
 		mld = api.ModuleLoaderData{
 			Name:      moduleName,
 			Namespace: namespace,
@@ -209,6 +210,7 @@ var _ = Describe("MakeJobTemplate", func() {
 		mld.Selector = nodeSelector
 
 		gomock.InOrder(
+			clnt.EXPECT().Get(ctx, types.NamespacedName{Name: "default", Namespace: mld.Namespace}, &v1.ServiceAccount{}),
 			clnt.EXPECT().Get(ctx, types.NamespacedName{Name: mld.Sign.KeySecret.Name, Namespace: mld.Namespace}, gomock.Any()).DoAndReturn(
 				func(_ interface{}, _ interface{}, secret *v1.Secret, _ ...ctrlclient.GetOption) error {
 					secret.Data = privateSignData
@@ -254,6 +256,7 @@ var _ = Describe("MakeJobTemplate", func() {
 		mld.RegistryTLS = &kmmv1beta1.TLSOptions{}
 
 		gomock.InOrder(
+			clnt.EXPECT().Get(ctx, types.NamespacedName{Name: "default", Namespace: mld.Namespace}, &v1.ServiceAccount{}),
 			clnt.EXPECT().Get(ctx, types.NamespacedName{Name: mld.Sign.KeySecret.Name, Namespace: mld.Namespace}, gomock.Any()).DoAndReturn(
 				func(_ interface{}, _ interface{}, secret *v1.Secret, _ ...ctrlclient.GetOption) error {
 					secret.Data = privateSignData
@@ -304,6 +307,92 @@ var _ = Describe("MakeJobTemplate", func() {
 		),
 	)
 
+	It("should look for the unsigned image in the mirrors", func() {
+		ctx := context.Background()
+		mld.Sign = &kmmv1beta1.Sign{
+			UnsignedImage: signedImage,
+			KeySecret:     &v1.LocalObjectReference{Name: "securebootkey"},
+			CertSecret:    &v1.LocalObjectReference{Name: "securebootcert"},
+		}
+		mld.RegistryTLS = &kmmv1beta1.TLSOptions{}
+
+		m = NewSigner(clnt, scheme, jobhelper, &registry.MirrorConfig{
+			Registries: []registry.MirrorRegistry{
+				{Prefix: "my.registry", Location: "local.registry", Mirrors: []string{"mirror1.local", "mirror2.local"}},
+			},
+		})
+
+		gomock.InOrder(
+			clnt.EXPECT().Get(ctx, types.NamespacedName{Name: "default", Namespace: mld.Namespace}, &v1.ServiceAccount{}),
+			clnt.EXPECT().Get(ctx, types.NamespacedName{Name: mld.Sign.KeySecret.Name, Namespace: mld.Namespace}, gomock.Any()).DoAndReturn(
+				func(_ interface{}, _ interface{}, secret *v1.Secret, _ ...ctrlclient.GetOption) error {
+					secret.Data = privateSignData
+					return nil
+				},
+			),
+			clnt.EXPECT().Get(ctx, types.NamespacedName{Name: mld.Sign.CertSecret.Name, Namespace: mld.Namespace}, gomock.Any()).DoAndReturn(
+				func(_ interface{}, _ interface{}, secret *v1.Secret, _ ...ctrlclient.GetOption) error {
+					secret.Data = publicSignData
+					return nil
+				},
+			),
+		)
+
+		actual, err := m.MakeJobTemplate(ctx, &mld, labels, "", false, mld.Owner)
+		Expect(err).NotTo(HaveOccurred())
+
+		args := actual.Spec.Template.Spec.Containers[0].Args
+		Expect(args).To(ContainElements("-unsignedimage", "local.registry/my/image-signed"))
+		Expect(args).To(ContainElements("-unsignedimage-mirrors", "mirror1.local/my/image-signed,mirror2.local/my/image-signed"))
+	})
+
+	It("should mount the pull secrets, including the ServiceAccount's, and the push secret separately", func() {
+		ctx := context.Background()
+		mld.Sign = &kmmv1beta1.Sign{
+			UnsignedImage: signedImage,
+			KeySecret:     &v1.LocalObjectReference{Name: "securebootkey"},
+			CertSecret:    &v1.LocalObjectReference{Name: "securebootcert"},
+		}
+		mld.RegistryTLS = &kmmv1beta1.TLSOptions{}
+		mld.ImagePullSecrets = []v1.LocalObjectReference{{Name: "pull"}}
+		mld.PushSecret = &v1.LocalObjectReference{Name: "push"}
+
+		gomock.InOrder(
+			clnt.EXPECT().Get(ctx, types.NamespacedName{Name: "default", Namespace: mld.Namespace}, &v1.ServiceAccount{}).DoAndReturn(
+				func(_ interface{}, _ interface{}, sa *v1.ServiceAccount, _ ...ctrlclient.GetOption) error {
+					sa.ImagePullSecrets = []v1.LocalObjectReference{{Name: "pull"}, {Name: "sa-pull"}}
+					return nil
+				},
+			),
+			clnt.EXPECT().Get(ctx, types.NamespacedName{Name: mld.Sign.KeySecret.Name, Namespace: mld.Namespace}, gomock.Any()).DoAndReturn(
+				func(_ interface{}, _ interface{}, secret *v1.Secret, _ ...ctrlclient.GetOption) error {
+					secret.Data = privateSignData
+					return nil
+				},
+			),
+			clnt.EXPECT().Get(ctx, types.NamespacedName{Name: mld.Sign.CertSecret.Name, Namespace: mld.Namespace}, gomock.Any()).DoAndReturn(
+				func(_ interface{}, _ interface{}, secret *v1.Secret, _ ...ctrlclient.GetOption) error {
+					secret.Data = publicSignData
+					return nil
+				},
+			),
+		)
+
+		actual, err := m.MakeJobTemplate(ctx, &mld, labels, "", true, mld.Owner)
+		Expect(err).NotTo(HaveOccurred())
+
+		container := actual.Spec.Template.Spec.Containers[0]
+		Expect(container.Args).To(ContainElements("-secretdir", "/docker_config/", "-pushsecretdir", "/docker_config_push/"))
+		Expect(container.VolumeMounts).To(ContainElements(
+			v1.VolumeMount{Name: "secret-pull", ReadOnly: true, MountPath: "/docker_config/pull"},
+			v1.VolumeMount{Name: "secret-push", ReadOnly: true, MountPath: "/docker_config/push"},
+			v1.VolumeMount{Name: "secret-push", ReadOnly: true, MountPath: "/docker_config_push/push"},
+			v1.VolumeMount{Name: "secret-sa-pull", ReadOnly: true, MountPath: "/docker_config/sa-pull"},
+		))
+		Expect(actual.Spec.Template.Spec.Volumes).To(HaveLen(5))
+		Expect(actual.Spec.Template.Spec.Volumes[4].Secret.Optional).To(Equal(pointer.Bool(true)))
+	})
+
 	DescribeTable("should set correct kmod-signer TLS flags", func(kmRegistryTLS,
 		unsignedImageRegistryTLS kmmv1beta1.TLSOptions, expectedFlag string) {
 		ctx := context.Background()
@@ -316,6 +405,7 @@ var _ = Describe("MakeJobTemplate", func() {
 		mld.RegistryTLS = &kmRegistryTLS
 
 		gomock.InOrder(
+			clnt.EXPECT().Get(ctx, types.NamespacedName{Name: "default", Namespace: mld.Namespace}, &v1.ServiceAccount{}),
 			clnt.EXPECT().Get(ctx, types.NamespacedName{Name: mld.Sign.KeySecret.Name, Namespace: mld.Namespace}, gomock.Any()).DoAndReturn(
 				func(_ interface{}, _ interface{}, secret *v1.Secret, _ ...ctrlclient.GetOption) error {
 					secret.Data = privateSignData
@@ -367,5 +457,21 @@ var _ = Describe("MakeJobTemplate", func() {
 			},
 			"--skip-tls-verify-pull",
 		),
+		Entry(
+			"push CA bundle",
+			kmmv1beta1.TLSOptions{
+				CABundle: &v1.LocalObjectReference{Name: "registry-ca"},
+			},
+			kmmv1beta1.TLSOptions{},
+			"/etc/kmm/ca-bundles/registry-ca/ca-bundle.crt",
+		),
+		Entry(
+			"pull CA bundle",
+			kmmv1beta1.TLSOptions{},
+			kmmv1beta1.TLSOptions{
+				CABundle: &v1.LocalObjectReference{Name: "unsigned-ca"},
+			},
+			"/etc/kmm/ca-bundles/unsigned-ca/ca-bundle.crt",
+		),
 	)
 })
@@ -2,17 +2,24 @@ package sign
 
 import (
 	"context"
+	"time"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/kubernetes-sigs/kernel-module-management/internal/api"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/gc"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/utils"
 )
 
 //go:generate mockgen -source=manager.go -package=sign -destination=mock_manager.go
 
 type SignManager interface {
-	GarbageCollect(ctx context.Context, modName, namespace string, owner metav1.Object) ([]string, error)
+	GarbageCollect(
+		ctx context.Context,
+		modName, namespace string,
+		mldMappings map[string]*api.ModuleLoaderData,
+		policy gc.Policy,
+		owner metav1.Object) ([]string, time.Duration, error)
 
 	ShouldSync(ctx context.Context, mld *api.ModuleLoaderData) (bool, error)
 
@@ -7,9 +7,11 @@ package sign
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	api "github.com/kubernetes-sigs/kernel-module-management/internal/api"
+	gc "github.com/kubernetes-sigs/kernel-module-management/internal/gc"
 	utils "github.com/kubernetes-sigs/kernel-module-management/internal/utils"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -38,18 +40,19 @@ func (m *MockSignManager) EXPECT() *MockSignManagerMockRecorder {
 }
 
 // GarbageCollect mocks base method.
-func (m *MockSignManager) GarbageCollect(ctx context.Context, modName, namespace string, owner v1.Object) ([]string, error) {
+func (m *MockSignManager) GarbageCollect(ctx context.Context, modName, namespace string, mldMappings map[string]*api.ModuleLoaderData, policy gc.Policy, owner v1.Object) ([]string, time.Duration, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GarbageCollect", ctx, modName, namespace, owner)
+	ret := m.ctrl.Call(m, "GarbageCollect", ctx, modName, namespace, mldMappings, policy, owner)
 	ret0, _ := ret[0].([]string)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
+	ret1, _ := ret[1].(time.Duration)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
 }
 
 // GarbageCollect indicates an expected call of GarbageCollect.
-func (mr *MockSignManagerMockRecorder) GarbageCollect(ctx, modName, namespace, owner interface{}) *gomock.Call {
+func (mr *MockSignManagerMockRecorder) GarbageCollect(ctx, modName, namespace, mldMappings, policy, owner interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GarbageCollect", reflect.TypeOf((*MockSignManager)(nil).GarbageCollect), ctx, modName, namespace, owner)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GarbageCollect", reflect.TypeOf((*MockSignManager)(nil).GarbageCollect), ctx, modName, namespace, mldMappings, policy, owner)
 }
 
 // ShouldSync mocks base method.
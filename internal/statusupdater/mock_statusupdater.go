@@ -11,6 +11,7 @@ import (
 	gomock "github.com/golang/mock/gomock"
 	v1beta1 "github.com/kubernetes-sigs/kernel-module-management/api-hub/v1beta1"
 	v1beta10 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
+	conflict "github.com/kubernetes-sigs/kernel-module-management/internal/conflict"
 	v1 "k8s.io/api/apps/v1"
 	v10 "k8s.io/api/core/v1"
 	sets "k8s.io/apimachinery/pkg/util/sets"
@@ -40,18 +41,46 @@ func (m *MockModuleStatusUpdater) EXPECT() *MockModuleStatusUpdaterMockRecorder
 	return m.recorder
 }
 
+// ModuleSuspendedUpdateStatus mocks base method.
+func (m *MockModuleStatusUpdater) ModuleSuspendedUpdateStatus(ctx context.Context, mod *v1beta10.Module) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ModuleSuspendedUpdateStatus", ctx, mod)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ModuleSuspendedUpdateStatus indicates an expected call of ModuleSuspendedUpdateStatus.
+func (mr *MockModuleStatusUpdaterMockRecorder) ModuleSuspendedUpdateStatus(ctx, mod interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ModuleSuspendedUpdateStatus", reflect.TypeOf((*MockModuleStatusUpdater)(nil).ModuleSuspendedUpdateStatus), ctx, mod)
+}
+
+// ModuleTeardownUpdateStatus mocks base method.
+func (m *MockModuleStatusUpdater) ModuleTeardownUpdateStatus(ctx context.Context, mod *v1beta10.Module, stage, message string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ModuleTeardownUpdateStatus", ctx, mod, stage, message)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ModuleTeardownUpdateStatus indicates an expected call of ModuleTeardownUpdateStatus.
+func (mr *MockModuleStatusUpdaterMockRecorder) ModuleTeardownUpdateStatus(ctx, mod, stage, message interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ModuleTeardownUpdateStatus", reflect.TypeOf((*MockModuleStatusUpdater)(nil).ModuleTeardownUpdateStatus), ctx, mod, stage, message)
+}
+
 // ModuleUpdateStatus mocks base method.
-func (m *MockModuleStatusUpdater) ModuleUpdateStatus(ctx context.Context, mod *v1beta10.Module, kernelMappingNodes, targetedNodes []v10.Node, dsByKernelVersion map[string]*v1.DaemonSet) error {
+func (m *MockModuleStatusUpdater) ModuleUpdateStatus(ctx context.Context, mod *v1beta10.Module, kernelMappingNodes, targetedNodes []v10.Node, dsByKernelVersion map[string]*v1.DaemonSet, kernelTargets []v1beta10.KernelTargetStatus, upgrade *v1beta10.UpgradeStatus, images []v1beta10.ModuleLoaderImageStatus, devicePluginImage *v1beta10.PinnedImage, conflicts []conflict.Conflict) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ModuleUpdateStatus", ctx, mod, kernelMappingNodes, targetedNodes, dsByKernelVersion)
+	ret := m.ctrl.Call(m, "ModuleUpdateStatus", ctx, mod, kernelMappingNodes, targetedNodes, dsByKernelVersion, kernelTargets, upgrade, images, devicePluginImage, conflicts)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // ModuleUpdateStatus indicates an expected call of ModuleUpdateStatus.
-func (mr *MockModuleStatusUpdaterMockRecorder) ModuleUpdateStatus(ctx, mod, kernelMappingNodes, targetedNodes, dsByKernelVersion interface{}) *gomock.Call {
+func (mr *MockModuleStatusUpdaterMockRecorder) ModuleUpdateStatus(ctx, mod, kernelMappingNodes, targetedNodes, dsByKernelVersion, kernelTargets, upgrade, images, devicePluginImage, conflicts interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ModuleUpdateStatus", reflect.TypeOf((*MockModuleStatusUpdater)(nil).ModuleUpdateStatus), ctx, mod, kernelMappingNodes, targetedNodes, dsByKernelVersion)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ModuleUpdateStatus", reflect.TypeOf((*MockModuleStatusUpdater)(nil).ModuleUpdateStatus), ctx, mod, kernelMappingNodes, targetedNodes, dsByKernelVersion, kernelTargets, upgrade, images, devicePluginImage, conflicts)
 }
 
 // MockManagedClusterModuleStatusUpdater is a mock of ManagedClusterModuleStatusUpdater interface.
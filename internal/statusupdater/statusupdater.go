@@ -3,10 +3,13 @@ package statusupdater
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
 	workv1 "open-cluster-management.io/api/work/v1"
@@ -14,15 +17,26 @@ import (
 
 	hubv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api-hub/v1beta1"
 	kmmv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/api"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/conflict"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/daemonset"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/metrics"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/utils"
 )
 
+const moduleSuspendedMessage = "spec.suspend is true; KMM does not reconcile the Module, and its status is frozen at " +
+	"its values from before the suspension"
+
 //go:generate mockgen -source=statusupdater.go -package=statusupdater -destination=mock_statusupdater.go
 
 type ModuleStatusUpdater interface {
 	ModuleUpdateStatus(ctx context.Context, mod *kmmv1beta1.Module, kernelMappingNodes []v1.Node,
-		targetedNodes []v1.Node, dsByKernelVersion map[string]*appsv1.DaemonSet) error
+		targetedNodes []v1.Node, dsByKernelVersion map[string]*appsv1.DaemonSet,
+		kernelTargets []kmmv1beta1.KernelTargetStatus, upgrade *kmmv1beta1.UpgradeStatus,
+		images []kmmv1beta1.ModuleLoaderImageStatus, devicePluginImage *kmmv1beta1.PinnedImage,
+		conflicts []conflict.Conflict) error
+	ModuleTeardownUpdateStatus(ctx context.Context, mod *kmmv1beta1.Module, stage, message string) error
+	ModuleSuspendedUpdateStatus(ctx context.Context, mod *kmmv1beta1.Module) error
 }
 
 //go:generate mockgen -source=statusupdater.go -package=statusupdater -destination=mock_statusupdater.go
@@ -79,7 +93,12 @@ func (m *moduleStatusUpdater) ModuleUpdateStatus(ctx context.Context,
 	mod *kmmv1beta1.Module,
 	kernelMappingNodes []v1.Node,
 	targetedNodes []v1.Node,
-	dsByKernelVersion map[string]*appsv1.DaemonSet) error {
+	dsByKernelVersion map[string]*appsv1.DaemonSet,
+	kernelTargets []kmmv1beta1.KernelTargetStatus,
+	upgrade *kmmv1beta1.UpgradeStatus,
+	images []kmmv1beta1.ModuleLoaderImageStatus,
+	devicePluginImage *kmmv1beta1.PinnedImage,
+	conflicts []conflict.Conflict) error {
 
 	nodesMatchingSelectorNumber := int32(len(targetedNodes))
 	numDesired := int32(len(kernelMappingNodes))
@@ -100,10 +119,227 @@ func (m *moduleStatusUpdater) ModuleUpdateStatus(ctx context.Context,
 		mod.Status.DevicePlugin.DesiredNumber = numDesired
 		mod.Status.DevicePlugin.AvailableNumber = numAvailableDevicePlugin
 	}
+	mod.Status.KernelTargets = kernelTargets
+	mod.Status.Upgrade = upgrade
+	mod.Status.ModuleLoaderImages = images
+	mod.Status.DevicePluginImage = devicePluginImage
+	setRolledBackCondition(mod, images)
+	setPinningFailedCondition(mod, images, devicePluginImage)
+	setSuspendedCondition(&mod.Status.Conditions, mod.Spec.Suspend, mod.Generation, moduleSuspendedMessage)
+	rebootRequiredNodes, err := m.getRebootRequiredNodes(ctx, mod)
+	if err != nil {
+		return err
+	}
+	setRebootRequiredCondition(mod, rebootRequiredNodes)
+	setConflictCondition(mod, conflicts)
+	m.metricsAPI.SetRebootRequiredNodes(mod.Name, mod.Namespace, len(rebootRequiredNodes))
 	m.updateMetrics(ctx, mod, dsByKernelVersion)
 	return m.client.Status().Update(ctx, mod)
 }
 
+// ModuleTeardownUpdateStatus sets the TearingDown condition of mod to the current teardown stage.
+// The status is only updated if the condition changed; the number of nodes requiring a reboot, which is when the
+// kernel module could not be unloaded during the teardown, is always updated.
+func (m *moduleStatusUpdater) ModuleTeardownUpdateStatus(ctx context.Context, mod *kmmv1beta1.Module, stage, message string) error {
+	rebootRequiredNodes, err := m.getRebootRequiredNodes(ctx, mod)
+	if err != nil {
+		return err
+	}
+
+	m.metricsAPI.SetRebootRequiredNodes(mod.Name, mod.Namespace, len(rebootRequiredNodes))
+
+	condition := metav1.Condition{
+		Type:               kmmv1beta1.ModuleConditionTearingDown,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: mod.Generation,
+		Reason:             stage,
+		Message:            message,
+	}
+
+	if c := meta.FindStatusCondition(mod.Status.Conditions, condition.Type); c != nil &&
+		c.Status == condition.Status && c.Reason == condition.Reason {
+		return nil
+	}
+
+	meta.SetStatusCondition(&mod.Status.Conditions, condition)
+
+	return m.client.Status().Update(ctx, mod)
+}
+
+// ModuleSuspendedUpdateStatus sets the Suspended condition of mod to True.
+// The status is only updated if the condition changed, so that the rest of the status stays frozen.
+func (m *moduleStatusUpdater) ModuleSuspendedUpdateStatus(ctx context.Context, mod *kmmv1beta1.Module) error {
+	if meta.IsStatusConditionTrue(mod.Status.Conditions, kmmv1beta1.ModuleConditionSuspended) {
+		return nil
+	}
+
+	setSuspendedCondition(&mod.Status.Conditions, true, mod.Generation, moduleSuspendedMessage)
+
+	return m.client.Status().Update(ctx, mod)
+}
+
+// setFeatureCondition sets condition in conditions once its feature reported something: a False condition is only
+// set if the condition is already present, so that features that are not configured do not add conditions.
+func setFeatureCondition(conditions *[]metav1.Condition, condition metav1.Condition) {
+	if condition.Status == metav1.ConditionFalse && meta.FindStatusCondition(*conditions, condition.Type) == nil {
+		return
+	}
+
+	meta.SetStatusCondition(conditions, condition)
+}
+
+// setRolledBackCondition sets the RolledBack condition of mod, listing the kernels whose image was rolled back.
+func setRolledBackCondition(mod *kmmv1beta1.Module, images []kmmv1beta1.ModuleLoaderImageStatus) {
+	kernels := make([]string, 0)
+
+	for _, i := range images {
+		if i.RolledBackFrom != "" {
+			kernels = append(kernels, api.KernelArchKey(i.KernelVersion, i.Architecture))
+		}
+	}
+
+	condition := metav1.Condition{
+		Type:               kmmv1beta1.ModuleConditionRolledBack,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: mod.Generation,
+		Reason:             "ImagesUpToDate",
+		Message:            "All kernels run the ModuleLoader image in the spec",
+	}
+
+	if len(kernels) > 0 {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "ImageFailed"
+		condition.Message = "The ModuleLoader image was rolled back to the last known good image for kernels: " +
+			strings.Join(kernels, ", ")
+	}
+
+	setFeatureCondition(&mod.Status.Conditions, condition)
+}
+
+// setPinningFailedCondition sets the PinningFailed condition of mod, listing the images run by their tag because their
+// digest could not be resolved.
+func setPinningFailedCondition(mod *kmmv1beta1.Module, images []kmmv1beta1.ModuleLoaderImageStatus, devicePluginImage *kmmv1beta1.PinnedImage) {
+	failures := make([]string, 0)
+
+	for _, i := range images {
+		if i.PinError != "" {
+			failures = append(failures, fmt.Sprintf("kernel %s (%s)", api.KernelArchKey(i.KernelVersion, i.Architecture), i.PinError))
+		}
+	}
+
+	if mod.Spec.DevicePlugin != nil && devicePluginImage == nil {
+		failures = append(failures, "the device plugin")
+	}
+
+	condition := metav1.Condition{
+		Type:               kmmv1beta1.ModuleConditionPinningFailed,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: mod.Generation,
+		Reason:             "ImagesPinned",
+		Message:            "All images are run by their digest",
+	}
+
+	if len(failures) > 0 {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "DigestUnresolved"
+		condition.Message = "The digest of the image could not be resolved, and the image is run by its tag, for: " +
+			strings.Join(failures, "; ")
+	}
+
+	setFeatureCondition(&mod.Status.Conditions, condition)
+}
+
+// getRebootRequiredNodes returns the sorted names of the nodes from which the kernel module of mod could not be
+// unloaded.
+// Those nodes are listed by their label rather than taken from the targeted nodes, as they typically stopped being
+// targeted when the kernel module had to be unloaded.
+func (m *moduleStatusUpdater) getRebootRequiredNodes(ctx context.Context, mod *kmmv1beta1.Module) ([]string, error) {
+	nodes := v1.NodeList{}
+
+	labelName := utils.GetModuleRebootRequiredLabelName(mod.Namespace, mod.Name)
+
+	if err := m.client.List(ctx, &nodes, client.HasLabels{labelName}); err != nil {
+		return nil, fmt.Errorf("could not list the nodes requiring a reboot: %v", err)
+	}
+
+	names := make([]string, 0, len(nodes.Items))
+
+	for _, n := range nodes.Items {
+		names = append(names, n.Name)
+	}
+
+	sort.Strings(names)
+
+	return names, nil
+}
+
+// setRebootRequiredCondition sets the RebootRequired condition of mod, listing the nodes that need a reboot.
+func setRebootRequiredCondition(mod *kmmv1beta1.Module, nodes []string) {
+	condition := metav1.Condition{
+		Type:               kmmv1beta1.ModuleConditionRebootRequired,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: mod.Generation,
+		Reason:             "ModuleUnloaded",
+		Message:            "The kernel module was unloaded from all nodes when required",
+	}
+
+	if len(nodes) > 0 {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "ModuleInUse"
+		condition.Message = "The kernel module was in use and could not be unloaded; drain or reboot nodes: " +
+			strings.Join(nodes, ", ")
+	}
+
+	setFeatureCondition(&mod.Status.Conditions, condition)
+}
+
+// setConflictCondition sets the Conflict condition of mod, naming the other Modules that load the same kernel module
+// and the nodes they claim.
+func setConflictCondition(mod *kmmv1beta1.Module, conflicts []conflict.Conflict) {
+	condition := metav1.Condition{
+		Type:               kmmv1beta1.ModuleConditionConflict,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: mod.Generation,
+		Reason:             "NoConflict",
+		Message:            "No other Module loads the kernel module on the targeted nodes",
+	}
+
+	if len(conflicts) > 0 {
+		claims := make([]string, 0, len(conflicts))
+
+		for _, c := range conflicts {
+			claims = append(claims, fmt.Sprintf("Module %s on nodes %s", c.Module, strings.Join(c.Nodes, ", ")))
+		}
+
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "KernelModuleConflict"
+		condition.Message = fmt.Sprintf("Kernel module %s is also loaded by: %s",
+			mod.Spec.ModuleLoader.Container.Modprobe.ModuleName, strings.Join(claims, "; "))
+	}
+
+	setFeatureCondition(&mod.Status.Conditions, condition)
+}
+
+// setSuspendedCondition sets the Suspended condition in conditions, depending on the suspend field of the spec.
+// suspendedMessage describes what KMM still does while the object is suspended.
+func setSuspendedCondition(conditions *[]metav1.Condition, suspended bool, generation int64, suspendedMessage string) {
+	condition := metav1.Condition{
+		Type:               kmmv1beta1.ModuleConditionSuspended,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: generation,
+		Reason:             "Reconciling",
+		Message:            "KMM reconciles the spec",
+	}
+
+	if suspended {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "SuspendedInSpec"
+		condition.Message = suspendedMessage
+	}
+
+	setFeatureCondition(conditions, condition)
+}
+
 func (m *managedClusterModuleStatusUpdater) ManagedClusterModuleUpdateStatus(ctx context.Context,
 	mcm *hubv1beta1.ManagedClusterModule,
 	ownedManifestWorks []workv1.ManifestWork) error {
@@ -127,6 +363,12 @@ func (m *managedClusterModuleStatusUpdater) ManagedClusterModuleUpdateStatus(ctx
 	mcm.Status.NumberDesired = int32(len(ownedManifestWorks))
 	mcm.Status.NumberApplied = numApplied
 	mcm.Status.NumberDegraded = numDegraded
+	setSuspendedCondition(
+		&mcm.Status.Conditions,
+		mcm.Spec.ModuleSpec.Suspend,
+		mcm.Generation,
+		"spec.moduleSpec.suspend is true; KMM only updates the status, and does not garbage-collect build jobs",
+	)
 
 	return m.client.Status().Update(ctx, mcm)
 }
@@ -2,6 +2,7 @@ package statusupdater
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -10,16 +11,21 @@ import (
 	"github.com/golang/mock/gomock"
 	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/sets"
 	workv1 "open-cluster-management.io/api/work/v1"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
 
 	hubv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api-hub/v1beta1"
 	kmmv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/client"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/conflict"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/constants"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/daemonset"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/metrics"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/utils"
 )
 
 type daemonSetConfig struct {
@@ -42,6 +48,8 @@ var _ = Describe("module status update", func() {
 		su          ModuleStatusUpdater
 	)
 
+	rebootRequiredLabel := utils.GetModuleRebootRequiredLabelName(namespace, name)
+
 	BeforeEach(func() {
 		ctrl = gomock.NewController(GinkgoT())
 		clnt = client.NewMockClient(ctrl)
@@ -75,13 +83,35 @@ var _ = Describe("module status update", func() {
 						ds.Status.NumberAvailable == ds.Status.DesiredNumberScheduled)
 				}
 			}
+			clnt.EXPECT().List(context.Background(), &v1.NodeList{}, ctrlclient.HasLabels{rebootRequiredLabel})
+			mockMetrics.EXPECT().SetRebootRequiredNodes(name, namespace, 0)
 			statusWrite := client.NewMockStatusWriter(ctrl)
 			clnt.EXPECT().Status().Return(statusWrite)
 			statusWrite.EXPECT().Update(context.Background(), mod).Return(nil)
 
-			res := su.ModuleUpdateStatus(context.Background(), mod, mappingsNodes, targetedNodes, dsMap)
+			kernelTargets := []kmmv1beta1.KernelTargetStatus{
+				{KernelVersion: "1.2.3", State: kmmv1beta1.KernelTargetReady},
+			}
+
+			upgradeStatus := &kmmv1beta1.UpgradeStatus{
+				OutdatedNumber: 2,
+				InProgress:     []kmmv1beta1.NodeUpgradeStatus{{Node: "node1", State: kmmv1beta1.NodeUpgradeDraining}},
+			}
+
+			images := []kmmv1beta1.ModuleLoaderImageStatus{
+				{KernelVersion: "1.2.3", LastKnownGoodImage: "example.org/repo/image@sha256:1234"},
+			}
+
+			devicePluginImage := &kmmv1beta1.PinnedImage{Image: "example.org/repo/device-plugin:tag", Digest: "sha256:5678"}
+
+			res := su.ModuleUpdateStatus(context.Background(), mod, mappingsNodes, targetedNodes, dsMap, kernelTargets, upgradeStatus, images, devicePluginImage, nil)
 
 			Expect(res).To(BeNil())
+			Expect(mod.Status.KernelTargets).To(Equal(kernelTargets))
+			Expect(mod.Status.Upgrade).To(Equal(upgradeStatus))
+			Expect(mod.Status.ModuleLoaderImages).To(Equal(images))
+			Expect(mod.Status.DevicePluginImage).To(Equal(devicePluginImage))
+			Expect(mod.Status.Conditions).To(BeEmpty())
 			Expect(mod.Status.ModuleLoader.NodesMatchingSelectorNumber).To(Equal(int32(len(targetedNodes))))
 			Expect(mod.Status.ModuleLoader.DesiredNumber).To(Equal(int32(len(mappingsNodes))))
 			Expect(mod.Status.ModuleLoader.AvailableNumber).To(Equal(moduleLoaderAvailable))
@@ -120,6 +150,181 @@ var _ = Describe("module status update", func() {
 			true,
 		),
 	)
+
+	It("should set the RolledBack condition if an image was rolled back", func() {
+		clnt.EXPECT().List(context.Background(), &v1.NodeList{}, ctrlclient.HasLabels{rebootRequiredLabel})
+		mockMetrics.EXPECT().SetRebootRequiredNodes(name, namespace, 0)
+		statusWrite := client.NewMockStatusWriter(ctrl)
+		clnt.EXPECT().Status().Return(statusWrite)
+		statusWrite.EXPECT().Update(context.Background(), mod).Return(nil)
+
+		images := []kmmv1beta1.ModuleLoaderImageStatus{
+			{
+				KernelVersion:      "1.2.3",
+				Architecture:       "x86_64",
+				LastKnownGoodImage: "example.org/repo/image@sha256:1234",
+				RolledBackFrom:     "example.org/repo/image:broken",
+			},
+		}
+
+		err := su.ModuleUpdateStatus(context.Background(), mod, nil, nil, nil, nil, nil, images, nil, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		cond := meta.FindStatusCondition(mod.Status.Conditions, kmmv1beta1.ModuleConditionRolledBack)
+		Expect(cond).NotTo(BeNil())
+		Expect(cond.Status).To(Equal(metav1.ConditionTrue))
+		Expect(cond.Message).To(ContainSubstring("1.2.3"))
+	})
+
+	It("should set the PinningFailed condition if an image is run by its tag", func() {
+		mod.Spec.DevicePlugin = &kmmv1beta1.DevicePluginSpec{}
+
+		clnt.EXPECT().List(context.Background(), &v1.NodeList{}, ctrlclient.HasLabels{rebootRequiredLabel})
+		mockMetrics.EXPECT().SetRebootRequiredNodes(name, namespace, 0)
+		statusWrite := client.NewMockStatusWriter(ctrl)
+		clnt.EXPECT().Status().Return(statusWrite)
+		statusWrite.EXPECT().Update(context.Background(), mod).Return(nil)
+
+		images := []kmmv1beta1.ModuleLoaderImageStatus{
+			{KernelVersion: "1.2.3", Architecture: "x86_64", PinError: "registry unreachable"},
+			{KernelVersion: "4.5.6", Pinned: &kmmv1beta1.PinnedImage{Image: "example.org/repo/image:tag", Digest: "sha256:1234"}},
+		}
+
+		err := su.ModuleUpdateStatus(context.Background(), mod, nil, nil, nil, nil, nil, images, nil, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		cond := meta.FindStatusCondition(mod.Status.Conditions, kmmv1beta1.ModuleConditionPinningFailed)
+		Expect(cond).NotTo(BeNil())
+		Expect(cond.Status).To(Equal(metav1.ConditionTrue))
+		Expect(cond.Message).To(ContainSubstring("1.2.3/x86_64 (registry unreachable)"))
+		Expect(cond.Message).To(ContainSubstring("the device plugin"))
+		Expect(cond.Message).NotTo(ContainSubstring("4.5.6"))
+	})
+
+	It("should set a condition to False once its feature reported something", func() {
+		meta.SetStatusCondition(&mod.Status.Conditions, metav1.Condition{
+			Type:   kmmv1beta1.ModuleConditionRolledBack,
+			Status: metav1.ConditionTrue,
+			Reason: "ImageFailed",
+		})
+
+		clnt.EXPECT().List(context.Background(), &v1.NodeList{}, ctrlclient.HasLabels{rebootRequiredLabel})
+		mockMetrics.EXPECT().SetRebootRequiredNodes(name, namespace, 0)
+		statusWrite := client.NewMockStatusWriter(ctrl)
+		clnt.EXPECT().Status().Return(statusWrite)
+		statusWrite.EXPECT().Update(context.Background(), mod).Return(nil)
+
+		err := su.ModuleUpdateStatus(context.Background(), mod, nil, nil, nil, nil, nil, nil, nil, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(mod.Status.Conditions).To(HaveLen(1))
+		Expect(
+			meta.IsStatusConditionFalse(mod.Status.Conditions, kmmv1beta1.ModuleConditionRolledBack),
+		).To(BeTrue())
+	})
+
+	It("should set the Suspended condition only once when the Module is suspended", func() {
+		mod.Spec.Suspend = true
+
+		statusWrite := client.NewMockStatusWriter(ctrl)
+		clnt.EXPECT().Status().Return(statusWrite)
+		statusWrite.EXPECT().Update(context.Background(), mod).Return(nil)
+
+		err := su.ModuleSuspendedUpdateStatus(context.Background(), mod)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(
+			meta.IsStatusConditionTrue(mod.Status.Conditions, kmmv1beta1.ModuleConditionSuspended),
+		).To(BeTrue())
+		Expect(
+			meta.FindStatusCondition(mod.Status.Conditions, kmmv1beta1.ModuleConditionSuspended).Message,
+		).To(ContainSubstring("frozen"))
+
+		err = su.ModuleSuspendedUpdateStatus(context.Background(), mod)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should set the RebootRequired condition if nodes have the reboot-required label, even if not targeted", func() {
+		targetedNodes := []v1.Node{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "node3"},
+			},
+		}
+
+		clnt.EXPECT().List(context.Background(), &v1.NodeList{}, ctrlclient.HasLabels{rebootRequiredLabel}).DoAndReturn(
+			func(_ interface{}, list *v1.NodeList, _ ...interface{}) error {
+				list.Items = []v1.Node{
+					{ObjectMeta: metav1.ObjectMeta{Name: "node2"}},
+					{ObjectMeta: metav1.ObjectMeta{Name: "node1"}},
+				}
+				return nil
+			},
+		)
+		mockMetrics.EXPECT().SetRebootRequiredNodes(name, namespace, 2)
+		statusWrite := client.NewMockStatusWriter(ctrl)
+		clnt.EXPECT().Status().Return(statusWrite)
+		statusWrite.EXPECT().Update(context.Background(), mod).Return(nil)
+
+		err := su.ModuleUpdateStatus(context.Background(), mod, nil, targetedNodes, nil, nil, nil, nil, nil, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		cond := meta.FindStatusCondition(mod.Status.Conditions, kmmv1beta1.ModuleConditionRebootRequired)
+		Expect(cond).NotTo(BeNil())
+		Expect(cond.Status).To(Equal(metav1.ConditionTrue))
+		Expect(cond.Message).To(HaveSuffix("node1, node2"))
+	})
+
+	It("should return an error if the nodes requiring a reboot cannot be listed", func() {
+		clnt.EXPECT().List(context.Background(), &v1.NodeList{}, ctrlclient.HasLabels{rebootRequiredLabel}).Return(errors.New("some error"))
+
+		err := su.ModuleUpdateStatus(context.Background(), mod, nil, nil, nil, nil, nil, nil, nil, nil)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should set the Conflict condition naming the other Modules", func() {
+		mod.Spec.ModuleLoader.Container.Modprobe.ModuleName = "kmod"
+
+		conflicts := []conflict.Conflict{
+			{
+				Module: types.NamespacedName{Namespace: "other-namespace", Name: "other-name"},
+				Nodes:  []string{"node1", "node2"},
+			},
+		}
+
+		clnt.EXPECT().List(context.Background(), &v1.NodeList{}, ctrlclient.HasLabels{rebootRequiredLabel})
+		mockMetrics.EXPECT().SetRebootRequiredNodes(name, namespace, 0)
+		statusWrite := client.NewMockStatusWriter(ctrl)
+		clnt.EXPECT().Status().Return(statusWrite)
+		statusWrite.EXPECT().Update(context.Background(), mod).Return(nil)
+
+		err := su.ModuleUpdateStatus(context.Background(), mod, nil, nil, nil, nil, nil, nil, nil, conflicts)
+		Expect(err).NotTo(HaveOccurred())
+
+		cond := meta.FindStatusCondition(mod.Status.Conditions, kmmv1beta1.ModuleConditionConflict)
+		Expect(cond).NotTo(BeNil())
+		Expect(cond.Status).To(Equal(metav1.ConditionTrue))
+		Expect(cond.Message).To(
+			Equal("Kernel module kmod is also loaded by: Module other-namespace/other-name on nodes node1, node2"),
+		)
+	})
+
+	It("should set the TearingDown condition only when the teardown stage changes", func() {
+		clnt.EXPECT().List(context.Background(), &v1.NodeList{}, ctrlclient.HasLabels{rebootRequiredLabel}).Times(2)
+		mockMetrics.EXPECT().SetRebootRequiredNodes(name, namespace, 0).Times(2)
+		statusWrite := client.NewMockStatusWriter(ctrl)
+		clnt.EXPECT().Status().Return(statusWrite)
+		statusWrite.EXPECT().Update(context.Background(), mod).Return(nil)
+
+		err := su.ModuleTeardownUpdateStatus(context.Background(), mod, "SomeStage", "some message")
+		Expect(err).NotTo(HaveOccurred())
+
+		cond := meta.FindStatusCondition(mod.Status.Conditions, kmmv1beta1.ModuleConditionTearingDown)
+		Expect(cond).NotTo(BeNil())
+		Expect(cond.Status).To(Equal(metav1.ConditionTrue))
+		Expect(cond.Reason).To(Equal("SomeStage"))
+		Expect(cond.Message).To(Equal("some message"))
+
+		err = su.ModuleTeardownUpdateStatus(context.Background(), mod, "SomeStage", "some message")
+		Expect(err).NotTo(HaveOccurred())
+	})
 })
 
 var _ = Describe("ManagedClusterModule status update", func() {
@@ -0,0 +1,368 @@
+package teardown
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	kmmv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/constants"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/daemonset"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/nodecondition"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/utils"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// unreachableNodeTimeout is how long KMM waits for the terminating ModuleLoader pods of nodes that are not ready to be
+// gone when unloading the kernel module.
+// Their PreStop hook only runs once the kubelet is back: the pods are then ignored so that the Module can be deleted.
+const unreachableNodeTimeout = 5 * time.Minute
+
+// Stage is the current step of the teardown of a Module.
+type Stage string
+
+const (
+	// StageDevicePlugin is the step during which the device plugin pods are terminating.
+	StageDevicePlugin Stage = "DevicePluginTermination"
+	// StageModuleLoader is the step during which the ModuleLoader pods are terminating.
+	StageModuleLoader Stage = "ModuleLoaderTermination"
+	// StageUnreachableNodes is the step during which only the ModuleLoader pods of nodes that are not ready are
+	// terminating.
+	StageUnreachableNodes Stage = "ModuleLoaderUnreachableNodes"
+	// StageDone means that the Module can be deleted.
+	StageDone Stage = "Done"
+)
+
+// Message returns a human-readable description of s.
+func (s Stage) Message() string {
+	switch s {
+	case StageDevicePlugin:
+		return "Waiting for the device plugin pods and node labels to be removed"
+	case StageModuleLoader:
+		return "Waiting for the ModuleLoader pods to be removed"
+	case StageUnreachableNodes:
+		return "Waiting for the ModuleLoader pods of nodes that are not ready to be removed; they are ignored after " +
+			unreachableNodeTimeout.String() + ", and unload the kernel module once their node is ready again"
+	default:
+		return "Teardown complete"
+	}
+}
+
+//go:generate mockgen -source=teardown.go -package=teardown -destination=mock_teardown.go
+
+type Manager interface {
+	TearDown(ctx context.Context, mod *kmmv1beta1.Module) (Stage, error)
+}
+
+type manager struct {
+	client           client.Client
+	nodeConditionAPI nodecondition.Updater
+}
+
+func NewManager(client client.Client, nodeConditionAPI nodecondition.Updater) Manager {
+	return &manager{client: client, nodeConditionAPI: nodeConditionAPI}
+}
+
+// TearDown prepares the deletion of mod.
+// It first deletes the device plugin DaemonSet and waits for its pods and node labels to be gone, so that no device
+// plugin holds the kernel module when it is unloaded.
+// It then deletes the ModuleLoader DaemonSets according to the unload policy of mod.
+// With the Unload policy, the ModuleLoader pods unload the kernel module when they are deleted; it waits for them to
+// be gone, so that their PreStop hook has completed, except for the pods of nodes that are not ready, which it only
+// waits for until unreachableNodeTimeout.
+// With the Keep policy, it deletes the ModuleLoader DaemonSets without their pods and annotates the pods with the
+// skip-unload annotation; it deletes each pod once it acknowledged that it will not unload the kernel module.
+// Finally, it removes the node condition and the startup taint of mod from all nodes.
+// It returns StageDone once mod can be deleted.
+func (m *manager) TearDown(ctx context.Context, mod *kmmv1beta1.Module) (Stage, error) {
+	done, err := m.tearDownDevicePlugin(ctx, mod)
+	if err != nil {
+		return StageDevicePlugin, fmt.Errorf("could not tear down the device plugin: %v", err)
+	}
+
+	if !done {
+		return StageDevicePlugin, nil
+	}
+
+	stage := StageDone
+
+	if mod.Spec.UnloadPolicy == kmmv1beta1.UnloadPolicyKeep {
+		done, err = m.tearDownModuleLoaderKeep(ctx, mod)
+		if !done {
+			stage = StageModuleLoader
+		}
+	} else {
+		stage, err = m.tearDownModuleLoaderUnload(ctx, mod)
+	}
+
+	if err != nil {
+		return StageModuleLoader, fmt.Errorf("could not tear down the ModuleLoader: %v", err)
+	}
+
+	if stage != StageDone {
+		return stage, nil
+	}
+
+	if err = m.cleanUpNodes(ctx, mod); err != nil {
+		return StageModuleLoader, fmt.Errorf("could not clean up the nodes: %v", err)
+	}
+
+	return StageDone, nil
+}
+
+// cleanUpNodes removes the node condition, the startup taint and the conflict label of mod from all nodes, as KMM
+// stops managing them.
+func (m *manager) cleanUpNodes(ctx context.Context, mod *kmmv1beta1.Module) error {
+	nodeList := v1.NodeList{}
+
+	if err := m.client.List(ctx, &nodeList); err != nil {
+		return fmt.Errorf("could not list nodes: %v", err)
+	}
+
+	for i := range nodeList.Items {
+		node := &nodeList.Items[i]
+
+		if err := m.nodeConditionAPI.RemoveModuleCondition(ctx, node, mod.Namespace, mod.Name); err != nil {
+			return err
+		}
+
+		if err := m.cleanUpNode(ctx, mod, node); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// cleanUpNode removes the startup taint and the conflict label of mod from node.
+func (m *manager) cleanUpNode(ctx context.Context, mod *kmmv1beta1.Module, node *v1.Node) error {
+	patchFrom := client.MergeFrom(node.DeepCopy())
+	changed := false
+
+	if mod.Spec.StartupTaint != nil {
+		taint := mod.Spec.StartupTaint.Taint()
+
+		taints := make([]v1.Taint, 0, len(node.Spec.Taints))
+
+		for _, t := range node.Spec.Taints {
+			if !t.MatchTaint(&taint) {
+				taints = append(taints, t)
+			}
+		}
+
+		if len(taints) != len(node.Spec.Taints) {
+			log.FromContext(ctx).Info("Removing the startup taint", "node", node.Name)
+			node.Spec.Taints = taints
+			changed = true
+		}
+	}
+
+	conflictLabelName := utils.GetModuleConflictLabelName(mod.Namespace, mod.Name)
+
+	if _, ok := node.Labels[conflictLabelName]; ok {
+		delete(node.Labels, conflictLabelName)
+		changed = true
+	}
+
+	if !changed {
+		return nil
+	}
+
+	if err := m.client.Patch(ctx, node, patchFrom); err != nil {
+		return fmt.Errorf("could not patch node %s: %v", node.Name, err)
+	}
+
+	return nil
+}
+
+// deleteDaemonSets deletes the DaemonSets of mod that have the role label set to role.
+// It returns true if no such DaemonSet existed.
+func (m *manager) deleteDaemonSets(ctx context.Context, mod *kmmv1beta1.Module, role string, propagation metav1.DeletionPropagation) (bool, error) {
+	logger := log.FromContext(ctx)
+
+	dsList := appsv1.DaemonSetList{}
+
+	if err := m.client.List(ctx, &dsList, listOptions(mod, role)...); err != nil {
+		return false, fmt.Errorf("could not list the %s DaemonSets: %v", role, err)
+	}
+
+	for i := range dsList.Items {
+		ds := &dsList.Items[i]
+
+		if ds.DeletionTimestamp != nil {
+			continue
+		}
+
+		logger.Info("Deleting DaemonSet", "name", ds.Name, "propagation", propagation)
+
+		if err := m.client.Delete(ctx, ds, client.PropagationPolicy(propagation)); client.IgnoreNotFound(err) != nil {
+			return false, fmt.Errorf("could not delete DaemonSet %s: %v", ds.Name, err)
+		}
+	}
+
+	return len(dsList.Items) == 0, nil
+}
+
+// tearDownDevicePlugin deletes the device plugin DaemonSet of mod.
+// It returns true once no device plugin pod is left and no node is labeled as running the device plugin.
+func (m *manager) tearDownDevicePlugin(ctx context.Context, mod *kmmv1beta1.Module) (bool, error) {
+	if _, err := m.deleteDaemonSets(ctx, mod, "device-plugin", metav1.DeletePropagationBackground); err != nil {
+		return false, err
+	}
+
+	podList := v1.PodList{}
+
+	if err := m.client.List(ctx, &podList, listOptions(mod, "device-plugin")...); err != nil {
+		return false, fmt.Errorf("could not list the device plugin pods: %v", err)
+	}
+
+	if len(podList.Items) > 0 {
+		return false, nil
+	}
+
+	nodeList := v1.NodeList{}
+
+	if err := m.client.List(ctx, &nodeList, client.HasLabels{daemonset.GetDevicePluginNodeLabel(mod.Name)}); err != nil {
+		return false, fmt.Errorf("could not list the nodes running the device plugin: %v", err)
+	}
+
+	return len(nodeList.Items) == 0, nil
+}
+
+// tearDownModuleLoaderUnload deletes the ModuleLoader DaemonSets of mod with their pods, which unload the kernel module
+// in their PreStop hook.
+// It returns StageDone once the DaemonSets and all ModuleLoader pods are gone, except for the pods of nodes that are
+// not ready and that have been terminating for unreachableNodeTimeout.
+// It returns StageUnreachableNodes while only pods of nodes that are not ready are left.
+func (m *manager) tearDownModuleLoaderUnload(ctx context.Context, mod *kmmv1beta1.Module) (Stage, error) {
+	logger := log.FromContext(ctx)
+
+	dsGone, err := m.deleteDaemonSets(ctx, mod, "module-loader", metav1.DeletePropagationBackground)
+	if err != nil {
+		return StageModuleLoader, err
+	}
+
+	if !dsGone {
+		return StageModuleLoader, nil
+	}
+
+	podList := v1.PodList{}
+
+	if err = m.client.List(ctx, &podList, listOptions(mod, "module-loader")...); err != nil {
+		return StageModuleLoader, fmt.Errorf("could not list the ModuleLoader pods: %v", err)
+	}
+
+	stage := StageDone
+	now := time.Now()
+
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+
+		if pod.DeletionTimestamp == nil || pod.Spec.NodeName == "" {
+			return StageModuleLoader, nil
+		}
+
+		ready, err := m.isNodeReady(ctx, pod.Spec.NodeName)
+		if err != nil {
+			return StageModuleLoader, err
+		}
+
+		if ready {
+			return StageModuleLoader, nil
+		}
+
+		if now.Sub(pod.DeletionTimestamp.Time) < unreachableNodeTimeout {
+			stage = StageUnreachableNodes
+			continue
+		}
+
+		logger.Info(
+			utils.WarnString("ignoring the ModuleLoader pod of a node that is not ready; it will unload the kernel module once the node is ready again"),
+			"name", pod.Name,
+			"node", pod.Spec.NodeName,
+		)
+	}
+
+	return stage, nil
+}
+
+// isNodeReady returns true if the node named name exists and is ready.
+func (m *manager) isNodeReady(ctx context.Context, name string) (bool, error) {
+	node := v1.Node{}
+
+	if err := m.client.Get(ctx, types.NamespacedName{Name: name}, &node); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return false, nil
+		}
+
+		return false, fmt.Errorf("could not get node %s: %v", name, err)
+	}
+
+	for _, c := range node.Status.Conditions {
+		if c.Type == v1.NodeReady {
+			return c.Status == v1.ConditionTrue, nil
+		}
+	}
+
+	return false, nil
+}
+
+// tearDownModuleLoaderKeep deletes the ModuleLoader DaemonSets of mod without their pods, and deletes the pods without
+// unloading the kernel module.
+// It returns true once all ModuleLoader pods were deleted.
+func (m *manager) tearDownModuleLoaderKeep(ctx context.Context, mod *kmmv1beta1.Module) (bool, error) {
+	logger := log.FromContext(ctx)
+
+	// the pods must not be recreated once deleted
+	if _, err := m.deleteDaemonSets(ctx, mod, "module-loader", metav1.DeletePropagationOrphan); err != nil {
+		return false, err
+	}
+
+	podList := v1.PodList{}
+
+	if err := m.client.List(ctx, &podList, listOptions(mod, "module-loader")...); err != nil {
+		return false, fmt.Errorf("could not list the ModuleLoader pods: %v", err)
+	}
+
+	done := true
+
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+
+		canDelete, err := daemonset.SkipUnload(ctx, m.client, pod)
+		if err != nil {
+			return false, err
+		}
+
+		if !canDelete {
+			logger.Info("Waiting for the ModuleLoader pod to acknowledge that it will not unload the kernel module", "name", pod.Name)
+			done = false
+			continue
+		}
+
+		logger.Info("Deleting ModuleLoader pod without unloading the kernel module", "name", pod.Name)
+
+		if err = m.client.Delete(ctx, pod); client.IgnoreNotFound(err) != nil {
+			return false, fmt.Errorf("could not delete pod %s: %v", pod.Name, err)
+		}
+	}
+
+	return done, nil
+}
+
+func listOptions(mod *kmmv1beta1.Module, role string) []client.ListOption {
+	return []client.ListOption{
+		client.InNamespace(mod.Namespace),
+		client.MatchingLabels{constants.ModuleNameLabel: mod.Name, constants.DaemonSetRole: role},
+	}
+}
@@ -0,0 +1,514 @@
+package teardown
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	kmmv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/client"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/constants"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/nodecondition"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/utils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var _ = Describe("TearDown", func() {
+	var (
+		clnt   *client.MockClient
+		mockNC *nodecondition.MockUpdater
+		m      Manager
+		mod    *kmmv1beta1.Module
+		ctx    = context.Background()
+	)
+
+	BeforeEach(func() {
+		ctrl := gomock.NewController(GinkgoT())
+		clnt = client.NewMockClient(ctrl)
+		mockNC = nodecondition.NewMockUpdater(ctrl)
+		m = NewManager(clnt, mockNC)
+		mod = &kmmv1beta1.Module{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "name",
+				Namespace: "namespace",
+			},
+			Spec: kmmv1beta1.ModuleSpec{UnloadPolicy: kmmv1beta1.UnloadPolicyKeep},
+		}
+	})
+
+	// moduleLoaderStatus returns the status of a pod whose ModuleLoader container is running.
+	moduleLoaderStatus := func(ready bool) v1.PodStatus {
+		return v1.PodStatus{
+			ContainerStatuses: []v1.ContainerStatus{
+				{
+					Name:  constants.ModuleLoaderContainerName,
+					Ready: ready,
+					State: v1.ContainerState{Running: &v1.ContainerStateRunning{}},
+				},
+			},
+		}
+	}
+
+	// expectDevicePluginGone expects the calls made when the device plugin was already torn down.
+	expectDevicePluginGone := func() []*gomock.Call {
+		return []*gomock.Call{
+			clnt.EXPECT().List(ctx, &appsv1.DaemonSetList{}, gomock.Any()),
+			clnt.EXPECT().List(ctx, &v1.PodList{}, gomock.Any()),
+			clnt.EXPECT().List(ctx, &v1.NodeList{}, gomock.Any()),
+		}
+	}
+
+	It("should delete the device plugin DaemonSet and wait for its pods", func() {
+		ds := appsv1.DaemonSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "device-plugin"},
+		}
+
+		gomock.InOrder(
+			clnt.EXPECT().List(ctx, &appsv1.DaemonSetList{}, gomock.Any()).DoAndReturn(
+				func(_ interface{}, list *appsv1.DaemonSetList, _ ...ctrlclient.ListOption) error {
+					list.Items = []appsv1.DaemonSet{ds}
+					return nil
+				},
+			),
+			clnt.EXPECT().Delete(ctx, &ds, ctrlclient.PropagationPolicy(metav1.DeletePropagationBackground)),
+			clnt.EXPECT().List(ctx, &v1.PodList{}, gomock.Any()).DoAndReturn(
+				func(_ interface{}, list *v1.PodList, _ ...ctrlclient.ListOption) error {
+					list.Items = []v1.Pod{
+						{ObjectMeta: metav1.ObjectMeta{Name: "pod"}},
+					}
+					return nil
+				},
+			),
+		)
+
+		Expect(
+			m.TearDown(ctx, mod),
+		).To(
+			Equal(StageDevicePlugin),
+		)
+	})
+
+	It("should wait for the device plugin node labels to be removed", func() {
+		gomock.InOrder(
+			clnt.EXPECT().List(ctx, &appsv1.DaemonSetList{}, gomock.Any()),
+			clnt.EXPECT().List(ctx, &v1.PodList{}, gomock.Any()),
+			clnt.EXPECT().List(ctx, &v1.NodeList{}, gomock.Any()).DoAndReturn(
+				func(_ interface{}, list *v1.NodeList, _ ...ctrlclient.ListOption) error {
+					list.Items = []v1.Node{
+						{ObjectMeta: metav1.ObjectMeta{Name: "node"}},
+					}
+					return nil
+				},
+			),
+		)
+
+		Expect(
+			m.TearDown(ctx, mod),
+		).To(
+			Equal(StageDevicePlugin),
+		)
+	})
+
+	It("should delete the ModuleLoader DaemonSets once the device plugin is gone with the Unload policy", func() {
+		mod.Spec.UnloadPolicy = kmmv1beta1.UnloadPolicyUnload
+
+		ds := appsv1.DaemonSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "module-loader"},
+		}
+
+		calls := append(
+			expectDevicePluginGone(),
+			clnt.EXPECT().List(ctx, &appsv1.DaemonSetList{}, gomock.Any()).DoAndReturn(
+				func(_ interface{}, list *appsv1.DaemonSetList, _ ...ctrlclient.ListOption) error {
+					list.Items = []appsv1.DaemonSet{ds}
+					return nil
+				},
+			),
+			clnt.EXPECT().Delete(ctx, &ds, ctrlclient.PropagationPolicy(metav1.DeletePropagationBackground)),
+		)
+
+		gomock.InOrder(calls...)
+
+		Expect(
+			m.TearDown(ctx, mod),
+		).To(
+			Equal(StageModuleLoader),
+		)
+	})
+
+	It("should wait for the ModuleLoader pods to be gone with the Unload policy", func() {
+		mod.Spec.UnloadPolicy = kmmv1beta1.UnloadPolicyUnload
+
+		calls := append(
+			expectDevicePluginGone(),
+			clnt.EXPECT().List(ctx, &appsv1.DaemonSetList{}, gomock.Any()),
+			clnt.EXPECT().List(ctx, &v1.PodList{}, gomock.Any()).DoAndReturn(
+				func(_ interface{}, list *v1.PodList, _ ...ctrlclient.ListOption) error {
+					// the pod is running its PreStop hook
+					list.Items = []v1.Pod{
+						{ObjectMeta: metav1.ObjectMeta{Name: "pod", DeletionTimestamp: &metav1.Time{Time: time.Now()}}},
+					}
+					return nil
+				},
+			),
+		)
+
+		gomock.InOrder(calls...)
+
+		Expect(
+			m.TearDown(ctx, mod),
+		).To(
+			Equal(StageModuleLoader),
+		)
+	})
+
+	DescribeTable("should only wait for a while for the ModuleLoader pods of nodes that are not ready with the Unload policy",
+		func(node *v1.Node, terminatingFor time.Duration, expectedStage Stage) {
+			mod.Spec.UnloadPolicy = kmmv1beta1.UnloadPolicyUnload
+
+			pod := v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "pod",
+					DeletionTimestamp: &metav1.Time{Time: time.Now().Add(-terminatingFor)},
+				},
+				Spec: v1.PodSpec{NodeName: "node"},
+			}
+
+			calls := append(
+				expectDevicePluginGone(),
+				clnt.EXPECT().List(ctx, &appsv1.DaemonSetList{}, gomock.Any()),
+				clnt.EXPECT().List(ctx, &v1.PodList{}, gomock.Any()).DoAndReturn(
+					func(_ interface{}, list *v1.PodList, _ ...ctrlclient.ListOption) error {
+						list.Items = []v1.Pod{pod}
+						return nil
+					},
+				),
+				clnt.EXPECT().Get(ctx, types.NamespacedName{Name: "node"}, &v1.Node{}).DoAndReturn(
+					func(_ interface{}, _ interface{}, n *v1.Node, _ ...ctrlclient.GetOption) error {
+						if node == nil {
+							return k8serrors.NewNotFound(schema.GroupResource{Resource: "nodes"}, "node")
+						}
+						*n = *node
+						return nil
+					},
+				),
+			)
+
+			if expectedStage == StageDone {
+				calls = append(calls, clnt.EXPECT().List(ctx, &v1.NodeList{}))
+			}
+
+			gomock.InOrder(calls...)
+
+			Expect(
+				m.TearDown(ctx, mod),
+			).To(
+				Equal(expectedStage),
+			)
+		},
+		Entry(
+			"ready node",
+			&v1.Node{Status: v1.NodeStatus{Conditions: []v1.NodeCondition{{Type: v1.NodeReady, Status: v1.ConditionTrue}}}},
+			time.Hour,
+			StageModuleLoader,
+		),
+		Entry(
+			"node not ready for a short while",
+			&v1.Node{Status: v1.NodeStatus{Conditions: []v1.NodeCondition{{Type: v1.NodeReady, Status: v1.ConditionUnknown}}}},
+			time.Minute,
+			StageUnreachableNodes,
+		),
+		Entry(
+			"node not ready for a long time",
+			&v1.Node{Status: v1.NodeStatus{Conditions: []v1.NodeCondition{{Type: v1.NodeReady, Status: v1.ConditionUnknown}}}},
+			time.Hour,
+			StageDone,
+		),
+		Entry("deleted node", nil, time.Hour, StageDone),
+	)
+
+	It("should be done once the ModuleLoader DaemonSets are gone with the Unload policy", func() {
+		mod.Spec.UnloadPolicy = kmmv1beta1.UnloadPolicyUnload
+
+		calls := append(
+			expectDevicePluginGone(),
+			clnt.EXPECT().List(ctx, &appsv1.DaemonSetList{}, gomock.Any()),
+			clnt.EXPECT().List(ctx, &v1.PodList{}, gomock.Any()),
+			clnt.EXPECT().List(ctx, &v1.NodeList{}),
+		)
+
+		gomock.InOrder(calls...)
+
+		Expect(
+			m.TearDown(ctx, mod),
+		).To(
+			Equal(StageDone),
+		)
+	})
+
+	It("should orphan the ModuleLoader pods and annotate them with the Keep policy", func() {
+		ds := appsv1.DaemonSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "module-loader"},
+		}
+
+		calls := append(
+			expectDevicePluginGone(),
+			clnt.EXPECT().List(ctx, &appsv1.DaemonSetList{}, gomock.Any()).DoAndReturn(
+				func(_ interface{}, list *appsv1.DaemonSetList, _ ...ctrlclient.ListOption) error {
+					list.Items = []appsv1.DaemonSet{ds}
+					return nil
+				},
+			),
+			clnt.EXPECT().Delete(ctx, &ds, ctrlclient.PropagationPolicy(metav1.DeletePropagationOrphan)),
+			clnt.EXPECT().List(ctx, &v1.PodList{}, gomock.Any()).DoAndReturn(
+				func(_ interface{}, list *v1.PodList, _ ...ctrlclient.ListOption) error {
+					list.Items = []v1.Pod{
+						{
+							ObjectMeta: metav1.ObjectMeta{Name: "pod"},
+							Status:     moduleLoaderStatus(true),
+						},
+					}
+					return nil
+				},
+			),
+			clnt.EXPECT().Patch(ctx, gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ interface{}, pod *v1.Pod, _ ctrlclient.Patch, _ ...ctrlclient.PatchOption) error {
+					Expect(pod.Annotations).To(HaveKey(constants.SkipUnloadAnnotation))
+					return nil
+				},
+			),
+		)
+
+		gomock.InOrder(calls...)
+
+		Expect(
+			m.TearDown(ctx, mod),
+		).To(
+			Equal(StageModuleLoader),
+		)
+	})
+
+	It("should wait for the ModuleLoader pods to acknowledge the annotation with the Keep policy", func() {
+		pod := v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "pod",
+				Annotations: map[string]string{constants.SkipUnloadAnnotation: ""},
+			},
+			Status: moduleLoaderStatus(true),
+		}
+
+		calls := append(
+			expectDevicePluginGone(),
+			clnt.EXPECT().List(ctx, &appsv1.DaemonSetList{}, gomock.Any()),
+			clnt.EXPECT().List(ctx, &v1.PodList{}, gomock.Any()).DoAndReturn(
+				func(_ interface{}, list *v1.PodList, _ ...ctrlclient.ListOption) error {
+					list.Items = []v1.Pod{pod}
+					return nil
+				},
+			),
+		)
+
+		gomock.InOrder(calls...)
+
+		Expect(
+			m.TearDown(ctx, mod),
+		).To(
+			Equal(StageModuleLoader),
+		)
+	})
+
+	It("should not annotate ModuleLoader pods that are not ready yet with the Keep policy", func() {
+		pod := v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "pod"},
+			Status:     moduleLoaderStatus(false),
+		}
+
+		calls := append(
+			expectDevicePluginGone(),
+			clnt.EXPECT().List(ctx, &appsv1.DaemonSetList{}, gomock.Any()),
+			clnt.EXPECT().List(ctx, &v1.PodList{}, gomock.Any()).DoAndReturn(
+				func(_ interface{}, list *v1.PodList, _ ...ctrlclient.ListOption) error {
+					list.Items = []v1.Pod{pod}
+					return nil
+				},
+			),
+		)
+
+		gomock.InOrder(calls...)
+
+		Expect(
+			m.TearDown(ctx, mod),
+		).To(
+			Equal(StageModuleLoader),
+		)
+	})
+
+	DescribeTable("should delete the ModuleLoader pods that will not unload the kernel module with the Keep policy",
+		func(pod v1.Pod) {
+			calls := append(
+				expectDevicePluginGone(),
+				clnt.EXPECT().List(ctx, &appsv1.DaemonSetList{}, gomock.Any()),
+				clnt.EXPECT().List(ctx, &v1.PodList{}, gomock.Any()).DoAndReturn(
+					func(_ interface{}, list *v1.PodList, _ ...ctrlclient.ListOption) error {
+						list.Items = []v1.Pod{pod}
+						return nil
+					},
+				),
+				clnt.EXPECT().Delete(ctx, &pod),
+				clnt.EXPECT().List(ctx, &v1.NodeList{}),
+			)
+
+			gomock.InOrder(calls...)
+
+			Expect(
+				m.TearDown(ctx, mod),
+			).To(
+				Equal(StageDone),
+			)
+		},
+		Entry(
+			"annotation acknowledged",
+			v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "pod",
+					Annotations: map[string]string{constants.SkipUnloadAnnotation: ""},
+				},
+				Status: moduleLoaderStatus(false),
+			},
+		),
+		Entry(
+			"container not running",
+			v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod"}},
+		),
+	)
+
+	It("should remove the startup taint from the nodes once the ModuleLoader is gone", func() {
+		mod.Spec.UnloadPolicy = kmmv1beta1.UnloadPolicyUnload
+		mod.Spec.StartupTaint = &kmmv1beta1.StartupTaint{Key: "not-ready"}
+
+		otherTaint := v1.Taint{Key: "other", Effect: v1.TaintEffectNoSchedule}
+
+		calls := append(
+			expectDevicePluginGone(),
+			clnt.EXPECT().List(ctx, &appsv1.DaemonSetList{}, gomock.Any()),
+			clnt.EXPECT().List(ctx, &v1.PodList{}, gomock.Any()),
+			clnt.EXPECT().List(ctx, &v1.NodeList{}).DoAndReturn(
+				func(_ interface{}, list *v1.NodeList, _ ...ctrlclient.ListOption) error {
+					list.Items = []v1.Node{
+						{
+							ObjectMeta: metav1.ObjectMeta{Name: "tainted"},
+							Spec: v1.NodeSpec{
+								Taints: []v1.Taint{{Key: "not-ready", Effect: v1.TaintEffectNoSchedule}, otherTaint},
+							},
+						},
+						{
+							ObjectMeta: metav1.ObjectMeta{Name: "not-tainted"},
+							Spec:       v1.NodeSpec{Taints: []v1.Taint{otherTaint}},
+						},
+					}
+					return nil
+				},
+			),
+			mockNC.EXPECT().RemoveModuleCondition(ctx, gomock.Any(), mod.Namespace, mod.Name),
+			clnt.EXPECT().Patch(ctx, gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ interface{}, node *v1.Node, _ ctrlclient.Patch, _ ...ctrlclient.PatchOption) error {
+					Expect(node.Name).To(Equal("tainted"))
+					Expect(node.Spec.Taints).To(Equal([]v1.Taint{otherTaint}))
+					return nil
+				},
+			),
+			mockNC.EXPECT().RemoveModuleCondition(ctx, gomock.Any(), mod.Namespace, mod.Name),
+		)
+
+		gomock.InOrder(calls...)
+
+		Expect(
+			m.TearDown(ctx, mod),
+		).To(
+			Equal(StageDone),
+		)
+	})
+
+	It("should remove the conflict label from the nodes once the ModuleLoader is gone", func() {
+		mod.Spec.UnloadPolicy = kmmv1beta1.UnloadPolicyUnload
+
+		conflictLabel := utils.GetModuleConflictLabelName(mod.Namespace, mod.Name)
+
+		calls := append(
+			expectDevicePluginGone(),
+			clnt.EXPECT().List(ctx, &appsv1.DaemonSetList{}, gomock.Any()),
+			clnt.EXPECT().List(ctx, &v1.PodList{}, gomock.Any()),
+			clnt.EXPECT().List(ctx, &v1.NodeList{}).DoAndReturn(
+				func(_ interface{}, list *v1.NodeList, _ ...ctrlclient.ListOption) error {
+					list.Items = []v1.Node{
+						{
+							ObjectMeta: metav1.ObjectMeta{
+								Name:   "node",
+								Labels: map[string]string{conflictLabel: "", "other": ""},
+							},
+						},
+					}
+					return nil
+				},
+			),
+			mockNC.EXPECT().RemoveModuleCondition(ctx, gomock.Any(), mod.Namespace, mod.Name),
+			clnt.EXPECT().Patch(ctx, gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ interface{}, node *v1.Node, _ ctrlclient.Patch, _ ...ctrlclient.PatchOption) error {
+					Expect(node.Labels).To(Equal(map[string]string{"other": ""}))
+					return nil
+				},
+			),
+		)
+
+		gomock.InOrder(calls...)
+
+		Expect(
+			m.TearDown(ctx, mod),
+		).To(
+			Equal(StageDone),
+		)
+	})
+
+	It("should return an error if the node condition cannot be removed", func() {
+		mod.Spec.UnloadPolicy = kmmv1beta1.UnloadPolicyUnload
+
+		node := v1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "node"},
+		}
+
+		calls := append(
+			expectDevicePluginGone(),
+			clnt.EXPECT().List(ctx, &appsv1.DaemonSetList{}, gomock.Any()),
+			clnt.EXPECT().List(ctx, &v1.PodList{}, gomock.Any()),
+			clnt.EXPECT().List(ctx, &v1.NodeList{}).DoAndReturn(
+				func(_ interface{}, list *v1.NodeList, _ ...ctrlclient.ListOption) error {
+					list.Items = []v1.Node{node}
+					return nil
+				},
+			),
+			mockNC.EXPECT().RemoveModuleCondition(ctx, &node, mod.Namespace, mod.Name).Return(errors.New("some error")),
+		)
+
+		gomock.InOrder(calls...)
+
+		stage, err := m.TearDown(ctx, mod)
+		Expect(err).To(HaveOccurred())
+		Expect(stage).To(Equal(StageModuleLoader))
+	})
+
+	It("should return an error if the DaemonSets cannot be listed", func() {
+		clnt.EXPECT().List(ctx, &appsv1.DaemonSetList{}, gomock.Any()).Return(errors.New("some error"))
+
+		_, err := m.TearDown(ctx, mod)
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,408 @@
+package upgrade
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	kmmv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/constants"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/daemonset"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/kubectl/pkg/util/podutils"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+//go:generate mockgen -source=upgrade.go -package=upgrade -destination=mock_upgrade.go
+
+// cordonedByKMM is the value of the in-progress annotation on nodes that KMM cordoned itself, and must therefore
+// uncordon once the upgrade is finished.
+const cordonedByKMM = "cordoned"
+
+// NodeNameField is the field by which pods are indexed, so that the pods of a node can be listed without listing all
+// pods of the cluster.
+const NodeNameField = "spec.nodeName"
+
+const (
+	inProgressAnnotationPrefix = "kmm.node.kubernetes.io/"
+	inProgressAnnotationSuffix = ".upgrade"
+)
+
+// Orchestrator replaces the outdated ModuleLoader pods of a Module node by node, following
+// spec.moduleLoader.upgradeStrategy.
+type Orchestrator interface {
+	Upgrade(ctx context.Context, mod *kmmv1beta1.Module, dsByKernelVersion map[string]*appsv1.DaemonSet) (*kmmv1beta1.UpgradeStatus, error)
+}
+
+type orchestrator struct {
+	client    client.Client
+	daemonAPI daemonset.DaemonSetCreator
+}
+
+func NewOrchestrator(client client.Client, daemonAPI daemonset.DaemonSetCreator) Orchestrator {
+	return &orchestrator{
+		client:    client,
+		daemonAPI: daemonAPI,
+	}
+}
+
+// IndexPodsByNodeName indexes pods by NodeNameField in indexer.
+// It must be called before the manager that owns indexer is started.
+func IndexPodsByNodeName(ctx context.Context, indexer client.FieldIndexer) error {
+	return indexer.IndexField(ctx, &v1.Pod{}, NodeNameField, func(obj client.Object) []string {
+		return []string{obj.(*v1.Pod).Spec.NodeName}
+	})
+}
+
+// InProgressAnnotation returns the annotation set on the nodes on which the ModuleLoader pod of moduleName is being
+// upgraded.
+func InProgressAnnotation(moduleName string) string {
+	return inProgressAnnotationPrefix + moduleName + inProgressAnnotationSuffix
+}
+
+// IsInProgress returns true if the ModuleLoader pod of moduleName is being upgraded on node.
+func IsInProgress(node *v1.Node, moduleName string) bool {
+	_, ok := node.Annotations[InProgressAnnotation(moduleName)]
+	return ok
+}
+
+// IsAnyInProgress returns true if the ModuleLoader pod of any Module is being upgraded on node.
+func IsAnyInProgress(node *v1.Node) bool {
+	for a := range node.Annotations {
+		name := strings.TrimPrefix(a, inProgressAnnotationPrefix)
+
+		if name != a && strings.HasSuffix(name, inProgressAnnotationSuffix) && name != inProgressAnnotationSuffix {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Upgrade advances the upgrade of all nodes in progress, and starts upgrading new nodes running an outdated
+// ModuleLoader pod as long as fewer than spec.moduleLoader.upgradeStrategy.maxParallelNodes nodes are in progress.
+// dsByKernelVersion must contain the desired state of the ModuleLoader DaemonSets.
+// If the Module has no upgrade strategy, it releases the nodes left in progress and returns a nil status.
+func (o *orchestrator) Upgrade(
+	ctx context.Context,
+	mod *kmmv1beta1.Module,
+	dsByKernelVersion map[string]*appsv1.DaemonSet) (*kmmv1beta1.UpgradeStatus, error) {
+	logger := log.FromContext(ctx)
+
+	nodes, err := o.nodesInProgress(ctx, mod.Name)
+	if err != nil {
+		return nil, fmt.Errorf("could not list the nodes being upgraded: %v", err)
+	}
+
+	strategy := mod.Spec.ModuleLoader.UpgradeStrategy
+	if strategy == nil {
+		for i := range nodes {
+			if err = o.finishNode(ctx, &nodes[i], mod.Name); err != nil {
+				return nil, fmt.Errorf("could not release node %s: %v", nodes[i].Name, err)
+			}
+		}
+
+		return nil, nil
+	}
+
+	podsByNode, err := o.loaderPodsByNode(ctx, mod)
+	if err != nil {
+		return nil, fmt.Errorf("could not list the ModuleLoader pods: %v", err)
+	}
+
+	outdatedNodes := sets.New[string]()
+
+	for nodeName, pods := range podsByNode {
+		for i := range pods {
+			if isOutdated(&pods[i], dsByKernelVersion) {
+				outdatedNodes.Insert(nodeName)
+			}
+		}
+	}
+
+	status := &kmmv1beta1.UpgradeStatus{OutdatedNumber: int32(outdatedNodes.Len())}
+	inProgress := sets.New[string]()
+
+	for i := range nodes {
+		node := &nodes[i]
+		inProgress.Insert(node.Name)
+
+		state, err := o.upgradeNode(ctx, mod, node, podsByNode[node.Name], dsByKernelVersion)
+		if err != nil {
+			return nil, fmt.Errorf("could not upgrade node %s: %v", node.Name, err)
+		}
+
+		if state == "" {
+			logger.Info("Finished upgrading node", "node", node.Name)
+
+			if err = o.finishNode(ctx, node, mod.Name); err != nil {
+				return nil, fmt.Errorf("could not release node %s: %v", node.Name, err)
+			}
+
+			continue
+		}
+
+		status.InProgress = append(status.InProgress, kmmv1beta1.NodeUpgradeStatus{Node: node.Name, State: state})
+	}
+
+	maxParallelNodes := int(strategy.MaxParallelNodes)
+	if maxParallelNodes < 1 {
+		maxParallelNodes = 1
+	}
+
+	for _, nodeName := range sets.List(outdatedNodes) {
+		if len(status.InProgress) >= maxParallelNodes {
+			break
+		}
+
+		if inProgress.Has(nodeName) {
+			continue
+		}
+
+		node := v1.Node{}
+
+		if err = o.client.Get(ctx, types.NamespacedName{Name: nodeName}, &node); err != nil {
+			return nil, fmt.Errorf("could not get node %s: %v", nodeName, err)
+		}
+
+		logger.Info("Starting to upgrade node", "node", nodeName)
+
+		if err = o.startNode(ctx, &node, mod.Name); err != nil {
+			return nil, fmt.Errorf("could not start upgrading node %s: %v", nodeName, err)
+		}
+
+		state, err := o.upgradeNode(ctx, mod, &node, podsByNode[nodeName], dsByKernelVersion)
+		if err != nil {
+			return nil, fmt.Errorf("could not upgrade node %s: %v", nodeName, err)
+		}
+
+		status.InProgress = append(status.InProgress, kmmv1beta1.NodeUpgradeStatus{Node: nodeName, State: state})
+	}
+
+	return status, nil
+}
+
+// upgradeNode performs the next step of the upgrade of node and returns the state the node is in.
+// An empty state means that the upgrade of node is finished.
+func (o *orchestrator) upgradeNode(
+	ctx context.Context,
+	mod *kmmv1beta1.Module,
+	node *v1.Node,
+	pods []v1.Pod,
+	dsByKernelVersion map[string]*appsv1.DaemonSet) (kmmv1beta1.NodeUpgradeState, error) {
+	drained, err := o.drain(ctx, node.Name, mod.Spec.ModuleLoader.UpgradeStrategy.DrainResources)
+	if err != nil {
+		return "", fmt.Errorf("could not drain the node: %v", err)
+	}
+
+	if !drained {
+		return kmmv1beta1.NodeUpgradeDraining, nil
+	}
+
+	var readyPod *v1.Pod
+
+	for i := range pods {
+		pod := &pods[i]
+
+		if !pod.DeletionTimestamp.IsZero() {
+			return kmmv1beta1.NodeUpgradeReplacing, nil
+		}
+
+		if isOutdated(pod, dsByKernelVersion) {
+			log.FromContext(ctx).Info("Deleting outdated ModuleLoader pod", "node", node.Name, "pod", pod.Name)
+
+			if err = o.client.Delete(ctx, pod); err != nil && !k8serrors.IsNotFound(err) {
+				return "", fmt.Errorf("could not delete pod %s: %v", pod.Name, err)
+			}
+
+			return kmmv1beta1.NodeUpgradeReplacing, nil
+		}
+
+		if podutils.IsPodReady(pod) {
+			readyPod = pod
+		}
+	}
+
+	if len(pods) == 0 {
+		return kmmv1beta1.NodeUpgradeReplacing, nil
+	}
+
+	if readyPod == nil {
+		return kmmv1beta1.NodeUpgradeWaitingForReady, nil
+	}
+
+	if _, ok := node.Labels[o.daemonAPI.GetNodeLabelFromPod(readyPod, mod.Name)]; !ok {
+		return kmmv1beta1.NodeUpgradeWaitingForReady, nil
+	}
+
+	return "", nil
+}
+
+// drain evicts the pods running on nodeName that request any of resources.
+// It returns true once no such pod runs on the node anymore.
+func (o *orchestrator) drain(ctx context.Context, nodeName string, resources []v1.ResourceName) (bool, error) {
+	if len(resources) == 0 {
+		return true, nil
+	}
+
+	podList := v1.PodList{}
+
+	if err := o.client.List(ctx, &podList, client.MatchingFields{NodeNameField: nodeName}); err != nil {
+		return false, fmt.Errorf("could not list the pods of node %s: %v", nodeName, err)
+	}
+
+	drained := true
+
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+
+		if !requestsAny(pod, resources) {
+			continue
+		}
+
+		if pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+			continue
+		}
+
+		drained = false
+
+		if !pod.DeletionTimestamp.IsZero() {
+			continue
+		}
+
+		log.FromContext(ctx).Info("Evicting pod", "node", nodeName, "pod", client.ObjectKeyFromObject(pod))
+
+		eviction := &policyv1.Eviction{
+			ObjectMeta: metav1.ObjectMeta{Name: pod.Name, Namespace: pod.Namespace},
+		}
+
+		if err := o.client.SubResource("eviction").Create(ctx, pod, eviction); err != nil {
+			// The eviction is retried in a later reconciliation if it is blocked by a PodDisruptionBudget.
+			if k8serrors.IsTooManyRequests(err) || k8serrors.IsNotFound(err) {
+				continue
+			}
+
+			return false, fmt.Errorf("could not evict pod %s/%s: %v", pod.Namespace, pod.Name, err)
+		}
+	}
+
+	return drained, nil
+}
+
+// startNode cordons node, if it is not cordoned already, and marks it as being upgraded.
+func (o *orchestrator) startNode(ctx context.Context, node *v1.Node, moduleName string) error {
+	patchFrom := client.MergeFrom(node.DeepCopy())
+
+	value := ""
+
+	if !node.Spec.Unschedulable {
+		node.Spec.Unschedulable = true
+		value = cordonedByKMM
+	}
+
+	metav1.SetMetaDataAnnotation(&node.ObjectMeta, InProgressAnnotation(moduleName), value)
+
+	return o.client.Patch(ctx, node, patchFrom)
+}
+
+// finishNode uncordons node if KMM cordoned it, and removes the in-progress annotation.
+func (o *orchestrator) finishNode(ctx context.Context, node *v1.Node, moduleName string) error {
+	patchFrom := client.MergeFrom(node.DeepCopy())
+
+	if node.Annotations[InProgressAnnotation(moduleName)] == cordonedByKMM {
+		node.Spec.Unschedulable = false
+	}
+
+	delete(node.Annotations, InProgressAnnotation(moduleName))
+
+	return o.client.Patch(ctx, node, patchFrom)
+}
+
+func (o *orchestrator) nodesInProgress(ctx context.Context, moduleName string) ([]v1.Node, error) {
+	nodeList := v1.NodeList{}
+
+	if err := o.client.List(ctx, &nodeList); err != nil {
+		return nil, err
+	}
+
+	nodes := make([]v1.Node, 0)
+
+	for _, node := range nodeList.Items {
+		if IsInProgress(&node, moduleName) {
+			nodes = append(nodes, node)
+		}
+	}
+
+	return nodes, nil
+}
+
+// loaderPodsByNode returns the ModuleLoader pods of mod, keyed by the name of the node they run on.
+func (o *orchestrator) loaderPodsByNode(ctx context.Context, mod *kmmv1beta1.Module) (map[string][]v1.Pod, error) {
+	podList := v1.PodList{}
+
+	opts := []client.ListOption{
+		client.InNamespace(mod.Namespace),
+		client.MatchingLabels{
+			constants.ModuleNameLabel: mod.Name,
+			constants.DaemonSetRole:   "module-loader",
+		},
+	}
+
+	if err := o.client.List(ctx, &podList, opts...); err != nil {
+		return nil, err
+	}
+
+	podsByNode := make(map[string][]v1.Pod)
+
+	for _, pod := range podList.Items {
+		if pod.Spec.NodeName == "" {
+			continue
+		}
+
+		podsByNode[pod.Spec.NodeName] = append(podsByNode[pod.Spec.NodeName], pod)
+	}
+
+	return podsByNode, nil
+}
+
+// isOutdated returns true if pod was created from a previous version of the template of its DaemonSet.
+// Pods whose DaemonSet is unknown are never outdated.
+func isOutdated(pod *v1.Pod, dsByKernelVersion map[string]*appsv1.DaemonSet) bool {
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil {
+		return false
+	}
+
+	for _, ds := range dsByKernelVersion {
+		if ds.UID != owner.UID {
+			continue
+		}
+
+		return pod.Annotations[constants.PodTemplateHashAnnotation] != ds.Spec.Template.Annotations[constants.PodTemplateHashAnnotation]
+	}
+
+	return false
+}
+
+func requestsAny(pod *v1.Pod, resources []v1.ResourceName) bool {
+	for _, c := range pod.Spec.Containers {
+		for _, r := range resources {
+			if _, ok := c.Resources.Requests[r]; ok {
+				return true
+			}
+
+			if _, ok := c.Resources.Limits[r]; ok {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,338 @@
+package upgrade
+
+import (
+	"context"
+
+	"github.com/golang/mock/gomock"
+	kmmv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/client"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/constants"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/daemonset"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
+	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	moduleName = "module-name"
+	namespace  = "namespace"
+	readyLabel = "kmm.node.kubernetes.io/module-name.ready"
+)
+
+var _ = Describe("Upgrade", func() {
+	var (
+		ctrl          *gomock.Controller
+		clnt          *client.MockClient
+		mockDC        *daemonset.MockDaemonSetCreator
+		o             Orchestrator
+		mod           *kmmv1beta1.Module
+		dsByKV        map[string]*appsv1.DaemonSet
+		ctx           = context.Background()
+		dsUID         = types.UID("ds-uid")
+		newHash       = "new-hash"
+		oldHash       = "old-hash"
+		drainResource = v1.ResourceName("example.com/device")
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		clnt = client.NewMockClient(ctrl)
+		mockDC = daemonset.NewMockDaemonSetCreator(ctrl)
+		o = NewOrchestrator(clnt, mockDC)
+
+		mod = &kmmv1beta1.Module{
+			ObjectMeta: metav1.ObjectMeta{Name: moduleName, Namespace: namespace},
+			Spec: kmmv1beta1.ModuleSpec{
+				ModuleLoader: kmmv1beta1.ModuleLoaderSpec{
+					UpgradeStrategy: &kmmv1beta1.UpgradeStrategy{MaxParallelNodes: 1},
+				},
+			},
+		}
+
+		dsByKV = map[string]*appsv1.DaemonSet{
+			"1.2.3": {
+				ObjectMeta: metav1.ObjectMeta{Name: "ds", Namespace: namespace, UID: dsUID},
+				Spec: appsv1.DaemonSetSpec{
+					Template: v1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{
+							Annotations: map[string]string{constants.PodTemplateHashAnnotation: newHash},
+						},
+					},
+				},
+			},
+		}
+	})
+
+	loaderPod := func(name, nodeName, hash string, ready bool) v1.Pod {
+		status := v1.ConditionFalse
+		if ready {
+			status = v1.ConditionTrue
+		}
+
+		return v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            name,
+				Namespace:       namespace,
+				Annotations:     map[string]string{constants.PodTemplateHashAnnotation: hash},
+				OwnerReferences: []metav1.OwnerReference{{UID: dsUID, Controller: pointer.Bool(true)}},
+			},
+			Spec: v1.PodSpec{NodeName: nodeName},
+			Status: v1.PodStatus{
+				Conditions: []v1.PodCondition{{Type: v1.PodReady, Status: status}},
+			},
+		}
+	}
+
+	expectNodes := func(nodes ...v1.Node) *gomock.Call {
+		return clnt.EXPECT().List(ctx, &v1.NodeList{}).DoAndReturn(
+			func(_ context.Context, list *v1.NodeList, _ ...runtimeclient.ListOption) error {
+				list.Items = nodes
+				return nil
+			},
+		)
+	}
+
+	expectLoaderPods := func(pods ...v1.Pod) *gomock.Call {
+		return clnt.EXPECT().List(ctx, &v1.PodList{}, gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, list *v1.PodList, _ ...runtimeclient.ListOption) error {
+				list.Items = pods
+				return nil
+			},
+		)
+	}
+
+	It("should release the nodes left in progress if there is no upgrade strategy", func() {
+		mod.Spec.ModuleLoader.UpgradeStrategy = nil
+
+		node := v1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "node1",
+				Annotations: map[string]string{InProgressAnnotation(moduleName): cordonedByKMM},
+			},
+			Spec: v1.NodeSpec{Unschedulable: true},
+		}
+
+		gomock.InOrder(
+			expectNodes(node),
+			clnt.EXPECT().Patch(ctx, gomock.Any(), gomock.Any()).Do(
+				func(_ context.Context, n *v1.Node, _ runtimeclient.Patch, _ ...runtimeclient.PatchOption) {
+					Expect(n.Spec.Unschedulable).To(BeFalse())
+					Expect(n.Annotations).NotTo(HaveKey(InProgressAnnotation(moduleName)))
+				},
+			),
+		)
+
+		status, err := o.Upgrade(ctx, mod, dsByKV)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status).To(BeNil())
+	})
+
+	It("should do nothing if all pods are up to date", func() {
+		gomock.InOrder(
+			expectNodes(v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}}),
+			expectLoaderPods(loaderPod("pod1", "node1", newHash, true)),
+		)
+
+		status, err := o.Upgrade(ctx, mod, dsByKV)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status).To(Equal(&kmmv1beta1.UpgradeStatus{}))
+	})
+
+	It("should cordon a single node and replace its outdated pod", func() {
+		pod1 := loaderPod("pod1", "node1", oldHash, true)
+		pod2 := loaderPod("pod2", "node2", oldHash, true)
+
+		gomock.InOrder(
+			expectNodes(),
+			expectLoaderPods(pod2, pod1),
+			clnt.EXPECT().Get(ctx, types.NamespacedName{Name: "node1"}, &v1.Node{}).DoAndReturn(
+				func(_ context.Context, _ types.NamespacedName, n *v1.Node, _ ...runtimeclient.GetOption) error {
+					n.Name = "node1"
+					return nil
+				},
+			),
+			clnt.EXPECT().Patch(ctx, gomock.Any(), gomock.Any()).Do(
+				func(_ context.Context, n *v1.Node, _ runtimeclient.Patch, _ ...runtimeclient.PatchOption) {
+					Expect(n.Spec.Unschedulable).To(BeTrue())
+					Expect(n.Annotations).To(HaveKeyWithValue(InProgressAnnotation(moduleName), cordonedByKMM))
+				},
+			),
+			clnt.EXPECT().Delete(ctx, &pod1),
+		)
+
+		status, err := o.Upgrade(ctx, mod, dsByKV)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status).To(Equal(&kmmv1beta1.UpgradeStatus{
+			OutdatedNumber: 2,
+			InProgress:     []kmmv1beta1.NodeUpgradeStatus{{Node: "node1", State: kmmv1beta1.NodeUpgradeReplacing}},
+		}))
+	})
+
+	It("should evict the pods using the drain resources before replacing the pod", func() {
+		mod.Spec.ModuleLoader.UpgradeStrategy.DrainResources = []v1.ResourceName{drainResource}
+
+		node := v1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "node1",
+				Annotations: map[string]string{InProgressAnnotation(moduleName): cordonedByKMM},
+			},
+		}
+
+		workload := v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "workload", Namespace: "other"},
+			Spec: v1.PodSpec{
+				NodeName: "node1",
+				Containers: []v1.Container{
+					{
+						Resources: v1.ResourceRequirements{
+							Limits: v1.ResourceList{drainResource: resource.MustParse("1")},
+						},
+					},
+				},
+			},
+		}
+
+		src := client.NewMockSubResourceClient(ctrl)
+
+		gomock.InOrder(
+			expectNodes(node),
+			expectLoaderPods(loaderPod("pod1", "node1", oldHash, true)),
+			clnt.EXPECT().List(ctx, &v1.PodList{}, runtimeclient.MatchingFields{NodeNameField: "node1"}).DoAndReturn(
+				func(_ context.Context, list *v1.PodList, _ ...runtimeclient.ListOption) error {
+					list.Items = []v1.Pod{workload}
+					return nil
+				},
+			),
+			clnt.EXPECT().SubResource("eviction").Return(src),
+			src.EXPECT().Create(ctx, &workload, gomock.Any()),
+		)
+
+		status, err := o.Upgrade(ctx, mod, dsByKV)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status).To(Equal(&kmmv1beta1.UpgradeStatus{
+			OutdatedNumber: 1,
+			InProgress:     []kmmv1beta1.NodeUpgradeStatus{{Node: "node1", State: kmmv1beta1.NodeUpgradeDraining}},
+		}))
+	})
+
+	It("should wait for the module to be ready", func() {
+		node := v1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "node1",
+				Annotations: map[string]string{InProgressAnnotation(moduleName): cordonedByKMM},
+			},
+		}
+
+		pod := loaderPod("pod1", "node1", newHash, true)
+
+		gomock.InOrder(
+			expectNodes(node),
+			expectLoaderPods(pod),
+			mockDC.EXPECT().GetNodeLabelFromPod(&pod, moduleName).Return(readyLabel),
+		)
+
+		status, err := o.Upgrade(ctx, mod, dsByKV)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status).To(Equal(&kmmv1beta1.UpgradeStatus{
+			InProgress: []kmmv1beta1.NodeUpgradeStatus{{Node: "node1", State: kmmv1beta1.NodeUpgradeWaitingForReady}},
+		}))
+	})
+
+	It("should not uncordon a node that was cordoned before the upgrade", func() {
+		node := v1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "node1",
+				Labels:      map[string]string{readyLabel: ""},
+				Annotations: map[string]string{InProgressAnnotation(moduleName): ""},
+			},
+			Spec: v1.NodeSpec{Unschedulable: true},
+		}
+
+		pod := loaderPod("pod1", "node1", newHash, true)
+
+		gomock.InOrder(
+			expectNodes(node),
+			expectLoaderPods(pod),
+			mockDC.EXPECT().GetNodeLabelFromPod(&pod, moduleName).Return(readyLabel),
+			clnt.EXPECT().Patch(ctx, gomock.Any(), gomock.Any()).Do(
+				func(_ context.Context, n *v1.Node, _ runtimeclient.Patch, _ ...runtimeclient.PatchOption) {
+					Expect(n.Spec.Unschedulable).To(BeTrue())
+					Expect(n.Annotations).NotTo(HaveKey(InProgressAnnotation(moduleName)))
+				},
+			),
+		)
+
+		status, err := o.Upgrade(ctx, mod, dsByKV)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status).To(Equal(&kmmv1beta1.UpgradeStatus{}))
+	})
+
+	It("should finish a node and start the next one", func() {
+		node1 := v1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "node1",
+				Labels:      map[string]string{readyLabel: ""},
+				Annotations: map[string]string{InProgressAnnotation(moduleName): cordonedByKMM},
+			},
+			Spec: v1.NodeSpec{Unschedulable: true},
+		}
+
+		pod1 := loaderPod("pod1", "node1", newHash, true)
+		pod2 := loaderPod("pod2", "node2", oldHash, true)
+
+		gomock.InOrder(
+			expectNodes(node1),
+			expectLoaderPods(pod1, pod2),
+			mockDC.EXPECT().GetNodeLabelFromPod(&pod1, moduleName).Return(readyLabel),
+			clnt.EXPECT().Patch(ctx, gomock.Any(), gomock.Any()).Do(
+				func(_ context.Context, n *v1.Node, _ runtimeclient.Patch, _ ...runtimeclient.PatchOption) {
+					Expect(n.Name).To(Equal("node1"))
+					Expect(n.Spec.Unschedulable).To(BeFalse())
+				},
+			),
+			clnt.EXPECT().Get(ctx, types.NamespacedName{Name: "node2"}, &v1.Node{}).DoAndReturn(
+				func(_ context.Context, _ types.NamespacedName, n *v1.Node, _ ...runtimeclient.GetOption) error {
+					n.Name = "node2"
+					return nil
+				},
+			),
+			clnt.EXPECT().Patch(ctx, gomock.Any(), gomock.Any()),
+			clnt.EXPECT().Delete(ctx, &pod2),
+		)
+
+		status, err := o.Upgrade(ctx, mod, dsByKV)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status).To(Equal(&kmmv1beta1.UpgradeStatus{
+			OutdatedNumber: 1,
+			InProgress:     []kmmv1beta1.NodeUpgradeStatus{{Node: "node2", State: kmmv1beta1.NodeUpgradeReplacing}},
+		}))
+	})
+})
+
+var _ = Describe("IsAnyInProgress", func() {
+	It("should return true if any Module is being upgraded on the node", func() {
+		node := v1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{InProgressAnnotation("some-module"): cordonedByKMM},
+			},
+		}
+
+		Expect(IsAnyInProgress(&node)).To(BeTrue())
+	})
+
+	It("should return false if no Module is being upgraded on the node", func() {
+		node := v1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{"kmm.node.kubernetes.io/some-annotation": ""},
+			},
+		}
+
+		Expect(IsAnyInProgress(&node)).To(BeFalse())
+	})
+})
@@ -6,6 +6,7 @@ import (
 	"fmt"
 
 	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -30,8 +31,8 @@ var ErrNoMatchingJob = errors.New("no matching job")
 
 type JobHelper interface {
 	IsJobChanged(existingJob *batchv1.Job, newJob *batchv1.Job) (bool, error)
-	JobLabels(modName string, targetKernel string, jobType string) map[string]string
-	GetModuleJobByKernel(ctx context.Context, modName, namespace, targetKernel, jobType string, owner metav1.Object) (*batchv1.Job, error)
+	JobLabels(modName string, targetKernel string, targetArch string, jobType string) map[string]string
+	GetModuleJobByKernel(ctx context.Context, modName, namespace, targetKernel, targetArch, jobType string, owner metav1.Object) (*batchv1.Job, error)
 	GetModuleJobs(ctx context.Context, modName, namespace, jobType string, owner metav1.Object) ([]batchv1.Job, error)
 	DeleteJob(ctx context.Context, job *batchv1.Job) error
 	CreateJob(ctx context.Context, jobTemplate *batchv1.Job) error
@@ -60,18 +61,24 @@ func (jh *jobHelper) IsJobChanged(existingJob *batchv1.Job, newJob *batchv1.Job)
 	return true, nil
 }
 
-func (jh *jobHelper) JobLabels(modName string, targetKernel string, jobType string) map[string]string {
-	return moduleKernelLabels(modName, targetKernel, jobType)
+func (jh *jobHelper) JobLabels(modName string, targetKernel string, targetArch string, jobType string) map[string]string {
+	return moduleKernelLabels(modName, targetKernel, targetArch, jobType)
 }
 
-func (jh *jobHelper) GetModuleJobByKernel(ctx context.Context, modName, namespace, targetKernel, jobType string, owner metav1.Object) (*batchv1.Job, error) {
-	matchLabels := moduleKernelLabels(modName, targetKernel, jobType)
+func (jh *jobHelper) GetModuleJobByKernel(ctx context.Context, modName, namespace, targetKernel, targetArch, jobType string, owner metav1.Object) (*batchv1.Job, error) {
+	matchLabels := moduleKernelLabels(modName, targetKernel, targetArch, jobType)
 	jobs, err := jh.getJobs(ctx, namespace, matchLabels)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get module %s, jobs by kernel %s: %v", modName, targetKernel, err)
 	}
 
 	moduleOwnedJobs := filterJobsByOwner(jobs, owner)
+
+	if targetArch == "" {
+		// the jobs of a given architecture are not those of a kernel whose architecture is unknown
+		moduleOwnedJobs = filterJobsWithoutLabel(moduleOwnedJobs, constants.ArchitectureLabel)
+	}
+
 	numFoundJobs := len(moduleOwnedJobs)
 	if numFoundJobs == 0 {
 		return nil, ErrNoMatchingJob
@@ -139,9 +146,12 @@ func (jh *jobHelper) getJobs(ctx context.Context, namespace string, labels map[s
 	return jobList.Items, nil
 }
 
-func moduleKernelLabels(moduleName, targetKernel, jobType string) map[string]string {
+func moduleKernelLabels(moduleName, targetKernel, targetArch, jobType string) map[string]string {
 	labels := moduleLabels(moduleName, jobType)
 	labels[constants.TargetKernelTarget] = targetKernel
+	if targetArch != "" {
+		labels[constants.ArchitectureLabel] = targetArch
+	}
 	return labels
 }
 
@@ -161,3 +171,31 @@ func filterJobsByOwner(jobs []batchv1.Job, owner metav1.Object) []batchv1.Job {
 	}
 	return ownedJobs
 }
+
+func filterJobsWithoutLabel(jobs []batchv1.Job, label string) []batchv1.Job {
+	filteredJobs := make([]batchv1.Job, 0, len(jobs))
+	for _, job := range jobs {
+		if _, ok := job.Labels[label]; !ok {
+			filteredJobs = append(filteredJobs, job)
+		}
+	}
+	return filteredJobs
+}
+
+// JobNodeSelector returns the node selector for build and sign jobs.
+// If arch is not empty, jobs are only scheduled on nodes of that architecture.
+func JobNodeSelector(selector map[string]string, arch string) map[string]string {
+	if arch == "" {
+		return selector
+	}
+
+	nodeSelector := make(map[string]string, len(selector)+1)
+
+	for k, v := range selector {
+		nodeSelector[k] = v
+	}
+
+	nodeSelector[v1.LabelArchStable] = arch
+
+	return nodeSelector
+}
@@ -34,11 +34,39 @@ var _ = Describe("JobLabels", func() {
 			ObjectMeta: metav1.ObjectMeta{Name: "moduleName"},
 		}
 		mgr := NewJobHelper(clnt)
-		labels := mgr.JobLabels(mod.Name, "targetKernel", "jobType")
+		labels := mgr.JobLabels(mod.Name, "targetKernel", "", "jobType")
 
 		Expect(labels).To(HaveKeyWithValue(constants.ModuleNameLabel, "moduleName"))
 		Expect(labels).To(HaveKeyWithValue(constants.TargetKernelTarget, "targetKernel"))
 		Expect(labels).To(HaveKeyWithValue(constants.JobType, "jobType"))
+		Expect(labels).NotTo(HaveKey(constants.ArchitectureLabel))
+	})
+
+	It("get job labels with an architecture", func() {
+		mgr := NewJobHelper(clnt)
+		labels := mgr.JobLabels("moduleName", "targetKernel", "arm64", "jobType")
+
+		Expect(labels).To(HaveKeyWithValue(constants.TargetKernelTarget, "targetKernel"))
+		Expect(labels).To(HaveKeyWithValue(constants.ArchitectureLabel, "arm64"))
+	})
+})
+
+var _ = Describe("JobNodeSelector", func() {
+	It("should return the selector if no architecture is set", func() {
+		selector := map[string]string{"key": "value"}
+
+		Expect(JobNodeSelector(selector, "")).To(Equal(selector))
+	})
+
+	It("should add the architecture without modifying the selector", func() {
+		selector := map[string]string{"key": "value"}
+
+		Expect(
+			JobNodeSelector(selector, "arm64"),
+		).To(
+			Equal(map[string]string{"key": "value", "kubernetes.io/arch": "arm64"}),
+		)
+		Expect(selector).To(HaveLen(1))
 	})
 })
 
@@ -86,7 +114,7 @@ var _ = Describe("GetModuleJobByKernel", func() {
 			},
 		)
 
-		job, err := jh.GetModuleJobByKernel(ctx, mod.Name, mod.Namespace, "targetKernel", "jobType", &mod)
+		job, err := jh.GetModuleJobByKernel(ctx, mod.Name, mod.Namespace, "targetKernel", "", "jobType", &mod)
 
 		Expect(job).To(Equal(&j))
 		Expect(err).NotTo(HaveOccurred())
@@ -112,7 +140,7 @@ var _ = Describe("GetModuleJobByKernel", func() {
 
 		clnt.EXPECT().List(ctx, &jobList, opts).Return(errors.New("random error"))
 
-		_, err := jh.GetModuleJobByKernel(ctx, mod.Name, mod.Namespace, "targetKernel", "jobType", &mod)
+		_, err := jh.GetModuleJobByKernel(ctx, mod.Name, mod.Namespace, "targetKernel", "", "jobType", &mod)
 
 		Expect(err).To(HaveOccurred())
 	})
@@ -154,7 +182,7 @@ var _ = Describe("GetModuleJobByKernel", func() {
 			},
 		)
 
-		_, err = jh.GetModuleJobByKernel(ctx, mod.Name, mod.Namespace, "targetKernel", "jobType", &mod)
+		_, err = jh.GetModuleJobByKernel(ctx, mod.Name, mod.Namespace, "targetKernel", "", "jobType", &mod)
 
 		Expect(err).To(HaveOccurred())
 	})
@@ -201,11 +229,40 @@ var _ = Describe("GetModuleJobByKernel", func() {
 			},
 		)
 
-		job, err := jh.GetModuleJobByKernel(ctx, mod.Name, mod.Namespace, "targetKernel", "jobType", &mod)
+		job, err := jh.GetModuleJobByKernel(ctx, mod.Name, mod.Namespace, "targetKernel", "", "jobType", &mod)
 
 		Expect(err).NotTo(HaveOccurred())
 		Expect(job).To(Equal(&j1))
 	})
+
+	It("should not return the jobs of an architecture if no architecture is requested", func() {
+		ctx := context.Background()
+
+		mod := kmmv1beta1.Module{
+			ObjectMeta: metav1.ObjectMeta{Name: "moduleName", Namespace: "moduleNamespace"},
+		}
+
+		j := batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "moduleJob",
+				Namespace: "moduleNamespace",
+				Labels:    map[string]string{constants.ArchitectureLabel: "arm64"},
+			},
+		}
+
+		err := controllerutil.SetControllerReference(&mod, &j, scheme)
+		Expect(err).NotTo(HaveOccurred())
+
+		clnt.EXPECT().List(ctx, gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ interface{}, list *batchv1.JobList, _ ...interface{}) error {
+				list.Items = []batchv1.Job{j}
+				return nil
+			},
+		)
+
+		_, err = jh.GetModuleJobByKernel(ctx, mod.Name, mod.Namespace, "targetKernel", "", "jobType", &mod)
+		Expect(err).To(Equal(ErrNoMatchingJob))
+	})
 })
 
 var _ = Describe("GetModuleJobs", func() {
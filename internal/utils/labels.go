@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"fmt"
+)
+
+const (
+	nodeLabelPrefix = "kmm.node.kubernetes.io/"
+
+	// maxLabelNameLength is the maximum length of the name part of a label or annotation key.
+	maxLabelNameLength = 63
+
+	labelNameHashLength = 10
+)
+
+// GetModuleVersionLabelName returns the node label holding the version of the Module namespace/name that the node
+// should run.
+func GetModuleVersionLabelName(namespace, name string) string {
+	return moduleLabelName("version-module", namespace, name)
+}
+
+// GetModuleScheduledVersionLabelName returns the node label holding the version of the Module namespace/name whose
+// ModuleLoader runs on the node.
+// KMM copies the version label to it once the ModuleLoader pod of the previous version is gone from the node, so that
+// the kernel module of the previous version is unloaded before that of the new version is loaded.
+func GetModuleScheduledVersionLabelName(namespace, name string) string {
+	return moduleLabelName("scheduled-version-module", namespace, name)
+}
+
+// GetModuleUnmanagedLabelName returns the node label marking that the kernel module of the Module namespace/name was
+// left loaded on the node when the Module was deleted.
+func GetModuleUnmanagedLabelName(namespace, name string) string {
+	return moduleLabelName("unmanaged-module", namespace, name)
+}
+
+// GetModuleRebootRequiredLabelName returns the node label marking that the kernel module of the Module namespace/name
+// could not be unloaded from the node, which needs to be drained or rebooted.
+func GetModuleRebootRequiredLabelName(namespace, name string) string {
+	return moduleLabelName("reboot-required-module", namespace, name)
+}
+
+// GetModuleLoadedKernelLabelName returns the node label holding the kernel version for which the kernel module of the
+// Module namespace/name is loaded on the node.
+func GetModuleLoadedKernelLabelName(namespace, name string) string {
+	return moduleLabelName("loaded-kernel-module", namespace, name)
+}
+
+// GetModuleLoadedVersionLabelName returns the node label holding the version of the Module namespace/name whose kernel
+// module is loaded on the node.
+func GetModuleLoadedVersionLabelName(namespace, name string) string {
+	return moduleLabelName("loaded-version-module", namespace, name)
+}
+
+// GetModuleLoadedVersionNumberLabelName returns the node label holding the version of the Module namespace/name whose
+// kernel module is loaded on the node as an integer, so that it can be compared by the Gt and Lt operators of node
+// affinities.
+func GetModuleLoadedVersionNumberLabelName(namespace, name string) string {
+	return moduleLabelName("loaded-version-number-module", namespace, name)
+}
+
+// GetModuleLoadedDigestAnnotationName returns the node annotation holding the digest of the ModuleLoader image that
+// loaded the kernel module of the Module namespace/name on the node.
+// Digests are not valid label values, hence the annotation.
+func GetModuleLoadedDigestAnnotationName(namespace, name string) string {
+	return moduleLabelName("loaded-digest-module", namespace, name)
+}
+
+// GetModuleConflictLabelName returns the node label marking that the kernel module of the Module namespace/name is
+// already claimed by another Module on the node, which the ModuleLoader of namespace/name must not run on.
+func GetModuleConflictLabelName(namespace, name string) string {
+	return moduleLabelName("conflict-module", namespace, name)
+}
+
+// moduleLabelName returns the kind.namespace.name label key for the Module namespace/name.
+// The name part of label keys is limited to 63 characters: longer ones are truncated and suffixed with a hash of the
+// namespace and the name, so that they remain unique.
+func moduleLabelName(kind, namespace, name string) string {
+	labelName := fmt.Sprintf("%s.%s.%s", kind, namespace, name)
+
+	if len(labelName) <= maxLabelNameLength {
+		return nodeLabelPrefix + labelName
+	}
+
+	hash := fmt.Sprintf("%x", sha256.Sum256([]byte(namespace+"/"+name)))[:labelNameHashLength]
+
+	return nodeLabelPrefix + labelName[:maxLabelNameLength-labelNameHashLength-1] + "." + hash
+}
@@ -0,0 +1,127 @@
+package utils
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+var _ = Describe("GetModuleVersionLabelName", func() {
+	It("should include the namespace and the name of the Module", func() {
+		Expect(
+			GetModuleVersionLabelName("some-namespace", "some-name"),
+		).To(
+			Equal("kmm.node.kubernetes.io/version-module.some-namespace.some-name"),
+		)
+	})
+})
+
+var _ = Describe("GetModuleScheduledVersionLabelName", func() {
+	It("should include the namespace and the name of the Module", func() {
+		Expect(
+			GetModuleScheduledVersionLabelName("some-namespace", "some-name"),
+		).To(
+			Equal("kmm.node.kubernetes.io/scheduled-version-module.some-namespace.some-name"),
+		)
+	})
+})
+
+var _ = Describe("GetModuleUnmanagedLabelName", func() {
+	It("should include the namespace and the name of the Module", func() {
+		Expect(
+			GetModuleUnmanagedLabelName("some-namespace", "some-name"),
+		).To(
+			Equal("kmm.node.kubernetes.io/unmanaged-module.some-namespace.some-name"),
+		)
+	})
+})
+
+var _ = Describe("GetModuleRebootRequiredLabelName", func() {
+	It("should include the namespace and the name of the Module", func() {
+		Expect(
+			GetModuleRebootRequiredLabelName("some-namespace", "some-name"),
+		).To(
+			Equal("kmm.node.kubernetes.io/reboot-required-module.some-namespace.some-name"),
+		)
+	})
+})
+
+var _ = Describe("GetModuleLoadedKernelLabelName", func() {
+	It("should include the namespace and the name of the Module", func() {
+		Expect(
+			GetModuleLoadedKernelLabelName("some-namespace", "some-name"),
+		).To(
+			Equal("kmm.node.kubernetes.io/loaded-kernel-module.some-namespace.some-name"),
+		)
+	})
+})
+
+var _ = Describe("GetModuleLoadedVersionLabelName", func() {
+	It("should include the namespace and the name of the Module", func() {
+		Expect(
+			GetModuleLoadedVersionLabelName("some-namespace", "some-name"),
+		).To(
+			Equal("kmm.node.kubernetes.io/loaded-version-module.some-namespace.some-name"),
+		)
+	})
+})
+
+var _ = Describe("GetModuleLoadedVersionNumberLabelName", func() {
+	It("should include the namespace and the name of the Module", func() {
+		Expect(
+			GetModuleLoadedVersionNumberLabelName("some-namespace", "some-name"),
+		).To(
+			Equal("kmm.node.kubernetes.io/loaded-version-number-module.some-namespace.some-name"),
+		)
+	})
+})
+
+var _ = Describe("GetModuleLoadedDigestAnnotationName", func() {
+	It("should include the namespace and the name of the Module", func() {
+		Expect(
+			GetModuleLoadedDigestAnnotationName("some-namespace", "some-name"),
+		).To(
+			Equal("kmm.node.kubernetes.io/loaded-digest-module.some-namespace.some-name"),
+		)
+	})
+})
+
+var _ = Describe("GetModuleConflictLabelName", func() {
+	It("should include the namespace and the name of the Module", func() {
+		Expect(
+			GetModuleConflictLabelName("some-namespace", "some-name"),
+		).To(
+			Equal("kmm.node.kubernetes.io/conflict-module.some-namespace.some-name"),
+		)
+	})
+})
+
+var _ = Describe("moduleLabelName", func() {
+	const (
+		longNamespace = "a-namespace-with-a-rather-long-name"
+		longName      = "a-module-with-an-even-longer-name-than-its-namespace"
+	)
+
+	It("should return keys that are valid label keys", func() {
+		labelName := GetModuleRebootRequiredLabelName(longNamespace, longName)
+
+		Expect(validation.IsQualifiedName(labelName)).To(BeEmpty())
+		Expect(labelName).To(HavePrefix("kmm.node.kubernetes.io/reboot-required-module.a-namespace"))
+	})
+
+	It("should return different keys for Modules whose names only differ after the truncation", func() {
+		Expect(
+			GetModuleConflictLabelName(longNamespace, longName+"-1"),
+		).NotTo(
+			Equal(GetModuleConflictLabelName(longNamespace, longName+"-2")),
+		)
+	})
+
+	It("should not truncate keys that fit", func() {
+		Expect(
+			GetModuleLoadedKernelLabelName("ns", "name"),
+		).To(
+			Equal("kmm.node.kubernetes.io/loaded-kernel-module.ns.name"),
+		)
+	})
+})
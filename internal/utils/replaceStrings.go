@@ -14,7 +14,18 @@ const (
 	kernelVersionPatchIdx = 2
 )
 
-var kernelRegexp = regexp.MustCompile("[.,-]")
+var (
+	kernelRegexp = regexp.MustCompile("[.,-]")
+
+	// kernelFlavorSuffixRegexp matches flavors appended to the kernel release, e.g. 5.14.0-284.el9.aarch64+64k.
+	kernelFlavorSuffixRegexp = regexp.MustCompile(`\+([a-z0-9]+)$`)
+	// kernelRTRegexp matches the RHEL realtime kernels, e.g. 4.18.0-372.9.1.rt7.166.el8.x86_64.
+	kernelRTRegexp = regexp.MustCompile(`\.rt[0-9]+\.`)
+	// kernelDebianFlavorRegexp matches the Debian / Ubuntu flavors, e.g. 5.15.0-1034-aws.
+	kernelDebianFlavorRegexp = regexp.MustCompile(`^[0-9]+\.[0-9]+\.[0-9]+-[0-9]+-([a-z][a-z0-9-]*)$`)
+
+	osImageIDInvalidCharsRegexp = regexp.MustCompile("[^a-z0-9]+")
+)
 
 func KernelComponentsAsEnvVars(kernel string) []string {
 	osConfigFieldsList := kernelRegexp.Split(kernel, -1)
@@ -31,6 +42,46 @@ func KernelComponentsAsEnvVars(kernel string) []string {
 	return envvars
 }
 
+// KernelFlavor returns the flavor of the kernel (e.g. rt, 64k, generic), or an empty string if the kernel release
+// does not carry one.
+func KernelFlavor(kernel string) string {
+	if m := kernelFlavorSuffixRegexp.FindStringSubmatch(kernel); m != nil {
+		return m[1]
+	}
+
+	if kernelRTRegexp.MatchString(kernel) {
+		return "rt"
+	}
+
+	if m := kernelDebianFlavorRegexp.FindStringSubmatch(kernel); m != nil {
+		return m[1]
+	}
+
+	return ""
+}
+
+// OSImageComponents splits the OS image reported in the node's NodeInfo (e.g. "Ubuntu 22.04.2 LTS") into an ID
+// (e.g. "ubuntu") and a version (e.g. "22.04.2").
+func OSImageComponents(osImage string) (string, string) {
+	fields := strings.Fields(osImage)
+
+	idFields := make([]string, 0, len(fields))
+	version := ""
+
+	for _, f := range fields {
+		if f[0] >= '0' && f[0] <= '9' {
+			version = f
+			break
+		}
+
+		idFields = append(idFields, f)
+	}
+
+	id := osImageIDInvalidCharsRegexp.ReplaceAllString(strings.ToLower(strings.Join(idFields, "-")), "-")
+
+	return strings.Trim(id, "-"), version
+}
+
 func ReplaceInTemplates(envvars []string, templates ...string) ([]string, error) {
 	parser := parse.New("mapping", envvars, &parse.Restrictions{})
 
@@ -22,6 +22,32 @@ var _ = Describe("KernelComponentsAsEnvVars", func() {
 	})
 })
 
+var _ = Describe("KernelFlavor", func() {
+	DescribeTable("should work as expected", func(kernel, expected string) {
+		Expect(KernelFlavor(kernel)).To(Equal(expected))
+	},
+		Entry("no flavor", "5.14.0-284.el9.x86_64", ""),
+		Entry("64k", "5.14.0-284.el9.aarch64+64k", "64k"),
+		Entry("RHEL realtime", "4.18.0-372.9.1.rt7.166.el8.x86_64", "rt"),
+		Entry("Ubuntu generic", "5.15.0-60-generic", "generic"),
+		Entry("Ubuntu cloud", "5.15.0-1034-aws", "aws"),
+	)
+})
+
+var _ = Describe("OSImageComponents", func() {
+	DescribeTable("should work as expected", func(osImage, expectedID, expectedVersion string) {
+		id, version := OSImageComponents(osImage)
+		Expect(id).To(Equal(expectedID))
+		Expect(version).To(Equal(expectedVersion))
+	},
+		Entry("empty", "", "", ""),
+		Entry("Ubuntu", "Ubuntu 22.04.2 LTS", "ubuntu", "22.04.2"),
+		Entry("RHCOS", "Red Hat Enterprise Linux CoreOS 413.92.202303281804-0 (Plow)", "red-hat-enterprise-linux-coreos", "413.92.202303281804-0"),
+		Entry("Debian", "Debian GNU/Linux 11 (bullseye)", "debian-gnu-linux", "11"),
+		Entry("no version", "Container-Optimized OS from Google", "container-optimized-os-from-google", ""),
+	)
+})
+
 var _ = Describe("ReplaceInTemplates", func() {
 	It("should work as expected", func() {
 		vars := []string{"A=AAA", "B=BBB", "C=CCC"}
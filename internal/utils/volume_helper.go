@@ -2,8 +2,12 @@ package utils
 
 import (
 	v1 "k8s.io/api/core/v1"
+
+	"github.com/kubernetes-sigs/kernel-module-management/internal/constants"
 )
 
+const caBundlesMountDir = "/etc/kmm/ca-bundles"
+
 func MakeSecretVolume(secretRef *v1.LocalObjectReference, key string, path string) v1.Volume {
 	if secretRef == nil {
 		return v1.Volume{}
@@ -46,3 +50,56 @@ func MakeSecretVolumeMount(secretRef *v1.LocalObjectReference, mountPath string)
 func volumeNameFromSecretRef(ref v1.LocalObjectReference) string {
 	return "secret-" + ref.Name
 }
+
+// CABundleMountDir returns the directory in which the CA bundle ConfigMap is mounted in build and sign pods.
+func CABundleMountDir(cmRef v1.LocalObjectReference) string {
+	return caBundlesMountDir + "/" + cmRef.Name
+}
+
+// CABundleFile returns the path of the CA bundle file in build and sign pods.
+func CABundleFile(cmRef v1.LocalObjectReference) string {
+	return CABundleMountDir(cmRef) + "/" + constants.CABundleCMKey
+}
+
+// AppendCABundleVolume appends the volume and the volume mount for the CA bundle ConfigMap, unless cmRef is nil or
+// the ConfigMap is already mounted.
+func AppendCABundleVolume(
+	volumes []v1.Volume,
+	volumeMounts []v1.VolumeMount,
+	cmRef *v1.LocalObjectReference) ([]v1.Volume, []v1.VolumeMount) {
+
+	if cmRef == nil {
+		return volumes, volumeMounts
+	}
+
+	name := "ca-bundle-" + cmRef.Name
+
+	for _, vol := range volumes {
+		if vol.Name == name {
+			return volumes, volumeMounts
+		}
+	}
+
+	vol := v1.Volume{
+		Name: name,
+		VolumeSource: v1.VolumeSource{
+			ConfigMap: &v1.ConfigMapVolumeSource{
+				LocalObjectReference: *cmRef,
+				Items: []v1.KeyToPath{
+					{
+						Key:  constants.CABundleCMKey,
+						Path: constants.CABundleCMKey,
+					},
+				},
+			},
+		},
+	}
+
+	volMount := v1.VolumeMount{
+		Name:      name,
+		ReadOnly:  true,
+		MountPath: CABundleMountDir(*cmRef),
+	}
+
+	return append(volumes, vol), append(volumeMounts, volMount)
+}
@@ -28,3 +28,30 @@ var _ = Describe("Labels", func() {
 		Expect(volMount).To(Equal(secretMount))
 	})
 })
+
+var _ = Describe("AppendCABundleVolume", func() {
+	It("should not add anything if no ConfigMap is referenced", func() {
+		volumes, volumeMounts := AppendCABundleVolume(nil, nil, nil)
+		Expect(volumes).To(BeEmpty())
+		Expect(volumeMounts).To(BeEmpty())
+	})
+
+	It("should mount the ConfigMap only once", func() {
+		cmRef := &v1.LocalObjectReference{Name: "some-ca"}
+
+		volumes, volumeMounts := AppendCABundleVolume(nil, nil, cmRef)
+		volumes, volumeMounts = AppendCABundleVolume(volumes, volumeMounts, cmRef)
+
+		Expect(volumes).To(HaveLen(1))
+		Expect(volumes[0].Name).To(Equal("ca-bundle-some-ca"))
+		Expect(volumes[0].ConfigMap.Name).To(Equal("some-ca"))
+		Expect(volumeMounts).To(Equal([]v1.VolumeMount{
+			{
+				Name:      "ca-bundle-some-ca",
+				ReadOnly:  true,
+				MountPath: "/etc/kmm/ca-bundles/some-ca",
+			},
+		}))
+		Expect(CABundleFile(*cmRef)).To(Equal("/etc/kmm/ca-bundles/some-ca/ca-bundle.crt"))
+	})
+})